@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	kitot "github.com/go-kit/kit/tracing/opentracing"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/opentracing/opentracing-go"
+	"github.com/studiously/classsvc/classsvc"
+	"github.com/studiously/introspector"
+	"github.com/studiously/unitsvc/unitsvc"
+)
+
+// makeClassServiceClient is classsvc.MakeClientEndpoints with every call traced as a client
+// span of tracer. The span and the request ID are propagated to classsvc in the request headers.
+func makeClassServiceClient(instance string, tracer opentracing.Tracer, logger log.Logger) (classsvc.Endpoints, error) {
+	tgt, err := url.Parse(httpURL(instance))
+	if err != nil {
+		return classsvc.Endpoints{}, err
+	}
+	tgt.Path = ""
+
+	options := []httptransport.ClientOption{
+		httptransport.ClientBefore(introspector.FromHTTPContext()),
+		httptransport.ClientBefore(kitot.ToHTTPRequest(tracer, logger)),
+		httptransport.ClientBefore(unitsvc.RequestIDToHTTPRequest()),
+	}
+	client := func(method, name string, enc httptransport.EncodeRequestFunc, dec httptransport.DecodeResponseFunc) endpoint.Endpoint {
+		return kitot.TraceClient(tracer, "classsvc."+name)(httptransport.NewClient(method, tgt, enc, decodeClassServiceError(dec), options...).Endpoint())
+	}
+
+	return classsvc.Endpoints{
+		ListClassesEndpoint: client("GET", "ListClasses", classsvc.EncodeListClassesRequest, classsvc.DecodeListClassesResponse),
+		GetClassEndpoint:    client("GET", "GetClass", classsvc.EncodeGetClassRequest, classsvc.DecodeGetClassResponse),
+		CreateClassEndpoint: client("POST", "CreateClass", classsvc.EncodeCreateClassRequest, classsvc.DecodeCreateClassResponse),
+		UpdateClassEndpoint: client("PATCH", "UpdateClass", classsvc.EncodeUpdateClassRequest, classsvc.DecodeUpdateClassResponse),
+		DeleteClassEndpoint: client("DELETE", "DeleteClass", classsvc.EncodeDeleteClassRequest, classsvc.DecodeDeleteClassResponse),
+		JoinClassEndpoint:   client("POST", "JoinClass", classsvc.EncodeJoinClassRequest, classsvc.DecodeJoinClassResponse),
+		SetRoleEndpoint:     client("PATCH", "SetRole", classsvc.EncodeSetRoleRequest, classsvc.DecodeSetRoleResponse),
+		LeaveClassEndpoint:  client("DELETE", "LeaveClass", classsvc.EncodeLeaveClassRequest, classsvc.DecodeLeaveClassResponse),
+		ListMembersEndpoint: client("GET", "ListMembers", classsvc.EncodeListMembersRequest, classsvc.DecodeListMembersResponse),
+		GetMemberEndpoint:   client("GET", "GetMember", classsvc.EncodeGetMemberRequest, classsvc.DecodeGetMemberResponse),
+	}, nil
+}
+
+// decodeClassServiceError wraps dec so that error responses come back as the classsvc errors
+// behind their status codes. classsvc's own response types cannot decode their error field.
+func decodeClassServiceError(dec httptransport.DecodeResponseFunc) httptransport.DecodeResponseFunc {
+	return func(ctx context.Context, resp *http.Response) (interface{}, error) {
+		switch resp.StatusCode {
+		case http.StatusOK:
+			return dec(ctx, resp)
+		case http.StatusNotFound:
+			return nil, classsvc.ErrNotFound
+		case http.StatusUnauthorized:
+			return nil, classsvc.ErrUnauthorized
+		case http.StatusForbidden:
+			return nil, classsvc.ErrForbidden
+		default:
+			return nil, fmt.Errorf("classsvc responded %s", resp.Status)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/studiously/unitsvc/ddl"
+	"github.com/studiously/unitsvc/unitsvc"
+)
+
+var (
+	exportClass  string
+	exportFormat string
+	exportOutput string
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Exports the units of a class from the database",
+	Long: `Exports the units of a class straight from the database configured by DATABASE_DRIVER and DATABASE_CONFIG, without checking who is asking. It is meant for operators moving a class to another system; teachers export their own classes at /units/export.
+
+The default format, imscc, is an IMS Common Cartridge that Canvas, Moodle and other learning management systems import as a course with one module per unit. json and csv are the formats of /units/export, which /units/import accepts.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		classID, err := uuid.Parse(exportClass)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "invalid --class:", err)
+			os.Exit(-1)
+		}
+		switch exportFormat {
+		case unitsvc.FormatCommonCartridge, unitsvc.FormatJSON, unitsvc.FormatCSV:
+		default:
+			fmt.Fprintln(os.Stderr, "unsupported --format:", exportFormat)
+			os.Exit(-1)
+		}
+		repo, db := mustOpenRepository()
+		defer db.Close()
+
+		out := os.Stdout
+		if exportOutput != "" && exportOutput != "-" {
+			if out, err = os.Create(exportOutput); err != nil {
+				fmt.Fprintln(os.Stderr, "could not create output file:", err)
+				os.Exit(-1)
+			}
+		}
+		err = exportUnits(context.Background(), repo, classID, exportFormat, out)
+		if out != os.Stdout {
+			if cerr := out.Close(); err == nil {
+				err = cerr
+			}
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "export failed:", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+// mustOpenRepository opens the database configured by DATABASE_DRIVER and DATABASE_CONFIG for a
+// command that works on it directly, exiting if it cannot be used. Such commands never migrate
+// the database, but an outdated one cannot be used.
+func mustOpenRepository() (unitsvc.Repository, *sql.DB) {
+	driver := viper.GetString("database.driver")
+	db, err := openDatabase(driver, viper.GetString("database.config"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "database connection failed:", err)
+		os.Exit(-1)
+	}
+	if pending, err := ddl.Pending(driver, db); err != nil {
+		fmt.Fprintln(os.Stderr, "could not check database migrations:", err)
+		os.Exit(-1)
+	} else if pending > 0 {
+		fmt.Fprintf(os.Stderr, "database has %d migrations pending; run unitsvc host to apply them\n", pending)
+		os.Exit(-1)
+	}
+	repo, err := unitsvc.NewRepository(driver, db)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "database driver not supported:", err)
+		os.Exit(-1)
+	}
+	return repo, db
+}
+
+// exportUnits writes the units of a class in repo to w in format. A class without units is
+// exported as empty, as the service cannot tell it apart from a class that does not exist.
+func exportUnits(ctx context.Context, repo unitsvc.Repository, classID uuid.UUID, format string, w io.Writer) error {
+	units, err := repo.UnitsByClassID(ctx, classID)
+	if err != nil {
+		return err
+	}
+	return unitsvc.WriteExport(w, format, classID, units)
+}
+
+func init() {
+	RootCmd.AddCommand(exportCmd)
+
+	exportCmd.Flags().StringVar(&exportClass, "class", "", "ID of the class to export")
+	exportCmd.Flags().StringVar(&exportFormat, "format", unitsvc.FormatCommonCartridge, "Export format: 'imscc', 'json' or 'csv'")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "-", "File to write the export to, or - for standard output")
+}
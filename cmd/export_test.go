@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/studiously/unitsvc/models"
+	"github.com/studiously/unitsvc/unitsvc"
+)
+
+func TestExportUnits(t *testing.T) {
+	ctx := context.Background()
+	repo := unitsvc.NewMemoryRepository()
+	classID := uuid.New()
+	for i, title := range []string{"Cells", "Genetics"} {
+		err := repo.SaveUnit(ctx, &models.Unit{
+			ID:           uuid.New(),
+			ClassID:      classID,
+			Title:        title,
+			DisplayOrder: i,
+			CreatedAt:    time.Now().UTC(),
+		})
+		if err != nil {
+			t.Fatalf("SaveUnit: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := exportUnits(ctx, repo, classID, unitsvc.FormatCommonCartridge, &buf); err != nil {
+		t.Fatalf("exportUnits: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("export is not a zip file: %v", err)
+	}
+	if len(zr.File) != 1 || zr.File[0].Name != "imsmanifest.xml" {
+		t.Fatalf("got files %v, want imsmanifest.xml", zr.File)
+	}
+	rc, err := zr.File[0].Open()
+	if err != nil {
+		t.Fatalf("open manifest: %v", err)
+	}
+	manifest, err := ioutil.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatalf("read manifest: %v", err)
+	}
+	cells, genetics := strings.Index(string(manifest), "<title>Cells</title>"), strings.Index(string(manifest), "<title>Genetics</title>")
+	if cells < 0 || genetics < cells {
+		t.Errorf("manifest does not list Cells then Genetics:\n%s", manifest)
+	}
+}
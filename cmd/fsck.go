@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/google/uuid"
+	"github.com/nats-io/go-nats"
+	"github.com/opentracing/opentracing-go"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/studiously/classsvc/classsvc"
+	"github.com/studiously/introspector"
+	"github.com/studiously/unitsvc/models"
+	"github.com/studiously/unitsvc/unitsvc"
+)
+
+// fsckPageSize is how many classes are read from the database at a time.
+const fsckPageSize = 100
+
+// errNoClassFound stops a fix that would delete the units of every class, which is what
+// credentials that cannot read classes look like.
+var errNoClassFound = errors.New("none of the classes were found in classsvc; check that the credentials can read every class")
+
+var fsckFix bool
+
+// fsckCmd represents the fsck command
+var fsckCmd = &cobra.Command{
+	Use:   "fsck",
+	Short: "Checks the units in the database for orphaned classes and duplicate display orders",
+	Long: `Checks every class with units in the database configured by DATABASE_DRIVER and DATABASE_CONFIG. Classes deleted in classsvc leave their units behind, and units that share a display order in a class are listed in an arbitrary order.
+
+Classes are looked up in the classsvc instance at CLASSSVC_ADDR with a token that the Hydra cluster at HYDRA_CLUSTER_URL issues to HYDRA_CLIENT_ID, which must be allowed to read every class.
+
+With --fix, the units of orphaned classes are deleted and the units of other classes are renumbered in their current order. Fixes are made through the service, so they are audited, queued for webhooks and published to NATS_CLUSTER_URL, if it is set, like any other change. Nothing is fixed if none of the classes were found, as that is more likely a problem with the credentials.
+
+The command exits with 1 if it found problems that it did not fix.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 0 {
+			cmd.Usage()
+			os.Exit(-1)
+		}
+		repo, db := mustOpenRepository()
+		defer db.Close()
+		cs, err := makeClassServiceClient(viper.GetString("classsvc.addr"), opentracing.NoopTracer{}, log.NewNopLogger())
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "invalid classsvc address:", err)
+			os.Exit(-1)
+		}
+
+		token, err := requestToken([]string{"classes.get"})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "could not get a token to look up classes:", err)
+			os.Exit(-1)
+		}
+
+		ctx := context.WithValue(context.Background(), introspector.TokenContextKey, token)
+		report, err := checkClasses(ctx, repo, classExists(cs))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "check failed:", err)
+			os.Exit(-1)
+		}
+		if fsckFix && report.problems() > 0 {
+			service := unitsvc.New(repo, nil, unitsvc.SkipAuthorization(), unitsvc.MaxVersions(viper.GetInt("versions.max")))
+			if url := viper.GetString("nats.cluster_url"); url != "" {
+				nc, err := nats.Connect(url)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, "could not connect to NATS cluster:", err)
+					os.Exit(-1)
+				}
+				defer nc.Close()
+				defer nc.FlushTimeout(5 * time.Second)
+				service = unitsvc.MessagingMiddleware(nc)(service)
+			}
+			err = fixClasses(ctx, service, report)
+			writeFsckReport(os.Stdout, report)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "fix failed:", err)
+				os.Exit(-1)
+			}
+			return
+		}
+		writeFsckReport(os.Stdout, report)
+		if report.problems() > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// fsckReport is what a check found in the classes with units, and what was fixed.
+type fsckReport struct {
+	Classes    int         // classes with units that were checked
+	Orphans    []fsckClass // classes that no longer exist
+	Duplicates []fsckClass // classes whose units share display orders
+	Fixed      int         // problems fixed, in the order above
+}
+
+// fsckClass is a class with a problem, and its units in display order.
+type fsckClass struct {
+	ClassID uuid.UUID
+	Units   []*models.Unit
+}
+
+func (r *fsckReport) problems() int {
+	return len(r.Orphans) + len(r.Duplicates)
+}
+
+// classExists returns a function that looks up classes in cs. The token of the context is used
+// to look them up, so it must be allowed to read every class.
+func classExists(cs classsvc.Service) func(ctx context.Context, classID uuid.UUID) (bool, error) {
+	return func(ctx context.Context, classID uuid.UUID) (bool, error) {
+		_, err := cs.GetClass(ctx, classID)
+		switch err {
+		case nil:
+			return true, nil
+		case classsvc.ErrNotFound:
+			return false, nil
+		default:
+			return false, err
+		}
+	}
+}
+
+// checkClasses checks every class with units in repo, a page at a time. Classes for which exists
+// is false are orphaned.
+func checkClasses(ctx context.Context, repo unitsvc.Repository, exists func(ctx context.Context, classID uuid.UUID) (bool, error)) (*fsckReport, error) {
+	report := &fsckReport{}
+	after := uuid.Nil
+	for {
+		classIDs, err := repo.ClassIDs(ctx, after, fsckPageSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(classIDs) == 0 {
+			return report, nil
+		}
+		for _, classID := range classIDs {
+			ok, err := exists(ctx, classID)
+			if err != nil {
+				return nil, fmt.Errorf("class %v: %v", classID, err)
+			}
+			units, err := repo.UnitsByClassID(ctx, classID)
+			if err != nil {
+				return nil, err
+			}
+			report.Classes++
+			if !ok {
+				report.Orphans = append(report.Orphans, fsckClass{classID, units})
+			} else if hasDuplicateOrders(units) {
+				report.Duplicates = append(report.Duplicates, fsckClass{classID, units})
+			}
+			after = classID
+		}
+	}
+}
+
+func hasDuplicateOrders(units []*models.Unit) bool {
+	seen := make(map[int]bool, len(units))
+	for _, u := range units {
+		if seen[u.DisplayOrder] {
+			return true
+		}
+		seen[u.DisplayOrder] = true
+	}
+	return false
+}
+
+// fixClasses deletes the units of the orphaned classes of report, and renumbers the units of the
+// classes with duplicate display orders, through service. It counts the problems it fixed in
+// report.Fixed, and stops at the first it cannot fix.
+func fixClasses(ctx context.Context, service unitsvc.Service, report *fsckReport) error {
+	if report.Classes > 0 && len(report.Orphans) == report.Classes {
+		return errNoClassFound
+	}
+	for _, c := range report.Orphans {
+		for _, u := range c.Units {
+			if err := service.DeleteUnit(ctx, u.ID); err != nil && err != unitsvc.ErrNotFound {
+				return fmt.Errorf("class %v: %v", c.ClassID, err)
+			}
+		}
+		report.Fixed++
+	}
+	for _, c := range report.Duplicates {
+		rows := make([]unitsvc.ImportRow, 0, len(c.Units))
+		for i, u := range c.Units {
+			rows = append(rows, unitsvc.ImportRow{Row: i + 1, ID: u.ID, Title: u.Title})
+		}
+		// A dry run first, so that units created since the check are not deleted.
+		result, err := service.ImportUnits(ctx, c.ClassID, rows, true)
+		if err == nil && (len(result.Created) > 0 || len(result.Deleted) > 0) {
+			err = errors.New("its units changed during the check")
+		}
+		if err == nil {
+			_, err = service.ImportUnits(ctx, c.ClassID, rows, false)
+		}
+		if err != nil {
+			return fmt.Errorf("class %v: %v", c.ClassID, err)
+		}
+		report.Fixed++
+	}
+	return nil
+}
+
+// writeFsckReport writes report as text, one line per problem.
+func writeFsckReport(w io.Writer, report *fsckReport) {
+	fmt.Fprintf(w, "%d classes checked\n", report.Classes)
+	for _, c := range report.Orphans {
+		fmt.Fprintf(w, "class %v no longer exists: %d units\n", c.ClassID, len(c.Units))
+	}
+	for _, c := range report.Duplicates {
+		fmt.Fprintf(w, "class %v has units with the same display order\n", c.ClassID)
+	}
+	if report.Fixed > 0 {
+		fmt.Fprintf(w, "%d of %d problems fixed\n", report.Fixed, report.problems())
+	}
+}
+
+// startFsck checks the classes in repo against cs now and then every interval, until the
+// returned stop function is called, and logs what it finds. It never fixes anything.
+func startFsck(repo unitsvc.Repository, cs classsvc.Service, interval time.Duration, logger log.Logger) (stop func(ctx context.Context) error) {
+	return startPeriodic(interval, func(ctx context.Context) {
+		token, err := requestToken([]string{"classes.get"})
+		if err != nil {
+			logger.Log("msg", "could not get a token to look up classes", "error", err)
+			return
+		}
+		report, err := checkClasses(context.WithValue(ctx, introspector.TokenContextKey, token), repo, classExists(cs))
+		if err != nil {
+			logger.Log("msg", "could not check classes", "error", err)
+			return
+		}
+		if report.Classes > 0 && len(report.Orphans) == report.Classes {
+			logger.Log("msg", errNoClassFound, "classes", report.Classes)
+			return
+		}
+		for _, c := range report.Orphans {
+			logger.Log("msg", "class no longer exists", "class_id", c.ClassID, "units", len(c.Units))
+		}
+		for _, c := range report.Duplicates {
+			logger.Log("msg", "class has units with the same display order", "class_id", c.ClassID)
+		}
+		logger.Log("msg", "checked classes", "classes", report.Classes, "problems", report.problems())
+	})
+}
+
+func init() {
+	RootCmd.AddCommand(fsckCmd)
+
+	fsckCmd.Flags().BoolVar(&fsckFix, "fix", false, "Delete the units of orphaned classes and renumber duplicate display orders")
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/studiously/unitsvc/models"
+	"github.com/studiously/unitsvc/unitsvc"
+	unittesting "github.com/studiously/unitsvc/unitsvc/testing"
+)
+
+func TestFsck(t *testing.T) {
+	ctx := unittesting.NewContext(uuid.New())
+	repo := unitsvc.NewMemoryRepository()
+	healthy, duplicated, orphaned := uuid.New(), uuid.New(), uuid.New()
+	for classID, orders := range map[uuid.UUID][]int{
+		healthy:    {0, 1, 2},
+		duplicated: {0, 1, 1},
+		orphaned:   {0, 1},
+	} {
+		for i, order := range orders {
+			unit := &models.Unit{ID: uuid.New(), ClassID: classID, Title: string('A' + rune(i)), DisplayOrder: order, CreatedAt: time.Now()}
+			if err := repo.SaveUnit(ctx, unit); err != nil {
+				t.Fatalf("SaveUnit: %v", err)
+			}
+		}
+	}
+	exists := func(ctx context.Context, classID uuid.UUID) (bool, error) {
+		return classID != orphaned, nil
+	}
+
+	report, err := checkClasses(ctx, repo, exists)
+	if err != nil {
+		t.Fatalf("checkClasses: %v", err)
+	}
+	if report.Classes != 3 || len(report.Orphans) != 1 || report.Orphans[0].ClassID != orphaned ||
+		len(report.Duplicates) != 1 || report.Duplicates[0].ClassID != duplicated {
+		t.Fatalf("got report %+v", report)
+	}
+
+	service := unitsvc.New(repo, nil, unitsvc.SkipAuthorization())
+	if err := fixClasses(ctx, service, report); err != nil {
+		t.Fatalf("fixClasses: %v", err)
+	}
+	var buf bytes.Buffer
+	writeFsckReport(&buf, report)
+	if !strings.Contains(buf.String(), "2 of 2 problems fixed") {
+		t.Errorf("got report:\n%s", buf.String())
+	}
+
+	if units, err := repo.UnitsByClassID(ctx, orphaned); err != nil || len(units) != 0 {
+		t.Errorf("got %d units of the orphaned class, %v; want none", len(units), err)
+	}
+	if entries, err := repo.AuditByClassID(ctx, orphaned); err != nil || len(entries) != 2 {
+		t.Errorf("got %d audit entries of the orphaned class, %v; want 2", len(entries), err)
+	}
+	report, err = checkClasses(ctx, repo, exists)
+	if err != nil || report.problems() != 0 || report.Classes != 2 {
+		t.Errorf("checkClasses after fix: got %+v, %v; want no problems", report, err)
+	}
+}
+
+func TestFsckNoClassFound(t *testing.T) {
+	ctx := unittesting.NewContext(uuid.New())
+	repo := unitsvc.NewMemoryRepository()
+	unit := &models.Unit{ID: uuid.New(), ClassID: uuid.New(), Title: "Cells", CreatedAt: time.Now()}
+	if err := repo.SaveUnit(ctx, unit); err != nil {
+		t.Fatalf("SaveUnit: %v", err)
+	}
+	report, err := checkClasses(ctx, repo, func(context.Context, uuid.UUID) (bool, error) { return false, nil })
+	if err != nil {
+		t.Fatalf("checkClasses: %v", err)
+	}
+	if err := fixClasses(ctx, unitsvc.New(repo, nil, unitsvc.SkipAuthorization()), report); err != errNoClassFound {
+		t.Errorf("fixClasses: got %v, want errNoClassFound", err)
+	}
+	if _, err := repo.UnitByID(ctx, unit.ID); err != nil {
+		t.Errorf("UnitByID after refused fix: %v", err)
+	}
+}
@@ -1,34 +1,44 @@
 package cmd
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/go-kit/kit/log"
-	"github.com/go-kit/kit/metrics"
-	"github.com/go-kit/kit/metrics/prometheus"
+	"github.com/nats-io/go-nats"
+	"github.com/opentracing/opentracing-go"
 	"github.com/ory/hydra/oauth2"
 	"github.com/ory/hydra/sdk"
 	stdprometheus "github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
-	"github.com/rubenv/sql-migrate"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/studiously/classsvc/classsvc"
 	"github.com/studiously/unitsvc/ddl"
+	"github.com/studiously/unitsvc/health"
+	"github.com/studiously/unitsvc/logging"
+	"github.com/studiously/unitsvc/tracing"
 	"github.com/studiously/unitsvc/unitsvc"
 )
 
 var (
-	addr         string
-	debugAddr    string
+	addr          string
+	debugAddr     string
+	devMode       bool
+	storage       string
+	drainTimeout  time.Duration
+	shutdownDelay time.Duration
 )
 
 // hostCmd represents the host command
@@ -43,8 +53,18 @@ All possible controls are listed below. The host process additionally exposes a
 
 Core Controls
 =============
-- DATABASE_DRIVER: The driver to use with the database. Only 'postgres' is currently supported.
-- DATABASE_CONFIG: A URL to a persistent backend.
+- DATABASE_DRIVER: The driver to use with the database. One of 'postgres', 'mysql' or 'sqlite'.
+- DATABASE_CONFIG: A URL to a persistent backend. MySQL DSNs must set parseTime=true, e.g. "user:pass@tcp(localhost:3306)/unitsvc?parseTime=true". For SQLite, this is a file path.
+
+With --storage=memory, units are kept in process memory and no database is used. With --dev, DATABASE_DRIVER is forced to 'sqlite' and DATABASE_CONFIG defaults to a fresh file in the system temp directory.
+
+Logging Controls
+================
+- LOG_LEVEL: One of 'debug', 'info' (the default), 'warn' or 'error'.
+- LOG_FORMAT: One of 'logfmt' (the default) or 'json'.
+- LOG_REDACT: Comma-separated log keys whose values are replaced with [REDACTED], e.g. "title". Tokens, secrets and passwords are always redacted.
+- LOG_SAMPLE_READS: Log only one in this many successful reads (ListUnits and GetUnit). Defaults to logging every read.
+- LOG_AUDIT_FILE: File to append the audit stream to. Every successful mutation is audited, regardless of LOG_LEVEL and LOG_SAMPLE_READS. By default, audit lines go to the main log with stream=audit.
 
 Class Service Controls
 ======================
@@ -62,35 +82,127 @@ A Hydra server is required to perform token introspection and thus authorization
 Messaging Controls
 =============
 A NATS cluster is required for messaging across services. Without it, stale data pertaining to deleted resources may remain in the database, merely becoming inaccessible.
+Each change is published to its subject, such as units.create, with the ID as text, and to the same subject prefixed with "v2." with a JSON event of the ID and request ID. The unprefixed subjects are deprecated.
 - NATS_CLUSTER_URL: URL of NATS cluster.
+
+Audit Controls
+==============
+Every change to a unit is recorded in the database with who made it, from which client and request, and the unit before and after. Teachers can read the history of a unit at /units/{unitID}/history and of a whole class at /audit/?classID=, given the units.audit scope.
+- AUDIT_RETENTION: How long audit entries are kept, e.g. "2160h" for 90 days. Older entries are deleted hourly. Defaults to keeping them forever.
+
+Every version of a unit is also kept as a snapshot. Teachers can list them at /units/{unitID}/versions, with the changes from each version to the next, and revert a unit to an earlier version by POSTing {"version": n} to /units/{unitID}/revert.
+- VERSIONS_MAX: How many versions are kept for each unit; the oldest are deleted first. Defaults to keeping every version.
+
+Streaming Controls
+==================
+Clients can follow the changes to a class instead of polling /units/?classID=. /units/stream?classID= streams them as Server-Sent Events, and /units/stream/ws?classID= as WebSocket messages. Both need the units.list scope, and accept the access token in an access_token query parameter for browsers, which cannot set headers on these requests. The token and the user's membership of the class are checked again every minute, and the stream is closed once either is no longer valid. Units moved to another place in the class arrive as 'reordered' rather than 'updated' events. Clients resume after the event in the Last-Event-ID header or lastEventID query parameter; if too many events were missed, they receive a 'reset' event and should reload the class. Across several instances, changes are only streamed from other instances if NATS_CLUSTER_URL is set.
+- STREAM_BACKLOG: How many recent events are kept for resuming streams. Defaults to 1000.
+
+Webhook Controls
+================
+Integrations that cannot connect to NATS can receive the same events over HTTP. Teachers subscribe a URL to a class by POSTing {"class_id", "url", "secret", "events"} to /webhooks, where events optionally limits deliveries to some of units.create, units.rename, units.delete, units.revert, units.current, which is sent when a teacher moves the class to another unit, and units.progress, which is sent when a student marks their progress through a unit. They list webhooks at /webhooks?classID=, delete them at /webhooks/{webhookID}, and read the delivery log at /webhooks/{webhookID}/deliveries, or only the deliveries that were given up on with ?status=dead. All of these need the units.webhooks scope.
+Each change is queued in the database with the change itself and POSTed as the JSON event published to the "v2." NATS subjects, with X-Unitsvc-Event, X-Unitsvc-Delivery, X-Unitsvc-Timestamp and X-Unitsvc-Signature headers. The signature is "sha256=" and the hex HMAC-SHA256 of the timestamp, a period and the body, keyed with the webhook's secret. Failed deliveries are retried with exponential backoff from 30 seconds up to an hour between attempts. Events may be delivered more than once; receivers should ignore repeated X-Unitsvc-Delivery IDs. Deliveries are only made to public addresses: URLs that resolve to loopback, private, link-local or other reserved addresses fail with "receiver did not respond", and the cause is only logged.
+- WEBHOOKS_INTERVAL: How often queued deliveries are checked for. Defaults to "5s".
+- WEBHOOKS_TIMEOUT: How long a receiver has to respond before the attempt fails. Defaults to "10s".
+- WEBHOOKS_MAX_ATTEMPTS: How many times a delivery is attempted before it is given up on. Defaults to 10.
+
+Import and Export
+=================
+Teachers can keep the units of a class in a spreadsheet. /units/export?classID= downloads them in display order as JSON, or with format=csv as CSV with id, display_order, title, created_at, description, metadata, parent_id, resources, status, starts_at and ends_at columns, where metadata is a JSON object of strings, parent_id is the id of the unit a unit is nested in, or empty for a top-level unit, resources is a JSON array of links with a title and an http or https url, status is draft, published or archived, and starts_at and ends_at are the RFC 3339 times a unit is scheduled between, either of which may be empty. New columns are only ever added at the end. With format=imscc, it downloads an IMS Common Cartridge with a module for each top-level unit, holding an item for each unit nested in it, an Overview page with its description and a web link for each of its resources, for learning management systems such as Canvas and Moodle; "unitsvc export" writes the same files straight from the database. Exports need the units.export scope. POSTing an edited export to /units/import?classID= makes the class match it in one transaction: rows with an id update that unit, rows without one create a unit, and units missing from the file are deleted. CSV files need only a title column; without display_order, units are ordered by row, and without description, metadata, parent_id, resources, status or the schedule columns, units keep theirs, and new units are published and unscheduled. A parent_id must be the id of another row; units nested in a unit that the import deletes move up to its nearest ancestor that it keeps, just as deleting a unit moves the units nested in it up to its parent. The format is read from the format parameter, or else the Content-Type. Cartridges are imported as one unit for each module of their organization, and for each item without content nested in one, in the module's unit; siblings are ordered as they are listed. Web links become the units' resources, and other content is left out apart from the Overview pages of exported cartridges. With dryRun=true, the changes are reported but not made. If any row is invalid, nothing changes and the 422 response lists the problem with each row. Imports need the units.import scope and are limited to 1 MB.
+
+My Units
+========
+Dashboards can list the units of every class the user belongs to at /units/?mine=true instead of one class at a time, given the units.list scope. Units are grouped by class, and classes come in pages of 20, or up to 100 with limit. Each page but the last has a "next" class ID to pass as after for the following page. Units can be filtered by status, given once for each status to include, and to those scheduled in a window with from and to, RFC 3339 times either of which may be left out; units without a starts_at or ends_at are left out of a window, and one without the other is open on that side.
+
+Search
+======
+/units/search?q= finds units by title and description across every class the user belongs to, best match first, each with a snippet of its title with the matched words between <mark> and </mark>. Repeated classID parameters search only those classes, and limit returns up to 100 matches instead of 20. Search needs the units.search scope. On PostgreSQL, titles and descriptions are searched with a full-text index, so "cell" also finds "Cells", and units whose titles match rank above those whose descriptions do; other databases find units whose titles or descriptions contain every word of the query.
+
+Current Unit
+============
+Each class can be on one of its units, which unitsvc keeps instead of classsvc's current_unit field. Members get it at /units/current?classID= with the units.get scope. Teachers move the class by PUTting {"unit_id"} to it, or the nil UUID to move it to no unit, and POST to /units/current/advance?classID= to move it to the next unit in display order, or the first if it is on none. Both need the units.current scope and publish a units.current event with the class ID. When the unit a class is on is deleted, by any means, the class moves to the unit that takes its place, or the last unit if it was the last, or to no unit if none are left.
+
+Student Progress
+================
+Students record how far they have got through a unit by PUTting {"status"} to /units/{unitID}/progress, where status is one of not_started, in_progress and completed; each change publishes a units.progress event with the unit ID. Progress keeps when the student started the unit and when they completed it, and marking it not_started again clears both. Teachers see every student's progress through each unit of a class at /progress/?classID=, with the students listed by classsvc and units they never marked shown as not_started. Both need the units.progress scope. A unit's progress is deleted with it.
+
+OneRoster Sync
+==============
+Classes can get their units from the gradebook of a student information system. A teacher links a OneRoster class to a class they teach by PUTting {"class_id"} to /oneroster/classes/{sourcedId}, and POSTs OneRoster 1.1 CSV bundles to /oneroster/sync. Each line item category of a linked class becomes a unit of it, and the unit made for each category is remembered, so later syncs rename its unit when the category is renamed and delete it when the category is removed: by leaving it out of a bulk bundle, or marking it tobedeleted in a delta bundle. Units that a teacher renamed or deleted since the last sync are left as they are and reported as conflicts, unless overwrite=true is given; a removed category's renamed unit is kept but no longer synced. The response reports the units created, renamed and deleted in each class, the conflicts, and the classes that were skipped because they are not linked or not taught by the user. With dryRun=true, the changes are reported but not made. Both need the units.oneroster scope, and bundles are limited to 1 MB. Operators link and import for every class with "unitsvc oneroster link" and "unitsvc oneroster import".
+
+Consistency Checks
+==================
+Units are not deleted when their class is deleted in classsvc. "unitsvc fsck" reports the classes with units that classsvc no longer has, and the classes whose units share a display order; with --fix, it deletes the units of the former and renumbers the units of the latter, publishing the events of every change. It looks classes up with a token that Hydra issues to HYDRA_CLIENT_ID, which must be allowed to read every class.
+- FSCK_INTERVAL: How often to run the same checks in the background and log what they find, without fixing anything. Disabled unless set, as in "24h".
+
+Tracing Controls
+================
+Requests are traced with OpenTracing, including calls to Hydra, classsvc and the database. Traces are continued from, and propagated to other services in, Zipkin B3 headers.
+- TRACING_EXPORTER: Where finished spans go. One of 'none' (the default), 'stdout', which logs each span for local runs, or 'zipkin'.
+- TRACING_ZIPKIN_URL: The Zipkin HTTP collector the 'zipkin' exporter reports spans to in batches, as "http://zipkin:9411/api/v2/spans". Spans still queued are sent on shutdown.
+
+Health
+======
+The debug server serves /healthz, which responds 200 whenever the process is up, and /readyz, which responds 200 only if the database, its migrations, classsvc, Hydra and NATS are all usable. /readyz reports each dependency's status and latency as JSON, and fails as soon as a shutdown signal arrives.
+
+Metrics
+=======
+The debug server serves Prometheus metrics on /metrics: per-method service latency histograms and in-flight gauges, per-route HTTP request counts by status code, database connection pool statistics and the latency of calls to classsvc and Hydra.
+
+Shutdown
+========
+On SIGINT or SIGTERM, /readyz fails immediately and the host waits --shutdown-delay for load balancers to notice. It then stops accepting requests and lets in-flight ones finish, flushes pending NATS publishes and closes the database, in that order. All of this must complete within --drain-timeout; components still running after that are closed forcibly.
 `,
 	Run: func(cmd *cobra.Command, args []string) {
-		var logger log.Logger
+		// Components register how to stop themselves as they start; they are stopped in reverse.
+		var stops shutdown
+
+		var logger, audit log.Logger
 		{
-			logger = log.NewLogfmtLogger(logrus.StandardLogger().Out)
+			format := viper.GetString("log.format")
+			redact := strings.FieldsFunc(viper.GetString("log.redact"), func(r rune) bool { return r == ',' || r == ' ' })
+			var err error
+			logger, err = logging.New(logrus.StandardLogger().Out, format, viper.GetString("log.level"))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "invalid logging configuration:", err)
+				os.Exit(-1)
+			}
+			logger = logging.Redact(logger, redact...)
 			logger = log.With(logger, "ts", log.DefaultTimestampUTC)
 			logger = log.With(logger, "caller", log.DefaultCaller)
+
+			audit = log.With(logger, "stream", "audit")
+			if path := viper.GetString("log.audit_file"); path != "" {
+				f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+				if err != nil {
+					logger.Log("msg", "could not open audit log", "error", err, "path", path)
+					os.Exit(-1)
+				}
+				stops.add("audit log", func(context.Context) error { return f.Close() })
+				audit, _ = logging.New(f, format, "debug")
+				audit = logging.Redact(audit, redact...)
+				audit = log.With(audit, "ts", log.DefaultTimestampUTC)
+			}
 		}
 
 		// Set up metrics
-		var duration metrics.Histogram
-		{
-			// Transport level metrics.
-			duration = prometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
-				Namespace: "unitsvc",
-				Name:      "request_duration_ns",
-				Help:      "Request duration in nanoseconds.",
-			}, []string{"method", "success"})
-		}
-		var requestCount metrics.Counter
+		var hm = newHostMetrics(stdprometheus.DefaultRegisterer)
+
+		var tracer opentracing.Tracer
 		{
-			requestCount = prometheus.NewCounterFrom(stdprometheus.CounterOpts{
-				Namespace: "unitsvc",
-				Name:      "request_count",
-				Help:      "Total count of requests to all endpoints.",
-			}, []string{})
+			var flush func() error
+			var err error
+			tracer, flush, err = tracing.New(viper.GetString("tracing.exporter"), viper.GetString("tracing.zipkin.url"), os.Stdout)
+			if err != nil {
+				logger.Log("msg", "could not set up tracing", "error", err)
+				os.Exit(-1)
+			}
+			stops.add("tracing", func(context.Context) error { return flush() })
 		}
 
+		// Readiness checks are registered as each dependency is set up.
+		var checks = health.New(2 * time.Second)
+
 		var introspector oauth2.Introspector
 		{
 			client, err := sdk.Connect(
@@ -104,15 +216,32 @@ A NATS cluster is required for messaging across services. Without it, stale data
 				logger.Log("msg", "could not connect to Hydra cluster", "error", err, "cluster_url", viper.GetString("hydra.cluster_url"))
 				os.Exit(-1)
 			}
-			introspector = client.Introspection
+			introspector = instrumentedIntrospector{tracedIntrospector{client.Introspection}, hm.dependencyLatency}
+			checks.Add("hydra", health.HTTP(http.DefaultClient, strings.TrimSuffix(viper.GetString("hydra.cluster_url"), "/")+"/health"))
 		}
 
-		var db *sql.DB
-		{
+		var repo unitsvc.Repository
+		switch storage {
+		case "memory":
+			logger.Log("msg", "storing units in memory; they will be lost on exit")
+			repo = unitsvc.NewMemoryRepository()
+		case "database":
 			var driver = viper.GetString("database.driver")
 			var config = viper.GetString("database.config")
+			if devMode {
+				driver = "sqlite"
+				if config == "" {
+					dir, err := ioutil.TempDir("", "unitsvc")
+					if err != nil {
+						logger.Log("msg", "could not create development database", "error", err)
+						os.Exit(-1)
+					}
+					config = filepath.Join(dir, "units.db")
+				}
+				logger.Log("msg", "development mode: using SQLite database", "path", config)
+			}
 
-			db, err := sql.Open(driver, config)
+			db, err := openDatabase(driver, config)
 			if err != nil {
 				logger.Log("msg", "database connection failed", "error", err)
 				os.Exit(-1)
@@ -121,52 +250,180 @@ A NATS cluster is required for messaging across services. Without it, stale data
 				logger.Log("msg", "database unresponsive")
 				os.Exit(-1)
 			}
-			if err := setupDatabase(driver, db); err != nil {
+			if err := ddl.Migrate(driver, db); err != nil {
 				logger.Log("msg", "database migrations failed", "error", err)
 				os.Exit(-1)
 			}
+			repo, err = unitsvc.NewRepository(driver, db)
+			if err != nil {
+				logger.Log("msg", "database driver not supported", "error", err, "driver", driver)
+				os.Exit(-1)
+			}
+			stops.add("database", func(context.Context) error { return db.Close() })
+			stdprometheus.MustRegister(newDBStatsCollector(db, driver))
+			checks.Add("database", health.Ping(db))
+			checks.Add("migrations", func(ctx context.Context) error {
+				pending, err := ddl.Pending(driver, db)
+				if err == nil && pending > 0 {
+					err = fmt.Errorf("%d migrations pending", pending)
+				}
+				return err
+			})
+		default:
+			logger.Log("msg", "unknown storage backend", "storage", storage)
+			os.Exit(-1)
+		}
+
+		if retention := viper.GetDuration("audit.retention"); retention > 0 {
+			stops.add("audit retention", startAuditRetention(repo, retention, auditPurgeInterval, log.With(logger, "component", "audit retention")))
+		}
+
+		{
+			timeout := viper.GetDuration("webhooks.timeout")
+			if timeout <= 0 {
+				timeout = 10 * time.Second
+			}
+			interval := viper.GetDuration("webhooks.interval")
+			if interval <= 0 {
+				interval = 5 * time.Second
+			}
+			logger := log.With(logger, "component", "webhooks")
+			d := unitsvc.NewWebhookDispatcher(repo, unitsvc.NewWebhookClient(timeout), logger)
+			if n := viper.GetInt("webhooks.max_attempts"); n > 0 {
+				d.MaxAttempts = n
+			}
+			stops.add("webhook dispatcher", startWebhookDispatcher(d, interval, logger))
 		}
 
 		var cs classsvc.Service
 		{
-			service, err := classsvc.MakeClientEndpoints(viper.GetString("classsvc.addr"))
+			service, err := makeClassServiceClient(viper.GetString("classsvc.addr"), tracer, logger)
 			if err != nil {
 				logger.Log("msg", "failed to set up classsvc client: is the instance address valid?", "error", err)
 			}
-			cs = service
+			cs = instrumentClassService(service, hm.dependencyLatency)
+			checks.Add("classsvc", health.HTTP(http.DefaultClient, httpURL(viper.GetString("classsvc.addr"))))
 		}
 
-		var service unitsvc.Service
+		if interval := viper.GetDuration("fsck.interval"); interval > 0 {
+			stops.add("consistency checks", startFsck(repo, cs, interval, log.With(logger, "component", "fsck")))
+		}
+
+		var nc *nats.Conn
+		if url := viper.GetString("nats.cluster_url"); url != "" {
+			var err error
+			nc, err = nats.Connect(url)
+			if err != nil {
+				logger.Log("msg", "could not connect to NATS cluster", "error", err, "cluster_url", url)
+				os.Exit(-1)
+			}
+			stops.add("nats", func(ctx context.Context) error {
+				defer nc.Close()
+				timeout := time.Second
+				if deadline, ok := ctx.Deadline(); ok {
+					timeout = time.Until(deadline)
+				}
+				return nc.FlushTimeout(timeout)
+			})
+			checks.Add("nats", func(context.Context) error {
+				if !nc.IsConnected() {
+					return fmt.Errorf("NATS connection is %v", nc.Status())
+				}
+				return nil
+			})
+		} else {
+			logger.Log("msg", "NATS_CLUSTER_URL not set; unit events will not be published")
+		}
+
+		// Changes are streamed to clients from feed. With NATS, every instance's feed receives the
+		// changes made through all instances; otherwise it only receives this instance's changes.
+		var feed *unitsvc.Feed
+		var changes unitsvc.Option
 		{
-			service = unitsvc.New(db, cs)
-			service = unitsvc.LoggingMiddleware(logger)(service)
-			service = unitsvc.InstrumentingMiddleware(requestCount, duration)(service)
+			backlog := viper.GetInt("stream.backlog")
+			if backlog <= 0 {
+				backlog = 1000
+			}
+			feed = unitsvc.NewFeed(backlog)
+			changes = unitsvc.OnChange(feed.OnChange)
+			if nc != nil {
+				changes = unitsvc.OnChange(unitsvc.PublishChanges(nc))
+				_, err := nc.Subscribe(unitsvc.SubjUnitChanges, func(m *nats.Msg) {
+					if err := feed.Receive(m.Data); err != nil {
+						logger.Log("msg", "could not decode unit change", "error", err, "subject", m.Subject)
+					}
+				})
+				if err != nil {
+					logger.Log("msg", "could not subscribe to unit changes", "error", err, "subject", unitsvc.SubjUnitChanges)
+					os.Exit(-1)
+				}
+			}
+		}
 
+		var service unitsvc.Service
+		{
+			service = unitsvc.New(repo, cs, unitsvc.MaxVersions(viper.GetInt("versions.max")), changes)
+			if nc != nil {
+				service = unitsvc.MessagingMiddleware(nc)(service)
+			}
+			service = unitsvc.LoggingMiddleware(logger, audit, viper.GetInt("log.sample_reads"))(service)
+			service = unitsvc.InstrumentingMiddleware(hm.requestCount, hm.requestLatency, hm.requestInFlight)(service)
 		}
 
 		errs := make(chan error, 100)
 
-		go func() {
-			logger := log.With(logger, "transport", "debug")
+		// The debug server is stopped after the HTTP server, so /readyz and /metrics stay up while requests drain.
+		var debugServer *http.Server
+		{
 			m := http.NewServeMux()
 			m.Handle("/metrics", promhttp.Handler())
+			m.Handle("/healthz", checks.LivenessHandler())
+			m.Handle("/readyz", checks.ReadinessHandler())
+			debugServer = &http.Server{Addr: debugAddr, Handler: m}
+			stops.addServer("debug server", debugServer)
+		}
+		go func() {
+			logger := log.With(logger, "transport", "debug")
 			logger.Log("addr", debugAddr)
-			errs <- http.ListenAndServe(debugAddr, m)
+			errs <- debugServer.ListenAndServe()
 		}()
 
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+
+		var httpServer = &http.Server{
+			Addr: addr,
+			Handler: unitsvc.InstrumentHTTPHandler(
+				unitsvc.MakeHTTPHandler(service, feed, logger, introspector, tracer),
+				hm.httpRequestCount, hm.httpRequestLatency, hm.httpRequestInFlight,
+			),
+		}
+		stops.addServer("HTTP server", httpServer)
+		// Streams never go idle by themselves, so they are ended before the HTTP server drains.
+		stops.add("change streams", func(context.Context) error {
+			feed.Close()
+			return nil
+		})
 		go func() {
-			c := make(chan os.Signal, 100)
-			signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
-			errs <- fmt.Errorf("%s", <-c)
+			logger.Log("transport", "HTTP", "addr", addr)
+			errs <- httpServer.ListenAndServe()
 		}()
 
-		var h = unitsvc.MakeHTTPHandler(service, logger, introspector)
-		go func(address string) {
-			logger.Log("transport", "HTTP", "addr", addr)
-			errs <- http.ListenAndServe(address, h)
-		}(addr)
+		select {
+		case sig := <-sigs:
+			logger.Log("exit", sig)
+			// Fail readiness first and keep serving for a while, so that traffic stops being routed here.
+			checks.Shutdown()
+			time.Sleep(shutdownDelay)
+		case err := <-errs:
+			// A server failed to start or stopped by itself; there is no traffic worth waiting for.
+			logger.Log("exit", err)
+			checks.Shutdown()
+		}
 
-		logger.Log("exit", <-errs)
+		ctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+		defer cancel()
+		stops.run(ctx, logger)
 	},
 }
 
@@ -175,17 +432,33 @@ func init() {
 
 	hostCmd.Flags().StringVarP(&addr, "bind-addr", "a", ":8080", "HTTP listen address")
 	hostCmd.Flags().StringVarP(&debugAddr, "debug-addr", "d", ":8081", "Debug and metrics listen address")
+	hostCmd.Flags().BoolVar(&devMode, "dev", false, "Store units in a local SQLite database instead of DATABASE_DRIVER")
+	hostCmd.Flags().StringVar(&storage, "storage", "database", "Where to store units: 'database' or 'memory' (not persisted)")
+	hostCmd.Flags().DurationVar(&drainTimeout, "drain-timeout", 30*time.Second, "How long to wait for in-flight requests and pending messages on shutdown")
+	hostCmd.Flags().DurationVar(&shutdownDelay, "shutdown-delay", 0, "How long to keep serving after readiness fails on shutdown, so load balancers can stop routing here")
 
 }
 
-func setupDatabase(driver string, db *sql.DB) error {
-	var migrations = &migrate.AssetMigrationSource{
-		Asset:    ddl.Asset,
-		AssetDir: ddl.AssetDir,
-		Dir:      driver,
+// httpURL adds the scheme to a bare host:port address, as MakeClientEndpoints does.
+func httpURL(addr string) string {
+	if !strings.HasPrefix(addr, "http") {
+		return "http://" + addr
+	}
+	return addr
+}
+
+// openDatabase opens a database with driver and config. SQLite allows a single writer at a time,
+// so its pool is limited to one connection to avoid "database is locked" errors under
+// concurrent writes.
+func openDatabase(driver, config string) (*sql.DB, error) {
+	db, err := sql.Open(driver, config)
+	if err != nil {
+		return nil, err
+	}
+	if driver == "sqlite" {
+		db.SetMaxOpenConns(1)
 	}
-	_, err := migrate.Exec(db, driver, migrations, migrate.Up)
-	return err
+	return db, nil
 }
 
 func pingDatabase(db *sql.DB) (err error) {
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/prometheus"
+	"github.com/ory/hydra/oauth2"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"github.com/studiously/classsvc/classsvc"
+)
+
+const namespace = "unitsvc"
+
+// hostMetrics holds the metrics served on the debug server's /metrics.
+type hostMetrics struct {
+	// Service level, labelled by method and error.
+	requestCount    metrics.Counter
+	requestLatency  metrics.Histogram
+	requestInFlight metrics.Gauge
+
+	// Transport level, labelled by route, method and status code.
+	httpRequestCount    metrics.Counter
+	httpRequestLatency  metrics.Histogram
+	httpRequestInFlight metrics.Gauge
+
+	// Calls to classsvc and Hydra, labelled by dependency, method and error.
+	dependencyLatency metrics.Histogram
+}
+
+// newHostMetrics creates the host's metrics and registers them with reg.
+func newHostMetrics(reg stdprometheus.Registerer) *hostMetrics {
+	counter := func(subsystem, name, help string, labels ...string) metrics.Counter {
+		cv := stdprometheus.NewCounterVec(stdprometheus.CounterOpts{
+			Namespace: namespace, Subsystem: subsystem, Name: name, Help: help,
+		}, labels)
+		reg.MustRegister(cv)
+		return prometheus.NewCounter(cv)
+	}
+	histogram := func(subsystem, name, help string, labels ...string) metrics.Histogram {
+		hv := stdprometheus.NewHistogramVec(stdprometheus.HistogramOpts{
+			Namespace: namespace, Subsystem: subsystem, Name: name, Help: help,
+			Buckets: stdprometheus.DefBuckets,
+		}, labels)
+		reg.MustRegister(hv)
+		return prometheus.NewHistogram(hv)
+	}
+	gauge := func(subsystem, name, help string, labels ...string) metrics.Gauge {
+		gv := stdprometheus.NewGaugeVec(stdprometheus.GaugeOpts{
+			Namespace: namespace, Subsystem: subsystem, Name: name, Help: help,
+		}, labels)
+		reg.MustRegister(gv)
+		return prometheus.NewGauge(gv)
+	}
+
+	return &hostMetrics{
+		requestCount:    counter("service", "requests_total", "Total count of service method calls.", "method", "error"),
+		requestLatency:  histogram("service", "request_duration_seconds", "Service method call duration in seconds.", "method", "error"),
+		requestInFlight: gauge("service", "requests_in_flight", "Service method calls in progress.", "method"),
+
+		httpRequestCount:    counter("http", "requests_total", "Total count of HTTP requests.", "route", "method", "code"),
+		httpRequestLatency:  histogram("http", "request_duration_seconds", "HTTP request duration in seconds.", "route", "method", "code"),
+		httpRequestInFlight: gauge("http", "requests_in_flight", "HTTP requests in progress.", "route", "method"),
+
+		dependencyLatency: histogram("dependency", "request_duration_seconds", "Duration of calls to classsvc and Hydra in seconds.", "dependency", "method", "error"),
+	}
+}
+
+// instrumentClassService records the latency of every classsvc client call.
+func instrumentClassService(e classsvc.Endpoints, latency metrics.Histogram) classsvc.Endpoints {
+	instrument := func(method string) endpoint.Middleware {
+		return func(next endpoint.Endpoint) endpoint.Endpoint {
+			return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+				defer func(begin time.Time) {
+					latency.With("dependency", "classsvc", "method", method, "error", fmt.Sprint(err != nil)).Observe(time.Since(begin).Seconds())
+				}(time.Now())
+				return next(ctx, request)
+			}
+		}
+	}
+	return classsvc.Endpoints{
+		ListClassesEndpoint: instrument("ListClasses")(e.ListClassesEndpoint),
+		GetClassEndpoint:    instrument("GetClass")(e.GetClassEndpoint),
+		CreateClassEndpoint: instrument("CreateClass")(e.CreateClassEndpoint),
+		UpdateClassEndpoint: instrument("UpdateClass")(e.UpdateClassEndpoint),
+		DeleteClassEndpoint: instrument("DeleteClass")(e.DeleteClassEndpoint),
+		JoinClassEndpoint:   instrument("JoinClass")(e.JoinClassEndpoint),
+		SetRoleEndpoint:     instrument("SetRole")(e.SetRoleEndpoint),
+		LeaveClassEndpoint:  instrument("LeaveClass")(e.LeaveClassEndpoint),
+		ListMembersEndpoint: instrument("ListMembers")(e.ListMembersEndpoint),
+		GetMemberEndpoint:   instrument("GetMember")(e.GetMemberEndpoint),
+	}
+}
+
+// instrumentedIntrospector records the latency of Hydra token introspection.
+// Inactive tokens count as errors.
+type instrumentedIntrospector struct {
+	next    oauth2.Introspector
+	latency metrics.Histogram
+}
+
+func (i instrumentedIntrospector) IntrospectToken(ctx context.Context, token string, scopes ...string) (introspection *oauth2.Introspection, err error) {
+	defer func(begin time.Time) {
+		i.latency.With("dependency", "hydra", "method", "IntrospectToken", "error", fmt.Sprint(err != nil)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return i.next.IntrospectToken(ctx, token, scopes...)
+}
+
+// dbStatsCollector exports a database's connection pool statistics. The in-use, idle and wait
+// statistics come from sql.DBStats fields added in Go 1.11, which the Go 1.21 requirement covers.
+type dbStatsCollector struct {
+	db *sql.DB
+
+	maxOpen      *stdprometheus.Desc
+	open         *stdprometheus.Desc
+	inUse        *stdprometheus.Desc
+	idle         *stdprometheus.Desc
+	waitCount    *stdprometheus.Desc
+	waitDuration *stdprometheus.Desc
+}
+
+func newDBStatsCollector(db *sql.DB, driver string) *dbStatsCollector {
+	desc := func(name, help string) *stdprometheus.Desc {
+		return stdprometheus.NewDesc(stdprometheus.BuildFQName(namespace, "db", name), help, nil, stdprometheus.Labels{"driver": driver})
+	}
+	return &dbStatsCollector{
+		db:           db,
+		maxOpen:      desc("max_open_connections", "Maximum number of open connections to the database."),
+		open:         desc("open_connections", "Established connections, both in use and idle."),
+		inUse:        desc("in_use_connections", "Connections currently in use."),
+		idle:         desc("idle_connections", "Idle connections."),
+		waitCount:    desc("wait_count_total", "Total number of connections waited for."),
+		waitDuration: desc("wait_duration_seconds_total", "Total time blocked waiting for a new connection in seconds."),
+	}
+}
+
+func (c *dbStatsCollector) Describe(ch chan<- *stdprometheus.Desc) {
+	ch <- c.maxOpen
+	ch <- c.open
+	ch <- c.inUse
+	ch <- c.idle
+	ch <- c.waitCount
+	ch <- c.waitDuration
+}
+
+func (c *dbStatsCollector) Collect(ch chan<- stdprometheus.Metric) {
+	stats := c.db.Stats()
+	ch <- stdprometheus.MustNewConstMetric(c.maxOpen, stdprometheus.GaugeValue, float64(stats.MaxOpenConnections))
+	ch <- stdprometheus.MustNewConstMetric(c.open, stdprometheus.GaugeValue, float64(stats.OpenConnections))
+	ch <- stdprometheus.MustNewConstMetric(c.inUse, stdprometheus.GaugeValue, float64(stats.InUse))
+	ch <- stdprometheus.MustNewConstMetric(c.idle, stdprometheus.GaugeValue, float64(stats.Idle))
+	ch <- stdprometheus.MustNewConstMetric(c.waitCount, stdprometheus.CounterValue, float64(stats.WaitCount))
+	ch <- stdprometheus.MustNewConstMetric(c.waitDuration, stdprometheus.CounterValue, stats.WaitDuration.Seconds())
+}
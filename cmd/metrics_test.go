@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/studiously/classsvc/models"
+	"github.com/studiously/unitsvc/unitsvc"
+	unittesting "github.com/studiously/unitsvc/unitsvc/testing"
+)
+
+// gather returns the metric families in reg by name.
+func gather(t *testing.T, reg *stdprometheus.Registry) map[string]*dto.MetricFamily {
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+	families := make(map[string]*dto.MetricFamily)
+	for _, mf := range mfs {
+		families[mf.GetName()] = mf
+	}
+	return families
+}
+
+// labels returns the label pairs of m as a map.
+func labels(m *dto.Metric) map[string]string {
+	lvs := make(map[string]string)
+	for _, lp := range m.GetLabel() {
+		lvs[lp.GetName()] = lp.GetValue()
+	}
+	return lvs
+}
+
+func TestServiceMetrics(t *testing.T) {
+	reg := stdprometheus.NewRegistry()
+	hm := newHostMetrics(reg)
+
+	cs := unittesting.NewClassService()
+	subject, classID := uuid.New(), uuid.New()
+	cs.Enroll(subject, classID, models.UserRoleTeacher)
+	svc := unitsvc.InstrumentingMiddleware(hm.requestCount, hm.requestLatency, hm.requestInFlight)(unitsvc.NewInMemory(cs))
+
+	// Every method must use the label names the metrics were registered with.
+	ctx := unittesting.NewContext(subject)
+	if err := svc.CreateUnit(ctx, classID, "Cells"); err != nil {
+		t.Fatal(err)
+	}
+	units, err := svc.ListUnits(ctx, classID)
+	if err != nil || len(units) != 1 {
+		t.Fatalf("ListUnits: %v, %v", units, err)
+	}
+	svc.GetUnit(ctx, units[0])
+	svc.RenameUnit(ctx, units[0], "Cell Biology")
+	svc.DeleteUnit(ctx, units[0])
+	svc.GetUnit(ctx, units[0])
+
+	families := gather(t, reg)
+	latency := families["unitsvc_service_request_duration_seconds"]
+	if latency.GetType() != dto.MetricType_HISTOGRAM {
+		t.Fatalf("request latency is a %v, want a histogram", latency.GetType())
+	}
+	var failedGets uint64
+	for _, m := range latency.GetMetric() {
+		if lvs := labels(m); lvs["method"] == "GetUnit" && lvs["error"] == "true" {
+			failedGets = m.GetHistogram().GetSampleCount()
+		}
+	}
+	if failedGets != 1 {
+		t.Errorf("recorded %d failed GetUnit calls, want 1", failedGets)
+	}
+	for _, m := range families["unitsvc_service_requests_in_flight"].GetMetric() {
+		if v := m.GetGauge().GetValue(); v != 0 {
+			t.Errorf("%v in flight after all calls returned: %v", labels(m), v)
+		}
+	}
+}
+
+func TestHTTPMetrics(t *testing.T) {
+	reg := stdprometheus.NewRegistry()
+	hm := newHostMetrics(reg)
+
+	r := mux.NewRouter()
+	r.Methods("GET").Path("/units/{unitID}").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	h := unitsvc.InstrumentHTTPHandler(r, hm.httpRequestCount, hm.httpRequestLatency, hm.httpRequestInFlight)
+	for i := 0; i < 3; i++ {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/units/"+uuid.New().String(), nil))
+	}
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/nowhere", nil))
+
+	counts := make(map[string]float64)
+	for _, m := range gather(t, reg)["unitsvc_http_requests_total"].GetMetric() {
+		lvs := labels(m)
+		counts[lvs["route"]+" "+lvs["code"]] = m.GetCounter().GetValue()
+	}
+	if len(counts) != 2 || counts["/units/{unitID} 404"] != 3 || counts["unmatched 404"] != 1 {
+		t.Errorf("got request counts %v", counts)
+	}
+}
+
+func TestDBStatsCollector(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(3)
+	if err := db.Ping(); err != nil {
+		t.Fatal(err)
+	}
+
+	reg := stdprometheus.NewRegistry()
+	reg.MustRegister(newDBStatsCollector(db, "sqlite"))
+	families := gather(t, reg)
+	for name, want := range map[string]float64{
+		"unitsvc_db_max_open_connections": 3,
+		"unitsvc_db_open_connections":     1,
+		"unitsvc_db_idle_connections":     1,
+	} {
+		m := families[name].GetMetric()
+		if len(m) != 1 || m[0].GetGauge().GetValue() != want || labels(m[0])["driver"] != "sqlite" {
+			t.Errorf("%s = %v, want %v", name, m, want)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/go-nats"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/studiously/unitsvc/unitsvc"
+)
+
+var (
+	onerosterDryRun    bool
+	onerosterOverwrite bool
+)
+
+// onerosterCmd represents the oneroster command
+var onerosterCmd = &cobra.Command{
+	Use:   "oneroster",
+	Short: "Syncs units with the line item categories of a student information system",
+	Long: `Syncs the units of classes with OneRoster 1.1 CSV bundles exported by a student information system, straight from the database configured by DATABASE_DRIVER and DATABASE_CONFIG and without checking who is asking. Teachers sync the classes they teach with /oneroster/sync.
+
+Each OneRoster class is first linked to a class with 'unitsvc oneroster link'. 'unitsvc oneroster import' then makes each line item category of a linked class a unit of it, and remembers which unit it made so that later imports rename and delete the same units. Units that a teacher renamed or deleted since the last import are left as they are and reported as conflicts, unless --overwrite is given.
+
+Changes are published to NATS_CLUSTER_URL, if it is set, and queued for the classes' webhooks.`,
+}
+
+var onerosterLinkCmd = &cobra.Command{
+	Use:   "link <sourcedId> <classID>",
+	Short: "Links a OneRoster class to a class",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 2 {
+			cmd.Usage()
+			os.Exit(-1)
+		}
+		classID, err := uuid.Parse(args[1])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "invalid class ID:", err)
+			os.Exit(-1)
+		}
+		repo, db := mustOpenRepository()
+		defer db.Close()
+		if err := unitsvc.New(repo, nil, unitsvc.SkipAuthorization()).LinkOneRosterClass(context.Background(), args[0], classID); err != nil {
+			fmt.Fprintln(os.Stderr, "link failed:", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+var onerosterImportCmd = &cobra.Command{
+	Use:   "import <bundle.zip>",
+	Short: "Syncs the units of the linked classes in a OneRoster bundle",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			cmd.Usage()
+			os.Exit(-1)
+		}
+		f, err := os.Open(args[0])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "could not open bundle:", err)
+			os.Exit(-1)
+		}
+		bundle, err := unitsvc.ParseOneRoster(f)
+		f.Close()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "could not read bundle:", err)
+			os.Exit(-1)
+		}
+
+		repo, db := mustOpenRepository()
+		defer db.Close()
+		service := unitsvc.New(repo, nil, unitsvc.SkipAuthorization(), unitsvc.MaxVersions(viper.GetInt("versions.max")))
+		if url := viper.GetString("nats.cluster_url"); url != "" {
+			nc, err := nats.Connect(url)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "could not connect to NATS cluster:", err)
+				os.Exit(-1)
+			}
+			defer nc.Close()
+			defer nc.FlushTimeout(5 * time.Second)
+			service = unitsvc.MessagingMiddleware(nc)(service)
+		}
+		report, err := service.SyncOneRoster(context.Background(), bundle, onerosterDryRun, onerosterOverwrite)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "import failed:", err)
+			os.Exit(-1)
+		}
+		writeSyncReport(os.Stdout, report)
+	},
+}
+
+// writeSyncReport writes report as text, one line per change or conflict under a line per class.
+func writeSyncReport(w io.Writer, report *unitsvc.SyncReport) {
+	if report.DryRun {
+		fmt.Fprintln(w, "Dry run: no changes were made.")
+	}
+	for _, c := range report.Classes {
+		if c.Skipped != "" {
+			fmt.Fprintf(w, "%s: skipped: %s\n", c.SourcedID, c.Skipped)
+			continue
+		}
+		fmt.Fprintf(w, "%s (class %v): %d created, %d updated, %d deleted, %d conflicts\n",
+			c.SourcedID, c.ClassID, len(c.Created), len(c.Updated), len(c.Deleted), len(c.Conflicts))
+		for _, u := range c.Created {
+			fmt.Fprintf(w, "  created %v %q\n", u.ID, u.Title)
+		}
+		for _, u := range c.Updated {
+			fmt.Fprintf(w, "  renamed %v to %q\n", u.ID, u.Title)
+		}
+		for _, u := range c.Deleted {
+			fmt.Fprintf(w, "  deleted %v %q\n", u.ID, u.Title)
+		}
+		for _, conflict := range c.Conflicts {
+			fmt.Fprintf(w, "  conflict in category %s: %s\n", conflict.SourcedID, conflict.Message)
+		}
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(onerosterCmd)
+	onerosterCmd.AddCommand(onerosterLinkCmd, onerosterImportCmd)
+
+	onerosterImportCmd.Flags().BoolVar(&onerosterDryRun, "dry-run", false, "Report the changes without making them")
+	onerosterImportCmd.Flags().BoolVar(&onerosterOverwrite, "overwrite", false, "Overwrite units that teachers renamed or deleted since the last import")
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/studiously/unitsvc/unitsvc"
+)
+
+func TestSyncReport(t *testing.T) {
+	ctx := context.Background()
+	service := unitsvc.New(unitsvc.NewMemoryRepository(), nil, unitsvc.SkipAuthorization())
+	classID := uuid.New()
+	if err := service.LinkOneRosterClass(ctx, "class-bio", classID); err != nil {
+		t.Fatalf("LinkOneRosterClass: %v", err)
+	}
+	bundle := &unitsvc.RosterBundle{Classes: []unitsvc.RosterClass{
+		{SourcedID: "class-bio", Categories: []unitsvc.RosterCategory{{SourcedID: "cat-hw", Title: "Homework"}}},
+		{SourcedID: "class-eng", Categories: []unitsvc.RosterCategory{{SourcedID: "cat-hw", Title: "Homework"}}},
+	}}
+	report, err := service.SyncOneRoster(ctx, bundle, true, false)
+	if err != nil {
+		t.Fatalf("SyncOneRoster: %v", err)
+	}
+
+	var buf bytes.Buffer
+	writeSyncReport(&buf, report)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("got report:\n%s\nwant 4 lines", buf.String())
+	}
+	for i, want := range []string{
+		"Dry run",
+		"class-bio (class " + classID.String() + "): 1 created, 0 updated, 0 deleted, 0 conflicts",
+		`"Homework"`,
+		"class-eng: skipped: ",
+	} {
+		if !strings.Contains(lines[i], want) {
+			t.Errorf("got line %q, want it to contain %q", lines[i], want)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"context"
+	"time"
+)
+
+// startPeriodic calls run now and then every interval, until the returned stop function is
+// called. run is passed a context that is canceled by stop. stop waits for a run in progress
+// to finish, or for ctx to expire.
+func startPeriodic(interval time.Duration, run func(ctx context.Context)) (stop func(ctx context.Context) error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			run(ctx)
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return func(stopCtx context.Context) error {
+		cancel()
+		select {
+		case <-done:
+			return nil
+		case <-stopCtx.Done():
+			return stopCtx.Err()
+		}
+	}
+}
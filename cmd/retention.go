@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/studiously/unitsvc/unitsvc"
+)
+
+// auditPurgeInterval is how often expired audit entries are deleted.
+const auditPurgeInterval = time.Hour
+
+// startAuditRetention deletes audit entries older than retention from repo now and then
+// every interval, until the returned stop function is called. stop waits for a purge in
+// progress to finish, or for ctx to expire.
+func startAuditRetention(repo unitsvc.Repository, retention, interval time.Duration, logger log.Logger) (stop func(ctx context.Context) error) {
+	return startPeriodic(interval, func(ctx context.Context) {
+		purged, err := repo.PurgeAudit(ctx, time.Now().UTC().Add(-retention))
+		if err != nil {
+			logger.Log("msg", "could not purge audit entries", "error", err)
+		} else if purged > 0 {
+			logger.Log("msg", "purged audit entries", "count", purged, "retention", retention)
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/google/uuid"
+	"github.com/studiously/unitsvc/models"
+	"github.com/studiously/unitsvc/unitsvc"
+)
+
+func TestAuditRetention(t *testing.T) {
+	ctx := context.Background()
+	repo := unitsvc.NewMemoryRepository()
+	unitID := uuid.New()
+	for _, age := range []time.Duration{48 * time.Hour, time.Minute} {
+		err := repo.AppendAudit(ctx, &models.AuditEntry{
+			ID:        uuid.New(),
+			UnitID:    unitID,
+			Action:    models.AuditCreate,
+			CreatedAt: time.Now().UTC().Add(-age),
+		})
+		if err != nil {
+			t.Fatalf("AppendAudit: %v", err)
+		}
+	}
+
+	stop := startAuditRetention(repo, 24*time.Hour, time.Millisecond, log.NewNopLogger())
+	deadline := time.Now().Add(time.Second)
+	for {
+		entries, err := repo.AuditByUnitID(ctx, unitID)
+		if err != nil {
+			t.Fatalf("AuditByUnitID: %v", err)
+		}
+		if len(entries) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d audit entries, want the expired one purged", len(entries))
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	stopCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	if err := stop(stopCtx); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+}
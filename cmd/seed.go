@@ -0,0 +1,254 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"github.com/studiously/unitsvc/models"
+	"github.com/studiously/unitsvc/unitsvc"
+	"gopkg.in/yaml.v2"
+)
+
+// seedClientID is the client recorded in the audit entries of seeded units.
+const seedClientID = "unitsvc-seed"
+
+var (
+	seedFile  string
+	seedUnits int
+	seedValue int64
+	seedStart string
+)
+
+// seedTopics are the unit titles that seeded classes pick from, by subject.
+var seedTopics = map[string][]string{
+	"Biology":       {"Cells", "Genetics", "Evolution", "Ecology", "Photosynthesis", "Human Anatomy", "Microbiology", "Cell Division", "Classification", "Homeostasis"},
+	"Chemistry":     {"Atomic Structure", "The Periodic Table", "Chemical Bonding", "Stoichiometry", "Gases", "Solutions", "Acids and Bases", "Thermochemistry", "Reaction Rates", "Organic Chemistry"},
+	"World History": {"Ancient Mesopotamia", "Classical Greece", "The Roman Empire", "The Middle Ages", "The Renaissance", "The Age of Exploration", "The Industrial Revolution", "World War I", "World War II", "The Cold War"},
+	"Algebra":       {"Expressions", "Linear Equations", "Inequalities", "Functions", "Systems of Equations", "Exponents", "Polynomials", "Factoring", "Quadratic Equations", "Radicals"},
+	"Literature":    {"Short Stories", "Poetry", "Greek Tragedy", "Shakespeare", "The Novel", "Memoir", "Drama", "Mythology", "Argumentative Writing", "Research Writing"},
+}
+
+// seedFixture is a YAML file of the classes to seed.
+type seedFixture struct {
+	Units   int            `yaml:"units"` // per class, unless the class sets it
+	Classes []fixtureClass `yaml:"classes"`
+}
+
+type fixtureClass struct {
+	ID      uuid.UUID `yaml:"-"`
+	RawID   string    `yaml:"id"`
+	Subject string    `yaml:"subject"` // one of seedTopics; picked by the seed if empty
+	Units   int       `yaml:"units"`
+	Titles  []string  `yaml:"titles"` // used instead of generated titles, in order
+}
+
+// seedCmd represents the seed command
+var seedCmd = &cobra.Command{
+	Use:   "seed [classID...]",
+	Short: "Generates units for classes in the database",
+	Long: `Generates units for demo environments, load tests and local development straight in the database configured by DATABASE_DRIVER and DATABASE_CONFIG. Classes are given as arguments, in a YAML fixture file, or both.
+
+Each class gets units titled after the topics of a school subject, created a week apart from --start, each with an audit entry and a first version as if a teacher had created it. The units of a class depend only on its ID, --seed and --start, so seeding again with the same values adds nothing, and other values add other units.
+
+A fixture file looks like this:
+
+  units: 8            # per class; defaults to --units
+  classes:
+    - id: 0b7e3a6c-5d3f-4b6e-9a55-2f1d8c4e7a10
+      subject: Chemistry
+      units: 12
+    - id: c2a94f0e-8e61-4d0b-b7f3-91e5a0d6b3c4
+      titles: [Cells, Genetics, Evolution]
+
+Subjects are Algebra, Biology, Chemistry, Literature and World History.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var classes []fixtureClass
+		for _, arg := range args {
+			classID, err := uuid.Parse(arg)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "invalid class ID %q: %v\n", arg, err)
+				os.Exit(-1)
+			}
+			classes = append(classes, fixtureClass{ID: classID, Units: seedUnits})
+		}
+		if seedFile != "" {
+			f, err := os.Open(seedFile)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "could not open fixture file:", err)
+				os.Exit(-1)
+			}
+			fixture, err := readSeedFixture(f, seedUnits)
+			f.Close()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "invalid fixture file:", err)
+				os.Exit(-1)
+			}
+			classes = append(classes, fixture.Classes...)
+		}
+		if len(classes) == 0 {
+			cmd.Usage()
+			os.Exit(-1)
+		}
+		start, err := time.Parse("2006-01-02", seedStart)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "invalid --start:", err)
+			os.Exit(-1)
+		}
+
+		repo, db := mustOpenRepository()
+		defer db.Close()
+		for _, class := range classes {
+			units, err := generateUnits(class, seedValue, start)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "class %v: %v\n", class.ID, err)
+				os.Exit(-1)
+			}
+			created, err := saveSeededUnits(context.Background(), repo, units)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "seeding class %v failed: %v\n", class.ID, err)
+				os.Exit(-1)
+			}
+			fmt.Printf("%v: %d units created, %d already there\n", class.ID, created, len(units)-created)
+		}
+	},
+}
+
+// readSeedFixture reads a fixture file. Classes that do not set how many units to generate get
+// the fixture's number, or units if it sets none.
+func readSeedFixture(r io.Reader, units int) (*seedFixture, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var fixture seedFixture
+	if err := yaml.Unmarshal(data, &fixture); err != nil {
+		return nil, err
+	}
+	if fixture.Units == 0 {
+		fixture.Units = units
+	}
+	for i := range fixture.Classes {
+		class := &fixture.Classes[i]
+		if class.ID, err = uuid.Parse(class.RawID); err != nil {
+			return nil, fmt.Errorf("class %d: invalid id %q", i+1, class.RawID)
+		}
+		if class.Units == 0 && len(class.Titles) == 0 {
+			class.Units = fixture.Units
+		}
+	}
+	return &fixture, nil
+}
+
+// generateUnits returns the units to seed a class with. They depend only on the class, seed and
+// start: IDs are derived from the class ID, and titles from a random source seeded with seed and
+// the class ID.
+func generateUnits(class fixtureClass, seed int64, start time.Time) ([]*models.Unit, error) {
+	h := fnv.New64a()
+	h.Write(class.ID[:])
+	rng := rand.New(rand.NewSource(seed ^ int64(h.Sum64())))
+
+	titles := class.Titles
+	if len(titles) == 0 {
+		if class.Units < 0 {
+			return nil, errors.New("the number of units must not be negative")
+		}
+		subject := class.Subject
+		if subject == "" {
+			subjects := make([]string, 0, len(seedTopics))
+			for s := range seedTopics {
+				subjects = append(subjects, s)
+			}
+			sort.Strings(subjects)
+			subject = subjects[rng.Intn(len(subjects))]
+		}
+		topics, ok := seedTopics[subject]
+		if !ok {
+			return nil, fmt.Errorf("unknown subject %q", subject)
+		}
+		order := rng.Perm(len(topics))
+		for i := 0; i < class.Units; i++ {
+			title := topics[order[i%len(topics)]]
+			if part := i/len(topics) + 1; part > 1 {
+				title = fmt.Sprintf("%s (Part %d)", title, part)
+			}
+			titles = append(titles, title)
+		}
+	}
+
+	units := make([]*models.Unit, 0, len(titles))
+	for i, title := range titles {
+		units = append(units, &models.Unit{
+			ID:           uuid.NewSHA1(class.ID, []byte(fmt.Sprintf("seed %d unit %d %s", seed, i, start.Format("2006-01-02")))),
+			ClassID:      class.ID,
+			Title:        title,
+			DisplayOrder: i,
+			CreatedAt:    start.Add(time.Duration(i)*7*24*time.Hour + time.Duration(rng.Intn(8*60))*time.Minute).UTC(),
+		})
+	}
+	return units, nil
+}
+
+// saveSeededUnits saves the units that are not in repo yet in one transaction, each with an
+// audit entry and a version. It returns how many it saved.
+func saveSeededUnits(ctx context.Context, repo unitsvc.Repository, units []*models.Unit) (int, error) {
+	created := 0
+	err := repo.Transact(ctx, func(repo unitsvc.Repository) error {
+		created = 0
+		for _, unit := range units {
+			if _, err := repo.UnitByID(ctx, unit.ID); err == nil {
+				continue
+			} else if err != unitsvc.ErrNotFound {
+				return err
+			}
+			if err := repo.SaveUnit(ctx, unit); err != nil {
+				return err
+			}
+			entry := &models.AuditEntry{
+				ID:        uuid.New(),
+				UnitID:    unit.ID,
+				ClassID:   unit.ClassID,
+				Action:    models.AuditCreate,
+				ClientID:  seedClientID,
+				After:     unit,
+				CreatedAt: unit.CreatedAt,
+			}
+			if err := repo.AppendAudit(ctx, entry); err != nil {
+				return err
+			}
+			// A seeded unit that was deleted keeps its versions, and is seeded as the next one.
+			versions, err := repo.VersionsByUnitID(ctx, unit.ID)
+			if err != nil {
+				return err
+			}
+			version := &models.UnitVersion{UnitID: unit.ID, Version: 1, Unit: unit, CreatedAt: unit.CreatedAt}
+			if n := len(versions); n > 0 {
+				version.Version = versions[n-1].Version + 1
+			}
+			if err := repo.AppendVersion(ctx, version); err != nil {
+				return err
+			}
+			created++
+		}
+		return nil
+	})
+	return created, err
+}
+
+func init() {
+	RootCmd.AddCommand(seedCmd)
+
+	seedCmd.Flags().StringVarP(&seedFile, "file", "f", "", "YAML fixture file of the classes to seed")
+	seedCmd.Flags().IntVar(&seedUnits, "units", 8, "Units to generate per class")
+	seedCmd.Flags().Int64Var(&seedValue, "seed", 1, "Seed of the generated units; the same seed generates the same units")
+	seedCmd.Flags().StringVar(&seedStart, "start", "2017-09-04", "Date the first unit of each class was created, as YYYY-MM-DD")
+}
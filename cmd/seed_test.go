@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/studiously/unitsvc/unitsvc"
+)
+
+func TestGenerateUnits(t *testing.T) {
+	start := time.Date(2017, 9, 4, 0, 0, 0, 0, time.UTC)
+	class := fixtureClass{ID: uuid.New(), Subject: "Biology", Units: 12}
+	units, err := generateUnits(class, 1, start)
+	if err != nil {
+		t.Fatalf("generateUnits: %v", err)
+	}
+	if len(units) != 12 {
+		t.Fatalf("got %d units, want 12", len(units))
+	}
+	titles := make(map[string]bool)
+	for i, u := range units {
+		if u.ClassID != class.ID || u.DisplayOrder != i || titles[u.Title] {
+			t.Errorf("got unit %d %+v", i, u)
+		}
+		titles[u.Title] = true
+		if day := u.CreatedAt.Truncate(24 * time.Hour); !day.Equal(start.AddDate(0, 0, 7*i)) {
+			t.Errorf("unit %d created on %v, want %v", i, day, start.AddDate(0, 0, 7*i))
+		}
+	}
+	if !strings.HasSuffix(units[10].Title, "(Part 2)") {
+		t.Errorf("got title %q for the 11th unit, want a second part", units[10].Title)
+	}
+
+	again, _ := generateUnits(class, 1, start)
+	if !reflect.DeepEqual(units, again) {
+		t.Error("generated different units with the same seed")
+	}
+	other, _ := generateUnits(class, 2, start)
+	if other[0].ID == units[0].ID {
+		t.Error("generated the same IDs with another seed")
+	}
+
+	if _, err := generateUnits(fixtureClass{ID: class.ID, Subject: "Alchemy", Units: 1}, 1, start); err == nil {
+		t.Error("generated units of an unknown subject")
+	}
+}
+
+func TestReadSeedFixture(t *testing.T) {
+	fixture, err := readSeedFixture(strings.NewReader(`
+units: 3
+classes:
+  - id: 0b7e3a6c-5d3f-4b6e-9a55-2f1d8c4e7a10
+  - id: c2a94f0e-8e61-4d0b-b7f3-91e5a0d6b3c4
+    titles: [Cells, Genetics]
+`), 8)
+	if err != nil {
+		t.Fatalf("readSeedFixture: %v", err)
+	}
+	if len(fixture.Classes) != 2 || fixture.Classes[0].Units != 3 || fixture.Classes[0].ID.String() != "0b7e3a6c-5d3f-4b6e-9a55-2f1d8c4e7a10" {
+		t.Fatalf("got fixture %+v", fixture)
+	}
+	units, err := generateUnits(fixture.Classes[1], 1, time.Now())
+	if err != nil || len(units) != 2 || units[0].Title != "Cells" || units[1].Title != "Genetics" {
+		t.Errorf("got units %v, %v from titles", units, err)
+	}
+
+	if _, err := readSeedFixture(strings.NewReader("classes:\n  - id: nonsense\n"), 8); err == nil {
+		t.Error("read a fixture with an invalid class ID")
+	}
+}
+
+func TestSaveSeededUnits(t *testing.T) {
+	ctx := context.Background()
+	repo := unitsvc.NewMemoryRepository()
+	units, err := generateUnits(fixtureClass{ID: uuid.New(), Units: 4}, 1, time.Now())
+	if err != nil {
+		t.Fatalf("generateUnits: %v", err)
+	}
+	if created, err := saveSeededUnits(ctx, repo, units); err != nil || created != 4 {
+		t.Fatalf("saveSeededUnits: got %d, %v; want 4 created", created, err)
+	}
+	// Seeding again adds nothing.
+	if created, err := saveSeededUnits(ctx, repo, units); err != nil || created != 0 {
+		t.Fatalf("saveSeededUnits again: got %d, %v; want none created", created, err)
+	}
+
+	saved, err := repo.UnitsByClassID(ctx, units[0].ClassID)
+	if err != nil || len(saved) != 4 {
+		t.Fatalf("UnitsByClassID: got %d units, %v", len(saved), err)
+	}
+	entries, err := repo.AuditByUnitID(ctx, units[0].ID)
+	if err != nil || len(entries) != 1 || entries[0].ClientID != seedClientID {
+		t.Errorf("AuditByUnitID: got %+v, %v", entries, err)
+	}
+	versions, err := repo.VersionsByUnitID(ctx, units[0].ID)
+	if err != nil || len(versions) != 1 || versions[0].Unit.Title != units[0].Title {
+		t.Errorf("VersionsByUnitID: got %+v, %v", versions, err)
+	}
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-kit/kit/log"
+)
+
+// shutdown stops the host's components in the reverse of the order they were started,
+// so that servers stop accepting requests before the workers, messaging and storage
+// they depend on are closed.
+type shutdown struct {
+	steps []shutdownStep
+}
+
+type shutdownStep struct {
+	component string
+	stop      func(ctx context.Context) error
+}
+
+// add registers stop to be called for component.
+func (s *shutdown) add(component string, stop func(ctx context.Context) error) {
+	s.steps = append(s.steps, shutdownStep{component, stop})
+}
+
+// addServer registers an HTTP server to be drained, or closed if draining is cut short by ctx.
+func (s *shutdown) addServer(component string, srv *http.Server) {
+	s.add(component, func(ctx context.Context) error {
+		if err := srv.Shutdown(ctx); err != nil {
+			srv.Close()
+			return err
+		}
+		return nil
+	})
+}
+
+// run stops every component, logging one line per component. Steps run even after ctx
+// expires, so that resources are always released.
+func (s *shutdown) run(ctx context.Context, logger log.Logger) {
+	for i := len(s.steps) - 1; i >= 0; i-- {
+		step := s.steps[i]
+		err := step.stop(ctx)
+		logger.Log("msg", "stopped", "component", step.component, "error", err)
+	}
+}
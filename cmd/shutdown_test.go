@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+)
+
+func TestShutdownOrder(t *testing.T) {
+	var stopped []string
+	var s shutdown
+	for _, component := range []string{"database", "nats", "worker", "server"} {
+		component := component
+		s.add(component, func(context.Context) error {
+			stopped = append(stopped, component)
+			if component == "nats" {
+				return errors.New("flush timed out")
+			}
+			return nil
+		})
+	}
+
+	var logged []string
+	logger := log.LoggerFunc(func(keyvals ...interface{}) error {
+		logged = append(logged, keyvals[3].(string))
+		return nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.run(ctx, logger)
+
+	want := []string{"server", "worker", "nats", "database"}
+	if !reflect.DeepEqual(stopped, want) {
+		t.Errorf("stopped %v, want %v", stopped, want)
+	}
+	if !reflect.DeepEqual(logged, want) {
+		t.Errorf("logged %v, want one line per component in order %v", logged, want)
+	}
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/ory/hydra/oauth2"
+)
+
+// tracedIntrospector records Hydra token introspection as a child of the request's span.
+type tracedIntrospector struct {
+	next oauth2.Introspector
+}
+
+func (i tracedIntrospector) IntrospectToken(ctx context.Context, token string, scopes ...string) (*oauth2.Introspection, error) {
+	parent := opentracing.SpanFromContext(ctx)
+	if parent == nil {
+		return i.next.IntrospectToken(ctx, token, scopes...)
+	}
+	span := parent.Tracer().StartSpan("hydra.IntrospectToken", opentracing.ChildOf(parent.Context()))
+	defer span.Finish()
+	ext.SpanKindRPCClient.Set(span)
+	introspection, err := i.next.IntrospectToken(opentracing.ContextWithSpan(ctx, span), token, scopes...)
+	if err != nil {
+		ext.Error.Set(span, true)
+	}
+	return introspection, err
+}
@@ -0,0 +1,327 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ory/hydra/sdk"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/studiously/introspector"
+	"github.com/studiously/unitsvc/models"
+	"github.com/studiously/unitsvc/unitsvc"
+	"gopkg.in/yaml.v2"
+)
+
+// Exit codes of the units commands.
+const (
+	exitFailed       = 1 // any other error, such as the instance being unreachable
+	exitUsage        = 2
+	exitUnauthorized = 3 // ErrUnauthorized, or no token could be obtained
+	exitForbidden    = 4 // ErrForbidden
+	exitNotFound     = 5 // ErrNotFound
+	exitInvalid      = 6 // ErrBadRequest or an *ImportError
+)
+
+// Output formats of the units commands.
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+// unitsCmd represents the units command
+var unitsCmd = &cobra.Command{
+	Use:   "units",
+	Short: "Manages units through the API of a running instance",
+	Long: `Manages units through the HTTP API of the instance at UNITSVC_ADDR (or --addr), with the same checks as any other client: the token's subject must be a member of the class, or a teacher of it for changes.
+
+The bearer token is read from --token or UNITSVC_TOKEN. Without one, a token is requested from the Hydra cluster at HYDRA_CLUSTER_URL with the client credentials HYDRA_CLIENT_ID and HYDRA_CLIENT_SECRET, scoped to what the command needs.
+
+Results are written as a table, or with --output json or yaml. Commands exit with 2 on bad usage, 3 if the token is missing, inactive or lacks a scope, 4 if the action is forbidden, 5 if the class or unit is not found, 6 if the request is invalid, and 1 on any other error.`,
+}
+
+var unitsListCmd = &cobra.Command{
+	Use:   "list <classID>",
+	Short: "Lists the units of a class in display order",
+	Run: func(cmd *cobra.Command, args []string) {
+		classID := mustParseArgs(cmd, args, 1, 1)[0]
+		ctx, cancel, client := mustConnect("units.list", "units.get")
+		defer cancel()
+		units, err := classUnits(ctx, client, classID)
+		exitOn(err)
+		exitOn(writeUnits(os.Stdout, viper.GetString("unitsvc.output"), units))
+	},
+}
+
+var unitsGetCmd = &cobra.Command{
+	Use:   "get <unitID>",
+	Short: "Gets a unit",
+	Run: func(cmd *cobra.Command, args []string) {
+		unitID := mustParseArgs(cmd, args, 1, 1)[0]
+		ctx, cancel, client := mustConnect("units.get")
+		defer cancel()
+		unit, err := client.GetUnit(ctx, unitID)
+		exitOn(err)
+		exitOn(writeUnits(os.Stdout, viper.GetString("unitsvc.output"), unit))
+	},
+}
+
+var unitsCreateCmd = &cobra.Command{
+	Use:   "create <classID> <title>",
+	Short: "Creates a unit at the end of a class",
+	Run: func(cmd *cobra.Command, args []string) {
+		classID := mustParseArgs(cmd, args, 2, 1)[0]
+		ctx, cancel, client := mustConnect("units.create")
+		defer cancel()
+		exitOn(client.CreateUnit(ctx, classID, args[1]))
+	},
+}
+
+var unitsRenameCmd = &cobra.Command{
+	Use:   "rename <unitID> <title>",
+	Short: "Renames a unit",
+	Run: func(cmd *cobra.Command, args []string) {
+		unitID := mustParseArgs(cmd, args, 2, 1)[0]
+		ctx, cancel, client := mustConnect("units.rename")
+		defer cancel()
+		exitOn(client.RenameUnit(ctx, unitID, args[1]))
+	},
+}
+
+var unitsDeleteCmd = &cobra.Command{
+	Use:   "delete <unitID>",
+	Short: "Deletes a unit",
+	Run: func(cmd *cobra.Command, args []string) {
+		unitID := mustParseArgs(cmd, args, 1, 1)[0]
+		ctx, cancel, client := mustConnect("units.delete")
+		defer cancel()
+		exitOn(client.DeleteUnit(ctx, unitID))
+	},
+}
+
+var unitsReorderCmd = &cobra.Command{
+	Use:   "reorder <classID> <unitID>...",
+	Short: "Moves units to the start of a class, in the given order",
+	Long: `Moves the given units to the start of a class, in the given order. The other units follow in the order they were in.
+
+The units are reordered with /units/import, so the token needs the units.import scope and its subject must teach the class. Nothing is changed if units were created or deleted in the meantime.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 2 {
+			cmd.Usage()
+			os.Exit(exitUsage)
+		}
+		ids := mustParseArgs(cmd, args, len(args), len(args))
+		ctx, cancel, client := mustConnect("units.list", "units.get", "units.import")
+		defer cancel()
+		units, err := classUnits(ctx, client, ids[0])
+		exitOn(err)
+		rows, err := reorderRows(units, ids[1:])
+		exitOn(err)
+		// A dry run first, so that units created since they were listed are not deleted.
+		result, err := client.ImportUnits(ctx, ids[0], rows, true)
+		exitOn(err)
+		if len(result.Created) > 0 || len(result.Deleted) > 0 {
+			exitOn(errors.New("the units of the class changed while reordering; try again"))
+		}
+		_, err = client.ImportUnits(ctx, ids[0], rows, false)
+		exitOn(err)
+		units, err = classUnits(ctx, client, ids[0])
+		exitOn(err)
+		exitOn(writeUnits(os.Stdout, viper.GetString("unitsvc.output"), units))
+	},
+}
+
+// mustParseArgs checks that there are n arguments and parses the first ids of them as IDs,
+// exiting with usage if either fails.
+func mustParseArgs(cmd *cobra.Command, args []string, n, ids int) []uuid.UUID {
+	if len(args) != n {
+		cmd.Usage()
+		os.Exit(exitUsage)
+	}
+	parsed := make([]uuid.UUID, 0, ids)
+	for _, arg := range args[:ids] {
+		id, err := uuid.Parse(arg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid ID %q: %v\n", arg, err)
+			os.Exit(exitUsage)
+		}
+		parsed = append(parsed, id)
+	}
+	return parsed
+}
+
+// mustConnect returns a client of the instance at unitsvc.addr and a context that authenticates
+// its calls until the timeout, exiting if no token can be obtained. scopes are requested from
+// Hydra if no token is set.
+func mustConnect(scopes ...string) (context.Context, context.CancelFunc, unitsvc.Endpoints) {
+	if output := viper.GetString("unitsvc.output"); output != outputTable && output != outputJSON && output != outputYAML {
+		fmt.Fprintln(os.Stderr, "unsupported --output:", output)
+		os.Exit(exitUsage)
+	}
+	client, err := unitsvc.MakeClientEndpoints(viper.GetString("unitsvc.addr"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "invalid address:", err)
+		os.Exit(exitUsage)
+	}
+	token := viper.GetString("unitsvc.token")
+	if token == "" {
+		if token, err = requestToken(scopes); err != nil {
+			fmt.Fprintln(os.Stderr, "could not get a token (set --token or UNITSVC_TOKEN to use your own):", err)
+			os.Exit(exitUnauthorized)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("unitsvc.timeout"))
+	return context.WithValue(ctx, introspector.TokenContextKey, token), cancel, client
+}
+
+// requestToken gets an access token with scopes from Hydra in a client credentials flow.
+func requestToken(scopes []string) (string, error) {
+	if viper.GetString("hydra.cluster_url") == "" {
+		return "", errors.New("HYDRA_CLUSTER_URL is not set")
+	}
+	client, err := sdk.Connect(
+		sdk.ClientID(viper.GetString("hydra.client.id")),
+		sdk.ClientSecret(viper.GetString("hydra.client.secret")),
+		sdk.ClusterURL(viper.GetString("hydra.cluster_url")),
+		sdk.Scopes(scopes...),
+	)
+	if err != nil {
+		return "", err
+	}
+	// The revocator holds the client credentials config that the SDK authenticated with.
+	token, err := client.Revocator.Config.Token(context.Background())
+	if err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
+
+// exitOn exits with the code for err, if it is not nil.
+func exitOn(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitCode(err))
+	}
+}
+
+// exitCode maps the errors of the service to exit codes.
+func exitCode(err error) int {
+	if _, ok := err.(*unitsvc.ImportError); ok {
+		return exitInvalid
+	}
+	switch err {
+	case unitsvc.ErrUnauthorized:
+		return exitUnauthorized
+	case unitsvc.ErrForbidden:
+		return exitForbidden
+	case unitsvc.ErrNotFound:
+		return exitNotFound
+	case unitsvc.ErrBadRequest:
+		return exitInvalid
+	default:
+		return exitFailed
+	}
+}
+
+// classUnits gets the units of a class in display order.
+func classUnits(ctx context.Context, client unitsvc.Endpoints, classID uuid.UUID) ([]*models.Unit, error) {
+	ids, err := client.ListUnits(ctx, classID)
+	if err != nil {
+		return nil, err
+	}
+	units := make([]*models.Unit, 0, len(ids))
+	for _, id := range ids {
+		unit, err := client.GetUnit(ctx, id)
+		if err == unitsvc.ErrNotFound {
+			continue // deleted since it was listed
+		} else if err != nil {
+			return nil, err
+		}
+		units = append(units, unit)
+	}
+	sort.SliceStable(units, func(i, j int) bool { return units[i].DisplayOrder < units[j].DisplayOrder })
+	return units, nil
+}
+
+// reorderRows returns import rows that keep units as they are, but move the units in order to
+// the front. It returns ErrBadRequest if order names a unit that is not in units, or names one twice.
+func reorderRows(units []*models.Unit, order []uuid.UUID) ([]unitsvc.ImportRow, error) {
+	byID := make(map[uuid.UUID]*models.Unit, len(units))
+	for _, u := range units {
+		byID[u.ID] = u
+	}
+	rows := make([]unitsvc.ImportRow, 0, len(units))
+	moved := make(map[uuid.UUID]bool, len(order))
+	for _, id := range order {
+		u, ok := byID[id]
+		if !ok || moved[id] {
+			return nil, unitsvc.ErrBadRequest
+		}
+		moved[id] = true
+		rows = append(rows, unitsvc.ImportRow{Row: len(rows) + 1, ID: u.ID, Title: u.Title})
+	}
+	for _, u := range units {
+		if !moved[u.ID] {
+			rows = append(rows, unitsvc.ImportRow{Row: len(rows) + 1, ID: u.ID, Title: u.Title})
+		}
+	}
+	return rows, nil
+}
+
+// writeUnits writes a unit or a list of units in format.
+func writeUnits(w io.Writer, format string, v interface{}) error {
+	switch format {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case outputYAML:
+		// Through JSON, so that IDs and times are written as they are in the API.
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		var doc interface{}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return err
+		}
+		if data, err = yaml.Marshal(doc); err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+	units, ok := v.([]*models.Unit)
+	if !ok {
+		units = []*models.Unit{v.(*models.Unit)}
+	}
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tORDER\tTITLE\tCREATED")
+	for _, u := range units {
+		fmt.Fprintf(tw, "%v\t%d\t%s\t%s\n", u.ID, u.DisplayOrder, u.Title, u.CreatedAt.Format(time.RFC3339))
+	}
+	return tw.Flush()
+}
+
+func init() {
+	RootCmd.AddCommand(unitsCmd)
+	unitsCmd.AddCommand(unitsListCmd, unitsGetCmd, unitsCreateCmd, unitsRenameCmd, unitsDeleteCmd, unitsReorderCmd)
+
+	flags := unitsCmd.PersistentFlags()
+	flags.String("addr", "localhost:8080", "Address of the unitsvc instance")
+	flags.String("token", "", "Bearer token to call the API with")
+	flags.StringP("output", "o", outputTable, "Output format: 'table', 'json' or 'yaml'")
+	flags.Duration("timeout", 30*time.Second, "How long to wait for the command to finish")
+	for _, name := range []string{"addr", "token", "output", "timeout"} {
+		viper.BindPFlag("unitsvc."+name, flags.Lookup(name))
+	}
+}
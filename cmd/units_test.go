@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/studiously/classsvc/models"
+	"github.com/studiously/introspector"
+	"github.com/studiously/unitsvc/unitsvc"
+	unittesting "github.com/studiously/unitsvc/unitsvc/testing"
+)
+
+func TestUnitsReorder(t *testing.T) {
+	s := unittesting.NewServer()
+	defer s.Close()
+	teacher, classID := uuid.New(), uuid.New()
+	s.Classes.Enroll(teacher, classID, models.UserRoleTeacher)
+	client, err := unitsvc.MakeClientEndpoints(s.URL)
+	if err != nil {
+		t.Fatalf("MakeClientEndpoints: %v", err)
+	}
+	ctx := context.WithValue(context.Background(), introspector.TokenContextKey, s.Token(teacher, "units"))
+	for _, title := range []string{"Cells", "Genetics", "Evolution", "Ecology"} {
+		if err := client.CreateUnit(ctx, classID, title); err != nil {
+			t.Fatalf("CreateUnit: %v", err)
+		}
+	}
+	units, err := classUnits(ctx, client, classID)
+	if err != nil {
+		t.Fatalf("classUnits: %v", err)
+	}
+
+	if _, err := reorderRows(units, []uuid.UUID{units[1].ID, uuid.New()}); err != unitsvc.ErrBadRequest {
+		t.Errorf("reorderRows with unknown unit: got %v, want ErrBadRequest", err)
+	}
+	if _, err := reorderRows(units, []uuid.UUID{units[1].ID, units[1].ID}); err != unitsvc.ErrBadRequest {
+		t.Errorf("reorderRows with repeated unit: got %v, want ErrBadRequest", err)
+	}
+	rows, err := reorderRows(units, []uuid.UUID{units[3].ID, units[1].ID})
+	if err != nil {
+		t.Fatalf("reorderRows: %v", err)
+	}
+	if _, err := client.ImportUnits(ctx, classID, rows, false); err != nil {
+		t.Fatalf("ImportUnits: %v", err)
+	}
+	units, err = classUnits(ctx, client, classID)
+	if err != nil {
+		t.Fatalf("classUnits: %v", err)
+	}
+	var titles []string
+	for _, u := range units {
+		titles = append(titles, u.Title)
+	}
+	if got, want := strings.Join(titles, ","), "Ecology,Genetics,Cells,Evolution"; got != want {
+		t.Errorf("got units %s after reorder, want %s", got, want)
+	}
+}
+
+func TestWriteUnits(t *testing.T) {
+	ctx := unittesting.NewContext(uuid.New())
+	service := unitsvc.New(unitsvc.NewMemoryRepository(), nil, unitsvc.SkipAuthorization())
+	classID := uuid.New()
+	if err := service.CreateUnit(ctx, classID, "Cells"); err != nil {
+		t.Fatalf("CreateUnit: %v", err)
+	}
+	units, err := service.ExportUnits(ctx, classID)
+	if err != nil {
+		t.Fatalf("ExportUnits: %v", err)
+	}
+
+	for _, tt := range []struct {
+		format string
+		v      interface{}
+		want   []string
+	}{
+		{outputTable, units, []string{"ID", "TITLE", units[0].ID.String(), "Cells"}},
+		{outputTable, units[0], []string{"ID", units[0].ID.String(), "Cells"}},
+		{outputJSON, units, []string{`"id": "` + units[0].ID.String() + `"`, `"title": "Cells"`}},
+		{outputYAML, units[0], []string{"id: " + units[0].ID.String(), "title: Cells", "display_order: 0"}},
+	} {
+		var buf bytes.Buffer
+		if err := writeUnits(&buf, tt.format, tt.v); err != nil {
+			t.Fatalf("writeUnits %s: %v", tt.format, err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(buf.String(), want) {
+				t.Errorf("got %s output:\n%s\nwant it to contain %q", tt.format, buf.String(), want)
+			}
+		}
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	for _, tt := range []struct {
+		err  error
+		want int
+	}{
+		{unitsvc.ErrUnauthorized, exitUnauthorized},
+		{unitsvc.ErrForbidden, exitForbidden},
+		{unitsvc.ErrNotFound, exitNotFound},
+		{unitsvc.ErrBadRequest, exitInvalid},
+		{&unitsvc.ImportError{Rows: []unitsvc.RowError{{Row: 1, Field: "title", Message: "is required"}}}, exitInvalid},
+		{context.DeadlineExceeded, exitFailed},
+	} {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/studiously/unitsvc/unitsvc"
+)
+
+// startWebhookDispatcher sends the webhook deliveries that are due now and then every interval,
+// until the returned stop function is called. A full batch is followed by the next one straight
+// away, so that a backlog is not limited to one batch per interval.
+func startWebhookDispatcher(d *unitsvc.WebhookDispatcher, interval time.Duration, logger log.Logger) (stop func(ctx context.Context) error) {
+	return startPeriodic(interval, func(ctx context.Context) {
+		for ctx.Err() == nil {
+			n, err := d.Dispatch(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					logger.Log("msg", "could not dispatch webhook deliveries", "error", err)
+				}
+				return
+			}
+			if n < d.BatchSize {
+				return
+			}
+		}
+	})
+}
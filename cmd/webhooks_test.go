@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/google/uuid"
+	"github.com/studiously/unitsvc/models"
+	"github.com/studiously/unitsvc/unitsvc"
+)
+
+func TestWebhookDispatcher(t *testing.T) {
+	ctx := context.Background()
+	received := make(chan struct{}, 10)
+	rcv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+	}))
+	defer rcv.Close()
+
+	repo := unitsvc.NewMemoryRepository()
+	hook := &models.Webhook{ID: uuid.New(), ClassID: uuid.New(), URL: rcv.URL, Secret: "s3cret"}
+	if err := repo.SaveWebhook(ctx, hook); err != nil {
+		t.Fatalf("SaveWebhook: %v", err)
+	}
+	// More deliveries than fit in a batch are all sent in the first round.
+	now := time.Now().UTC()
+	for i := 0; i < 3; i++ {
+		err := repo.AppendDelivery(ctx, &models.WebhookDelivery{
+			ID:            uuid.New(),
+			WebhookID:     hook.ID,
+			Event:         unitsvc.SubjCreateUnit,
+			Payload:       []byte(`{}`),
+			Status:        models.DeliveryPending,
+			NextAttemptAt: now,
+		})
+		if err != nil {
+			t.Fatalf("AppendDelivery: %v", err)
+		}
+	}
+
+	d := unitsvc.NewWebhookDispatcher(repo, http.DefaultClient, log.NewNopLogger())
+	d.BatchSize = 2
+	stop := startWebhookDispatcher(d, time.Hour, log.NewNopLogger())
+	for i := 0; i < 3; i++ {
+		select {
+		case <-received:
+		case <-time.After(time.Second):
+			t.Fatalf("got %d deliveries, want 3", i)
+		}
+	}
+
+	stopCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	if err := stop(stopCtx); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	delivered, err := repo.DeliveriesByWebhookID(ctx, hook.ID, models.DeliveryDelivered)
+	if err != nil {
+		t.Fatalf("DeliveriesByWebhookID: %v", err)
+	}
+	if len(delivered) != 3 {
+		t.Errorf("got %d deliveries marked delivered, want 3", len(delivered))
+	}
+}
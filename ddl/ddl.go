@@ -1,2 +1,94 @@
-//go:generate go-bindata -pkg ddl -o ddl_gen.go ./...
+//go:generate go-bindata -pkg ddl -o ddl_gen.go postgres/ mysql/ sqlite/
+
+// Package ddl embeds the schema migrations for every supported database driver.
 package ddl
+
+import (
+	"bytes"
+	"database/sql"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/rubenv/sql-migrate"
+)
+
+// Migrate applies all pending migrations for driver to db.
+//
+// PostgreSQL databases created by hand from postgres/1_init.sql, before unitsvc applied its own
+// migrations, have the units table but no record of that migration. They are baselined first:
+// 1_init.sql is recorded as applied instead of being run again, and the later migrations are
+// applied as usual.
+func Migrate(driver string, db *sql.DB) error {
+	if driver == "postgres" {
+		if err := baseline(db); err != nil {
+			return err
+		}
+	}
+	_, err := migrate.Exec(db, dialect(driver), assetSource(driver), migrate.Up)
+	return err
+}
+
+// Pending returns the number of migrations for driver that have not been applied to db.
+func Pending(driver string, db *sql.DB) (int, error) {
+	planned, _, err := migrate.PlanMigration(db, dialect(driver), assetSource(driver), migrate.Up, 0)
+	return len(planned), err
+}
+
+// initMigration is the PostgreSQL migration that was released before migrations were applied
+// automatically. It is kept as released, without sql-migrate annotations.
+const initMigration = "1_init.sql"
+
+// baseline records initMigration as applied to a PostgreSQL database that has the units table
+// but has never been migrated.
+func baseline(db *sql.DB) error {
+	records, err := migrate.GetMigrationRecords(db, "postgres")
+	if err != nil || len(records) > 0 {
+		return err
+	}
+	var exists bool
+	if err := db.QueryRow(`SELECT to_regclass('units') IS NOT NULL`).Scan(&exists); err != nil || !exists {
+		return err
+	}
+	_, err = db.Exec(`INSERT INTO gorp_migrations (id, applied_at) VALUES ($1, $2)`, initMigration, time.Now())
+	return err
+}
+
+// assetSource loads the embedded migrations in a directory. A migration without sql-migrate
+// annotations, such as postgres/1_init.sql, is read as a single Up migration.
+type assetSource string
+
+func (dir assetSource) FindMigrations() ([]*migrate.Migration, error) {
+	names, err := AssetDir(string(dir))
+	if err != nil {
+		return nil, err
+	}
+	var migrations []*migrate.Migration
+	for _, name := range names {
+		if !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+		data, err := Asset(path.Join(string(dir), name))
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Contains(data, []byte("-- +migrate Up")) {
+			data = append([]byte("-- +migrate Up\n"), data...)
+		}
+		m, err := migrate.ParseMigration(name, bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, m)
+	}
+	return migrate.MemoryMigrationSource{Migrations: migrations}.FindMigrations()
+}
+
+// dialect returns the sql-migrate dialect for driver.
+// sql-migrate names its SQLite dialect after the cgo driver.
+func dialect(driver string) string {
+	if driver == "sqlite" {
+		return "sqlite3"
+	}
+	return driver
+}
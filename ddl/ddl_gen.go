@@ -1,8 +1,38 @@
 // Code generated by go-bindata.
 // sources:
-// ddl.go
-// ddl_gen.go
+// mysql/10_unit_schedule.sql
+// mysql/1_init.sql
+// mysql/2_unit_audit.sql
+// mysql/3_unit_versions.sql
+// mysql/4_webhooks.sql
+// mysql/5_oneroster.sql
+// mysql/6_current_units.sql
+// mysql/7_unit_progress.sql
+// mysql/8_unit_details.sql
+// mysql/9_unit_hierarchy.sql
+// postgres/10_unit_details.sql
+// postgres/11_unit_hierarchy.sql
+// postgres/12_unit_search_description.sql
+// postgres/13_unit_schedule.sql
 // postgres/1_init.sql
+// postgres/2_created_at.sql
+// postgres/3_unit_audit.sql
+// postgres/4_unit_versions.sql
+// postgres/5_webhooks.sql
+// postgres/6_oneroster.sql
+// postgres/7_unit_search.sql
+// postgres/8_current_units.sql
+// postgres/9_unit_progress.sql
+// sqlite/10_unit_schedule.sql
+// sqlite/1_init.sql
+// sqlite/2_unit_audit.sql
+// sqlite/3_unit_versions.sql
+// sqlite/4_webhooks.sql
+// sqlite/5_oneroster.sql
+// sqlite/6_current_units.sql
+// sqlite/7_unit_progress.sql
+// sqlite/8_unit_details.sql
+// sqlite/9_unit_hierarchy.sql
 // DO NOT EDIT!
 
 package ddl
@@ -70,47 +100,288 @@ func (fi bindataFileInfo) Sys() interface{} {
 	return nil
 }
 
-var _ddlGo = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x14\xc9\xc1\x09\x80\x30\x0c\x05\xd0\x7b\xa7\xc8\x02\x4d\xee\x2e\x23\xd1\x7c\x3e\x52\x49\x8a\x74\x7f\xa4\xa7\x77\x78\x66\xac\x83\x48\x7c\xbe\x20\xac\x7e\x3d\x19\xbe\x5c\xfa\x1c\x94\x88\x57\x7a\x6d\x4e\x22\x95\x25\x6a\xaa\xda\xa6\xdf\xc3\x89\x1d\xed\x0f\x00\x00\xff\xff\xef\x5c\x6f\xa3\x42\x00\x00\x00")
 
-func ddlGoBytes() ([]byte, error) {
+var _mysql10_unit_scheduleSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x6c\xce\xb1\x0e\x82\x30\x10\xc6\xf1\xbd\x4f\xf1\x6d\x40\x84\xc1\x85\x85\xe9\xa4\x35\x9a\x14\x30\x4d\xeb\x6a\x30\x10\x25\x51\x24\xb4\x8d\xaf\x6f\xa2\x0e\x08\xae\x77\xff\xdc\xfd\x92\x04\xab\x7b\x77\x19\x6b\xd7\xc2\x0c\x8c\xa4\x16\x0a\x9a\x36\x52\xc0\xf7\x9d\xb3\x0c\x20\xce\x91\x57\xd2\x14\x25\xac\xab\x9d\xb7\x00\x70\x24\x95\xef\x48\x85\xeb\x34\x02\x17\x5b\x32\x52\x23\x18\xfc\xf9\xd6\xd9\x6b\xdb\x04\x28\x2b\x8d\xd2\x48\x19\x2f\x0e\x8c\xce\x9e\x6a\x07\x4e\x5a\xe8\x7d\x21\xc2\x34\x9a\x35\x6d\xdf\xbc\x0b\x4c\x9b\x8c\xb1\x29\x95\x3f\x9e\xfd\x5f\x2c\x57\xd5\xe1\x57\x1b\x2f\xa7\x1f\xc2\x7c\xf1\xfd\x9b\xb1\xd7\x00\x29\xd0\x80\x6a\x14\x01\x00\x00")
+
+func mysql10_unit_scheduleSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_mysql10_unit_scheduleSql,
+		"mysql/10_unit_schedule.sql",
+	)
+}
+
+func mysql10_unit_scheduleSql() (*asset, error) {
+	bytes, err := mysql10_unit_scheduleSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "mysql/10_unit_schedule.sql", size: 276, mode: os.FileMode(420), modTime: time.Unix(1792384555, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _mysql1_initSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xa4\x91\xc1\x4a\x33\x31\x14\x85\xf7\x79\x8a\xb3\x9c\xe1\xff\x0b\x0a\x52\x84\xd2\x45\xda\x5c\x6b\x70\x9a\x96\xf4\x0e\xb4\xab\x30\x9a\x28\xc1\x76\x5a\x66\x52\xb4\x6f\x2f\x45\x47\x67\xa5\x82\xdf\x2e\xc9\xe5\x23\xf7\x9c\xc1\x00\xff\x76\xf1\xa9\xa9\x52\x40\x79\x10\x53\x4b\x92\x09\x2c\x27\x05\xe1\x58\xc7\xd4\x22\x13\x40\xf4\xe8\x31\xd1\x46\xda\x4d\x76\x39\xcc\xf1\x3d\x66\xc1\x30\x65\x51\xfc\x17\xc0\xc3\xb6\x6a\x5b\x17\xfd\x1f\x14\x29\xa6\x6d\x40\x07\xd3\x9a\xf1\x1b\xfa\x0a\x1f\xdb\xc3\xb6\x3a\xb9\x7d\xe3\x43\x03\x6d\x98\x66\x64\x01\x00\x8a\x6e\x64\x59\x30\x2e\x7e\x5c\xa4\x09\x55\x0a\xde\x55\x09\x00\x94\x64\x62\x3d\xa7\x6c\x98\x7f\x2a\xa6\xa5\xb5\x64\xd8\x9d\xef\x57\x2c\xe7\xcb\xf3\x63\x5f\x31\x5d\x98\x15\x5b\xa9\x0d\xbf\x67\xec\x0e\xcf\xe1\x84\xa5\xd5\x73\x69\x37\xb8\xa3\x0d\xb2\xe8\xf3\xf3\xa4\x36\x8a\xd6\x1f\x43\x5d\x82\x2e\xfa\x57\x64\xdd\x29\x17\x39\xc8\xcc\xb4\x21\x8c\xa1\xeb\x7a\xaf\x26\x5f\x1f\xb9\x95\x76\x45\x8c\x31\x8e\xe9\xf1\x7a\x77\x7f\x35\x12\xa2\x5f\xb8\xda\xbf\xd4\x42\xd9\xc5\xb2\x5f\xf8\x48\xbc\x0d\x00\x42\xcb\xa4\x36\x14\x02\x00\x00")
+
+func mysql1_initSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_mysql1_initSql,
+		"mysql/1_init.sql",
+	)
+}
+
+func mysql1_initSql() (*asset, error) {
+	bytes, err := mysql1_initSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "mysql/1_init.sql", size: 532, mode: os.FileMode(420), modTime: time.Unix(1792370095, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _mysql2_unit_auditSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xa4\x93\x41\x6f\xaa\x40\x10\xc7\xef\xfb\x29\xfe\x47\xc8\xd3\x83\xbe\xa7\x31\x31\x1e\x56\xd8\x57\x49\x71\x35\xeb\xd2\xe8\x89\x20\xac\xcd\xa6\x0a\x16\x96\xb4\xfd\xf6\x0d\x15\x2a\x4d\x9b\xc6\xd6\xb9\xcd\x30\xf3\x63\x66\xf6\x3f\xdd\x2e\xfe\x1c\xf4\x7d\x1e\x19\x85\xe0\x48\x1c\xc1\xa8\x64\x90\x74\xea\x33\x94\xa9\x36\x61\x54\x26\xda\xc0\x22\x80\x4e\x70\xb6\xa9\xc7\xa9\xd8\x58\xbd\xa1\x8d\xef\x8d\x2f\x24\x78\xe0\xfb\x1d\x82\x13\x50\x27\x57\x10\xe2\x7d\x54\x14\x0d\xe2\x57\x84\x28\x36\x3a\x4b\x01\x00\xc0\x1d\x15\xce\x8c\x0a\xeb\x6f\xdf\xfe\x11\x21\xcb\xaf\xda\x43\xbc\xd7\x2a\x6d\x36\xd1\xf4\xd0\x1f\x0c\xec\x8b\x09\xb9\x7a\x2c\x55\x51\x23\x1a\x42\xaf\x3f\xba\x9c\xb0\x55\xbb\x2c\x57\x61\x61\xaa\x97\x97\x6c\x2d\xdf\x46\xdb\x19\x95\xd7\xb1\xf7\x60\x9c\xab\xc8\xa8\x24\x8c\x0c\x00\x97\x4a\x26\xbd\x39\xb3\x86\x36\x5c\xf6\x9f\x06\xbe\x84\x13\x08\xc1\xb8\x0c\xab\xf8\x4a\xd2\xf9\xb2\xfa\xd8\xfe\x97\xb3\xe0\x2b\x29\xa8\xc7\x65\x4b\x53\xe1\xf1\x41\xbd\x60\x29\xbc\x39\x15\x1b\xdc\xb2\x0d\x2c\x9d\xd8\x55\xba\xc7\x5d\xb6\x6e\x67\xd6\xba\x09\x75\xf2\x0c\xab\x76\x3a\xad\xbe\xbe\xae\x6a\xb4\x72\x2a\x6b\xbc\x0f\x75\xc4\x06\xe3\x37\x1e\x67\x98\xc0\x4b\xd3\xcc\x9d\x9e\x87\x9a\x51\xb1\x62\x12\x13\x94\x66\x37\x3a\x6c\xff\x8d\x09\x69\x1f\x8b\x9b\x3d\xa5\xc4\x15\x8b\xe5\xa7\x63\x19\x93\xd7\x01\x00\x56\x58\x32\xb4\x55\x03\x00\x00")
+
+func mysql2_unit_auditSqlBytes() ([]byte, error) {
 	return bindataRead(
-		_ddlGo,
-		"ddl.go",
+		_mysql2_unit_auditSql,
+		"mysql/2_unit_audit.sql",
 	)
 }
 
-func ddlGo() (*asset, error) {
-	bytes, err := ddlGoBytes()
+func mysql2_unit_auditSql() (*asset, error) {
+	bytes, err := mysql2_unit_auditSqlBytes()
 	if err != nil {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "ddl.go", size: 66, mode: os.FileMode(420), modTime: time.Unix(1496166086, 0)}
+	info := bindataFileInfo{name: "mysql/2_unit_audit.sql", size: 853, mode: os.FileMode(420), modTime: time.Unix(1792371791, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _ddl_genGo = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x01\x00\x00\xff\xff\x00\x00\x00\x00\x00\x00\x00\x00")
+var _mysql3_unit_versionsSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x9c\x91\x41\x4b\xfb\x40\x10\xc5\xef\xfb\x29\xde\x31\xe1\xdf\x1e\xfe\x20\x45\x28\x3d\x6c\x92\xb1\x2e\x26\x9b\x30\x99\x80\x39\x85\x68\xa3\x06\x69\x52\x92\x8d\xe2\xb7\x97\x60\xc5\x0a\x22\xd4\x39\xbe\xe1\xcd\x3c\x7e\x6f\xb9\xc4\xbf\x7d\xfb\x38\xd4\xae\x41\x71\x50\x21\x93\x16\x82\xe8\x20\x26\x4c\x5d\xeb\xaa\x97\x66\x18\xdb\xbe\x1b\xe1\x29\x7c\x28\xed\x0e\x00\x02\x63\x35\x97\xde\xff\x95\x8f\xdf\xc7\xa6\x02\x5b\xc4\xf1\x42\x01\xc7\x63\x00\x60\xac\xd0\x96\x18\xc0\x19\xfe\xd1\xcd\x39\x01\x00\x42\xb7\x02\xe0\x3c\x7f\x3d\xb9\xa7\x7e\xc0\x9f\xf3\xdf\x0f\x4d\xed\x9a\x5d\x55\x3b\x44\x5a\x48\x4c\x42\xde\xca\x47\x44\x57\xba\x88\x05\x61\xc1\x4c\x56\xaa\x59\xcf\x45\x27\xd9\xbc\x3c\xf5\x87\xa9\xcd\x85\xb5\xb1\xf2\x1d\x6e\x75\x78\x6e\xde\x90\xb1\x49\x34\x97\xb8\xa1\x12\xde\x11\xf5\xe2\x93\x99\xaf\x7c\x90\xdd\x1a\x4b\xd8\xc0\x74\x5d\x1f\x05\x5f\x7f\xaf\x35\xe7\x24\xd8\x60\x72\x0f\x97\xfb\xbb\x8b\xb5\x52\xa7\xc5\x46\xfd\x6b\xa7\x22\x4e\xb3\x9f\x8a\x5d\xab\xf7\x01\x00\xe0\x20\x00\xe1\x04\x02\x00\x00")
 
-func ddl_genGoBytes() ([]byte, error) {
+func mysql3_unit_versionsSqlBytes() ([]byte, error) {
 	return bindataRead(
-		_ddl_genGo,
-		"ddl_gen.go",
+		_mysql3_unit_versionsSql,
+		"mysql/3_unit_versions.sql",
 	)
 }
 
-func ddl_genGo() (*asset, error) {
-	bytes, err := ddl_genGoBytes()
+func mysql3_unit_versionsSql() (*asset, error) {
+	bytes, err := mysql3_unit_versionsSqlBytes()
 	if err != nil {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "ddl_gen.go", size: 0, mode: os.FileMode(420), modTime: time.Unix(1496169186, 0)}
+	info := bindataFileInfo{name: "mysql/3_unit_versions.sql", size: 516, mode: os.FileMode(420), modTime: time.Unix(1792372138, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _postgres1_initSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x7c\x90\x31\x4f\xc3\x30\x10\x85\x77\xff\x8a\x37\x26\x12\x03\x7b\x26\xb7\x3e\x2a\x0b\x73\x46\xee\x59\x6a\x26\xab\xc2\x19\x2c\x22\xa8\xea\x20\xd1\x7f\xcf\x50\xa7\x82\x81\xbe\xf1\xee\x7b\xc3\xf7\xb6\x81\xb4\x10\x44\x6f\x1c\xe1\xeb\xa3\x2c\x15\x9d\x02\x4a\xc6\xaf\xc4\x68\x0d\xfe\x84\xbd\x80\xa3\x73\x0f\x0a\x78\x9b\x8f\xb5\xa6\xb5\x71\x9f\x5d\xca\x32\x4f\xb7\x8f\xd0\x41\xfe\x67\x73\xa9\xa7\xf9\x78\x49\x9f\xe7\x3c\x9d\x61\x59\x68\x47\x01\x86\x9e\x74\x74\x82\xc7\x1b\xab\xfa\x41\x69\x27\x14\x9a\x85\x67\x37\x5e\x55\x14\xa0\x8d\xc1\xd6\xf3\x5e\x82\xb6\x2c\xd7\x73\x3a\xbd\x4f\x17\xbc\x06\xfb\xa2\xc3\x88\x67\x1a\xd1\x95\xdc\x0f\xaa\x6d\x61\xd9\xd0\xa1\x91\xab\x5b\x2a\xf9\x5b\x01\x9e\xdb\x46\x71\x6f\x79\x87\x8d\x04\x22\x74\x2b\xd4\x0f\x3f\x01\x00\x00\xff\xff\x27\x79\xb6\xfa\x4e\x01\x00\x00")
+var _mysql4_webhooksSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xac\x55\x5f\x6f\xda\x3e\x14\x7d\xcf\xa7\x38\x8f\x89\x7e\xf4\xe1\x27\x55\x68\x12\xea\x83\x21\x5e\x17\x0d\x0c\x32\x66\x2a\x4f\x96\xc1\x77\x5b\xd4\x90\x44\x89\xd3\x96\x6f\x3f\xa5\x94\xc5\x5b\x59\x47\xc3\xee\x9b\x73\x7d\x8e\xee\x9f\x73\x9c\xab\x2b\xfc\xb7\x4b\xbf\x55\xc6\x11\x56\x65\x30\x91\x9c\x29\x0e\xc5\xc6\x53\x8e\x47\xda\x7c\x2f\x8a\xfb\x1a\x61\x00\xa4\x16\xc7\x18\x27\x82\xc9\x75\xf8\xff\x30\xc2\xdb\x21\xe6\x0a\x62\x35\x9d\x0e\x02\x60\x9b\x99\xba\xd6\xa9\xed\x8b\x6f\xaa\x0c\x00\x00\x40\xf1\x3b\x85\x73\xc2\xc7\xd7\xb4\xad\xc8\xf5\xc7\xd3\x03\xe5\xae\xee\x8f\xdf\x56\x64\x1c\x59\xbd\xd9\xf7\x9c\xdf\x0b\xde\x38\xc4\x4c\x71\x95\xcc\x78\x38\x8c\x10\xf3\x8f\x6c\x35\x55\x98\xac\xa4\xe4\x42\xe9\xf6\xfb\x52\xb1\xd9\xa2\x4d\xfa\xf8\xc9\x5c\x2c\x95\x64\x89\x50\x3f\xf7\xaa\xcb\x7b\xda\x63\x21\x93\x19\x93\x6b\x7c\xe6\x6b\x84\xa9\x8d\xda\xcb\x89\x88\xf9\x5d\x77\xef\xb8\x3b\x9d\xda\x27\x84\xc7\x53\x14\x44\xe0\xe2\x36\x11\x1c\x37\x48\xf2\xbc\x88\xc7\x5d\x39\x9f\x98\x5c\x72\x85\x1b\x34\xee\xeb\x87\xdd\xe6\x7a\x14\x9c\xd4\x96\xb6\x94\xa5\x0f\x54\xa5\xf4\x4a\x65\x17\x68\xed\x48\x9e\xda\x4b\x58\x9e\x37\xee\x27\xbf\x30\xd9\xf6\x15\x0e\xaf\xa3\xf3\x59\x4a\xb3\xcf\x0a\xe3\xb5\xd5\x4f\xbd\xce\xb8\xa6\x3e\x51\xcb\x5f\x5b\xf2\x59\x8c\x73\xb4\x2b\x5d\xc7\x93\x08\xc5\x6f\xb9\x7c\x5f\x2d\x39\x3d\x39\xfd\x42\xd5\xca\xf1\x17\x3d\x9e\xcd\x92\x99\xda\x69\xaa\xaa\xa2\xba\x64\x2e\xcf\x2c\x87\xe1\xe8\x6d\x61\xa9\x5f\x47\x9e\xb7\x00\x00\xfd\x1c\xd6\x94\xf6\x1f\xb0\xbc\xf6\xa9\xe7\x91\x33\x1d\xeb\x23\x6c\x43\x07\xdb\x1e\xa6\x34\xf8\x7d\x7d\x6f\xa3\x3b\x27\x1d\x48\xba\xf3\xc0\x9b\xda\x7b\x5f\x02\xff\xa7\x13\x17\x8f\x79\x10\xcb\xf9\xe2\x8f\x0f\xc3\xe8\x44\xba\x1e\x05\x3f\x06\x00\x5b\x99\xc7\x27\xba\x06\x00\x00")
+
+func mysql4_webhooksSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_mysql4_webhooksSql,
+		"mysql/4_webhooks.sql",
+	)
+}
+
+func mysql4_webhooksSql() (*asset, error) {
+	bytes, err := mysql4_webhooksSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "mysql/4_webhooks.sql", size: 1722, mode: os.FileMode(420), modTime: time.Unix(1792372617, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _mysql5_onerosterSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xac\x93\x41\x4f\xc2\x40\x10\x85\xef\xfb\x2b\xde\xb1\x8d\x70\xd0\x08\x31\x21\x1c\x96\x76\x94\xc6\xb2\x90\x65\x6a\xe4\xd4\x54\x58\x4d\x23\xb4\xa4\x5d\x62\xf8\xf7\xa6\x54\x03\x6a\x25\x06\x9c\xe3\x4c\x5e\xdf\xcc\xfb\xba\xed\x36\x2e\x56\xe9\x4b\x91\x58\x83\x68\x2d\x3c\x4d\x92\x09\x2c\x07\x21\x21\xcf\x4c\x91\x97\xd6\x14\xf1\x7c\x99\x94\xa5\x29\xe1\x08\xa0\xcc\x37\xc5\xdc\x2c\xe2\x74\x81\x07\xa9\xbd\xa1\xd4\xce\x55\xa7\xe3\xe2\x58\xa9\x31\x43\x45\x61\xd8\x12\xc0\xee\x5b\x95\x1a\x18\x04\x4a\xea\x99\x73\xd9\x3d\xae\xfe\xa6\x2f\x4c\x62\xcd\x22\x4e\x2c\x7c\xc9\xc4\xc1\x88\x9c\xae\x0b\x9f\x6e\x65\x14\x32\xbc\x48\x6b\x52\x1c\x57\xfd\x29\xcb\xd1\xa4\x1a\x1e\xea\xbd\xb1\x9a\xb2\x96\x81\xe2\x9f\x07\xc6\xeb\x57\xb3\xc5\x44\x07\x23\xa9\x67\xb8\xa7\x19\x9c\xfd\xb9\xae\x70\x41\xea\x2e\x50\x84\x3e\x82\x2c\xcb\xfd\xc1\xde\x75\x28\xf5\x94\x18\x7d\x6c\xec\xf3\xcd\xea\xe9\xba\x27\x7e\xcb\x72\x93\xa5\xb6\x4e\xf2\xdc\x24\xce\x25\x51\x6d\x52\xdb\x9f\xe6\x6f\x53\xbb\x34\x75\x9b\xe9\x91\xf1\x97\xfa\xb2\xff\x36\x9b\xd7\x20\xff\x91\xe4\x2e\xde\x06\x8e\x9f\x61\xb7\x70\x3a\xd1\xc3\xc7\xe2\xe7\x6f\x99\xf0\xf5\x78\xd2\x0c\xb8\xd7\x3c\xfb\xf8\xcf\x7a\xe2\x7d\x00\xd5\x34\x7a\x40\x78\x03\x00\x00")
+
+func mysql5_onerosterSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_mysql5_onerosterSql,
+		"mysql/5_oneroster.sql",
+	)
+}
+
+func mysql5_onerosterSql() (*asset, error) {
+	bytes, err := mysql5_onerosterSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "mysql/5_oneroster.sql", size: 888, mode: os.FileMode(420), modTime: time.Unix(1792374580, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _mysql6_current_unitsSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xa4\x91\x41\x4b\xc3\x40\x10\x85\xef\xfb\x2b\xde\x31\x41\x7b\x10\xa4\x08\xa5\x87\x4d\x32\xea\x62\xb2\x09\x9b\xc9\x21\xa7\x25\x6d\xa2\x04\xed\x36\x24\x1b\xa4\xff\x5e\x82\x88\x15\xc4\x83\x9d\xe3\x0c\xdf\x3c\x78\xdf\x6a\x85\xab\x43\xff\x32\x36\xbe\x43\x35\x88\xd8\x90\x64\x02\xcb\x28\x25\xec\xe7\x71\xec\x9c\xb7\xb3\xeb\xfd\x84\x40\x00\xfb\xb7\x66\x9a\x6c\xdf\x02\x88\x94\x96\xa6\x0e\x6e\xd6\x21\xfe\x1e\x9d\x33\x74\x95\xa6\xd7\x02\x58\x3e\x7d\xe2\xff\xe4\x87\xb6\xf1\x5d\x6b\x77\xa7\xcb\xf8\xc6\x23\x91\x4c\xac\x32\x0a\xd6\x21\x12\xba\x97\x55\xca\x88\x2b\x63\x48\xb3\x5d\xf6\x25\xcb\xac\x58\x8e\xe7\x7c\x9c\xeb\x92\x8d\x54\x9a\x7f\x96\x63\x87\xd7\xee\x84\xc2\xa8\x4c\x9a\x1a\x4f\x54\x23\xf8\xaa\x2a\x14\x21\x48\x3f\x28\x4d\xd8\x42\x39\x77\x4c\xa2\xef\xbc\x47\x69\x4a\x62\x6c\x31\xfb\xe7\xbb\xc3\xee\x76\x23\xc4\xb9\x90\xe4\xf8\xee\x44\x62\xf2\xe2\x37\x21\x1b\xf1\x31\x00\x22\xc6\x6e\x38\xbc\x01\x00\x00")
+
+func mysql6_current_unitsSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_mysql6_current_unitsSql,
+		"mysql/6_current_units.sql",
+	)
+}
+
+func mysql6_current_unitsSql() (*asset, error) {
+	bytes, err := mysql6_current_unitsSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "mysql/6_current_units.sql", size: 444, mode: os.FileMode(420), modTime: time.Unix(1792378421, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _mysql7_unit_progressSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xa4\x92\x4f\x4b\xc3\x40\x10\xc5\xef\xfb\x29\xe6\x98\x60\x7b\x10\xa4\x08\xa5\x87\x6d\x76\xb4\x8b\xe9\x26\x6c\x36\x62\x4e\x21\x9a\xb5\x04\x9b\x3f\x64\x37\xa8\xdf\x5e\x12\x13\xac\xa1\x28\xe8\xdc\x76\x1e\xf3\xdb\xc7\xcc\x5b\x2e\xe1\xa2\x2c\x0e\x6d\x66\x35\xc4\x0d\xf1\x24\x52\x85\xa0\xe8\xd6\x47\xe8\xaa\xc2\xa6\x4d\x5b\x1f\x5a\x6d\x0c\x38\x04\x3e\x3b\x45\x0e\x43\x6d\xb9\xa0\x32\x71\x2e\x57\x2e\xfc\x5c\x22\x50\x20\x62\xdf\x5f\xf4\x04\xa3\xdb\xff\x11\x9e\x8e\x99\x31\x13\xe2\x4f\x04\x63\x33\xdb\x99\x51\xb8\xa7\xd2\xdb\x51\xf9\x3b\x62\x46\x68\xad\xce\xd3\xcc\x02\x00\xa3\x0a\x15\xdf\xa3\xb3\x72\x07\x7f\x75\xd9\x1c\xf5\xa8\xce\xb4\xae\xc9\xb3\x73\x73\xc0\xf0\x86\xc6\xbe\x02\x2f\x96\x12\x85\x4a\xfb\x7e\xa4\xe8\x3e\xec\xc5\xd3\x9f\xbd\x40\x44\x4a\x52\x2e\xd4\xf7\xf3\xa4\xcd\x8b\x7e\x87\x50\xf2\x3d\x95\x09\xdc\x61\x02\xce\x78\xac\xc5\xb4\xf3\xc1\x01\x17\x0c\x1f\x66\xa3\xd3\x46\xd3\x22\x7f\x03\x67\x7a\xb9\xc4\x05\x14\xb7\x5c\x20\x6c\x80\x57\x55\xcd\xb6\x5f\x36\x77\x54\x46\xa8\x60\x03\x9d\x7d\xbe\x2e\x1f\xaf\xd6\x84\x9c\x26\x89\xd5\xaf\x15\x61\x32\x08\xcf\x25\x69\x4d\x3e\x06\x00\x18\x71\x25\x55\x75\x02\x00\x00")
+
+func mysql7_unit_progressSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_mysql7_unit_progressSql,
+		"mysql/7_unit_progress.sql",
+	)
+}
+
+func mysql7_unit_progressSql() (*asset, error) {
+	bytes, err := mysql7_unit_progressSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "mysql/7_unit_progress.sql", size: 629, mode: os.FileMode(420), modTime: time.Unix(1792378698, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _mysql8_unit_detailsSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x6c\x8f\xbd\x4e\xc4\x30\x10\x84\x7b\x3f\xc5\xf4\x24\xbc\x40\xaa\x40\xd2\x99\x04\x9d\x1c\x89\x76\x95\x2c\xc6\xd2\x79\x1d\x79\x37\xfc\xbc\x3d\xc5\xe9\x24\x0e\x5d\xbd\xdf\xce\x37\xd3\xb6\x78\xc8\x29\x56\x32\xc6\xb2\xbb\xb6\x45\x18\xdf\x02\xd6\x72\x3e\xb2\x28\x56\x12\x29\x86\x0f\xfa\x64\x6c\xfc\x4e\xc7\xd9\xb4\x03\x7f\x27\xb5\x24\x11\x87\x24\x53\x44\x36\x70\xde\xed\x07\x6a\x35\x49\xd4\x06\x95\x69\x03\x29\xa4\x20\xb3\xd1\x46\x46\x8f\xae\xf7\x61\x3c\x21\xf4\x4f\x7e\xbc\x7c\x3a\xa0\x1f\x06\x3c\xcf\x7e\x79\x99\xb0\xb1\xae\x35\xed\x96\x8a\x5c\x4a\x4c\x73\xc0\xb4\x78\xdf\xdc\x72\xd7\x40\x00\xb7\x5c\xe7\xdc\xdf\x39\x43\xf9\x92\xbb\xce\xe1\x34\xbf\xde\x91\x36\xff\x4e\x57\x4f\xe7\x7e\x07\x00\xb4\xd0\x1c\x09\x25\x01\x00\x00")
+
+func mysql8_unit_detailsSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_mysql8_unit_detailsSql,
+		"mysql/8_unit_details.sql",
+	)
+}
+
+func mysql8_unit_detailsSql() (*asset, error) {
+	bytes, err := mysql8_unit_detailsSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "mysql/8_unit_details.sql", size: 293, mode: os.FileMode(420), modTime: time.Unix(1792383384, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _mysql9_unit_hierarchySql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x6c\x8f\x3b\x4b\xc4\x40\x14\x85\xfb\xf9\x15\xa7\x54\x4c\x04\x1b\x9b\x54\x59\x93\x42\x18\x13\x09\x13\xd0\x4a\x86\xe4\x1a\x07\x36\x77\xc2\xdc\x3b\x3e\xfe\xbd\xc5\x42\xf0\xb1\xf5\x79\x7c\xe7\x94\x25\xae\xd6\xb0\x24\xaf\x84\x71\x33\x65\x09\xd7\x3e\x39\x4c\xf1\x98\x57\x16\x4c\x9e\x39\x2a\xde\xfc\x3b\x61\xa6\x57\x9f\x8f\x2a\x15\xe8\x33\x88\x06\x5e\x90\x39\xa8\x60\x21\x05\xad\x9b\x7e\x41\x34\x05\x5e\xa4\x40\x22\x3f\xc3\x0b\x38\x22\x91\xc4\x9c\x26\x92\x6b\x53\x5b\xd7\x0e\x70\xf5\xc1\xb6\xa7\xa8\x01\xea\xa6\xc1\x5d\x6f\xc7\x87\x0e\x9b\x4f\xc4\xfa\x12\x66\x1c\xee\xbb\x7a\x78\xbe\xb8\xb9\xbd\x2c\x7e\x5b\xf6\xb2\xd3\xcc\xae\x77\xe8\x46\x6b\x2b\x63\x7e\x1e\x69\xe2\x07\x9f\x85\x35\x43\xff\xf8\x8f\x56\xfc\x11\x76\x46\x65\xbe\x07\x00\x09\x63\x56\xed\x1e\x01\x00\x00")
+
+func mysql9_unit_hierarchySqlBytes() ([]byte, error) {
+	return bindataRead(
+		_mysql9_unit_hierarchySql,
+		"mysql/9_unit_hierarchy.sql",
+	)
+}
+
+func mysql9_unit_hierarchySql() (*asset, error) {
+	bytes, err := mysql9_unit_hierarchySqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "mysql/9_unit_hierarchy.sql", size: 286, mode: os.FileMode(420), modTime: time.Unix(1792383655, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _postgres10_unit_detailsSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x6c\xce\xbd\xce\xc2\x20\x18\xc5\xf1\x9d\xab\x38\x1b\xc3\xdb\x5e\x41\x27\xfa\x82\x83\x79\x04\x53\x1f\x12\x57\x62\x89\x61\x28\x6d\x5a\x8c\x83\xf1\xde\x9d\xfc\x4c\xe7\x93\x7f\xce\xaf\xae\xf1\x37\xa4\xf3\x1c\x4a\x84\x9f\x84\x22\x36\x1d\x58\xb5\x64\x70\xc9\xa9\x2c\x02\x50\x5a\xe3\xdf\x91\xdf\x59\xf4\x71\x39\xcd\x69\x2a\x69\xcc\x60\x73\x64\x40\x9b\x8d\xf2\xc4\x90\x12\x80\x75\x0c\xeb\x89\xaa\xef\x6a\x88\x25\xf4\xa1\x04\x00\xd8\x1e\x9c\x6d\xdf\xd5\xed\x2e\x5f\x55\x23\xc4\xa7\x46\x8f\xd7\xbc\xea\xd1\x9d\xdb\xaf\x80\xaa\x9f\xe9\xf9\xda\x88\xc7\x00\x3b\x77\x3b\x28\xe4\x00\x00\x00")
+
+func postgres10_unit_detailsSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_postgres10_unit_detailsSql,
+		"postgres/10_unit_details.sql",
+	)
+}
+
+func postgres10_unit_detailsSql() (*asset, error) {
+	bytes, err := postgres10_unit_detailsSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "postgres/10_unit_details.sql", size: 228, mode: os.FileMode(420), modTime: time.Unix(1792383384, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _postgres11_unit_hierarchySql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x6c\xcd\xbf\xaa\xc2\x30\x14\x07\xe0\x3d\x4f\xf1\xdb\x3a\xdc\xf6\x09\x3a\xa5\xf7\x54\x50\x8e\x89\xd4\x9c\x49\x44\x8a\x06\xc9\x60\x5a\x92\x14\x5f\xdf\x4d\xfc\x37\x7f\xc3\xd7\x34\xf8\xbb\x85\x6b\x1a\x8b\x87\xcc\x4a\xb3\xeb\x07\x38\xdd\x71\x8f\x25\x86\x92\x15\xa0\x89\xf0\x6f\x59\xb6\x06\xf3\x98\x7c\x2c\xa7\x70\x81\xc8\x9a\xea\x77\x4c\x3e\x4f\x4b\x3a\xfb\x8c\xcd\xde\x9a\x0e\xd4\xaf\xb4\xb0\x43\x75\x38\x56\x30\xd6\xc1\x08\x73\xab\xd4\x6b\x49\xd3\x3d\xfe\x4c\x69\xb0\xbb\xaf\xb5\xfe\x80\xe7\xd8\xaa\xc7\x00\x3d\xce\x04\xaa\xc8\x00\x00\x00")
+
+func postgres11_unit_hierarchySqlBytes() ([]byte, error) {
+	return bindataRead(
+		_postgres11_unit_hierarchySql,
+		"postgres/11_unit_hierarchy.sql",
+	)
+}
+
+func postgres11_unit_hierarchySql() (*asset, error) {
+	bytes, err := postgres11_unit_hierarchySqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "postgres/11_unit_hierarchy.sql", size: 200, mode: os.FileMode(420), modTime: time.Unix(1792383655, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _postgres12_unit_search_descriptionSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xac\x92\x41\x6f\x9b\x4c\x10\x86\xef\xfb\x2b\xde\x83\x25\x8c\x3e\xec\x1f\xf0\xa1\x1c\x30\xac\xa9\xa5\x0a\xac\x05\x94\xde\xd0\x16\xb6\xb0\x2a\x66\x29\x3b\xa9\x2f\xf9\xf1\x95\x0d\x76\x13\x29\x76\x95\xb4\x57\x96\x79\x66\xe6\x7d\x66\xb5\xc2\x7f\x07\xdd\x8c\x92\x14\x8a\x81\xad\x56\x88\x94\xad\x46\x3d\x90\x36\xbd\x85\x1c\x15\xac\x92\x63\xd5\xaa\x1a\x64\x8c\x87\xa3\xd2\x4d\x4b\xaa\xc6\x57\xd5\x99\x23\x48\x53\xa7\x2c\xac\x01\xb5\x92\xf0\xd4\x6b\xb2\x38\xb6\xc6\xaa\xcb\xd3\x41\x52\xd5\x62\x94\xfd\x77\x7c\xd3\xa3\xa5\x35\x8b\x44\xba\x47\x2e\x76\x71\xcc\xc5\x54\x51\x4e\x3d\xca\xa7\xa1\x3e\x0d\x92\x26\xd3\x67\x9f\xbd\x1c\x2f\x23\x49\xea\xa0\x7a\xda\xa8\x46\xf7\x2c\x14\x3c\xc8\x39\xb6\x45\x12\xe6\xbb\x34\x79\x0d\xfa\xa9\x2a\x32\xe3\xd2\x85\xe0\x79\x21\x92\xec\xda\x2e\xc8\xb0\x58\xb0\x0d\x8f\x77\x09\x03\x12\xfe\xb8\x9e\x2a\xf0\xff\x03\x03\x00\xab\x68\xda\x70\x49\xa6\x24\x3b\x73\x9c\xa1\x29\x2b\x49\xb2\x33\xcd\x5a\xf5\x4d\xa7\x6d\xeb\x78\xa8\x8c\xec\x94\xad\xd4\xf2\x84\x39\x6f\xeb\xc1\x71\x5c\xd7\x83\x13\x38\x2e\x9e\x9f\xff\x8e\x58\xff\x16\x71\xe5\x6e\x1c\xd7\x67\x98\xb7\x3a\x8d\xef\x33\x9e\x44\x6c\xb1\xc0\xe7\x20\x89\x8b\x20\xe6\x18\xba\xa1\xb1\x3f\xba\x1b\xd9\xf1\xbe\xbe\x24\x77\xc7\x00\x03\x36\x7c\x9b\x0a\x8e\x5d\x92\x71\x91\x23\x15\x28\xf6\xd1\xa9\x2a\xdd\x62\x5e\xf5\xc5\x7c\x57\x63\x0c\xd8\xa6\x02\x3c\x08\x3f\x41\xa4\x8f\xe0\x5f\x78\x58\xe4\x1c\x7b\x91\x86\x3c\x2a\x04\x7f\x5b\x93\xcf\x66\xfa\xf9\x15\x19\xcf\xe7\xab\xc3\x03\xfb\x60\x82\x37\x7c\x7c\x88\x75\xd3\xc4\xab\x8c\x23\x73\xec\xdf\x73\xdb\xe7\x5f\xef\x9f\xaf\xff\x6f\x5c\xbd\x43\xcf\x25\x92\x19\x5e\xd2\xa8\x9b\x46\x8d\xcb\xa9\xa5\x87\xb7\x93\x3a\x77\xb9\x67\x11\x7f\x4e\xfb\xc2\xf8\x35\x00\xb1\x06\x1d\x94\x92\x04\x00\x00")
+
+func postgres12_unit_search_descriptionSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_postgres12_unit_search_descriptionSql,
+		"postgres/12_unit_search_description.sql",
+	)
+}
+
+func postgres12_unit_search_descriptionSql() (*asset, error) {
+	bytes, err := postgres12_unit_search_descriptionSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "postgres/12_unit_search_description.sql", size: 1170, mode: os.FileMode(420), modTime: time.Unix(1792384456, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _postgres13_unit_scheduleSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x74\x8f\xbf\xae\x82\x30\x1c\x85\xf7\x3e\xc5\xd9\xb8\x37\x17\x86\xbb\xb8\x30\x55\x5a\x03\x49\x69\x49\x6d\x35\x71\x31\x18\x88\x92\x28\x12\x5a\xe2\xeb\x9b\xa0\x83\x82\x8e\xbf\x3f\xe7\xe4\xfb\xa2\x08\x7f\x97\xe6\xd8\x97\xbe\x86\xed\x08\x15\x86\x6b\x18\xba\x14\x1c\x43\xdb\x78\x47\x00\xca\x18\x12\x25\x6c\x2e\xe1\x7c\xe9\x07\x07\x00\x1b\xaa\x93\x94\xea\x9f\xff\xc5\x2f\x18\x5f\x51\x2b\x0c\x82\x6e\x38\x9c\x1b\x77\xaa\xab\x00\x52\x19\x48\x2b\x44\x38\x2b\xe8\xbd\xdb\x97\x1e\x26\xcb\xf9\xda\xd0\xbc\xc0\x36\x33\xe9\x38\x62\xa7\x24\x9f\x04\xea\xb6\x1a\xdf\xf1\x35\x10\x13\xf2\x2a\xc1\xae\xb7\xf6\xa3\x06\xd3\xaa\x78\xf7\x08\xe7\xdb\x07\xdc\xf4\xf0\x84\x88\xc9\x7d\x00\x58\x6e\x7b\xc3\x2e\x01\x00\x00")
+
+func postgres13_unit_scheduleSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_postgres13_unit_scheduleSql,
+		"postgres/13_unit_schedule.sql",
+	)
+}
+
+func postgres13_unit_scheduleSql() (*asset, error) {
+	bytes, err := postgres13_unit_scheduleSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "postgres/13_unit_schedule.sql", size: 302, mode: os.FileMode(420), modTime: time.Unix(1792384555, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _postgres1_initSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x8c\xd0\x3f\x6b\xc3\x30\x10\x05\xf0\x5d\x9f\xe2\x8d\x36\x74\xe8\xee\x49\x89\xae\x41\x54\x3d\x15\xe5\x04\xf1\x24\x42\xe5\x41\xd4\xb4\x21\x72\xa1\xf9\xf6\x1d\xfc\x87\x76\x28\xe4\x8d\xef\x7e\xcb\xbb\x7d\x20\x2d\x04\xd1\x3b\x47\xf8\xfa\x28\x53\x45\xa3\x80\x92\xf1\x2b\x31\x5a\x83\x3f\x61\x2f\xe0\xe8\xdc\x83\x02\xde\xc6\x73\xad\xa9\xe4\x7b\xec\x54\xa6\x71\xd8\x2e\x42\x27\xf9\xdf\xe6\x52\x2f\xe3\xf9\x96\x3e\xaf\x79\xb8\xc2\xb2\xd0\x81\x02\x0c\x3d\xe9\xe8\x04\x8f\x9b\x55\x6d\xa7\xb4\x13\x0a\xcb\x0a\xcf\xae\x9f\xa7\x28\x40\x1b\x83\xbd\xe7\xa3\x04\x6d\x59\xe6\x3a\x5d\xde\x87\x1b\x5e\x83\x7d\xd1\xa1\xc7\x33\xf5\x68\x4a\x6e\x3b\xb5\xfc\xc2\xb2\xa1\xd3\x22\xd7\x6d\xa9\xe4\x6f\x05\x78\x9e\x7b\xc4\xa3\xe5\x03\x76\x12\x88\xd0\xac\xa8\xed\x7e\x06\x00\x27\x79\xb6\xfa\x4e\x01\x00\x00")
 
 func postgres1_initSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -125,7 +396,367 @@ func postgres1_initSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "postgres/1_init.sql", size: 334, mode: os.FileMode(420), modTime: time.Unix(1496169184, 0)}
+	info := bindataFileInfo{name: "postgres/1_init.sql", size: 334, mode: os.FileMode(420), modTime: time.Unix(1792382979, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _postgres2_created_atSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x6c\xcd\xb1\xaa\xc2\x30\x14\x06\xe0\x3d\x4f\xf1\x8f\xf7\x22\x7d\x82\x4e\xd1\x73\xc4\xc2\x49\x52\xea\x09\x82\x8b\x04\x0d\xd2\xc1\x54\x6a\xa4\xaf\x2f\x38\x09\x3a\x7e\xd3\xd7\x34\x58\xdd\xc6\xeb\x9c\x6a\x46\xbc\x1b\x2b\xca\x03\xd4\xae\x85\xf1\x2c\x63\x7d\x18\xc0\x12\x61\x13\x24\x3a\x8f\xf3\x9c\x53\xcd\x97\x53\xaa\xd0\xce\xf1\x5e\xad\xeb\x71\xe8\x74\xf7\x26\x8e\xc1\x33\x88\xb7\x36\x8a\xa2\x4c\xcb\xdf\x3f\x7c\x50\xf8\x28\xd2\x1a\xf3\x59\xd1\xb4\x94\x9f\x19\x0d\xa1\xff\xde\x5a\xf3\x1a\x00\xf3\x55\x4b\x51\xa8\x00\x00\x00")
+
+func postgres2_created_atSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_postgres2_created_atSql,
+		"postgres/2_created_at.sql",
+	)
+}
+
+func postgres2_created_atSql() (*asset, error) {
+	bytes, err := postgres2_created_atSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "postgres/2_created_at.sql", size: 168, mode: os.FileMode(420), modTime: time.Unix(1792370090, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _postgres3_unit_auditSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xa4\x93\xd1\x4e\xc2\x30\x14\x86\xef\xfb\x14\xff\x25\x44\x78\x02\xae\x0a\xab\xb1\x3a\x5a\xb2\x75\x11\xbc\x59\xea\x5a\x4c\x23\x6e\xd8\x75\x41\xdf\xde\x88\x5b\x18\x6a\x34\xce\x73\xd7\x73\xf2\x7f\xa7\xa7\xfd\xcf\x74\x8a\x8b\x27\xf7\xe0\x75\xb0\xc8\xf6\x64\x91\x30\xaa\x18\x14\x9d\xc7\x0c\x4d\xe9\x42\xae\x1b\xe3\x02\x46\x04\x70\x06\xa7\xc8\x32\x1e\xe1\xf7\x10\x52\x41\x64\x71\x3c\x21\xf8\xc0\x39\x33\x58\x5f\xec\x74\x5d\x77\x80\x01\x7a\x5d\x04\x57\x95\x00\x00\x40\xb1\xb5\xfa\xbb\xbe\xf2\xff\x98\xbf\xd8\x39\x5b\x76\x2f\x30\xa0\xbf\xb7\xcf\x8d\xad\x5b\xc0\x00\xfd\xbd\xdd\x56\xde\xe6\x75\x78\xff\xeb\xeb\x54\x8a\xf9\x71\xaa\x6d\xb0\xbe\x4d\x9e\xb2\x85\xb7\x3a\x58\x93\xeb\x00\x40\xf1\x25\x4b\x15\x5d\xae\x70\xcb\xd5\xd5\xf1\x88\x3b\x29\x18\x22\x76\x49\xb3\x58\xa1\xac\x0e\xa3\xf1\x59\xaf\x85\x14\xa9\x4a\x28\x17\xaa\xe7\xa2\x7c\xff\x68\x5f\xb1\x4a\xf8\x92\x26\x1b\xdc\xb0\x0d\x46\xce\x8c\xc9\x78\xd6\xd9\x8e\x8b\x88\xad\xfb\x82\xd6\x32\xb9\x33\x2f\x04\x90\xe2\xcc\x92\x6d\x71\xd2\xbb\xec\x0f\xa8\xce\x3d\xdf\xb3\xba\xea\x27\x18\xe9\xaf\x47\x54\x1d\x4a\x12\x25\x72\xf5\x65\x3d\x66\xe4\x6d\x00\x25\x27\x87\xc8\x47\x03\x00\x00")
+
+func postgres3_unit_auditSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_postgres3_unit_auditSql,
+		"postgres/3_unit_audit.sql",
+	)
+}
+
+func postgres3_unit_auditSql() (*asset, error) {
+	bytes, err := postgres3_unit_auditSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "postgres/3_unit_audit.sql", size: 839, mode: os.FileMode(420), modTime: time.Unix(1792371791, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _postgres4_unit_versionsSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x9c\x91\x41\x4b\xc3\x40\x10\x85\xef\xf3\x2b\xde\x31\xc1\xf6\x17\xf4\xb4\x6d\x46\x5d\x4d\x76\xc3\x66\x16\xa9\x97\x10\xec\xa2\x41\x4c\xca\x66\x6b\xf1\xdf\x8b\x5a\xc1\x82\x20\x71\x6e\x33\xf0\xe6\x3d\xbe\xb7\x5c\xe2\xe2\xa5\x7f\x8c\x5d\x0a\xf0\x7b\xda\x38\x56\xc2\x10\xb5\x2e\x19\x87\xa1\x4f\xed\x6b\x88\x53\x3f\x0e\x13\x32\xc2\xd7\xa5\xdf\x01\x80\xf7\xba\xc0\xdf\x63\xac\xc0\xf8\xb2\x5c\x10\x70\x7a\x05\x00\xda\x08\x5f\xb1\x9b\xa3\x9e\xd2\x47\x46\x00\xc0\x4d\x63\xcd\x7a\x9e\x77\x77\x48\x4f\x63\xc4\x3f\x93\x3f\xc4\xd0\xa5\xb0\x6b\xbb\x04\xd1\x15\x37\xa2\xaa\x1a\x77\x5a\xae\x3f\x57\xdc\x5b\xc3\x28\xf8\x52\xf9\x52\x30\x8c\xc7\x2c\x3f\x53\x6f\xac\x69\xc4\x29\x6d\xe4\x1c\x69\xbb\x7f\x0e\x6f\xa8\x9d\xae\x94\xdb\xe2\x96\xb7\xc8\x4e\x80\x17\xdf\xac\x72\xca\x57\x44\x3f\x4b\x2a\xc6\xe3\x40\x85\xb3\xf5\x6f\x25\xad\xe8\x7d\x00\x7e\x46\x2e\x90\xd0\x01\x00\x00")
+
+func postgres4_unit_versionsSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_postgres4_unit_versionsSql,
+		"postgres/4_unit_versions.sql",
+	)
+}
+
+func postgres4_unit_versionsSql() (*asset, error) {
+	bytes, err := postgres4_unit_versionsSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "postgres/4_unit_versions.sql", size: 464, mode: os.FileMode(420), modTime: time.Unix(1792372138, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _postgres5_webhooksSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xa4\x95\x51\x6f\x9b\x30\x14\x85\xdf\xfd\x2b\xce\x63\xa2\xa5\xbf\x20\x4f\xac\x78\x1b\x5a\x62\x22\x6a\xb4\x76\x2f\x96\x8b\xaf\x36\xab\x14\x90\x6d\x9a\xe6\xdf\x4f\x4d\xcb\xa0\x4b\xb2\x2d\x8e\xdf\xd0\xf5\xf9\xc0\xf7\x9e\x63\xae\xae\xf0\xe1\xd1\xfe\x70\x3a\x10\xca\x8e\x5d\x17\x3c\x91\x1c\x32\xf9\xb8\xe2\xd8\xd2\xfd\xcf\xb6\x7d\xf0\x98\x31\xc0\x1a\x0c\xab\x2c\xb3\x14\xff\x5e\x22\x97\x10\xe5\x6a\xb5\x60\x40\x55\x6b\xef\x95\x35\x71\xea\xde\xd5\x00\x00\x00\x92\xdf\xca\x33\xd5\x9e\x2a\x47\x21\x56\x4d\x4f\xd4\x04\x1f\xab\xae\x1c\xe9\x40\x46\xdd\xef\xa2\xba\xf6\xa6\xd6\x01\x32\x5b\xf3\x1b\x99\xac\x37\xf8\x96\xc9\x2f\xfb\x47\x7c\xcf\x05\x47\xca\x3f\x25\xe5\x4a\xa2\x69\xb7\xb3\xf9\x3b\xf5\x75\x2e\x6e\x64\x91\x64\x42\xfe\x9e\xa4\xea\x1e\x68\x87\x4d\x91\xad\x93\xe2\x0e\x5f\xf9\x1d\x66\xd6\xcc\xd9\x7c\x39\x0c\x3e\x13\x29\xbf\x1d\xb7\x0f\x63\x53\xd6\x3c\x33\x20\x17\x13\x4f\x0c\xb5\xf9\x92\x1d\xb5\x8d\x32\x54\xdb\x27\x72\x96\x0e\x0c\x14\x6d\xa3\x01\x6d\x4d\x3c\x63\x3f\xd0\x69\x31\x62\xac\x9d\xde\xd5\xad\x36\x17\x31\x7c\xd0\xa1\xf7\x97\x7d\x87\x0e\x81\x1e\xbb\x30\x52\x32\x21\xf9\x67\x5e\x9c\xc3\x68\xe8\x39\xa8\x37\xd0\x8b\xd3\x4e\x5b\xed\x34\xa3\xd6\x3e\x28\x72\xae\x75\xf1\x67\xd9\x33\x5e\x9b\xa2\xaa\xd6\x50\xcc\x59\x26\x81\x01\x00\xc4\xc4\xa6\xef\xcc\xc5\x8c\xc3\xe8\x4d\xd2\x70\x5e\x08\xa7\x42\xd3\xd3\x41\x12\xdf\xc5\xec\xb5\x7d\x8b\x3f\x67\xfa\x1f\xe8\x31\x59\x7f\x7f\xc3\xb8\x6f\x31\xe9\xf6\xcb\x1d\x30\xfd\x93\xa4\xed\xb6\x61\x69\x91\x6f\x4e\x5e\x09\xcb\x23\x65\xbf\x64\xbf\x06\x00\xc7\x54\x3a\xe4\x8f\x06\x00\x00")
+
+func postgres5_webhooksSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_postgres5_webhooksSql,
+		"postgres/5_webhooks.sql",
+	)
+}
+
+func postgres5_webhooksSql() (*asset, error) {
+	bytes, err := postgres5_webhooksSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "postgres/5_webhooks.sql", size: 1679, mode: os.FileMode(420), modTime: time.Unix(1792372617, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _postgres6_onerosterSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xac\x92\xcb\x6a\xf3\x30\x14\x84\xf7\x7a\x8a\x59\xda\xfc\xc9\x13\x78\xa5\x3f\x56\xa9\xa9\x6f\x28\x47\xb4\xe9\xc6\x18\x5b\x14\xd3\x54\x0a\x92\x42\xc8\xdb\x97\xd8\x2d\xbd\xb9\x94\x26\xd5\x4e\x88\xd1\x9c\x99\xef\x2c\x97\xf8\xf7\x34\x3c\xb8\x36\x68\xa8\x1d\x5b\x49\xc1\x49\x80\xf8\xff\x5c\xc0\x1a\xed\xac\x0f\xda\x35\xdd\xb6\xf5\x5e\x7b\x44\x0c\xf0\x76\xef\x3a\xdd\x37\x43\x0f\x12\x77\x84\x9f\x4f\x59\x11\x4a\x95\xe7\x0b\x06\x8c\x3f\x9d\xb4\x80\x52\x59\xfa\x5b\xb5\xd3\x6d\xd0\x7d\xd3\x06\x50\x56\x88\x35\xf1\xa2\xc6\x6d\x46\xd7\xe3\x15\xf7\x55\x29\x90\x8a\x2b\xae\x72\x82\xb1\x87\x28\xfe\xa0\x5e\x55\xe5\x9a\x24\xcf\x4a\xfa\x1a\xad\xd9\x3d\xea\x23\x6a\x99\x15\x5c\x6e\x70\x23\x36\x88\xde\x82\xc6\x2c\x4e\xd8\x77\xdd\xec\xcd\x10\xa6\x66\x2e\xcb\x76\x59\xaf\xa7\x29\x26\xeb\x73\xbc\xc3\x10\xb6\x1a\x00\x70\x8e\xb7\x3f\x9a\x6e\x82\xf2\x67\x54\xc6\x52\x67\x98\xbc\x56\xbc\xc0\x67\x3a\xef\x17\x39\xb5\x07\xc3\x52\x59\xd5\xf3\xb0\x92\xf9\xb7\x97\x4d\x48\xd8\xf3\x00\xbf\x64\xb8\x1e\x14\x03\x00\x00")
+
+func postgres6_onerosterSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_postgres6_onerosterSql,
+		"postgres/6_oneroster.sql",
+	)
+}
+
+func postgres6_onerosterSql() (*asset, error) {
+	bytes, err := postgres6_onerosterSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "postgres/6_oneroster.sql", size: 788, mode: os.FileMode(420), modTime: time.Unix(1792374580, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _postgres7_unit_searchSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x7c\x91\xc1\x6e\xea\x30\x10\x45\xf7\xfe\x8a\xbb\x83\xa7\x07\xfd\x81\xa8\x8b\x60\x0f\x69\x24\x1a\xa3\x89\xdd\xb2\x8b\x22\xb0\x8c\x25\x4a\xa2\xc4\xb4\xfd\xfc\x8a\x26\xd0\x22\x55\xec\xef\x3d\x73\x66\x66\x3e\xc7\xff\xb7\xe0\xbb\x3a\x3a\xd8\x56\xa4\x2b\x43\x0c\x93\x2e\x56\x84\xd3\x31\xc4\x5e\x00\xa9\x52\x90\x7a\x65\x9f\x0b\xf4\xae\xee\xb6\x7b\x98\xf2\x85\xa4\xd1\x9c\x08\xbb\x56\xa9\x19\xa3\x28\xc9\x5c\x12\x8f\x88\x4d\x15\xfb\x77\xb7\x8d\x4d\x37\x9d\xb4\xbe\xda\xd6\xb1\x3e\x34\xfe\xc1\x1d\xfd\x21\xf4\xfb\xc9\x0c\x31\xc4\x83\xfb\x97\x08\xc9\x74\x66\xe4\x85\xa2\xcd\x40\xaa\x06\x4a\x15\x76\x9f\x02\xd0\xc5\xc8\xb7\x65\x5e\x64\xc8\xf2\x02\xd3\x21\xf0\x53\x36\x9c\x67\x19\xf1\x6d\xfd\xd4\xee\xea\xe8\x04\xb0\xa0\xa5\xe6\xf3\x84\x92\xd8\x40\x33\x46\x6d\xbd\x1c\x24\xae\x23\x04\xb0\xd4\x0c\x4a\xe5\x13\x58\xbf\x82\x36\x24\xad\x21\xac\x59\x4b\x52\x96\x09\x97\x9d\x46\x78\x15\xbb\xe0\xbd\xeb\x46\xa1\x19\xee\xaf\x2a\x7e\x9f\x5b\x35\x1f\x47\xa1\x58\xaf\xef\xd9\x5f\xd5\x92\x3f\x7f\xf3\x5d\xbf\x79\x4e\x22\xbe\x06\x00\x9d\x0d\xf0\x4a\xd3\x01\x00\x00")
+
+func postgres7_unit_searchSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_postgres7_unit_searchSql,
+		"postgres/7_unit_search.sql",
+	)
+}
+
+func postgres7_unit_searchSql() (*asset, error) {
+	bytes, err := postgres7_unit_searchSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "postgres/7_unit_search.sql", size: 467, mode: os.FileMode(420), modTime: time.Unix(1792377666, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _postgres8_current_unitsSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xa4\xd0\x41\x4b\xc3\x30\x18\xc6\xf1\xfb\xfb\x29\x9e\x63\x8b\xee\x13\xec\x14\xd7\x88\xc1\x34\x29\xd9\x1b\x64\x5e\x42\x5c\x83\x14\x35\x2b\x6d\xc6\xd8\xb7\x17\x15\xc1\x82\x20\xb8\xf7\xf6\x1e\xfe\xcf\xe1\xb7\x5a\xe1\xea\x6d\x78\x9e\x62\x49\xf0\x23\x6d\x9c\x14\x2c\xc1\xe2\x46\x4b\xec\x8f\xd3\x94\x72\x09\xc7\x3c\x94\x19\x15\x01\xfb\xd7\x38\xcf\x61\xe8\x01\x78\xaf\x1a\xfc\x7d\xc6\x32\x8c\xd7\xfa\x9a\x80\x8f\x9d\xaf\xf8\x5f\xf5\xd8\xc7\x92\xfa\xf0\x74\xbe\xa4\x8e\x05\xac\x5a\xb9\x65\xd1\x76\x78\x50\x7c\xf7\xf9\xe2\xd1\x1a\x89\x46\xde\x0a\xaf\x19\xf9\x70\xaa\xea\x45\xbd\xb1\x66\xcb\x4e\x28\xc3\x4b\x94\x30\xbe\xa4\x33\x3a\xa7\x5a\xe1\x76\xb8\x97\x3b\x54\xdf\x44\x35\xd5\x6b\xa2\x9f\xbc\xcd\xe1\x94\xa9\x71\xb6\xfb\x8d\x77\x4d\xef\x03\x00\x87\x70\x7d\xb2\x8a\x01\x00\x00")
+
+func postgres8_current_unitsSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_postgres8_current_unitsSql,
+		"postgres/8_current_units.sql",
+	)
+}
+
+func postgres8_current_unitsSql() (*asset, error) {
+	bytes, err := postgres8_current_unitsSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "postgres/8_current_units.sql", size: 394, mode: os.FileMode(420), modTime: time.Unix(1792378421, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _postgres9_unit_progressSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xa4\x92\xd1\x4a\xc3\x30\x18\x85\xef\xf3\x14\xe7\x72\xc5\xed\x09\x7a\x15\xd7\x88\xc1\x36\x2d\xdd\x5f\xdc\xbc\x29\xc5\x86\x51\xdc\xda\x90\xa4\x4c\xdf\x5e\xac\x0d\xb8\x21\x0e\xf5\xbf\x4b\x72\xce\xe1\x27\xdf\x59\xad\x70\x73\xec\xf6\xb6\xf1\x1a\x95\x61\xeb\x52\x70\x12\x20\x7e\x9b\x0a\x8c\x7d\xe7\x6b\x63\x87\xbd\xd5\xce\x61\xc1\xf0\x79\xd3\xb5\x98\xa6\xaa\x64\x82\xeb\xa3\x72\x82\xaa\xd2\x74\xf9\xe1\x77\xda\xfe\xc7\xff\x7c\x68\x9c\x0b\x01\x7f\xf0\x3b\xdf\xf8\xd1\xcd\x0f\x24\xb6\xf4\x7b\xbf\xf5\xba\xad\x1b\x0f\x80\x64\x26\x36\xc4\xb3\x02\x8f\x92\xee\xa7\x23\x9e\x72\x25\xa6\x45\x87\xa3\x39\xe8\x59\xfa\x93\x70\x34\x6d\x73\x35\x11\x89\xb8\xe3\x55\x4a\xe8\x87\xd3\x22\x3a\xdb\x68\x9d\xab\x0d\x95\x5c\x2a\x3a\xc7\x55\x9b\x17\xfd\x86\xa2\x94\x19\x2f\x77\x78\x10\x3b\x2c\x66\x78\xcb\x40\x21\x62\x51\x1c\x80\x4b\x95\x88\xed\x45\x42\xf8\xec\xba\x6b\x5f\x19\x90\xab\xcb\x42\x04\x41\x14\x33\xf6\xb5\x47\xc9\x70\xea\x59\x52\xe6\xc5\x77\x3d\x8a\xd9\xfb\x00\xae\x0f\x88\xcb\x73\x02\x00\x00")
+
+func postgres9_unit_progressSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_postgres9_unit_progressSql,
+		"postgres/9_unit_progress.sql",
+	)
+}
+
+func postgres9_unit_progressSql() (*asset, error) {
+	bytes, err := postgres9_unit_progressSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "postgres/9_unit_progress.sql", size: 627, mode: os.FileMode(420), modTime: time.Unix(1792378702, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _sqlite10_unit_scheduleSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x8c\x8e\xc1\xca\x82\x40\x14\x46\xf7\xf3\x14\xdf\xce\xc5\x8f\x4f\xe0\x6a\xfe\xe6\x06\xc1\x55\x43\xee\x40\xbb\x30\x94\x12\xca\xc4\x7b\x87\x5e\xbf\x5d\x84\x04\xba\x3f\xe7\x70\xf2\x1c\x7f\x8f\xe1\x3a\xb7\xd6\x23\x4e\xce\xb3\x50\x03\xf1\xff\x4c\x48\xe3\x60\xea\x00\x1f\x02\x76\x35\xc7\xb2\x82\x5a\x6b\x49\x21\x74\x12\x04\xda\xfb\xc8\x82\x6c\x4a\x97\xfb\xa0\xb7\xbe\xcb\x50\xd5\x82\x2a\x32\x17\x1b\x42\xb3\xe9\xb9\x35\x04\x2f\x24\x87\x92\x56\x95\x7e\xec\x16\x82\xfb\x9e\x0f\xcf\xd7\xf8\x33\x11\x9a\xfa\xb8\x68\x14\xab\xe0\xe7\x6f\x13\x6a\x49\x0b\xf7\x1e\x00\x11\x9b\x53\x24\x4a\x01\x00\x00")
+
+func sqlite10_unit_scheduleSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_sqlite10_unit_scheduleSql,
+		"sqlite/10_unit_schedule.sql",
+	)
+}
+
+func sqlite10_unit_scheduleSql() (*asset, error) {
+	bytes, err := sqlite10_unit_scheduleSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "sqlite/10_unit_schedule.sql", size: 330, mode: os.FileMode(420), modTime: time.Unix(1792384555, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _sqlite1_initSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x9c\x91\x41\x4b\xc4\x30\x10\x85\xef\xf9\x15\xef\xb8\x45\x17\xbc\xf7\x14\x9b\x51\x82\x6d\x5a\xb2\x53\xd8\x3d\x85\x60\x82\x04\xeb\x6e\x69\x23\xba\xff\x5e\x44\x2b\xf5\xb4\xb0\xef\x98\xcc\x63\x86\xef\xdb\x6e\x71\xf3\x96\x5e\x26\x9f\x23\xfa\x51\x54\x96\x24\x13\x58\xde\xd7\x84\xf7\x63\xca\x33\x36\x02\x48\x01\xab\x30\xed\x19\x17\x62\x5a\x86\xe9\xeb\xfa\x56\x00\xcf\x83\x9f\x67\x97\xc2\x75\xed\x9c\xf2\x10\xaf\xde\x1d\xd2\x3c\x0e\xfe\xec\x4e\x53\x88\x13\xb4\x61\x7a\x24\x0b\x28\x7a\x90\x7d\xcd\xb8\xbb\x70\xf9\x14\x7d\x8e\xc1\xf9\x0c\x00\x4a\x32\xb1\x6e\xe8\xaf\x5d\xf5\xd6\x92\x61\xf7\xfd\xb8\x63\xd9\x74\xff\xdb\x55\x6b\x76\x6c\xa5\x36\xfc\x83\xd2\x8d\xaf\xf1\x8c\xce\xea\x46\xda\x03\x9e\xe8\x80\x4d\x0a\x85\x28\xca\x05\xbb\x36\x8a\xf6\xbf\xb3\x0b\x34\x97\xc2\xa7\x00\x5a\xb3\xe8\x58\x3e\x8a\x52\x88\xb5\x3d\x75\xfa\x38\x0a\x65\xdb\x6e\x6d\xaf\x14\x5f\x03\x00\x66\x91\xff\xca\xe1\x01\x00\x00")
+
+func sqlite1_initSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_sqlite1_initSql,
+		"sqlite/1_init.sql",
+	)
+}
+
+func sqlite1_initSql() (*asset, error) {
+	bytes, err := sqlite1_initSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "sqlite/1_init.sql", size: 481, mode: os.FileMode(420), modTime: time.Unix(1792370293, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _sqlite2_unit_auditSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x9c\x93\xc1\x4e\xc3\x30\x0c\x86\xef\x79\x0a\x1f\x37\xb1\x3d\xc1\x4e\x61\x0d\x52\x45\x9b\x56\x99\x2b\x6d\xa7\x28\x34\x1e\x8a\x18\xed\x48\x53\x01\x6f\x8f\x0a\x2d\x74\x80\x40\xad\x8f\xb6\xbe\x3f\xfe\x95\xdf\xeb\x35\x5c\x3d\xba\x7b\x6f\x02\x41\x71\x66\x5b\x25\x38\x0a\x40\x7e\x9d\x08\x68\x2b\x17\xb4\x69\xad\x0b\xb0\x60\x00\xce\xc2\x57\xa1\xd8\x23\xfc\x53\x32\x43\x90\x45\x92\xac\x18\x7c\x68\x39\x3b\x0f\x2e\x4f\xa6\x69\x06\x7a\x2a\x6c\xca\xe0\xea\x6a\xe6\xda\xa6\x0c\xb5\x9f\xeb\xb9\x3c\x39\xaa\x06\xd7\x53\x61\x4f\x4f\x2d\x35\x3d\x3d\x15\xbe\xa3\x63\xed\x49\x37\xa1\xfb\xd6\x0e\x7e\xf7\x72\x0c\xe4\xfb\xde\x67\xb3\xf4\x64\x02\x59\x6d\x02\x00\x44\x1c\x05\xc6\xa9\x80\x48\xdc\xf0\x22\x41\xd8\x16\x4a\x09\x89\xba\x6b\xee\x90\xa7\xf9\xe5\x33\xdb\x4c\xee\x50\xf1\x58\xe2\x28\x2b\xfa\xfc\x40\xaf\x90\xab\x38\xe5\xea\x00\xb7\xe2\x00\x0b\x67\x97\x6c\xb9\x19\xc2\x15\xcb\x48\xec\xc7\x40\x9f\x0d\xed\xec\x0b\x03\xc8\xe4\x45\xf0\xfa\xe1\x6a\xb4\xe9\x1f\x52\x43\x52\x7e\xd7\x1a\xa6\xdf\xc4\xd8\xf8\x08\xa2\xfa\xb9\x62\x91\xca\xf2\x1f\x47\xb0\x61\x6f\x03\x00\x25\x07\x82\x40\x2d\x03\x00\x00")
+
+func sqlite2_unit_auditSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_sqlite2_unit_auditSql,
+		"sqlite/2_unit_audit.sql",
+	)
+}
+
+func sqlite2_unit_auditSql() (*asset, error) {
+	bytes, err := sqlite2_unit_auditSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "sqlite/2_unit_audit.sql", size: 813, mode: os.FileMode(420), modTime: time.Unix(1792371791, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _sqlite3_unit_versionsSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x9c\x91\xc1\x4a\xc3\x40\x10\x86\xef\xf3\x14\xff\xb1\xc5\xf6\x09\x7a\x5a\x93\x51\x82\xc9\x26\x4c\x27\x60\x4f\xcb\x62\x17\x5d\xc4\xa4\x6c\xb6\x8a\x6f\x2f\xc5\x0a\x16\x04\x4b\xe6\xf8\xc3\x37\x33\x7c\xff\x7a\x8d\x9b\xb7\xf8\x9c\x7c\x0e\xe8\x0f\x54\x08\x1b\x65\xa8\xb9\xad\x19\xc7\x21\x66\xf7\x1e\xd2\x14\xc7\x61\xc2\x82\xf0\x9d\xc4\x3d\x00\x28\x3f\x2a\xfe\x19\xdb\x2a\x6c\x5f\xd7\x2b\x02\xce\x7b\x00\xa0\xb2\xca\xf7\x2c\x57\xa3\x53\x3e\x7d\x87\x39\x57\xfd\x31\xbf\x8c\x69\x16\xfa\x94\x82\xcf\x61\xef\x7c\x46\x69\x94\xb5\x6a\x18\x25\xdf\x99\xbe\x56\x14\xbd\x08\x5b\x75\xa7\x70\xab\xa6\xe9\x2e\xd1\xa2\xb5\x5b\x15\x53\x59\xbd\x74\xe8\x0e\xaf\xe1\x13\x9d\x54\x8d\x91\x1d\x1e\x78\x87\xc5\xd9\xe8\xea\xc7\xcf\x92\x96\x1b\xa2\xdf\xad\x94\xe3\xc7\x40\xa5\xb4\xdd\x5f\xad\x6c\xe8\x6b\x00\xf1\x15\x0e\x61\xc1\x01\x00\x00")
+
+func sqlite3_unit_versionsSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_sqlite3_unit_versionsSql,
+		"sqlite/3_unit_versions.sql",
+	)
+}
+
+func sqlite3_unit_versionsSql() (*asset, error) {
+	bytes, err := sqlite3_unit_versionsSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "sqlite/3_unit_versions.sql", size: 449, mode: os.FileMode(420), modTime: time.Unix(1792372138, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _sqlite4_webhooksSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xa4\x95\xcd\xae\xda\x30\x10\x85\xf7\x7e\x8a\x59\x82\x0a\x4f\x90\x55\x4a\xdc\x2a\x6a\x70\x90\x71\x24\x58\x59\x06\x8f\x5a\x8b\x40\x22\xdb\xe1\xe7\xed\x2b\xa0\x69\x42\xa1\xf7\x82\xf1\x32\xf6\x7c\xf6\xcc\x39\x47\x19\x8f\xe1\xcb\xd6\xfc\xb4\xca\x23\x14\x35\x99\x70\x1a\x0b\x0a\x22\xfe\x9a\x51\x38\xe0\xea\x57\x55\x6d\x1c\x0c\x08\x80\xd1\xd0\x2e\x41\x17\x02\x3e\x59\x2c\x17\xc0\x8a\x2c\x1b\x11\x80\x75\xa9\x9c\x93\x46\x07\x94\x36\xb6\x0c\xbd\xd5\xe1\xda\xa2\x0f\x2a\xc5\x3d\xee\xbc\x0b\xeb\xd5\xa2\xf2\xa8\xe5\xea\x14\x5c\xaa\x3c\x24\xb1\xa0\x22\x9d\x52\x48\xe8\xb7\xb8\xc8\x04\x4c\x0a\xce\x29\x13\xf2\xfc\x71\x2e\xe2\xe9\xec\xb6\x74\x92\xb3\xb9\xe0\x71\xca\xc4\x5f\xd1\x64\xbd\xc1\x13\xcc\x78\x3a\x8d\xf9\x12\x7e\xd0\x25\x0c\x8c\x1e\x92\x61\xd4\x6a\x9c\xb2\x84\x2e\xba\xe3\xad\x48\xd2\xe8\x23\x01\xc8\x59\x4f\xfe\x76\x6f\x18\x91\x87\x0e\x91\x1a\x4b\xb3\x47\x6b\xf0\xce\x2b\x61\xda\xb5\x5c\xa3\x03\x01\x17\x05\xdf\x79\x41\xad\x4e\x65\xa5\x74\x38\xc0\x79\xe5\x1b\xf7\xc6\x0b\x94\xf7\xb8\xad\x7d\x87\x48\x99\xa0\xdf\x29\x7f\x1a\xb0\xc3\xa3\x97\x7f\x28\x67\x53\x75\xae\x7a\x12\x50\x2a\xe7\x25\x5a\x5b\xd9\xc0\x16\x2e\x80\xeb\x20\xe4\xba\xd2\xf8\x72\x0b\xbd\x48\x00\x00\xc0\xcb\xc1\x68\x6a\xfd\x1e\xe0\x3e\x59\x3d\xb3\xbf\x96\xb1\x7e\xa1\x6e\xf0\x2e\x68\x37\x29\xba\x4e\x6d\xf4\xaf\x88\x4f\xa0\xbb\xec\x7c\x7c\x43\x77\x6e\xd4\x9b\xf3\x39\xe2\xfd\x7f\x42\x52\x1d\x76\x24\xe1\xf9\xec\xbf\x89\x8f\x1e\x6c\xbb\x88\xfc\x1e\x00\x86\xaa\xc6\x80\x59\x06\x00\x00")
+
+func sqlite4_webhooksSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_sqlite4_webhooksSql,
+		"sqlite/4_webhooks.sql",
+	)
+}
+
+func sqlite4_webhooksSql() (*asset, error) {
+	bytes, err := sqlite4_webhooksSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "sqlite/4_webhooks.sql", size: 1625, mode: os.FileMode(420), modTime: time.Unix(1792372617, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _sqlite5_onerosterSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xac\x92\xdf\x6a\x83\x30\x14\xc6\xef\xf3\x14\xdf\x65\xcb\xda\x27\xf0\x2a\xd3\x0c\x64\x1a\x25\x3d\xc2\x7a\x15\x44\x0f\x43\xd6\x69\x49\x52\x46\xdf\x7e\x58\x37\xf6\xcf\x31\xba\x36\x97\x09\x5f\x0e\xdf\xef\x77\xd6\x6b\xdc\x3c\x77\x8f\xae\x0e\x8c\x6a\x2f\x62\xa3\x24\x29\x90\xbc\xcd\x14\x86\x9e\xdd\xe0\x03\x3b\xdb\xec\x6a\xef\xd9\x63\x21\x00\x3f\x1c\x5c\xc3\xad\xed\x5a\x90\x7a\x20\xfc\x71\x74\x41\xd0\x55\x96\xad\x04\x70\xfa\x66\x0c\xe2\xfc\xa8\xe3\x3a\x70\x6b\xeb\x80\x44\x92\xa2\x34\x57\x48\xd4\x9d\xac\x32\x42\x5c\x19\xa3\x34\xd9\xf1\x72\x43\x32\x2f\xbf\x46\xe3\x42\x6f\xc8\xc8\x54\xd3\xcf\x46\x76\xff\xc4\x47\x94\x26\xcd\xa5\xd9\xe2\x5e\x6d\xb1\xf8\xe8\xb7\x14\xcb\x48\xfc\x86\xe4\xd0\x77\x61\x02\x72\x41\xab\x0b\x58\x8e\xf3\xa7\xa1\x67\x47\x43\x17\x76\x0c\xfc\x27\xea\x8f\x7d\x33\x59\xb8\x8e\x86\x13\xc5\x19\x09\xef\x4c\x57\xf8\xae\xe3\xf3\xc2\x26\xc3\x4b\x2f\x12\x53\x94\xf3\x76\xa2\xf9\xb7\x37\xf5\x91\x78\x1d\x00\x69\xab\xdf\xcd\xfc\x02\x00\x00")
+
+func sqlite5_onerosterSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_sqlite5_onerosterSql,
+		"sqlite/5_oneroster.sql",
+	)
+}
+
+func sqlite5_onerosterSql() (*asset, error) {
+	bytes, err := sqlite5_onerosterSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "sqlite/5_oneroster.sql", size: 764, mode: os.FileMode(420), modTime: time.Unix(1792374580, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _sqlite6_current_unitsSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x9c\xd0\xc1\x4a\xc4\x30\x10\xc6\xf1\xfb\x3c\xc5\x77\xdc\x45\xf7\x09\xf6\x14\x9b\x11\x8a\x69\x5a\xb2\x13\x70\x4f\x21\x6e\x83\x14\xb5\x96\x36\x45\xfa\xf6\x52\x44\xb0\x20\x88\x3b\xc7\x81\xff\x77\xf8\x1d\x0e\xb8\x79\xeb\x9e\xc7\x98\x13\xfc\x40\x85\x63\x25\x0c\x51\x77\x86\x71\x99\xc7\x31\xf5\x39\xcc\x7d\x97\x27\xec\x08\xb8\xbc\xc6\x69\x0a\x5d\x0b\x40\xf8\x51\xf0\xc7\xd9\x5a\x60\xbd\x31\xb7\x04\xac\x23\x5f\xe5\xff\xd3\xa1\x8d\x39\xb5\xe1\x69\xb9\x3a\x8d\x19\x5a\x09\x4b\x59\x31\x34\xdf\x2b\x6f\x04\x85\x77\x8e\xad\x84\xf5\x79\x12\x55\x35\xdb\xb4\xa8\xed\x49\x9c\x2a\xad\x6c\x21\xc2\xf0\x92\x16\x34\xae\xac\x94\x3b\xe3\x81\xcf\xd8\x7d\xb3\xec\x69\x7f\x24\xfa\x49\xaa\xdf\x3f\x7a\xd2\xae\x6e\x7e\x23\x3d\xd2\xe7\x00\xba\x20\x09\x6d\x7e\x01\x00\x00")
+
+func sqlite6_current_unitsSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_sqlite6_current_unitsSql,
+		"sqlite/6_current_units.sql",
+	)
+}
+
+func sqlite6_current_unitsSql() (*asset, error) {
+	bytes, err := sqlite6_current_unitsSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "sqlite/6_current_units.sql", size: 382, mode: os.FileMode(420), modTime: time.Unix(1792378421, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _sqlite7_unit_progressSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x9c\x92\xc1\x6a\xf3\x30\x10\x84\xef\x7a\x8a\x39\xc6\xfc\xc9\x13\xf8\xa4\xdf\xde\x82\xa9\x2d\x1b\x65\x0d\xc9\xc9\x98\x5a\x04\xd3\x24\x16\x92\x4c\xdb\xb7\x2f\x49\x23\x48\xd2\x42\x69\xf6\xb8\xa3\x4f\x12\x33\xb3\x5a\xe1\xdf\x61\xdc\xb9\x3e\x18\xb4\x56\x64\x9a\x24\x13\x58\xfe\x2f\x09\xf3\x71\x0c\x9d\x75\xd3\xce\x19\xef\xb1\x10\xf8\xda\x8c\x03\xce\xc3\xb4\x61\xfc\x32\xaa\x66\xa8\xb6\x2c\x97\x27\xd8\x1b\xf7\x30\xfc\xb2\xef\xbd\x8f\xf4\x5f\x61\x1f\xfa\x30\x7b\xe0\x51\xd8\x05\x33\x74\x7d\x00\x90\x4b\x26\x2e\x2a\x3a\x7f\x69\x3a\xd8\xbd\xb9\x48\xd7\xc2\x6c\x87\xfe\x1b\x81\x9c\x9e\x64\x5b\x32\xb2\x56\x6b\x52\xdc\x9d\x96\x6b\x96\x55\x73\xfb\x5c\x56\xab\x35\x6b\x59\x28\xbe\xf5\xbf\xb3\xaf\xe6\x03\x8d\x2e\x2a\xa9\xb7\x78\xa6\x2d\x16\x97\x34\x96\xd1\xd9\x44\x24\x69\x4c\xb0\x50\x39\x6d\xee\x6e\x88\x1e\x76\xe3\xf0\x2e\x80\x5a\xdd\x27\x1c\x0f\x24\xa9\x10\xd7\xc5\xc8\xa7\xb7\xa3\xc8\x75\xdd\xfc\x54\x8c\x54\x7c\x0e\x00\xa8\x55\xf4\xfa\x44\x02\x00\x00")
+
+func sqlite7_unit_progressSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_sqlite7_unit_progressSql,
+		"sqlite/7_unit_progress.sql",
+	)
+}
+
+func sqlite7_unit_progressSql() (*asset, error) {
+	bytes, err := sqlite7_unit_progressSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "sqlite/7_unit_progress.sql", size: 580, mode: os.FileMode(420), modTime: time.Unix(1792378702, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _sqlite8_unit_detailsSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x84\xcf\xb1\x0a\xc3\x20\x14\x85\xe1\xdd\xa7\x38\x9b\x43\xc9\x13\x38\xd9\x6a\xa7\x5b\x2d\xe1\x0a\x5d\xa5\x91\xe2\x10\x13\x12\x4b\x87\xd2\x77\xef\x2a\x21\x90\xfd\xf0\x71\xfe\xae\xc3\x69\xcc\xaf\x25\xd6\x84\x30\x0b\x4d\x6c\x7b\xb0\x3e\x93\xc5\xbb\xe4\xba\x0a\x40\x1b\x83\x8b\xa7\x70\x73\x18\xd2\xfa\x5c\xf2\x5c\xf3\x54\xc0\xf6\xc1\x30\xf6\xaa\x03\x31\xa4\x04\xe0\x3c\xc3\x05\x22\x75\xc4\x8c\xa9\xc6\x21\xd6\x08\x60\xc3\x7c\x7f\xb2\x61\x44\xfb\xce\x4c\x9f\xb2\x0b\x9b\xde\xdf\xb7\xb2\x3a\x5c\x36\x29\x4a\xfc\x07\x00\x9b\x70\xe1\x97\x06\x01\x00\x00")
+
+func sqlite8_unit_detailsSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_sqlite8_unit_detailsSql,
+		"sqlite/8_unit_details.sql",
+	)
+}
+
+func sqlite8_unit_detailsSql() (*asset, error) {
+	bytes, err := sqlite8_unit_detailsSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "sqlite/8_unit_details.sql", size: 262, mode: os.FileMode(420), modTime: time.Unix(1792383384, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _sqlite9_unit_hierarchySql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xd2\xd5\x55\xd0\xce\xcd\x4c\x2f\x4a\x2c\x49\x55\x08\x2d\xe0\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\xcd\xcb\x2c\x29\xe6\x52\x50\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\x28\x48\x2c\x4a\xcd\x2b\x89\xcf\x4c\x51\x08\x71\x8d\x08\xb1\x26\xa4\xbc\x28\xb5\x38\xbf\xb4\x28\x39\xb5\x18\xac\x5c\xc1\xc5\xd5\xcd\x31\xd4\x27\x44\x41\x3d\x3a\x56\x5d\xc1\xcf\x3f\x44\xc1\x2f\xd4\xc7\xc7\x9a\x8b\x0b\xd9\x0d\x2e\xf9\xe5\x79\x58\x8d\x75\x09\xf2\x0f\xc0\x30\xd7\x9a\xa0\x52\xb8\x8b\xad\xb9\x00\x03\x00\xeb\x5c\xdd\xee\xeb\x00\x00\x00")
+
+func sqlite9_unit_hierarchySqlBytes() ([]byte, error) {
+	return bindataRead(
+		_sqlite9_unit_hierarchySql,
+		"sqlite/9_unit_hierarchy.sql",
+	)
+}
+
+func sqlite9_unit_hierarchySql() (*asset, error) {
+	bytes, err := sqlite9_unit_hierarchySqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "sqlite/9_unit_hierarchy.sql", size: 235, mode: os.FileMode(420), modTime: time.Unix(1792383655, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
@@ -182,9 +813,39 @@ func AssetNames() []string {
 
 // _bindata is a table, holding each asset generator, mapped to its name.
 var _bindata = map[string]func() (*asset, error){
-	"ddl.go": ddlGo,
-	"ddl_gen.go": ddl_genGo,
+	"mysql/10_unit_schedule.sql": mysql10_unit_scheduleSql,
+	"mysql/1_init.sql": mysql1_initSql,
+	"mysql/2_unit_audit.sql": mysql2_unit_auditSql,
+	"mysql/3_unit_versions.sql": mysql3_unit_versionsSql,
+	"mysql/4_webhooks.sql": mysql4_webhooksSql,
+	"mysql/5_oneroster.sql": mysql5_onerosterSql,
+	"mysql/6_current_units.sql": mysql6_current_unitsSql,
+	"mysql/7_unit_progress.sql": mysql7_unit_progressSql,
+	"mysql/8_unit_details.sql": mysql8_unit_detailsSql,
+	"mysql/9_unit_hierarchy.sql": mysql9_unit_hierarchySql,
+	"postgres/10_unit_details.sql": postgres10_unit_detailsSql,
+	"postgres/11_unit_hierarchy.sql": postgres11_unit_hierarchySql,
+	"postgres/12_unit_search_description.sql": postgres12_unit_search_descriptionSql,
+	"postgres/13_unit_schedule.sql": postgres13_unit_scheduleSql,
 	"postgres/1_init.sql": postgres1_initSql,
+	"postgres/2_created_at.sql": postgres2_created_atSql,
+	"postgres/3_unit_audit.sql": postgres3_unit_auditSql,
+	"postgres/4_unit_versions.sql": postgres4_unit_versionsSql,
+	"postgres/5_webhooks.sql": postgres5_webhooksSql,
+	"postgres/6_oneroster.sql": postgres6_onerosterSql,
+	"postgres/7_unit_search.sql": postgres7_unit_searchSql,
+	"postgres/8_current_units.sql": postgres8_current_unitsSql,
+	"postgres/9_unit_progress.sql": postgres9_unit_progressSql,
+	"sqlite/10_unit_schedule.sql": sqlite10_unit_scheduleSql,
+	"sqlite/1_init.sql": sqlite1_initSql,
+	"sqlite/2_unit_audit.sql": sqlite2_unit_auditSql,
+	"sqlite/3_unit_versions.sql": sqlite3_unit_versionsSql,
+	"sqlite/4_webhooks.sql": sqlite4_webhooksSql,
+	"sqlite/5_oneroster.sql": sqlite5_onerosterSql,
+	"sqlite/6_current_units.sql": sqlite6_current_unitsSql,
+	"sqlite/7_unit_progress.sql": sqlite7_unit_progressSql,
+	"sqlite/8_unit_details.sql": sqlite8_unit_detailsSql,
+	"sqlite/9_unit_hierarchy.sql": sqlite9_unit_hierarchySql,
 }
 
 // AssetDir returns the file names below a certain
@@ -227,10 +888,44 @@ type bintree struct {
 	Children map[string]*bintree
 }
 var _bintree = &bintree{nil, map[string]*bintree{
-	"ddl.go": &bintree{ddlGo, map[string]*bintree{}},
-	"ddl_gen.go": &bintree{ddl_genGo, map[string]*bintree{}},
+	"mysql": &bintree{nil, map[string]*bintree{
+		"10_unit_schedule.sql": &bintree{mysql10_unit_scheduleSql, map[string]*bintree{}},
+		"1_init.sql": &bintree{mysql1_initSql, map[string]*bintree{}},
+		"2_unit_audit.sql": &bintree{mysql2_unit_auditSql, map[string]*bintree{}},
+		"3_unit_versions.sql": &bintree{mysql3_unit_versionsSql, map[string]*bintree{}},
+		"4_webhooks.sql": &bintree{mysql4_webhooksSql, map[string]*bintree{}},
+		"5_oneroster.sql": &bintree{mysql5_onerosterSql, map[string]*bintree{}},
+		"6_current_units.sql": &bintree{mysql6_current_unitsSql, map[string]*bintree{}},
+		"7_unit_progress.sql": &bintree{mysql7_unit_progressSql, map[string]*bintree{}},
+		"8_unit_details.sql": &bintree{mysql8_unit_detailsSql, map[string]*bintree{}},
+		"9_unit_hierarchy.sql": &bintree{mysql9_unit_hierarchySql, map[string]*bintree{}},
+	}},
 	"postgres": &bintree{nil, map[string]*bintree{
+		"10_unit_details.sql": &bintree{postgres10_unit_detailsSql, map[string]*bintree{}},
+		"11_unit_hierarchy.sql": &bintree{postgres11_unit_hierarchySql, map[string]*bintree{}},
+		"12_unit_search_description.sql": &bintree{postgres12_unit_search_descriptionSql, map[string]*bintree{}},
+		"13_unit_schedule.sql": &bintree{postgres13_unit_scheduleSql, map[string]*bintree{}},
 		"1_init.sql": &bintree{postgres1_initSql, map[string]*bintree{}},
+		"2_created_at.sql": &bintree{postgres2_created_atSql, map[string]*bintree{}},
+		"3_unit_audit.sql": &bintree{postgres3_unit_auditSql, map[string]*bintree{}},
+		"4_unit_versions.sql": &bintree{postgres4_unit_versionsSql, map[string]*bintree{}},
+		"5_webhooks.sql": &bintree{postgres5_webhooksSql, map[string]*bintree{}},
+		"6_oneroster.sql": &bintree{postgres6_onerosterSql, map[string]*bintree{}},
+		"7_unit_search.sql": &bintree{postgres7_unit_searchSql, map[string]*bintree{}},
+		"8_current_units.sql": &bintree{postgres8_current_unitsSql, map[string]*bintree{}},
+		"9_unit_progress.sql": &bintree{postgres9_unit_progressSql, map[string]*bintree{}},
+	}},
+	"sqlite": &bintree{nil, map[string]*bintree{
+		"10_unit_schedule.sql": &bintree{sqlite10_unit_scheduleSql, map[string]*bintree{}},
+		"1_init.sql": &bintree{sqlite1_initSql, map[string]*bintree{}},
+		"2_unit_audit.sql": &bintree{sqlite2_unit_auditSql, map[string]*bintree{}},
+		"3_unit_versions.sql": &bintree{sqlite3_unit_versionsSql, map[string]*bintree{}},
+		"4_webhooks.sql": &bintree{sqlite4_webhooksSql, map[string]*bintree{}},
+		"5_oneroster.sql": &bintree{sqlite5_onerosterSql, map[string]*bintree{}},
+		"6_current_units.sql": &bintree{sqlite6_current_unitsSql, map[string]*bintree{}},
+		"7_unit_progress.sql": &bintree{sqlite7_unit_progressSql, map[string]*bintree{}},
+		"8_unit_details.sql": &bintree{sqlite8_unit_detailsSql, map[string]*bintree{}},
+		"9_unit_hierarchy.sql": &bintree{sqlite9_unit_hierarchySql, map[string]*bintree{}},
 	}},
 }}
 
@@ -0,0 +1,55 @@
+package ddl
+
+import (
+	"bytes"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestAssetSource(t *testing.T) {
+	for _, driver := range []string{"postgres", "mysql", "sqlite"} {
+		migrations, err := assetSource(driver).FindMigrations()
+		if err != nil {
+			t.Fatalf("%s: %v", driver, err)
+		}
+		if len(migrations) == 0 || migrations[0].Id != initMigration {
+			t.Fatalf("%s: got %d migrations, want %s first", driver, len(migrations), initMigration)
+		}
+		for _, m := range migrations {
+			if len(m.Up) == 0 {
+				t.Errorf("%s/%s has no Up statements", driver, m.Id)
+			}
+		}
+	}
+
+	// The released PostgreSQL schema has no annotations, and must keep none.
+	if bytes.Contains(MustAsset("postgres/"+initMigration), []byte("+migrate")) {
+		t.Errorf("postgres/%s was changed after its release", initMigration)
+	}
+	migrations, _ := assetSource("postgres").FindMigrations()
+	if init := migrations[0]; len(init.Up) != 3 || len(init.Down) != 0 {
+		t.Errorf("postgres/%s: got %d Up and %d Down statements, want 3 and 0", initMigration, len(init.Up), len(init.Down))
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "units.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if n, err := Pending("sqlite", db); err != nil || n == 0 {
+		t.Fatalf("Pending before migrating: got %d, %v; want every migration", n, err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := Migrate("sqlite", db); err != nil {
+			t.Fatalf("Migrate #%d: %v", i+1, err)
+		}
+	}
+	if n, err := Pending("sqlite", db); err != nil || n != 0 {
+		t.Errorf("Pending after migrating: got %d, %v; want 0", n, err)
+	}
+}
@@ -0,0 +1,11 @@
+-- +migrate Up
+ALTER TABLE units
+  ADD COLUMN status    VARCHAR(16) DEFAULT 'published' NOT NULL,
+  ADD COLUMN starts_at DATETIME(6),
+  ADD COLUMN ends_at   DATETIME(6);
+
+-- +migrate Down
+ALTER TABLE units
+  DROP COLUMN status,
+  DROP COLUMN starts_at,
+  DROP COLUMN ends_at;
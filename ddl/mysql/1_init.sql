@@ -0,0 +1,13 @@
+-- +migrate Up
+CREATE TABLE units (
+  id            BINARY(16)                               NOT NULL,
+  class_id      BINARY(16)                               NOT NULL,
+  title         TEXT                                     NOT NULL,
+  display_order INTEGER     DEFAULT 0                    NOT NULL,
+  created_at    DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6) NOT NULL,
+  CONSTRAINT units_pkey PRIMARY KEY (id),
+  INDEX units_class_id_idx (class_id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+-- +migrate Down
+DROP TABLE units;
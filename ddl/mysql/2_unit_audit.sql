@@ -0,0 +1,19 @@
+-- +migrate Up
+CREATE TABLE unit_audit (
+  id           BINARY(16)                               NOT NULL,
+  unit_id      BINARY(16)                               NOT NULL,
+  class_id     BINARY(16)                               NOT NULL,
+  action       VARCHAR(32)                              NOT NULL,
+  actor        BINARY(16)                               NOT NULL,
+  client_id    VARCHAR(255)                             NOT NULL,
+  request_id   VARCHAR(128)                             NOT NULL,
+  before_state TEXT,
+  after_state  TEXT,
+  created_at   DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6) NOT NULL,
+  CONSTRAINT unit_audit_pkey PRIMARY KEY (id),
+  INDEX unit_audit_unit_id_idx (unit_id, created_at),
+  INDEX unit_audit_class_id_idx (class_id, created_at)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+-- +migrate Down
+DROP TABLE unit_audit;
@@ -0,0 +1,12 @@
+-- +migrate Up
+CREATE TABLE unit_versions (
+  unit_id    BINARY(16)                               NOT NULL,
+  version    INTEGER                                  NOT NULL,
+  state      TEXT                                     NOT NULL,
+  author     BINARY(16)                               NOT NULL,
+  created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6) NOT NULL,
+  CONSTRAINT unit_versions_pkey PRIMARY KEY (unit_id, version)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+-- +migrate Down
+DROP TABLE unit_versions;
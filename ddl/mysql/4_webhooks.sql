@@ -0,0 +1,33 @@
+-- +migrate Up
+CREATE TABLE webhooks (
+  id         BINARY(16)                               NOT NULL,
+  class_id   BINARY(16)                               NOT NULL,
+  url        TEXT                                     NOT NULL,
+  secret     TEXT                                     NOT NULL,
+  events     TEXT                                     NOT NULL,
+  created_by BINARY(16)                               NOT NULL,
+  created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6) NOT NULL,
+  CONSTRAINT webhooks_pkey PRIMARY KEY (id),
+  INDEX webhooks_class_id_idx (class_id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE webhook_deliveries (
+  id               BINARY(16)                               NOT NULL,
+  webhook_id       BINARY(16)                               NOT NULL,
+  event            VARCHAR(64)                              NOT NULL,
+  payload          TEXT                                     NOT NULL,
+  status           VARCHAR(16)                              NOT NULL,
+  attempts         INTEGER                                  NOT NULL,
+  next_attempt_at  DATETIME(6)                              NOT NULL,
+  last_error       TEXT                                     NOT NULL,
+  last_status_code INTEGER                                  NOT NULL,
+  created_at       DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6) NOT NULL,
+  updated_at       DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6) NOT NULL,
+  CONSTRAINT webhook_deliveries_pkey PRIMARY KEY (id),
+  INDEX webhook_deliveries_due_idx (status, next_attempt_at),
+  INDEX webhook_deliveries_webhook_id_idx (webhook_id, created_at)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+-- +migrate Down
+DROP TABLE webhook_deliveries;
+DROP TABLE webhooks;
@@ -0,0 +1,20 @@
+-- +migrate Up
+CREATE TABLE oneroster_classes (
+  sourced_id VARCHAR(255)                             NOT NULL,
+  class_id   BINARY(16)                               NOT NULL,
+  created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6) NOT NULL,
+  CONSTRAINT oneroster_classes_pkey PRIMARY KEY (sourced_id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE oneroster_units (
+  class_id   BINARY(16)                               NOT NULL,
+  sourced_id VARCHAR(255)                             NOT NULL,
+  unit_id    BINARY(16)                               NOT NULL,
+  title      TEXT                                     NOT NULL,
+  synced_at  DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6) NOT NULL,
+  CONSTRAINT oneroster_units_pkey PRIMARY KEY (class_id, sourced_id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+-- +migrate Down
+DROP TABLE oneroster_units;
+DROP TABLE oneroster_classes;
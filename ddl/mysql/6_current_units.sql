@@ -0,0 +1,11 @@
+-- +migrate Up
+CREATE TABLE current_units (
+  class_id   BINARY(16)                               NOT NULL,
+  unit_id    BINARY(16)                               NOT NULL,
+  updated_by BINARY(16)                               NOT NULL,
+  updated_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6) NOT NULL,
+  CONSTRAINT current_units_pkey PRIMARY KEY (class_id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+-- +migrate Down
+DROP TABLE current_units;
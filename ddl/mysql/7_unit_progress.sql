@@ -0,0 +1,15 @@
+-- +migrate Up
+CREATE TABLE unit_progress (
+  unit_id      BINARY(16)                               NOT NULL,
+  user_id      BINARY(16)                               NOT NULL,
+  class_id     BINARY(16)                               NOT NULL,
+  status       VARCHAR(16)                              NOT NULL,
+  started_at   DATETIME(6),
+  completed_at DATETIME(6),
+  updated_at   DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6) NOT NULL,
+  CONSTRAINT unit_progress_pkey PRIMARY KEY (unit_id, user_id),
+  INDEX unit_progress_class_id_idx (class_id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+-- +migrate Down
+DROP TABLE unit_progress;
@@ -0,0 +1,10 @@
+-- +migrate Up
+-- TEXT columns cannot have defaults; existing units get empty strings, read as no metadata.
+ALTER TABLE units
+  ADD COLUMN description TEXT NOT NULL,
+  ADD COLUMN metadata    TEXT NOT NULL;
+
+-- +migrate Down
+ALTER TABLE units
+  DROP COLUMN description,
+  DROP COLUMN metadata;
@@ -0,0 +1,10 @@
+-- +migrate Up
+-- TEXT columns cannot have defaults; existing units get empty strings, read as no resources.
+ALTER TABLE units
+  ADD COLUMN parent_id BINARY(16),
+  ADD COLUMN resources TEXT NOT NULL;
+
+-- +migrate Down
+ALTER TABLE units
+  DROP COLUMN parent_id,
+  DROP COLUMN resources;
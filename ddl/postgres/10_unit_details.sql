@@ -0,0 +1,9 @@
+-- +migrate Up
+ALTER TABLE units
+  ADD COLUMN description TEXT  DEFAULT ''   NOT NULL,
+  ADD COLUMN metadata    JSONB DEFAULT '{}' NOT NULL;
+
+-- +migrate Down
+ALTER TABLE units
+  DROP COLUMN description,
+  DROP COLUMN metadata;
@@ -0,0 +1,9 @@
+-- +migrate Up
+ALTER TABLE units
+  ADD COLUMN parent_id UUID,
+  ADD COLUMN resources JSONB DEFAULT '[]' NOT NULL;
+
+-- +migrate Down
+ALTER TABLE units
+  DROP COLUMN parent_id,
+  DROP COLUMN resources;
@@ -0,0 +1,27 @@
+-- +migrate Up
+-- Descriptions are searched too, weighted below titles so that units whose titles match rank first.
+DROP TRIGGER units_search_update ON units;
+-- +migrate StatementBegin
+CREATE FUNCTION units_search_vector() RETURNS TRIGGER AS $$
+BEGIN
+  NEW.search :=
+    setweight(to_tsvector('pg_catalog.english', coalesce(NEW.title, '')), 'A') ||
+    setweight(to_tsvector('pg_catalog.english', coalesce(NEW.description, '')), 'B');
+  RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+-- +migrate StatementEnd
+CREATE TRIGGER units_search_update
+  BEFORE INSERT OR UPDATE OF title, description ON units
+  FOR EACH ROW EXECUTE PROCEDURE units_search_vector();
+UPDATE units SET search =
+  setweight(to_tsvector('pg_catalog.english', coalesce(title, '')), 'A') ||
+  setweight(to_tsvector('pg_catalog.english', coalesce(description, '')), 'B');
+
+-- +migrate Down
+DROP TRIGGER units_search_update ON units;
+DROP FUNCTION units_search_vector();
+CREATE TRIGGER units_search_update
+  BEFORE INSERT OR UPDATE OF title ON units
+  FOR EACH ROW EXECUTE PROCEDURE tsvector_update_trigger(search, 'pg_catalog.english', title);
+UPDATE units SET search = to_tsvector('pg_catalog.english', title);
@@ -0,0 +1,7 @@
+-- +migrate Up
+ALTER TABLE units
+  ADD COLUMN created_at TIMESTAMP WITH TIME ZONE DEFAULT now() NOT NULL;
+
+-- +migrate Down
+ALTER TABLE units
+  DROP COLUMN created_at;
@@ -0,0 +1,12 @@
+-- +migrate Up
+CREATE TABLE unit_versions (
+  unit_id    UUID                                   NOT NULL,
+  version    INTEGER                                NOT NULL,
+  state      JSONB                                  NOT NULL,
+  author     UUID                                   NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT now() NOT NULL,
+  CONSTRAINT unit_versions_pkey PRIMARY KEY (unit_id, version)
+);
+
+-- +migrate Down
+DROP TABLE unit_versions;
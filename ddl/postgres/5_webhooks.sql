@@ -0,0 +1,36 @@
+-- +migrate Up
+CREATE TABLE webhooks (
+  id         UUID                                   NOT NULL,
+  class_id   UUID                                   NOT NULL,
+  url        TEXT                                   NOT NULL,
+  secret     TEXT                                   NOT NULL,
+  events     TEXT                                   NOT NULL,
+  created_by UUID                                   NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT now() NOT NULL,
+  CONSTRAINT webhooks_pkey PRIMARY KEY (id)
+);
+CREATE INDEX webhooks_class_id_idx
+  ON webhooks (class_id);
+
+CREATE TABLE webhook_deliveries (
+  id               UUID                                   NOT NULL,
+  webhook_id       UUID                                   NOT NULL,
+  event            TEXT                                   NOT NULL,
+  payload          TEXT                                   NOT NULL,
+  status           TEXT                                   NOT NULL,
+  attempts         INTEGER                                NOT NULL,
+  next_attempt_at  TIMESTAMP WITH TIME ZONE               NOT NULL,
+  last_error       TEXT                                   NOT NULL,
+  last_status_code INTEGER                                NOT NULL,
+  created_at       TIMESTAMP WITH TIME ZONE DEFAULT now() NOT NULL,
+  updated_at       TIMESTAMP WITH TIME ZONE DEFAULT now() NOT NULL,
+  CONSTRAINT webhook_deliveries_pkey PRIMARY KEY (id)
+);
+CREATE INDEX webhook_deliveries_due_idx
+  ON webhook_deliveries (status, next_attempt_at);
+CREATE INDEX webhook_deliveries_webhook_id_idx
+  ON webhook_deliveries (webhook_id, created_at);
+
+-- +migrate Down
+DROP TABLE webhook_deliveries;
+DROP TABLE webhooks;
@@ -0,0 +1,14 @@
+-- +migrate Up
+ALTER TABLE units
+  ADD COLUMN search TSVECTOR;
+UPDATE units SET search = to_tsvector('pg_catalog.english', title);
+CREATE INDEX units_search_idx
+  ON units USING GIN (search);
+CREATE TRIGGER units_search_update
+  BEFORE INSERT OR UPDATE OF title ON units
+  FOR EACH ROW EXECUTE PROCEDURE tsvector_update_trigger(search, 'pg_catalog.english', title);
+
+-- +migrate Down
+DROP TRIGGER units_search_update ON units;
+ALTER TABLE units
+  DROP COLUMN search;
@@ -0,0 +1,11 @@
+-- +migrate Up
+CREATE TABLE current_units (
+  class_id   UUID                                   NOT NULL,
+  unit_id    UUID                                   NOT NULL,
+  updated_by UUID                                   NOT NULL,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT now() NOT NULL,
+  CONSTRAINT current_units_pkey PRIMARY KEY (class_id)
+);
+
+-- +migrate Down
+DROP TABLE current_units;
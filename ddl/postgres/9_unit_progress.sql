@@ -0,0 +1,16 @@
+-- +migrate Up
+CREATE TABLE unit_progress (
+  unit_id      UUID                                   NOT NULL,
+  user_id      UUID                                   NOT NULL,
+  class_id     UUID                                   NOT NULL,
+  status       TEXT                                   NOT NULL,
+  started_at   TIMESTAMP WITH TIME ZONE,
+  completed_at TIMESTAMP WITH TIME ZONE,
+  updated_at   TIMESTAMP WITH TIME ZONE DEFAULT now() NOT NULL,
+  CONSTRAINT unit_progress_pkey PRIMARY KEY (unit_id, user_id)
+);
+CREATE INDEX unit_progress_class_id_idx
+  ON unit_progress (class_id);
+
+-- +migrate Down
+DROP TABLE unit_progress;
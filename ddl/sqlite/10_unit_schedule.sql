@@ -0,0 +1,15 @@
+-- +migrate Up
+ALTER TABLE units
+  ADD COLUMN status TEXT DEFAULT 'published' NOT NULL;
+ALTER TABLE units
+  ADD COLUMN starts_at DATETIME;
+ALTER TABLE units
+  ADD COLUMN ends_at DATETIME;
+
+-- +migrate Down
+ALTER TABLE units
+  DROP COLUMN ends_at;
+ALTER TABLE units
+  DROP COLUMN starts_at;
+ALTER TABLE units
+  DROP COLUMN status;
@@ -0,0 +1,14 @@
+-- +migrate Up
+CREATE TABLE units (
+  id            TEXT                                NOT NULL,
+  class_id      TEXT                                NOT NULL,
+  title         TEXT                                NOT NULL,
+  display_order INTEGER  DEFAULT 0                  NOT NULL,
+  created_at    DATETIME DEFAULT CURRENT_TIMESTAMP  NOT NULL,
+  CONSTRAINT units_pkey PRIMARY KEY (id)
+);
+CREATE INDEX units_class_id_idx
+  ON units (class_id);
+
+-- +migrate Down
+DROP TABLE units;
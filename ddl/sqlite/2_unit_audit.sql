@@ -0,0 +1,21 @@
+-- +migrate Up
+CREATE TABLE unit_audit (
+  id           TEXT                                NOT NULL,
+  unit_id      TEXT                                NOT NULL,
+  class_id     TEXT                                NOT NULL,
+  action       TEXT                                NOT NULL,
+  actor        TEXT                                NOT NULL,
+  client_id    TEXT                                NOT NULL,
+  request_id   TEXT                                NOT NULL,
+  before_state TEXT,
+  after_state  TEXT,
+  created_at   DATETIME DEFAULT CURRENT_TIMESTAMP  NOT NULL,
+  CONSTRAINT unit_audit_pkey PRIMARY KEY (id)
+);
+CREATE INDEX unit_audit_unit_id_idx
+  ON unit_audit (unit_id, created_at);
+CREATE INDEX unit_audit_class_id_idx
+  ON unit_audit (class_id, created_at);
+
+-- +migrate Down
+DROP TABLE unit_audit;
@@ -0,0 +1,12 @@
+-- +migrate Up
+CREATE TABLE unit_versions (
+  unit_id    TEXT                                NOT NULL,
+  version    INTEGER                             NOT NULL,
+  state      TEXT                                NOT NULL,
+  author     TEXT                                NOT NULL,
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP  NOT NULL,
+  CONSTRAINT unit_versions_pkey PRIMARY KEY (unit_id, version)
+);
+
+-- +migrate Down
+DROP TABLE unit_versions;
@@ -0,0 +1,36 @@
+-- +migrate Up
+CREATE TABLE webhooks (
+  id         TEXT                                NOT NULL,
+  class_id   TEXT                                NOT NULL,
+  url        TEXT                                NOT NULL,
+  secret     TEXT                                NOT NULL,
+  events     TEXT                                NOT NULL,
+  created_by TEXT                                NOT NULL,
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP  NOT NULL,
+  CONSTRAINT webhooks_pkey PRIMARY KEY (id)
+);
+CREATE INDEX webhooks_class_id_idx
+  ON webhooks (class_id);
+
+CREATE TABLE webhook_deliveries (
+  id               TEXT                                NOT NULL,
+  webhook_id       TEXT                                NOT NULL,
+  event            TEXT                                NOT NULL,
+  payload          TEXT                                NOT NULL,
+  status           TEXT                                NOT NULL,
+  attempts         INTEGER                             NOT NULL,
+  next_attempt_at  DATETIME                            NOT NULL,
+  last_error       TEXT                                NOT NULL,
+  last_status_code INTEGER                             NOT NULL,
+  created_at       DATETIME DEFAULT CURRENT_TIMESTAMP  NOT NULL,
+  updated_at       DATETIME DEFAULT CURRENT_TIMESTAMP  NOT NULL,
+  CONSTRAINT webhook_deliveries_pkey PRIMARY KEY (id)
+);
+CREATE INDEX webhook_deliveries_due_idx
+  ON webhook_deliveries (status, next_attempt_at);
+CREATE INDEX webhook_deliveries_webhook_id_idx
+  ON webhook_deliveries (webhook_id, created_at);
+
+-- +migrate Down
+DROP TABLE webhook_deliveries;
+DROP TABLE webhooks;
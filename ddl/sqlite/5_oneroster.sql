@@ -0,0 +1,20 @@
+-- +migrate Up
+CREATE TABLE oneroster_classes (
+  sourced_id TEXT                                NOT NULL,
+  class_id   TEXT                                NOT NULL,
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP  NOT NULL,
+  CONSTRAINT oneroster_classes_pkey PRIMARY KEY (sourced_id)
+);
+
+CREATE TABLE oneroster_units (
+  class_id   TEXT                                NOT NULL,
+  sourced_id TEXT                                NOT NULL,
+  unit_id    TEXT                                NOT NULL,
+  title      TEXT                                NOT NULL,
+  synced_at  DATETIME DEFAULT CURRENT_TIMESTAMP  NOT NULL,
+  CONSTRAINT oneroster_units_pkey PRIMARY KEY (class_id, sourced_id)
+);
+
+-- +migrate Down
+DROP TABLE oneroster_units;
+DROP TABLE oneroster_classes;
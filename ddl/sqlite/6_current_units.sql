@@ -0,0 +1,11 @@
+-- +migrate Up
+CREATE TABLE current_units (
+  class_id   TEXT                                NOT NULL,
+  unit_id    TEXT                                NOT NULL,
+  updated_by TEXT                                NOT NULL,
+  updated_at DATETIME DEFAULT CURRENT_TIMESTAMP  NOT NULL,
+  CONSTRAINT current_units_pkey PRIMARY KEY (class_id)
+);
+
+-- +migrate Down
+DROP TABLE current_units;
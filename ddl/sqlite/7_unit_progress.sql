@@ -0,0 +1,16 @@
+-- +migrate Up
+CREATE TABLE unit_progress (
+  unit_id      TEXT                                NOT NULL,
+  user_id      TEXT                                NOT NULL,
+  class_id     TEXT                                NOT NULL,
+  status       TEXT                                NOT NULL,
+  started_at   DATETIME,
+  completed_at DATETIME,
+  updated_at   DATETIME DEFAULT CURRENT_TIMESTAMP  NOT NULL,
+  CONSTRAINT unit_progress_pkey PRIMARY KEY (unit_id, user_id)
+);
+CREATE INDEX unit_progress_class_id_idx
+  ON unit_progress (class_id);
+
+-- +migrate Down
+DROP TABLE unit_progress;
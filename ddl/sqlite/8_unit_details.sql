@@ -0,0 +1,11 @@
+-- +migrate Up
+ALTER TABLE units
+  ADD COLUMN description TEXT DEFAULT ''   NOT NULL;
+ALTER TABLE units
+  ADD COLUMN metadata    TEXT DEFAULT '{}' NOT NULL;
+
+-- +migrate Down
+ALTER TABLE units
+  DROP COLUMN metadata;
+ALTER TABLE units
+  DROP COLUMN description;
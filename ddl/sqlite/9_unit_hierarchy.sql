@@ -0,0 +1,11 @@
+-- +migrate Up
+ALTER TABLE units
+  ADD COLUMN parent_id TEXT;
+ALTER TABLE units
+  ADD COLUMN resources TEXT DEFAULT '[]' NOT NULL;
+
+-- +migrate Down
+ALTER TABLE units
+  DROP COLUMN resources;
+ALTER TABLE units
+  DROP COLUMN parent_id;
@@ -0,0 +1,39 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Pinger is implemented by *sql.DB.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// Ping returns a Check that pings a database.
+func Ping(p Pinger) Check {
+	return func(ctx context.Context) error {
+		return p.PingContext(ctx)
+	}
+}
+
+// HTTP returns a Check that passes if a GET of url gets any response below 500.
+// It checks that a server is reachable without needing credentials for it.
+func HTTP(client *http.Client, url string) Check {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 500 {
+			return fmt.Errorf("%s responded %s", url, resp.Status)
+		}
+		return nil
+	}
+}
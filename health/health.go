@@ -0,0 +1,139 @@
+// Package health serves liveness and readiness probes for unitsvc's dependencies.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Check reports whether a dependency is usable, returning nil if it is.
+type Check func(ctx context.Context) error
+
+// Status is the result of a single Check.
+type Status struct {
+	OK        bool    `json:"ok"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the body served by the readiness handler.
+type Report struct {
+	Ready        bool              `json:"ready"`
+	ShuttingDown bool              `json:"shutting_down,omitempty"`
+	Checks       map[string]Status `json:"checks"`
+}
+
+// Health runs a set of named checks. Its zero value is not usable; use New.
+type Health struct {
+	timeout      time.Duration
+	mu           sync.RWMutex
+	checks       map[string]Check
+	shuttingDown int32
+}
+
+// New returns a Health whose checks are each given timeout to complete.
+func New(timeout time.Duration) *Health {
+	return &Health{
+		timeout: timeout,
+		checks:  make(map[string]Check),
+	}
+}
+
+// Add registers check under name, replacing any check with the same name.
+func (h *Health) Add(name string, check Check) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks[name] = check
+}
+
+// Shutdown marks the process as not ready, regardless of its checks, so that load
+// balancers stop routing to it before it drains. It cannot be undone.
+func (h *Health) Shutdown() {
+	atomic.StoreInt32(&h.shuttingDown, 1)
+}
+
+// Run runs every check concurrently and reports the results.
+func (h *Health) Run(ctx context.Context) Report {
+	h.mu.RLock()
+	checks := make(map[string]Check, len(h.checks))
+	for name, check := range h.checks {
+		checks[name] = check
+	}
+	h.mu.RUnlock()
+
+	report := Report{
+		Ready:        true,
+		ShuttingDown: atomic.LoadInt32(&h.shuttingDown) == 1,
+		Checks:       make(map[string]Status, len(checks)),
+	}
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+			status := h.run(ctx, check)
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = status
+			report.Ready = report.Ready && status.OK
+		}(name, check)
+	}
+	wg.Wait()
+	report.Ready = report.Ready && !report.ShuttingDown
+	return report
+}
+
+func (h *Health) run(ctx context.Context, check Check) Status {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+	begin := time.Now()
+	errc := make(chan error, 1)
+	go func() { errc <- check(ctx) }()
+	var err error
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	status := Status{
+		OK:        err == nil,
+		LatencyMS: float64(time.Since(begin)) / float64(time.Millisecond),
+	}
+	if err != nil {
+		status.Error = err.Error()
+	}
+	return status
+}
+
+// LivenessHandler reports that the process is up. It never checks dependencies, so that a
+// failing database does not get every replica restarted.
+func (h *Health) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]bool{"alive": true})
+	})
+}
+
+// ReadinessHandler runs every check and responds 200 if all pass, or 503 otherwise.
+func (h *Health) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := h.Run(r.Context())
+		code := http.StatusOK
+		if !report.Ready {
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, report)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,91 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func ready(t *testing.T, h *Health) (int, Report) {
+	w := httptest.NewRecorder()
+	h.ReadinessHandler().ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+	var report Report
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("decode report: %v", err)
+	}
+	return w.Code, report
+}
+
+func TestReadiness(t *testing.T) {
+	h := New(time.Second)
+	h.Add("up", func(context.Context) error { return nil })
+	if code, report := ready(t, h); code != http.StatusOK || !report.Ready || !report.Checks["up"].OK {
+		t.Fatalf("got %d %+v with passing check, want 200 and ready", code, report)
+	}
+
+	h.Add("down", func(context.Context) error { return errors.New("connection refused") })
+	code, report := ready(t, h)
+	if code != http.StatusServiceUnavailable || report.Ready {
+		t.Fatalf("got %d %+v with failing check, want 503 and not ready", code, report)
+	}
+	if s := report.Checks["down"]; s.OK || s.Error != "connection refused" {
+		t.Errorf("got status %+v for failing check", s)
+	}
+	if !report.Checks["up"].OK {
+		t.Errorf("passing check reported as failed alongside a failing one")
+	}
+}
+
+func TestReadinessTimeout(t *testing.T) {
+	h := New(10 * time.Millisecond)
+	h.Add("slow", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+	begin := time.Now()
+	code, report := ready(t, h)
+	if code != http.StatusServiceUnavailable || report.Checks["slow"].OK {
+		t.Fatalf("got %d %+v for slow check, want 503", code, report)
+	}
+	if elapsed := time.Since(begin); elapsed > 500*time.Millisecond {
+		t.Errorf("readiness took %v, want it bounded by the check timeout", elapsed)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	h := New(time.Second)
+	h.Add("up", func(context.Context) error { return nil })
+	h.Shutdown()
+	code, report := ready(t, h)
+	if code != http.StatusServiceUnavailable || report.Ready || !report.ShuttingDown {
+		t.Fatalf("got %d %+v after Shutdown, want 503 and shutting down", code, report)
+	}
+
+	w := httptest.NewRecorder()
+	h.LivenessHandler().ServeHTTP(w, httptest.NewRequest("GET", "/healthz", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got liveness %d after Shutdown, want 200", w.Code)
+	}
+}
+
+func TestHTTPCheck(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+	if err := HTTP(http.DefaultClient, srv.URL+"/missing")(ctx); err != nil {
+		t.Errorf("404 reported as unreachable: %v", err)
+	}
+	if err := HTTP(http.DefaultClient, srv.URL+"/broken")(ctx); err == nil {
+		t.Errorf("502 reported as reachable")
+	}
+}
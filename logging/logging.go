@@ -0,0 +1,88 @@
+// Package logging builds the loggers used by unitsvc from its configuration.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// Redacted replaces the values of redacted keys.
+const Redacted = "[REDACTED]"
+
+// SensitiveKeys are always redacted, whatever else is configured.
+var SensitiveKeys = []string{"token", "authorization", "password", "secret"}
+
+// New returns a logger writing to w in format, "logfmt" or "json", that drops lines below lvl,
+// which is one of "debug", "info", "warn" or "error". Lines without a level are never dropped.
+func New(w io.Writer, format, lvl string) (log.Logger, error) {
+	var logger log.Logger
+	switch format {
+	case "", "logfmt":
+		logger = log.NewLogfmtLogger(log.NewSyncWriter(w))
+	case "json":
+		logger = log.NewJSONLogger(log.NewSyncWriter(w))
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+	var allow level.Option
+	switch lvl {
+	case "debug":
+		allow = level.AllowDebug()
+	case "", "info":
+		allow = level.AllowInfo()
+	case "warn":
+		allow = level.AllowWarn()
+	case "error":
+		allow = level.AllowError()
+	default:
+		return nil, fmt.Errorf("unknown log level %q", lvl)
+	}
+	return level.NewFilter(logger, allow), nil
+}
+
+// Redact returns a logger that replaces the values of SensitiveKeys and keys with Redacted.
+// Keys are matched case-insensitively.
+func Redact(next log.Logger, keys ...string) log.Logger {
+	redact := make(map[string]bool, len(SensitiveKeys)+len(keys))
+	for _, k := range SensitiveKeys {
+		redact[k] = true
+	}
+	for _, k := range keys {
+		redact[strings.ToLower(k)] = true
+	}
+	return log.LoggerFunc(func(keyvals ...interface{}) error {
+		redacted := make([]interface{}, len(keyvals))
+		copy(redacted, keyvals)
+		for i := 0; i+1 < len(redacted); i += 2 {
+			if k, ok := redacted[i].(string); ok && redact[strings.ToLower(k)] {
+				redacted[i+1] = Redacted
+			}
+		}
+		return next.Log(redacted...)
+	})
+}
+
+// Sampler selects one in every n events. It is safe for concurrent use.
+type Sampler struct {
+	n     uint64
+	count uint64
+}
+
+// NewSampler returns a Sampler that selects one in every n events.
+// If n is less than 2, every event is selected.
+func NewSampler(n int) *Sampler {
+	if n < 1 {
+		n = 1
+	}
+	return &Sampler{n: uint64(n)}
+}
+
+// Sample reports whether the current event is selected.
+func (s *Sampler) Sample() bool {
+	return (atomic.AddUint64(&s.count, 1)-1)%s.n == 0
+}
@@ -0,0 +1,70 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "json", "warn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	level.Info(logger).Log("msg", "dropped")
+	level.Warn(logger).Log("msg", "kept")
+	logger.Log("msg", "unleveled")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got lines %q, want the warning and the unleveled line", lines)
+	}
+	var line map[string]string
+	if err := json.Unmarshal([]byte(lines[0]), &line); err != nil || line["msg"] != "kept" || line["level"] != "warn" {
+		t.Errorf("got first line %q (%v)", lines[0], err)
+	}
+
+	if _, err := New(&buf, "xml", "info"); err == nil {
+		t.Error("New accepted an unknown format")
+	}
+	if _, err := New(&buf, "logfmt", "verbose"); err == nil {
+		t.Error("New accepted an unknown level")
+	}
+}
+
+func TestRedact(t *testing.T) {
+	var buf bytes.Buffer
+	logger := Redact(log.NewLogfmtLogger(&buf), "Title")
+	keyvals := []interface{}{"token", "abc", "title", "Cells", "unit", "1", "Authorization", "Bearer abc"}
+	logger.Log(keyvals...)
+
+	want := "token=[REDACTED] title=[REDACTED] unit=1 Authorization=[REDACTED]\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+	if keyvals[1] != "abc" {
+		t.Error("Redact modified the caller's keyvals")
+	}
+}
+
+func TestSampler(t *testing.T) {
+	s := NewSampler(3)
+	var sampled int
+	for i := 0; i < 9; i++ {
+		if s.Sample() {
+			sampled++
+		}
+	}
+	if sampled != 3 {
+		t.Errorf("sampled %d of 9 events at 1 in 3", sampled)
+	}
+	s = NewSampler(0)
+	if !s.Sample() || !s.Sample() {
+		t.Error("a sampler with n < 1 dropped events")
+	}
+}
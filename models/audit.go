@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audit actions.
+const (
+	AuditCreate = "create"
+	AuditRename = "rename"
+	AuditDelete = "delete"
+	AuditRevert = "revert"
+	AuditUpdate = "update" // a change to the title, display order, description or metadata of a unit by an import
+)
+
+// AuditEntry represents a row from the append-only 'unit_audit' table: one change to a unit.
+type AuditEntry struct {
+	ID        uuid.UUID `json:"id"`
+	UnitID    uuid.UUID `json:"unit_id"`
+	ClassID   uuid.UUID `json:"class_id"`
+	Action    string    `json:"action"`
+	Actor     uuid.UUID `json:"actor"`      // uuid.Nil if the change was not made by a user
+	ClientID  string    `json:"client_id"`  // OAuth2 client the actor used
+	RequestID string    `json:"request_id"` // X-Request-ID of the request that made the change
+	Before    *Unit     `json:"before"`     // nil for AuditCreate
+	After     *Unit     `json:"after"`      // nil for AuditDelete
+	CreatedAt time.Time `json:"created_at"`
+}
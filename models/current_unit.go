@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CurrentUnit represents a row from the 'current_units' table: the unit that a class is on.
+type CurrentUnit struct {
+	ClassID   uuid.UUID `json:"class_id"`
+	UnitID    uuid.UUID `json:"unit_id"`
+	UpdatedBy uuid.UUID `json:"updated_by"` // the user who last moved it, including by deleting the unit it was on
+	UpdatedAt time.Time `json:"updated_at"`
+}
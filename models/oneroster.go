@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OneRosterClass represents a row from the 'oneroster_classes' table: the class that a OneRoster
+// class, identified by its sourcedId in the student information system, is synced to.
+type OneRosterClass struct {
+	SourcedID string    `json:"sourced_id"`
+	ClassID   uuid.UUID `json:"class_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OneRosterUnit represents a row from the 'oneroster_units' table: the unit that a OneRoster
+// category of a class was synced to.
+type OneRosterUnit struct {
+	ClassID   uuid.UUID `json:"class_id"`
+	SourcedID string    `json:"sourced_id"` // of the category
+	UnitID    uuid.UUID `json:"unit_id"`
+	Title     string    `json:"title"` // as of the last sync, to tell whether a teacher has renamed the unit since
+	SyncedAt  time.Time `json:"synced_at"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Progress statuses.
+const (
+	ProgressNotStarted = "not_started"
+	ProgressInProgress = "in_progress"
+	ProgressCompleted  = "completed"
+)
+
+// UnitProgress represents a row from the 'unit_progress' table: how far a student has got
+// through a unit.
+type UnitProgress struct {
+	UnitID      uuid.UUID  `json:"unit_id"`
+	UserID      uuid.UUID  `json:"user_id"` // the student's subject
+	ClassID     uuid.UUID  `json:"class_id"`
+	Status      string     `json:"status"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`   // when the student first left ProgressNotStarted
+	CompletedAt *time.Time `json:"completed_at,omitempty"` // when the student last reached ProgressCompleted, while they are there
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
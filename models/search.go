@@ -0,0 +1,11 @@
+package models
+
+// UnitMatch is a unit found by a search.
+type UnitMatch struct {
+	Unit *Unit `json:"unit"`
+	// Rank is how well the unit matched; higher is better. Ranks are only comparable within one search.
+	Rank float64 `json:"rank"`
+	// Snippet is the unit's title with the matched words between <mark> and </mark>, which are
+	// none if only the description matched. The rest of the title is not escaped.
+	Snippet string `json:"snippet"`
+}
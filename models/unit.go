@@ -0,0 +1,174 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// UnitStatus is whether a unit is being planned, taught or kept for reference.
+type UnitStatus string
+
+// Statuses of units. Units are published unless their status is set.
+const (
+	StatusDraft     UnitStatus = "draft"
+	StatusPublished UnitStatus = "published"
+	StatusArchived  UnitStatus = "archived"
+)
+
+// OrPublished returns s, or StatusPublished if s is empty.
+func (s UnitStatus) OrPublished() UnitStatus {
+	if s == "" {
+		return StatusPublished
+	}
+	return s
+}
+
+// Valid reports whether s is one of the statuses of units.
+func (s UnitStatus) Valid() bool {
+	switch s {
+	case StatusDraft, StatusPublished, StatusArchived:
+		return true
+	}
+	return false
+}
+
+// Value stores s; the empty status is stored as published.
+func (s UnitStatus) Value() (driver.Value, error) {
+	return string(s.OrPublished()), nil
+}
+
+// Scan reads a status. NULL and empty values are read as published.
+func (s *UnitStatus) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*s = ""
+	case []byte:
+		*s = UnitStatus(v)
+	case string:
+		*s = UnitStatus(v)
+	default:
+		return fmt.Errorf("cannot scan %T as a unit status", src)
+	}
+	*s = s.OrPublished()
+	return nil
+}
+
+// Resource is a link to material for a unit, such as a reading or a video.
+type Resource struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// Resources are the links attached to a unit, in the order they are listed. They are stored as a
+// JSON array.
+type Resources []Resource
+
+// Value stores r as a JSON array; nil resources are stored as an empty array.
+func (r Resources) Value() (driver.Value, error) {
+	if r == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal([]Resource(r))
+	return string(data), err
+}
+
+// Scan reads a JSON array. NULL and empty values are read as nil resources.
+func (r *Resources) Scan(src interface{}) error {
+	data, err := jsonColumn(src)
+	if err != nil {
+		return err
+	}
+	*r = nil
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, r)
+}
+
+// Equal reports whether r and o list the same links in the same order. Nil resources equal empty
+// resources.
+func (r Resources) Equal(o Resources) bool {
+	if len(r) != len(o) {
+		return false
+	}
+	for i := range r {
+		if r[i] != o[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Copy returns a copy of r that can be changed without affecting r.
+func (r Resources) Copy() Resources {
+	if r == nil {
+		return nil
+	}
+	return append(Resources{}, r...)
+}
+
+// Metadata is free-form information about a unit, such as the standards it covers or the weeks
+// it is taught in. It is stored as a JSON object of strings.
+type Metadata map[string]string
+
+// Value stores m as a JSON object; nil metadata is stored as an empty object.
+func (m Metadata) Value() (driver.Value, error) {
+	if m == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(map[string]string(m))
+	return string(data), err
+}
+
+// Scan reads a JSON object. NULL and empty values are read as nil metadata.
+func (m *Metadata) Scan(src interface{}) error {
+	data, err := jsonColumn(src)
+	if err != nil {
+		return err
+	}
+	*m = nil
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, m)
+}
+
+// Equal reports whether m and o have the same entries. Nil metadata equals empty metadata.
+func (m Metadata) Equal(o Metadata) bool {
+	if len(m) != len(o) {
+		return false
+	}
+	for k, v := range m {
+		if w, ok := o[k]; !ok || w != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Copy returns a copy of m that can be changed without affecting m.
+func (m Metadata) Copy() Metadata {
+	if m == nil {
+		return nil
+	}
+	c := make(Metadata, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// jsonColumn returns the JSON text of a column value scanned from any of the drivers.
+func jsonColumn(src interface{}) ([]byte, error) {
+	switch v := src.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	default:
+		return nil, fmt.Errorf("cannot scan %T as JSON", src)
+	}
+}
@@ -12,10 +12,18 @@ import (
 
 // Unit represents a row from 'public.units'.
 type Unit struct {
-	ID        uuid.UUID `json:"id"`         // id
-	ClassID   uuid.UUID `json:"class_id"`   // class_id
-	Title     string    `json:"title"`      // title
-	CreatedAt time.Time `json:"created_at"` // created_at
+	ID           uuid.UUID  `json:"id"`                  // id
+	ClassID      uuid.UUID  `json:"class_id"`            // class_id
+	Title        string     `json:"title"`               // title
+	DisplayOrder int        `json:"display_order"`       // display_order
+	CreatedAt    time.Time  `json:"created_at"`          // created_at
+	Description  string     `json:"description"`         // description
+	Metadata     Metadata   `json:"metadata"`            // metadata
+	ParentID     *uuid.UUID `json:"parent_id,omitempty"` // parent_id
+	Resources    Resources  `json:"resources"`           // resources
+	Status       UnitStatus `json:"status"`              // status
+	StartsAt     *time.Time `json:"starts_at,omitempty"` // starts_at
+	EndsAt       *time.Time `json:"ends_at,omitempty"`   // ends_at
 
 	// xo fields
 	_exists, _deleted bool
@@ -42,14 +50,14 @@ func (u *Unit) Insert(db XODB) error {
 
 	// sql insert query, primary key must be provided
 	const sqlstr = `INSERT INTO public.units (` +
-		`id, class_id, title, created_at` +
+		`id, class_id, title, display_order, created_at, description, metadata, parent_id, resources, status, starts_at, ends_at` +
 		`) VALUES (` +
-		`$1, $2, $3, $4` +
+		`$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12` +
 		`)`
 
 	// run query
-	XOLog(sqlstr, u.ID, u.ClassID, u.Title, u.CreatedAt)
-	err = db.QueryRow(sqlstr, u.ID, u.ClassID, u.Title, u.CreatedAt).Scan(&u.ID)
+	XOLog(sqlstr, u.ID, u.ClassID, u.Title, u.DisplayOrder, u.CreatedAt, u.Description, u.Metadata, u.ParentID, u.Resources, u.Status, u.StartsAt, u.EndsAt)
+	err = db.QueryRow(sqlstr, u.ID, u.ClassID, u.Title, u.DisplayOrder, u.CreatedAt, u.Description, u.Metadata, u.ParentID, u.Resources, u.Status, u.StartsAt, u.EndsAt).Scan(&u.ID)
 	if err != nil {
 		return err
 	}
@@ -76,14 +84,14 @@ func (u *Unit) Update(db XODB) error {
 
 	// sql query
 	const sqlstr = `UPDATE public.units SET (` +
-		`class_id, title, created_at` +
+		`class_id, title, display_order, created_at, description, metadata, parent_id, resources, status, starts_at, ends_at` +
 		`) = ( ` +
-		`$1, $2, $3` +
-		`) WHERE id = $4`
+		`$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11` +
+		`) WHERE id = $12`
 
 	// run query
-	XOLog(sqlstr, u.ClassID, u.Title, u.CreatedAt, u.ID)
-	_, err = db.Exec(sqlstr, u.ClassID, u.Title, u.CreatedAt, u.ID)
+	XOLog(sqlstr, u.ClassID, u.Title, u.DisplayOrder, u.CreatedAt, u.Description, u.Metadata, u.ParentID, u.Resources, u.Status, u.StartsAt, u.EndsAt, u.ID)
+	_, err = db.Exec(sqlstr, u.ClassID, u.Title, u.DisplayOrder, u.CreatedAt, u.Description, u.Metadata, u.ParentID, u.Resources, u.Status, u.StartsAt, u.EndsAt, u.ID)
 	return err
 }
 
@@ -109,18 +117,18 @@ func (u *Unit) Upsert(db XODB) error {
 
 	// sql query
 	const sqlstr = `INSERT INTO public.units (` +
-		`id, class_id, title, created_at` +
+		`id, class_id, title, display_order, created_at, description, metadata, parent_id, resources, status, starts_at, ends_at` +
 		`) VALUES (` +
-		`$1, $2, $3, $4` +
+		`$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12` +
 		`) ON CONFLICT (id) DO UPDATE SET (` +
-		`id, class_id, title, created_at` +
+		`id, class_id, title, display_order, created_at, description, metadata, parent_id, resources, status, starts_at, ends_at` +
 		`) = (` +
-		`EXCLUDED.id, EXCLUDED.class_id, EXCLUDED.title, EXCLUDED.created_at` +
+		`EXCLUDED.id, EXCLUDED.class_id, EXCLUDED.title, EXCLUDED.display_order, EXCLUDED.created_at, EXCLUDED.description, EXCLUDED.metadata, EXCLUDED.parent_id, EXCLUDED.resources, EXCLUDED.status, EXCLUDED.starts_at, EXCLUDED.ends_at` +
 		`)`
 
 	// run query
-	XOLog(sqlstr, u.ID, u.ClassID, u.Title, u.CreatedAt)
-	_, err = db.Exec(sqlstr, u.ID, u.ClassID, u.Title, u.CreatedAt)
+	XOLog(sqlstr, u.ID, u.ClassID, u.Title, u.DisplayOrder, u.CreatedAt, u.Description, u.Metadata, u.ParentID, u.Resources, u.Status, u.StartsAt, u.EndsAt)
+	_, err = db.Exec(sqlstr, u.ID, u.ClassID, u.Title, u.DisplayOrder, u.CreatedAt, u.Description, u.Metadata, u.ParentID, u.Resources, u.Status, u.StartsAt, u.EndsAt)
 	if err != nil {
 		return err
 	}
@@ -169,7 +177,7 @@ func UnitsByClassID(db XODB, classID uuid.UUID) ([]*Unit, error) {
 
 	// sql query
 	const sqlstr = `SELECT ` +
-		`id, class_id, title, created_at ` +
+		`id, class_id, title, display_order, created_at, description, metadata, parent_id, resources, status, starts_at, ends_at ` +
 		`FROM public.units ` +
 		`WHERE class_id = $1`
 
@@ -189,7 +197,7 @@ func UnitsByClassID(db XODB, classID uuid.UUID) ([]*Unit, error) {
 		}
 
 		// scan
-		err = q.Scan(&u.ID, &u.ClassID, &u.Title, &u.CreatedAt)
+		err = q.Scan(&u.ID, &u.ClassID, &u.Title, &u.DisplayOrder, &u.CreatedAt, &u.Description, &u.Metadata, &u.ParentID, &u.Resources, &u.Status, &u.StartsAt, &u.EndsAt)
 		if err != nil {
 			return nil, err
 		}
@@ -208,7 +216,7 @@ func UnitByID(db XODB, id uuid.UUID) (*Unit, error) {
 
 	// sql query
 	const sqlstr = `SELECT ` +
-		`id, class_id, title, created_at ` +
+		`id, class_id, title, display_order, created_at, description, metadata, parent_id, resources, status, starts_at, ends_at ` +
 		`FROM public.units ` +
 		`WHERE id = $1`
 
@@ -218,7 +226,7 @@ func UnitByID(db XODB, id uuid.UUID) (*Unit, error) {
 		_exists: true,
 	}
 
-	err = db.QueryRow(sqlstr, id).Scan(&u.ID, &u.ClassID, &u.Title, &u.CreatedAt)
+	err = db.QueryRow(sqlstr, id).Scan(&u.ID, &u.ClassID, &u.Title, &u.DisplayOrder, &u.CreatedAt, &u.Description, &u.Metadata, &u.ParentID, &u.Resources, &u.Status, &u.StartsAt, &u.EndsAt)
 	if err != nil {
 		return nil, err
 	}
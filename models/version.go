@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UnitVersion represents a row from the 'unit_versions' table: a full snapshot of a unit
+// taken whenever it is created or changed. Versions are numbered from 1 for each unit.
+type UnitVersion struct {
+	UnitID    uuid.UUID     `json:"unit_id"`
+	Version   int           `json:"version"`
+	Unit      *Unit         `json:"unit"`
+	Author    uuid.UUID     `json:"author"` // uuid.Nil if the version was not made by a user
+	CreatedAt time.Time     `json:"created_at"`
+	Changes   []FieldChange `json:"changes,omitempty"` // from the previous version; not stored
+}
+
+// FieldChange is a field whose value differs between two versions of a unit.
+type FieldChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
@@ -0,0 +1,262 @@
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/opentracing/opentracing-go"
+	otlog "github.com/opentracing/opentracing-go/log"
+)
+
+// Span contexts are propagated with Zipkin's B3 headers, so that traces started here can be
+// continued by services using other tracers.
+const (
+	headerTraceID = "x-b3-traceid"
+	headerSpanID  = "x-b3-spanid"
+	headerSampled = "x-b3-sampled"
+	baggagePrefix = "ot-baggage-"
+)
+
+// NewLogTracer returns a tracer that logs each span to logger when it finishes.
+// It is the stdout exporter, meant for local runs, where there is no collector to export spans to.
+func NewLogTracer(logger log.Logger) opentracing.Tracer {
+	return &logTracer{logger}
+}
+
+type logTracer struct {
+	logger log.Logger
+}
+
+func (t *logTracer) StartSpan(operationName string, opts ...opentracing.StartSpanOption) opentracing.Span {
+	var o opentracing.StartSpanOptions
+	for _, opt := range opts {
+		opt.Apply(&o)
+	}
+	s := &logSpan{
+		tracer:    t,
+		operation: operationName,
+		start:     o.StartTime,
+		tags:      make(map[string]interface{}, len(o.Tags)),
+	}
+	if s.start.IsZero() {
+		s.start = time.Now()
+	}
+	for k, v := range o.Tags {
+		s.tags[k] = v
+	}
+	for _, ref := range o.References {
+		if parent, ok := ref.ReferencedContext.(spanContext); ok {
+			s.context = spanContext{traceID: parent.traceID, baggage: parent.baggage}
+			s.parentID = parent.spanID
+			break
+		}
+	}
+	if s.context.traceID == "" {
+		s.context.traceID = newID()
+	}
+	s.context.spanID = newID()
+	return s
+}
+
+func (t *logTracer) Inject(sc opentracing.SpanContext, format interface{}, carrier interface{}) error {
+	ctx, ok := sc.(spanContext)
+	if !ok {
+		return opentracing.ErrInvalidSpanContext
+	}
+	if format != opentracing.TextMap && format != opentracing.HTTPHeaders {
+		return opentracing.ErrUnsupportedFormat
+	}
+	w, ok := carrier.(opentracing.TextMapWriter)
+	if !ok {
+		return opentracing.ErrInvalidCarrier
+	}
+	w.Set(headerTraceID, ctx.traceID)
+	w.Set(headerSpanID, ctx.spanID)
+	w.Set(headerSampled, "1")
+	for k, v := range ctx.baggage {
+		w.Set(baggagePrefix+k, v)
+	}
+	return nil
+}
+
+func (t *logTracer) Extract(format interface{}, carrier interface{}) (opentracing.SpanContext, error) {
+	if format != opentracing.TextMap && format != opentracing.HTTPHeaders {
+		return nil, opentracing.ErrUnsupportedFormat
+	}
+	r, ok := carrier.(opentracing.TextMapReader)
+	if !ok {
+		return nil, opentracing.ErrInvalidCarrier
+	}
+	var ctx spanContext
+	err := r.ForeachKey(func(key, val string) error {
+		switch key = strings.ToLower(key); {
+		case key == headerTraceID:
+			ctx.traceID = val
+		case key == headerSpanID:
+			ctx.spanID = val
+		case strings.HasPrefix(key, baggagePrefix):
+			ctx = ctx.withBaggageItem(strings.TrimPrefix(key, baggagePrefix), val)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if ctx.traceID == "" || ctx.spanID == "" {
+		return nil, opentracing.ErrSpanContextNotFound
+	}
+	return ctx, nil
+}
+
+// spanContext is immutable; baggage is copied on write.
+type spanContext struct {
+	traceID string
+	spanID  string
+	baggage map[string]string
+}
+
+// TraceID returns the trace's ID as lowercase hex.
+func (c spanContext) TraceID() string {
+	return c.traceID
+}
+
+func (c spanContext) ForeachBaggageItem(handler func(k, v string) bool) {
+	for k, v := range c.baggage {
+		if !handler(k, v) {
+			return
+		}
+	}
+}
+
+func (c spanContext) withBaggageItem(key, val string) spanContext {
+	baggage := make(map[string]string, len(c.baggage)+1)
+	for k, v := range c.baggage {
+		baggage[k] = v
+	}
+	baggage[key] = val
+	c.baggage = baggage
+	return c
+}
+
+type logSpan struct {
+	tracer   *logTracer
+	parentID string
+	start    time.Time
+
+	mu        sync.Mutex
+	context   spanContext
+	operation string
+	tags      map[string]interface{}
+	logs      []opentracing.LogRecord
+}
+
+func (s *logSpan) Finish() {
+	s.FinishWithOptions(opentracing.FinishOptions{})
+}
+
+func (s *logSpan) FinishWithOptions(opts opentracing.FinishOptions) {
+	finish := opts.FinishTime
+	if finish.IsZero() {
+		finish = time.Now()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, ld := range opts.BulkLogData {
+		s.logs = append(s.logs, ld.ToLogRecord())
+	}
+	s.logs = append(s.logs, opts.LogRecords...)
+
+	ids := []interface{}{"trace_id", s.context.traceID, "span_id", s.context.spanID}
+	for _, lr := range s.logs {
+		keyvals := append(ids, "ts", lr.Timestamp.UTC())
+		for _, f := range lr.Fields {
+			keyvals = append(keyvals, f.Key(), f.Value())
+		}
+		s.tracer.logger.Log(keyvals...)
+	}
+	keyvals := append(ids,
+		"parent_id", s.parentID,
+		"operation", s.operation,
+		"start", s.start.UTC(),
+		"duration", finish.Sub(s.start),
+	)
+	for k, v := range s.tags {
+		keyvals = append(keyvals, k, v)
+	}
+	s.tracer.logger.Log(keyvals...)
+}
+
+func (s *logSpan) Context() opentracing.SpanContext {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.context
+}
+
+func (s *logSpan) SetOperationName(operationName string) opentracing.Span {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.operation = operationName
+	return s
+}
+
+func (s *logSpan) SetTag(key string, value interface{}) opentracing.Span {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tags[key] = value
+	return s
+}
+
+func (s *logSpan) LogFields(fields ...otlog.Field) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.logs = append(s.logs, opentracing.LogRecord{Timestamp: time.Now(), Fields: fields})
+}
+
+func (s *logSpan) LogKV(alternatingKeyValues ...interface{}) {
+	fields, err := otlog.InterleavedKVToFields(alternatingKeyValues...)
+	if err != nil {
+		fields = []otlog.Field{otlog.Error(err)}
+	}
+	s.LogFields(fields...)
+}
+
+func (s *logSpan) SetBaggageItem(restrictedKey, value string) opentracing.Span {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.context = s.context.withBaggageItem(restrictedKey, value)
+	return s
+}
+
+func (s *logSpan) BaggageItem(restrictedKey string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.context.baggage[restrictedKey]
+}
+
+func (s *logSpan) Tracer() opentracing.Tracer {
+	return s.tracer
+}
+
+func (s *logSpan) LogEvent(event string) {
+	s.Log(opentracing.LogData{Event: event})
+}
+
+func (s *logSpan) LogEventWithPayload(event string, payload interface{}) {
+	s.Log(opentracing.LogData{Event: event, Payload: payload})
+}
+
+func (s *logSpan) Log(ld opentracing.LogData) {
+	lr := ld.ToLogRecord()
+	s.LogFields(lr.Fields...)
+}
+
+// newID returns a random 64-bit ID as 16 hex digits, the format B3 headers use.
+func newID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,48 @@
+// Package tracing provides the OpenTracing tracers unitsvc can export spans to.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/go-kit/kit/log"
+	"github.com/opentracing/opentracing-go"
+	zipkinot "github.com/openzipkin-contrib/zipkin-go-opentracing"
+)
+
+// New returns the tracer for an exporter, and a function that flushes the spans it has not
+// exported yet: "none" (or "") discards spans, "stdout" logs every finished span to w as
+// logfmt, and "zipkin" reports spans to the Zipkin HTTP collector at zipkinURL.
+func New(exporter, zipkinURL string, w io.Writer) (opentracing.Tracer, func() error, error) {
+	switch exporter {
+	case "", "none":
+		return opentracing.NoopTracer{}, noFlush, nil
+	case "stdout":
+		return NewLogTracer(log.NewLogfmtLogger(w)), noFlush, nil
+	case "zipkin":
+		return NewZipkinTracer(zipkinURL)
+	default:
+		return nil, nil, fmt.Errorf("unknown tracing exporter %q", exporter)
+	}
+}
+
+func noFlush() error {
+	return nil
+}
+
+// TraceID returns the ID of the trace that the span in ctx belongs to.
+// It returns "" if ctx has no span or its tracer does not expose trace IDs.
+func TraceID(ctx context.Context) string {
+	span := opentracing.SpanFromContext(ctx)
+	if span == nil {
+		return ""
+	}
+	switch sc := span.Context().(type) {
+	case zipkinot.SpanContext:
+		return sc.TraceID.String()
+	case interface{ TraceID() string }:
+		return sc.TraceID()
+	}
+	return ""
+}
@@ -0,0 +1,144 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/opentracing/opentracing-go"
+)
+
+// recorder collects logged spans, keyed by span ID.
+type recorder map[string]map[string]interface{}
+
+func (r recorder) Log(keyvals ...interface{}) error {
+	fields := make(map[string]interface{})
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		fields[keyvals[i].(string)] = keyvals[i+1]
+	}
+	if _, ok := fields["operation"]; ok {
+		r[fields["span_id"].(string)] = fields
+	}
+	return nil
+}
+
+func TestPropagation(t *testing.T) {
+	spans := recorder{}
+	tracer := NewLogTracer(spans)
+
+	client := tracer.StartSpan("client")
+	client.SetBaggageItem("tenant", "school-1")
+	header := http.Header{}
+	if err := tracer.Inject(client.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(header)); err != nil {
+		t.Fatal(err)
+	}
+	if header.Get("X-B3-TraceId") == "" || header.Get("X-B3-SpanId") == "" {
+		t.Fatalf("B3 headers not set: %v", header)
+	}
+
+	wire, err := tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(header))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := tracer.StartSpan("server", opentracing.ChildOf(wire))
+	server.SetTag("http.method", "GET")
+	if got := server.BaggageItem("tenant"); got != "school-1" {
+		t.Errorf("got baggage %q, want school-1", got)
+	}
+	ctx := opentracing.ContextWithSpan(context.Background(), server)
+	if got, want := TraceID(ctx), header.Get("X-B3-TraceId"); got != want {
+		t.Errorf("TraceID = %q, want propagated %q", got, want)
+	}
+	server.Finish()
+	client.Finish()
+
+	if len(spans) != 2 {
+		t.Fatalf("logged %d spans, want 2", len(spans))
+	}
+	var logged map[string]interface{}
+	for _, s := range spans {
+		if s["operation"] == "server" {
+			logged = s
+		}
+	}
+	if logged["trace_id"] != header.Get("X-B3-TraceId") || logged["parent_id"] != header.Get("X-B3-SpanId") || logged["http.method"] != "GET" {
+		t.Errorf("server span logged as %v", logged)
+	}
+}
+
+func TestExtractWithoutTrace(t *testing.T) {
+	tracer := NewLogTracer(log.NewNopLogger())
+	_, err := tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(http.Header{}))
+	if err != opentracing.ErrSpanContextNotFound {
+		t.Errorf("got error %v, want ErrSpanContextNotFound", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, exporter := range []string{"", "none", "stdout"} {
+		if _, _, err := New(exporter, "", nil); err != nil {
+			t.Errorf("New(%q): %v", exporter, err)
+		}
+	}
+	if _, _, err := New("jaeger", "", nil); err == nil {
+		t.Error("New accepted an unknown exporter")
+	}
+	if _, _, err := New("zipkin", "", nil); err == nil {
+		t.Error("New accepted the zipkin exporter without a collector URL")
+	}
+	if got := TraceID(context.Background()); got != "" {
+		t.Errorf("TraceID without a span = %q", got)
+	}
+}
+
+func TestZipkin(t *testing.T) {
+	var spans []struct {
+		TraceID string            `json:"traceId"`
+		Name    string            `json:"name"`
+		Tags    map[string]string `json:"tags"`
+	}
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&spans); err != nil {
+			t.Errorf("decode spans: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer collector.Close()
+
+	tracer, flush, err := New("zipkin", collector.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := tracer.StartSpan("client")
+	header := http.Header{}
+	if err := tracer.Inject(client.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(header)); err != nil {
+		t.Fatal(err)
+	}
+	if header.Get("X-B3-TraceId") == "" || header.Get("X-B3-SpanId") == "" {
+		t.Fatalf("B3 headers not set: %v", header)
+	}
+	wire, err := tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(header))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := tracer.StartSpan("server", opentracing.ChildOf(wire))
+	server.SetTag("db.type", "sqlite")
+	ctx := opentracing.ContextWithSpan(context.Background(), server)
+	if got, want := TraceID(ctx), header.Get("X-B3-TraceId"); got != want {
+		t.Errorf("TraceID = %q, want propagated %q", got, want)
+	}
+	server.Finish()
+	if err := flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(spans) != 1 {
+		t.Fatalf("collected %d spans, want 1", len(spans))
+	}
+	if s := spans[0]; s.Name != "server" || s.TraceID != header.Get("X-B3-TraceId") || s.Tags["db.type"] != "sqlite" {
+		t.Errorf("collected span %+v", s)
+	}
+}
@@ -0,0 +1,34 @@
+package tracing
+
+import (
+	"errors"
+
+	"github.com/opentracing/opentracing-go"
+	zipkinot "github.com/openzipkin-contrib/zipkin-go-opentracing"
+	"github.com/openzipkin/zipkin-go"
+	zipkinhttp "github.com/openzipkin/zipkin-go/reporter/http"
+)
+
+// serviceName is the name spans are reported under.
+const serviceName = "unitsvc"
+
+// NewZipkinTracer returns a tracer that reports finished spans in batches to the Zipkin HTTP
+// collector at url, as "http://zipkin:9411/api/v2/spans", and a function that sends the spans
+// still queued and stops reporting. Spans are propagated in B3 headers, as by NewLogTracer.
+func NewZipkinTracer(url string) (opentracing.Tracer, func() error, error) {
+	if url == "" {
+		return nil, nil, errors.New("zipkin exporter needs a collector URL")
+	}
+	reporter := zipkinhttp.NewReporter(url)
+	endpoint, err := zipkin.NewEndpoint(serviceName, "")
+	if err != nil {
+		reporter.Close()
+		return nil, nil, err
+	}
+	tracer, err := zipkin.NewTracer(reporter, zipkin.WithLocalEndpoint(endpoint))
+	if err != nil {
+		reporter.Close()
+		return nil, nil, err
+	}
+	return zipkinot.Wrap(tracer), reporter.Close, nil
+}
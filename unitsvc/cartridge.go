@@ -0,0 +1,297 @@
+package unitsvc
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/studiously/unitsvc/models"
+)
+
+const (
+	cartridgeManifest  = "imsmanifest.xml"
+	cartridgeNamespace = "http://www.imsglobal.org/xsd/imsccv1p1/imscp_v1p1"
+	cartridgeWebLinkNS = "http://www.imsglobal.org/xsd/imsccv1p1/imswl_v1p1"
+	// cartridgeUnitPrefix begins the identifiers of units' items, which must not start with a digit.
+	cartridgeUnitPrefix = "unit_"
+	// cartridgeOverviewSuffix ends the identifier of the item for a unit's description, after the
+	// identifier of the unit's item.
+	cartridgeOverviewSuffix = "_overview"
+	// cartridgeWebLinkType begins the resource types of web links in every cartridge version.
+	cartridgeWebLinkType = "imswl_xmlv1p"
+)
+
+type cartridge struct {
+	XMLName       xml.Name           `xml:"manifest"`
+	Namespace     string             `xml:"xmlns,attr,omitempty"`
+	Identifier    string             `xml:"identifier,attr"`
+	Schema        string             `xml:"metadata>schema"`
+	SchemaVersion string             `xml:"metadata>schemaversion"`
+	Organizations []cartridgeOrg     `xml:"organizations>organization"`
+	Resources     cartridgeResources `xml:"resources"`
+}
+
+type cartridgeOrg struct {
+	Identifier string          `xml:"identifier,attr"`
+	Structure  string          `xml:"structure,attr"`
+	Items      []cartridgeItem `xml:"item"`
+}
+
+type cartridgeItem struct {
+	Identifier    string          `xml:"identifier,attr"`
+	IdentifierRef string          `xml:"identifierref,attr,omitempty"`
+	Title         string          `xml:"title,omitempty"`
+	Items         []cartridgeItem `xml:"item"`
+}
+
+type cartridgeResources struct {
+	Resources []cartridgeResource `xml:"resource"`
+}
+
+type cartridgeResource struct {
+	Identifier string          `xml:"identifier,attr"`
+	Type       string          `xml:"type,attr"`
+	Href       string          `xml:"href,attr,omitempty"`
+	Files      []cartridgeFile `xml:"file"`
+}
+
+type cartridgeFile struct {
+	Href string `xml:"href,attr"`
+}
+
+// cartridgeWebLink is the file of a web link resource.
+type cartridgeWebLink struct {
+	XMLName   xml.Name `xml:"webLink"`
+	Namespace string   `xml:"xmlns,attr,omitempty"`
+	Title     string   `xml:"title"`
+	URL       struct {
+		Href string `xml:"href,attr"`
+	} `xml:"url"`
+}
+
+// overviewHTML is the page of a unit's description. readCartridge reads the description back from
+// between overviewStart and overviewEnd.
+const (
+	overviewStart = `<div class="description" style="white-space: pre-wrap">`
+	overviewEnd   = `</div>`
+	overviewHTML  = `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Overview</title></head>
+<body>` + overviewStart + `%s` + overviewEnd + `</body></html>
+`
+)
+
+// writeCartridge writes units as the modules of an IMS Common Cartridge 1.1: a zip file whose
+// imsmanifest.xml lists them as items under its organization's root item. Units nested in
+// another unit of units are items of its item, and the others are modules; siblings are listed in
+// the order they are given. A unit's item lists an Overview page with its description, if it has
+// one, followed by a web link resource for each of its resources.
+func writeCartridge(w io.Writer, classID uuid.UUID, units []*models.Unit) error {
+	inSet := make(map[uuid.UUID]bool, len(units))
+	for _, u := range units {
+		inSet[u.ID] = true
+	}
+	children := make(map[uuid.UUID][]*models.Unit)
+	var top []*models.Unit
+	for _, u := range units {
+		if u.ParentID != nil && inSet[*u.ParentID] && *u.ParentID != u.ID {
+			children[*u.ParentID] = append(children[*u.ParentID], u)
+		} else {
+			top = append(top, u)
+		}
+	}
+
+	var resources []cartridgeResource
+	files := make(map[string][]byte)
+	var itemsOf func(units []*models.Unit) ([]cartridgeItem, error)
+	itemsOf = func(units []*models.Unit) ([]cartridgeItem, error) {
+		var items []cartridgeItem
+		for _, u := range units {
+			item := cartridgeItem{Identifier: cartridgeUnitPrefix + u.ID.String(), Title: u.Title}
+			dir := "units/" + u.ID.String() + "/"
+			if u.Description != "" {
+				href := dir + "overview.html"
+				files[href] = []byte(fmt.Sprintf(overviewHTML, html.EscapeString(u.Description)))
+				resources = append(resources, cartridgeResource{"res_" + u.ID.String() + cartridgeOverviewSuffix, "webcontent", href, []cartridgeFile{{href}}})
+				item.Items = append(item.Items, cartridgeItem{Identifier: item.Identifier + cartridgeOverviewSuffix, IdentifierRef: resources[len(resources)-1].Identifier, Title: "Overview"})
+			}
+			for i, r := range u.Resources {
+				link := cartridgeWebLink{Namespace: cartridgeWebLinkNS, Title: r.Title}
+				link.URL.Href = r.URL
+				data, err := xml.Marshal(link)
+				if err != nil {
+					return nil, err
+				}
+				href := fmt.Sprintf("%slink%d.xml", dir, i+1)
+				files[href] = append([]byte(xml.Header), data...)
+				resources = append(resources, cartridgeResource{fmt.Sprintf("res_%s_link%d", u.ID, i+1), cartridgeWebLinkType + "1", "", []cartridgeFile{{href}}})
+				item.Items = append(item.Items, cartridgeItem{Identifier: fmt.Sprintf("%s_link%d", item.Identifier, i+1), IdentifierRef: resources[len(resources)-1].Identifier, Title: r.Title})
+			}
+			nested, err := itemsOf(children[u.ID])
+			if err != nil {
+				return nil, err
+			}
+			item.Items = append(item.Items, nested...)
+			items = append(items, item)
+		}
+		return items, nil
+	}
+	modules, err := itemsOf(top)
+	if err != nil {
+		return err
+	}
+	manifest := cartridge{
+		Namespace:     cartridgeNamespace,
+		Identifier:    "class_" + classID.String(),
+		Schema:        "IMS Common Cartridge",
+		SchemaVersion: "1.1.0",
+		Organizations: []cartridgeOrg{{Identifier: "organization", Structure: "rooted-hierarchy", Items: []cartridgeItem{{Identifier: "root", Items: modules}}}},
+		Resources:     cartridgeResources{resources},
+	}
+	zw := zip.NewWriter(w)
+	f, err := zw.Create(cartridgeManifest)
+	if err != nil {
+		return err
+	}
+	io.WriteString(f, xml.Header)
+	enc := xml.NewEncoder(f)
+	enc.Indent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		return err
+	}
+	for _, r := range resources {
+		f, err := zw.Create(r.Files[0].Href)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(files[r.Files[0].Href]); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// readCartridge reads the modules of a cartridge's first organization as units, and the items
+// without content nested in them as units nested in theirs. The web links listed in a unit's item
+// are read as its resources; other content is ignored, apart from the Overview pages written by
+// writeCartridge, which are read as descriptions. Modules exported by this service keep their unit
+// IDs; the modules of other cartridges are read as new units. Cartridges of any version are
+// accepted.
+func readCartridge(r io.Reader) ([]unitRecord, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, ErrBadRequest
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, ErrBadRequest
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	var manifest cartridge
+	if f, ok := files[cartridgeManifest]; !ok || readCartridgeFile(f, func(r io.Reader) error {
+		return xml.NewDecoder(r).Decode(&manifest)
+	}) != nil {
+		return nil, ErrBadRequest
+	}
+	if len(manifest.Organizations) == 0 {
+		return nil, ErrBadRequest
+	}
+	resources := make(map[string]cartridgeResource, len(manifest.Resources.Resources))
+	for _, res := range manifest.Resources.Resources {
+		resources[res.Identifier] = res
+	}
+	// resourceFile returns the file of the resource an item refers to.
+	resourceFile := func(item cartridgeItem) (cartridgeResource, *zip.File) {
+		res, ok := resources[item.IdentifierRef]
+		if !ok {
+			return res, nil
+		}
+		href := res.Href
+		if len(res.Files) > 0 {
+			href = res.Files[0].Href
+		}
+		return res, files[href]
+	}
+
+	// Organizations have a single root item, whose children are the modules, but some
+	// exporters list the modules directly.
+	modules := manifest.Organizations[0].Items
+	if len(modules) == 1 && len(modules[0].Items) > 0 {
+		modules = modules[0].Items
+	}
+	records := []unitRecord{}
+	var read func(items []cartridgeItem, parent int) error
+	read = func(items []cartridgeItem, parent int) error {
+		for _, item := range items {
+			if item.IdentifierRef != "" {
+				continue // content, read with the item it is listed in
+			}
+			parent := parent
+			description := ""
+			rec := unitRecord{Title: item.Title, ParentRow: &parent, Description: &description, Resources: models.Resources{}}
+			if s := strings.TrimPrefix(item.Identifier, cartridgeUnitPrefix); s != item.Identifier {
+				if _, err := uuid.Parse(s); err == nil {
+					rec.ID = s
+				}
+			}
+			for _, child := range item.Items {
+				res, f := resourceFile(child)
+				if f == nil {
+					continue
+				}
+				switch {
+				case strings.HasPrefix(res.Type, cartridgeWebLinkType):
+					var link cartridgeWebLink
+					if err := readCartridgeFile(f, func(r io.Reader) error { return xml.NewDecoder(r).Decode(&link) }); err != nil {
+						return err
+					}
+					title := strings.TrimSpace(link.Title)
+					if title == "" {
+						title = child.Title
+					}
+					rec.Resources = append(rec.Resources, models.Resource{Title: title, URL: strings.TrimSpace(link.URL.Href)})
+				case child.Identifier == item.Identifier+cartridgeOverviewSuffix:
+					if err := readCartridgeFile(f, func(r io.Reader) error {
+						page, err := ioutil.ReadAll(r)
+						if start := bytes.Index(page, []byte(overviewStart)); start >= 0 {
+							page = page[start+len(overviewStart):]
+							if end := bytes.LastIndex(page, []byte(overviewEnd)); end >= 0 {
+								description = html.UnescapeString(string(page[:end]))
+							}
+						}
+						return err
+					}); err != nil {
+						return err
+					}
+				}
+			}
+			records = append(records, rec)
+			if err := read(item.Items, len(records)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := read(modules, 0); err != nil {
+		return nil, ErrBadRequest
+	}
+	return records, nil
+}
+
+// readCartridgeFile calls fn with the contents of a file of a cartridge.
+func readCartridgeFile(f *zip.File, fn func(r io.Reader) error) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return fn(rc)
+}
@@ -0,0 +1,30 @@
+package unitsvc
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/ory/hydra/oauth2"
+	"github.com/studiously/introspector"
+)
+
+// Subject returns the user that the request's access token was issued to.
+// It reports false if the token has not been introspected, as in internal jobs and tests.
+func Subject(ctx context.Context) (uuid.UUID, bool) {
+	subject, ok := ctx.Value(introspector.SubjectContextKey).(uuid.UUID)
+	return subject, ok
+}
+
+// ClientID returns the OAuth2 client that the request's access token was issued to,
+// or "" if the token has not been introspected.
+func ClientID(ctx context.Context) string {
+	switch i := ctx.Value(introspector.OAuth2IntrospectionContextKey).(type) {
+	case *oauth2.Introspection:
+		if i != nil {
+			return i.ClientID
+		}
+	case oauth2.Introspection:
+		return i.ClientID
+	}
+	return ""
+}
@@ -0,0 +1,38 @@
+package unitsvc
+
+import (
+	"github.com/google/uuid"
+	"github.com/studiously/unitsvc/models"
+)
+
+// unitAfter returns the unit that follows unitID in units, which are in display order, or the
+// first unit if unitID is uuid.Nil. It returns nil if there is no such unit.
+func unitAfter(units []*models.Unit, unitID uuid.UUID) *models.Unit {
+	if unitID == uuid.Nil {
+		if len(units) == 0 {
+			return nil
+		}
+		return units[0]
+	}
+	for i, u := range units {
+		if u.ID == unitID && i+1 < len(units) {
+			return units[i+1]
+		}
+	}
+	return nil
+}
+
+// replacementUnit returns the unit a class moves to when the unit it is on is deleted from
+// units, which are the units left in display order: the first one at or after the deleted
+// unit's place, or else the last one. It returns nil if no units are left.
+func replacementUnit(units []*models.Unit, deleted *models.Unit) *models.Unit {
+	for _, u := range units {
+		if u.DisplayOrder >= deleted.DisplayOrder {
+			return u
+		}
+	}
+	if len(units) == 0 {
+		return nil
+	}
+	return units[len(units)-1]
+}
@@ -6,31 +6,98 @@ import (
 	"strings"
 
 	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	kitot "github.com/go-kit/kit/tracing/opentracing"
 	httptransport "github.com/go-kit/kit/transport/http"
 	"github.com/google/uuid"
+	"github.com/opentracing/opentracing-go"
 	"github.com/studiously/introspector"
 	"github.com/studiously/unitsvc/models"
 )
 
 type Endpoints struct {
-	ListUnitsEndpoint  endpoint.Endpoint
-	GetUnitEndpoint    endpoint.Endpoint
-	CreateUnitEndpoint endpoint.Endpoint
-	RenameUnitEndpoint endpoint.Endpoint
-	DeleteUnitEndpoint endpoint.Endpoint
+	ListUnitsEndpoint      endpoint.Endpoint
+	ListMyUnitsEndpoint    endpoint.Endpoint
+	GetUnitEndpoint        endpoint.Endpoint
+	CreateUnitEndpoint     endpoint.Endpoint
+	RenameUnitEndpoint     endpoint.Endpoint
+	DeleteUnitEndpoint     endpoint.Endpoint
+	SearchUnitsEndpoint    endpoint.Endpoint
+	GetCurrentUnitEndpoint endpoint.Endpoint
+	SetCurrentUnitEndpoint endpoint.Endpoint
+	AdvanceUnitEndpoint    endpoint.Endpoint
+	MarkProgressEndpoint   endpoint.Endpoint
+	ClassProgressEndpoint  endpoint.Endpoint
+	UnitHistoryEndpoint    endpoint.Endpoint
+	ClassAuditEndpoint     endpoint.Endpoint
+	ListVersionsEndpoint   endpoint.Endpoint
+	GetVersionEndpoint     endpoint.Endpoint
+	RevertUnitEndpoint     endpoint.Endpoint
+	CreateWebhookEndpoint  endpoint.Endpoint
+	ListWebhooksEndpoint   endpoint.Endpoint
+	DeleteWebhookEndpoint  endpoint.Endpoint
+	ListDeliveriesEndpoint endpoint.Endpoint
+	ExportUnitsEndpoint    endpoint.Endpoint
+	ImportUnitsEndpoint    endpoint.Endpoint
+	LinkOneRosterEndpoint  endpoint.Endpoint
+	SyncOneRosterEndpoint  endpoint.Endpoint
 }
 
 func MakeServerEndpoints(s Service) Endpoints {
 	return Endpoints{
-		ListUnitsEndpoint:  MakeListUnitsEndpoint(s),
-		GetUnitEndpoint:    MakeGetUnitEndpoint(s),
-		CreateUnitEndpoint: MakeCreateUnitEndpoint(s),
-		RenameUnitEndpoint: MakeRenameUnitEndpoint(s),
-		DeleteUnitEndpoint: MakeDeleteUnitEndpoint(s),
+		ListUnitsEndpoint:      MakeListUnitsEndpoint(s),
+		ListMyUnitsEndpoint:    MakeListMyUnitsEndpoint(s),
+		GetUnitEndpoint:        MakeGetUnitEndpoint(s),
+		CreateUnitEndpoint:     MakeCreateUnitEndpoint(s),
+		RenameUnitEndpoint:     MakeRenameUnitEndpoint(s),
+		DeleteUnitEndpoint:     MakeDeleteUnitEndpoint(s),
+		SearchUnitsEndpoint:    MakeSearchUnitsEndpoint(s),
+		GetCurrentUnitEndpoint: MakeGetCurrentUnitEndpoint(s),
+		SetCurrentUnitEndpoint: MakeSetCurrentUnitEndpoint(s),
+		AdvanceUnitEndpoint:    MakeAdvanceUnitEndpoint(s),
+		MarkProgressEndpoint:   MakeMarkProgressEndpoint(s),
+		ClassProgressEndpoint:  MakeClassProgressEndpoint(s),
+		UnitHistoryEndpoint:    MakeUnitHistoryEndpoint(s),
+		ClassAuditEndpoint:     MakeClassAuditEndpoint(s),
+		ListVersionsEndpoint:   MakeListVersionsEndpoint(s),
+		GetVersionEndpoint:     MakeGetVersionEndpoint(s),
+		RevertUnitEndpoint:     MakeRevertUnitEndpoint(s),
+		CreateWebhookEndpoint:  MakeCreateWebhookEndpoint(s),
+		ListWebhooksEndpoint:   MakeListWebhooksEndpoint(s),
+		DeleteWebhookEndpoint:  MakeDeleteWebhookEndpoint(s),
+		ListDeliveriesEndpoint: MakeListDeliveriesEndpoint(s),
+		ExportUnitsEndpoint:    MakeExportUnitsEndpoint(s),
+		ImportUnitsEndpoint:    MakeImportUnitsEndpoint(s),
+		LinkOneRosterEndpoint:  MakeLinkOneRosterEndpoint(s),
+		SyncOneRosterEndpoint:  MakeSyncOneRosterEndpoint(s),
 	}
 }
 
-func MakeClientEndpoints(instance string) (Endpoints, error) {
+// ClientOption configures the Endpoints returned by MakeClientEndpoints.
+type ClientOption func(*clientConfig)
+
+type clientConfig struct {
+	tracer opentracing.Tracer
+	logger log.Logger
+}
+
+// ClientTracer makes each call a client span of tracer, propagated to the instance in the request
+// headers. Spans that cannot be propagated are reported to logger. Calls are not traced by default.
+func ClientTracer(tracer opentracing.Tracer, logger log.Logger) ClientOption {
+	return func(c *clientConfig) {
+		c.tracer = tracer
+		c.logger = logger
+	}
+}
+
+// MakeClientEndpoints returns Endpoints that call the unitsvc instance over HTTP.
+func MakeClientEndpoints(instance string, opts ...ClientOption) (Endpoints, error) {
+	config := clientConfig{tracer: opentracing.NoopTracer{}, logger: log.NewNopLogger()}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	tracer, logger := config.tracer, config.logger
+
 	if !strings.HasPrefix(instance, "http") {
 		instance = "http://" + instance
 	}
@@ -42,14 +109,36 @@ func MakeClientEndpoints(instance string) (Endpoints, error) {
 
 	options := []httptransport.ClientOption{
 		httptransport.ClientBefore(introspector.FromHTTPContext()),
+		httptransport.ClientBefore(kitot.ToHTTPRequest(tracer, logger)),
+		httptransport.ClientBefore(RequestIDToHTTPRequest()),
 	}
 
 	return Endpoints{
-		ListUnitsEndpoint:  httptransport.NewClient("GET", tgt, EncodeListUnitsRequest, DecodeListUnitsResponse, options...).Endpoint(),
-		GetUnitEndpoint:    httptransport.NewClient("GET", tgt, EncodeGetUnitRequest, DecodeGetUnitResponse, options...).Endpoint(),
-		CreateUnitEndpoint: httptransport.NewClient("POST", tgt, EncodeCreateUnitRequest, DecodeCreateUnitResponse, options...).Endpoint(),
-		RenameUnitEndpoint: httptransport.NewClient("PATCH", tgt, EncodeRenameUnitRequest, DecodeRenameUnitResponse, options...).Endpoint(),
-		DeleteUnitEndpoint: httptransport.NewClient("DELETE", tgt, EncodeDeleteUnitRequest, DecodeDeleteUnitResponse, options...).Endpoint(),
+		ListUnitsEndpoint:      kitot.TraceClient(tracer, "ListUnits")(httptransport.NewClient("GET", tgt, EncodeListUnitsRequest, DecodeListUnitsResponse, options...).Endpoint()),
+		ListMyUnitsEndpoint:    kitot.TraceClient(tracer, "ListMyUnits")(httptransport.NewClient("GET", tgt, EncodeListMyUnitsRequest, DecodeListMyUnitsResponse, options...).Endpoint()),
+		GetUnitEndpoint:        kitot.TraceClient(tracer, "GetUnit")(httptransport.NewClient("GET", tgt, EncodeGetUnitRequest, DecodeGetUnitResponse, options...).Endpoint()),
+		CreateUnitEndpoint:     kitot.TraceClient(tracer, "CreateUnit")(httptransport.NewClient("POST", tgt, EncodeCreateUnitRequest, DecodeCreateUnitResponse, options...).Endpoint()),
+		RenameUnitEndpoint:     kitot.TraceClient(tracer, "RenameUnit")(httptransport.NewClient("PATCH", tgt, EncodeRenameUnitRequest, DecodeRenameUnitResponse, options...).Endpoint()),
+		DeleteUnitEndpoint:     kitot.TraceClient(tracer, "DeleteUnit")(httptransport.NewClient("DELETE", tgt, EncodeDeleteUnitRequest, DecodeDeleteUnitResponse, options...).Endpoint()),
+		SearchUnitsEndpoint:    kitot.TraceClient(tracer, "SearchUnits")(httptransport.NewClient("GET", tgt, EncodeSearchUnitsRequest, DecodeSearchUnitsResponse, options...).Endpoint()),
+		GetCurrentUnitEndpoint: kitot.TraceClient(tracer, "GetCurrentUnit")(httptransport.NewClient("GET", tgt, EncodeGetCurrentUnitRequest, DecodeCurrentUnitResponse, options...).Endpoint()),
+		SetCurrentUnitEndpoint: kitot.TraceClient(tracer, "SetCurrentUnit")(httptransport.NewClient("PUT", tgt, EncodeSetCurrentUnitRequest, DecodeSetCurrentUnitResponse, options...).Endpoint()),
+		AdvanceUnitEndpoint:    kitot.TraceClient(tracer, "AdvanceUnit")(httptransport.NewClient("POST", tgt, EncodeAdvanceUnitRequest, DecodeCurrentUnitResponse, options...).Endpoint()),
+		MarkProgressEndpoint:   kitot.TraceClient(tracer, "MarkUnitProgress")(httptransport.NewClient("PUT", tgt, EncodeMarkProgressRequest, DecodeMarkProgressResponse, options...).Endpoint()),
+		ClassProgressEndpoint:  kitot.TraceClient(tracer, "GetClassProgress")(httptransport.NewClient("GET", tgt, EncodeClassProgressRequest, DecodeClassProgressResponse, options...).Endpoint()),
+		UnitHistoryEndpoint:    kitot.TraceClient(tracer, "UnitHistory")(httptransport.NewClient("GET", tgt, EncodeUnitHistoryRequest, DecodeUnitHistoryResponse, options...).Endpoint()),
+		ClassAuditEndpoint:     kitot.TraceClient(tracer, "ClassAudit")(httptransport.NewClient("GET", tgt, EncodeClassAuditRequest, DecodeClassAuditResponse, options...).Endpoint()),
+		ListVersionsEndpoint:   kitot.TraceClient(tracer, "ListUnitVersions")(httptransport.NewClient("GET", tgt, EncodeListVersionsRequest, DecodeListVersionsResponse, options...).Endpoint()),
+		GetVersionEndpoint:     kitot.TraceClient(tracer, "GetUnitVersion")(httptransport.NewClient("GET", tgt, EncodeGetVersionRequest, DecodeGetVersionResponse, options...).Endpoint()),
+		RevertUnitEndpoint:     kitot.TraceClient(tracer, "RevertUnit")(httptransport.NewClient("POST", tgt, EncodeRevertUnitRequest, DecodeRevertUnitResponse, options...).Endpoint()),
+		CreateWebhookEndpoint:  kitot.TraceClient(tracer, "CreateWebhook")(httptransport.NewClient("POST", tgt, EncodeCreateWebhookRequest, DecodeCreateWebhookResponse, options...).Endpoint()),
+		ListWebhooksEndpoint:   kitot.TraceClient(tracer, "ListWebhooks")(httptransport.NewClient("GET", tgt, EncodeListWebhooksRequest, DecodeListWebhooksResponse, options...).Endpoint()),
+		DeleteWebhookEndpoint:  kitot.TraceClient(tracer, "DeleteWebhook")(httptransport.NewClient("DELETE", tgt, EncodeDeleteWebhookRequest, DecodeDeleteWebhookResponse, options...).Endpoint()),
+		ListDeliveriesEndpoint: kitot.TraceClient(tracer, "ListDeliveries")(httptransport.NewClient("GET", tgt, EncodeListDeliveriesRequest, DecodeListDeliveriesResponse, options...).Endpoint()),
+		ExportUnitsEndpoint:    kitot.TraceClient(tracer, "ExportUnits")(httptransport.NewClient("GET", tgt, EncodeExportUnitsRequest, DecodeExportUnitsResponse, options...).Endpoint()),
+		ImportUnitsEndpoint:    kitot.TraceClient(tracer, "ImportUnits")(httptransport.NewClient("POST", tgt, EncodeImportUnitsRequest, DecodeImportUnitsResponse, options...).Endpoint()),
+		LinkOneRosterEndpoint:  kitot.TraceClient(tracer, "LinkOneRosterClass")(httptransport.NewClient("PUT", tgt, EncodeLinkOneRosterRequest, DecodeLinkOneRosterResponse, options...).Endpoint()),
+		SyncOneRosterEndpoint:  kitot.TraceClient(tracer, "SyncOneRoster")(httptransport.NewClient("POST", tgt, EncodeSyncOneRosterRequest, DecodeSyncOneRosterResponse, options...).Endpoint()),
 	}, nil
 }
 
@@ -63,6 +152,16 @@ func (e Endpoints) ListUnits(ctx context.Context, classID uuid.UUID) ([]uuid.UUI
 	return resp.Units, resp.Error
 }
 
+func (e Endpoints) ListMyUnits(ctx context.Context, after uuid.UUID, limit int, filter UnitFilter) (*ClassUnitsPage, error) {
+	request := listMyUnitsRequest{After: after, Limit: limit, Filter: filter}
+	response, err := e.ListMyUnitsEndpoint(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	resp := response.(listMyUnitsResponse)
+	return resp.ClassUnitsPage, resp.Error
+}
+
 func (e Endpoints) GetUnit(ctx context.Context, unitID uuid.UUID) (*models.Unit, error) {
 	request := getUnitRequest{UnitID: unitID}
 	response, err := e.GetUnitEndpoint(ctx, request)
@@ -103,6 +202,196 @@ func (e Endpoints) DeleteUnit(ctx context.Context, unitID uuid.UUID) error {
 	return resp.Error
 }
 
+func (e Endpoints) SearchUnits(ctx context.Context, query string, filter SearchFilter) ([]*models.UnitMatch, error) {
+	request := searchUnitsRequest{Query: query, Filter: filter}
+	response, err := e.SearchUnitsEndpoint(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	resp := response.(searchUnitsResponse)
+	return resp.Matches, resp.Error
+}
+
+func (e Endpoints) GetCurrentUnit(ctx context.Context, classID uuid.UUID) (*models.Unit, error) {
+	request := getCurrentUnitRequest{ClassID: classID}
+	response, err := e.GetCurrentUnitEndpoint(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	resp := response.(currentUnitResponse)
+	return resp.Unit, resp.Error
+}
+
+func (e Endpoints) SetCurrentUnit(ctx context.Context, classID, unitID uuid.UUID) error {
+	request := setCurrentUnitRequest{ClassID: classID, UnitID: unitID}
+	response, err := e.SetCurrentUnitEndpoint(ctx, request)
+	if err != nil {
+		return err
+	}
+	resp := response.(setCurrentUnitResponse)
+	return resp.Error
+}
+
+func (e Endpoints) AdvanceUnit(ctx context.Context, classID uuid.UUID) (*models.Unit, error) {
+	request := advanceUnitRequest{ClassID: classID}
+	response, err := e.AdvanceUnitEndpoint(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	resp := response.(currentUnitResponse)
+	return resp.Unit, resp.Error
+}
+
+func (e Endpoints) MarkUnitProgress(ctx context.Context, unitID uuid.UUID, status string) (*models.UnitProgress, error) {
+	request := markProgressRequest{UnitID: unitID, Status: status}
+	response, err := e.MarkProgressEndpoint(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	resp := response.(markProgressResponse)
+	return resp.Progress, resp.Error
+}
+
+func (e Endpoints) GetClassProgress(ctx context.Context, classID uuid.UUID) (*ClassProgress, error) {
+	request := classProgressRequest{ClassID: classID}
+	response, err := e.ClassProgressEndpoint(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	resp := response.(classProgressResponse)
+	return resp.ClassProgress, resp.Error
+}
+
+func (e Endpoints) UnitHistory(ctx context.Context, unitID uuid.UUID) ([]*models.AuditEntry, error) {
+	request := unitHistoryRequest{UnitID: unitID}
+	response, err := e.UnitHistoryEndpoint(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	resp := response.(auditResponse)
+	return resp.Entries, resp.Error
+}
+
+func (e Endpoints) ClassAudit(ctx context.Context, classID uuid.UUID) ([]*models.AuditEntry, error) {
+	request := classAuditRequest{ClassID: classID}
+	response, err := e.ClassAuditEndpoint(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	resp := response.(auditResponse)
+	return resp.Entries, resp.Error
+}
+
+func (e Endpoints) ListUnitVersions(ctx context.Context, unitID uuid.UUID) ([]*models.UnitVersion, error) {
+	request := listVersionsRequest{UnitID: unitID}
+	response, err := e.ListVersionsEndpoint(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	resp := response.(listVersionsResponse)
+	return resp.Versions, resp.Error
+}
+
+func (e Endpoints) GetUnitVersion(ctx context.Context, unitID uuid.UUID, version int) (*models.UnitVersion, error) {
+	request := getVersionRequest{UnitID: unitID, Version: version}
+	response, err := e.GetVersionEndpoint(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	resp := response.(getVersionResponse)
+	return resp.Version, resp.Error
+}
+
+func (e Endpoints) RevertUnit(ctx context.Context, unitID uuid.UUID, version int) error {
+	request := revertUnitRequest{UnitID: unitID, Version: version}
+	response, err := e.RevertUnitEndpoint(ctx, request)
+	if err != nil {
+		return err
+	}
+	resp := response.(revertUnitResponse)
+	return resp.Error
+}
+
+func (e Endpoints) CreateWebhook(ctx context.Context, classID uuid.UUID, url, secret string, events []string) (*models.Webhook, error) {
+	request := createWebhookRequest{ClassID: classID, URL: url, Secret: secret, Events: events}
+	response, err := e.CreateWebhookEndpoint(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	resp := response.(createWebhookResponse)
+	return resp.Webhook, resp.Error
+}
+
+func (e Endpoints) ListWebhooks(ctx context.Context, classID uuid.UUID) ([]*models.Webhook, error) {
+	request := listWebhooksRequest{ClassID: classID}
+	response, err := e.ListWebhooksEndpoint(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	resp := response.(listWebhooksResponse)
+	return resp.Webhooks, resp.Error
+}
+
+func (e Endpoints) DeleteWebhook(ctx context.Context, webhookID uuid.UUID) error {
+	request := deleteWebhookRequest{WebhookID: webhookID}
+	response, err := e.DeleteWebhookEndpoint(ctx, request)
+	if err != nil {
+		return err
+	}
+	resp := response.(deleteWebhookResponse)
+	return resp.Error
+}
+
+func (e Endpoints) ListDeliveries(ctx context.Context, webhookID uuid.UUID, status string) ([]*models.WebhookDelivery, error) {
+	request := listDeliveriesRequest{WebhookID: webhookID, Status: status}
+	response, err := e.ListDeliveriesEndpoint(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	resp := response.(listDeliveriesResponse)
+	return resp.Deliveries, resp.Error
+}
+
+func (e Endpoints) ExportUnits(ctx context.Context, classID uuid.UUID) ([]*models.Unit, error) {
+	request := exportUnitsRequest{ClassID: classID, Format: FormatJSON}
+	response, err := e.ExportUnitsEndpoint(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	resp := response.(exportUnitsResponse)
+	return resp.Units, resp.Error
+}
+
+func (e Endpoints) ImportUnits(ctx context.Context, classID uuid.UUID, rows []ImportRow, dryRun bool) (*ImportResult, error) {
+	request := importUnitsRequest{ClassID: classID, Rows: rows, DryRun: dryRun}
+	response, err := e.ImportUnitsEndpoint(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	resp := response.(importUnitsResponse)
+	return resp.ImportResult, resp.Error
+}
+
+func (e Endpoints) LinkOneRosterClass(ctx context.Context, sourcedID string, classID uuid.UUID) error {
+	request := linkOneRosterRequest{SourcedID: sourcedID, ClassID: classID}
+	response, err := e.LinkOneRosterEndpoint(ctx, request)
+	if err != nil {
+		return err
+	}
+	resp := response.(linkOneRosterResponse)
+	return resp.Error
+}
+
+func (e Endpoints) SyncOneRoster(ctx context.Context, bundle *RosterBundle, dryRun, overwrite bool) (*SyncReport, error) {
+	request := syncOneRosterRequest{Bundle: bundle, DryRun: dryRun, Overwrite: overwrite}
+	response, err := e.SyncOneRosterEndpoint(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	resp := response.(syncOneRosterResponse)
+	return resp.SyncReport, resp.Error
+}
+
 func MakeListUnitsEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		req := request.(listUnitsRequest)
@@ -124,6 +413,29 @@ func (r listUnitsResponse) error() error {
 	return r.Error
 }
 
+func MakeListMyUnitsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(listMyUnitsRequest)
+		page, e := s.ListMyUnits(ctx, req.After, req.Limit, req.Filter)
+		return listMyUnitsResponse{page, e}, nil
+	}
+}
+
+type listMyUnitsRequest struct {
+	After  uuid.UUID
+	Limit  int
+	Filter UnitFilter
+}
+
+type listMyUnitsResponse struct {
+	*ClassUnitsPage
+	Error error `json:"error,omitempty"`
+}
+
+func (r listMyUnitsResponse) error() error {
+	return r.Error
+}
+
 func MakeGetUnitEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		req := request.(getUnitRequest)
@@ -206,3 +518,400 @@ type deleteUnitResponse struct {
 func (r deleteUnitResponse) error() error {
 	return r.Error
 }
+
+func MakeSearchUnitsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(searchUnitsRequest)
+		matches, e := s.SearchUnits(ctx, req.Query, req.Filter)
+		return searchUnitsResponse{matches, e}, nil
+	}
+}
+
+type searchUnitsRequest struct {
+	Query  string
+	Filter SearchFilter
+}
+
+type searchUnitsResponse struct {
+	Matches []*models.UnitMatch `json:"matches,omitempty"`
+	Error   error               `json:"error,omitempty"`
+}
+
+func (r searchUnitsResponse) error() error {
+	return r.Error
+}
+
+func MakeGetCurrentUnitEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(getCurrentUnitRequest)
+		unit, e := s.GetCurrentUnit(ctx, req.ClassID)
+		return currentUnitResponse{unit, e}, nil
+	}
+}
+
+type getCurrentUnitRequest struct {
+	ClassID uuid.UUID `json:"class_id"`
+}
+
+func MakeSetCurrentUnitEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(setCurrentUnitRequest)
+		e := s.SetCurrentUnit(ctx, req.ClassID, req.UnitID)
+		return setCurrentUnitResponse{e}, nil
+	}
+}
+
+type setCurrentUnitRequest struct {
+	ClassID uuid.UUID `json:"-"`
+	UnitID  uuid.UUID `json:"unit_id"`
+}
+
+type setCurrentUnitResponse struct {
+	Error error `json:"error,omitempty"`
+}
+
+func (r setCurrentUnitResponse) error() error {
+	return r.Error
+}
+
+func MakeAdvanceUnitEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(advanceUnitRequest)
+		unit, e := s.AdvanceUnit(ctx, req.ClassID)
+		return currentUnitResponse{unit, e}, nil
+	}
+}
+
+type advanceUnitRequest struct {
+	ClassID uuid.UUID `json:"class_id"`
+}
+
+// currentUnitResponse is shared by GetCurrentUnit and AdvanceUnit.
+type currentUnitResponse struct {
+	Unit  *models.Unit `json:"unit,omitempty"`
+	Error error        `json:"error,omitempty"`
+}
+
+func (r currentUnitResponse) error() error {
+	return r.Error
+}
+
+func MakeMarkProgressEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(markProgressRequest)
+		progress, e := s.MarkUnitProgress(ctx, req.UnitID, req.Status)
+		return markProgressResponse{progress, e}, nil
+	}
+}
+
+type markProgressRequest struct {
+	UnitID uuid.UUID `json:"unit_id"`
+	Status string    `json:"status"`
+}
+
+type markProgressResponse struct {
+	Progress *models.UnitProgress `json:"progress,omitempty"`
+	Error    error                `json:"error,omitempty"`
+}
+
+func (r markProgressResponse) error() error {
+	return r.Error
+}
+
+func MakeClassProgressEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(classProgressRequest)
+		progress, e := s.GetClassProgress(ctx, req.ClassID)
+		return classProgressResponse{progress, e}, nil
+	}
+}
+
+type classProgressRequest struct {
+	ClassID uuid.UUID `json:"class_id"`
+}
+
+type classProgressResponse struct {
+	*ClassProgress
+	Error error `json:"error,omitempty"`
+}
+
+func (r classProgressResponse) error() error {
+	return r.Error
+}
+
+func MakeUnitHistoryEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(unitHistoryRequest)
+		entries, e := s.UnitHistory(ctx, req.UnitID)
+		return auditResponse{entries, e}, nil
+	}
+}
+
+type unitHistoryRequest struct {
+	UnitID uuid.UUID `json:"unit_id"`
+}
+
+func MakeClassAuditEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(classAuditRequest)
+		entries, e := s.ClassAudit(ctx, req.ClassID)
+		return auditResponse{entries, e}, nil
+	}
+}
+
+type classAuditRequest struct {
+	ClassID uuid.UUID `json:"class_id"`
+}
+
+// auditResponse is shared by UnitHistory and ClassAudit.
+type auditResponse struct {
+	Entries []*models.AuditEntry `json:"entries,omitempty"`
+	Error   error                `json:"error,omitempty"`
+}
+
+func (r auditResponse) error() error {
+	return r.Error
+}
+
+func MakeListVersionsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(listVersionsRequest)
+		versions, e := s.ListUnitVersions(ctx, req.UnitID)
+		return listVersionsResponse{versions, e}, nil
+	}
+}
+
+type listVersionsRequest struct {
+	UnitID uuid.UUID `json:"unit_id"`
+}
+
+type listVersionsResponse struct {
+	Versions []*models.UnitVersion `json:"versions,omitempty"`
+	Error    error                 `json:"error,omitempty"`
+}
+
+func (r listVersionsResponse) error() error {
+	return r.Error
+}
+
+func MakeGetVersionEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(getVersionRequest)
+		version, e := s.GetUnitVersion(ctx, req.UnitID, req.Version)
+		return getVersionResponse{version, e}, nil
+	}
+}
+
+type getVersionRequest struct {
+	UnitID  uuid.UUID `json:"unit_id"`
+	Version int       `json:"version"`
+}
+
+type getVersionResponse struct {
+	Version *models.UnitVersion `json:"version,omitempty"`
+	Error   error               `json:"error,omitempty"`
+}
+
+func (r getVersionResponse) error() error {
+	return r.Error
+}
+
+func MakeRevertUnitEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(revertUnitRequest)
+		e := s.RevertUnit(ctx, req.UnitID, req.Version)
+		return revertUnitResponse{e}, nil
+	}
+}
+
+type revertUnitRequest struct {
+	UnitID  uuid.UUID `json:"unit_id"`
+	Version int       `json:"version"`
+}
+
+type revertUnitResponse struct {
+	Error error `json:"error,omitempty"`
+}
+
+func (r revertUnitResponse) error() error {
+	return r.Error
+}
+
+func MakeCreateWebhookEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(createWebhookRequest)
+		hook, e := s.CreateWebhook(ctx, req.ClassID, req.URL, req.Secret, req.Events)
+		return createWebhookResponse{hook, e}, nil
+	}
+}
+
+type createWebhookRequest struct {
+	ClassID uuid.UUID `json:"class_id"`
+	URL     string    `json:"url"`
+	Secret  string    `json:"secret"`
+	Events  []string  `json:"events,omitempty"`
+}
+
+type createWebhookResponse struct {
+	Webhook *models.Webhook `json:"webhook,omitempty"`
+	Error   error           `json:"error,omitempty"`
+}
+
+func (r createWebhookResponse) error() error {
+	return r.Error
+}
+
+func MakeListWebhooksEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(listWebhooksRequest)
+		hooks, e := s.ListWebhooks(ctx, req.ClassID)
+		return listWebhooksResponse{hooks, e}, nil
+	}
+}
+
+type listWebhooksRequest struct {
+	ClassID uuid.UUID `json:"class_id"`
+}
+
+type listWebhooksResponse struct {
+	Webhooks []*models.Webhook `json:"webhooks,omitempty"`
+	Error    error             `json:"error,omitempty"`
+}
+
+func (r listWebhooksResponse) error() error {
+	return r.Error
+}
+
+func MakeDeleteWebhookEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(deleteWebhookRequest)
+		e := s.DeleteWebhook(ctx, req.WebhookID)
+		return deleteWebhookResponse{e}, nil
+	}
+}
+
+type deleteWebhookRequest struct {
+	WebhookID uuid.UUID `json:"webhook_id"`
+}
+
+type deleteWebhookResponse struct {
+	Error error `json:"error,omitempty"`
+}
+
+func (r deleteWebhookResponse) error() error {
+	return r.Error
+}
+
+func MakeListDeliveriesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(listDeliveriesRequest)
+		deliveries, e := s.ListDeliveries(ctx, req.WebhookID, req.Status)
+		return listDeliveriesResponse{deliveries, e}, nil
+	}
+}
+
+type listDeliveriesRequest struct {
+	WebhookID uuid.UUID `json:"webhook_id"`
+	Status    string    `json:"status,omitempty"`
+}
+
+type listDeliveriesResponse struct {
+	Deliveries []*models.WebhookDelivery `json:"deliveries,omitempty"`
+	Error      error                     `json:"error,omitempty"`
+}
+
+func (r listDeliveriesResponse) error() error {
+	return r.Error
+}
+
+func MakeExportUnitsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(exportUnitsRequest)
+		units, e := s.ExportUnits(ctx, req.ClassID)
+		return exportUnitsResponse{req.ClassID, req.Format, units, e}, nil
+	}
+}
+
+type exportUnitsRequest struct {
+	ClassID uuid.UUID
+	Format  string
+}
+
+// exportUnitsResponse is written by EncodeExportUnitsResponse in Format rather than as JSON.
+type exportUnitsResponse struct {
+	ClassID uuid.UUID
+	Format  string
+	Units   []*models.Unit
+	Error   error
+}
+
+func (r exportUnitsResponse) error() error {
+	return r.Error
+}
+
+func MakeImportUnitsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(importUnitsRequest)
+		result, e := s.ImportUnits(ctx, req.ClassID, req.Rows, req.DryRun)
+		return importUnitsResponse{result, e}, nil
+	}
+}
+
+type importUnitsRequest struct {
+	ClassID uuid.UUID
+	Rows    []ImportRow
+	DryRun  bool
+}
+
+type importUnitsResponse struct {
+	*ImportResult
+	Error error `json:"error,omitempty"`
+}
+
+func (r importUnitsResponse) error() error {
+	return r.Error
+}
+
+func MakeLinkOneRosterEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(linkOneRosterRequest)
+		e := s.LinkOneRosterClass(ctx, req.SourcedID, req.ClassID)
+		return linkOneRosterResponse{e}, nil
+	}
+}
+
+type linkOneRosterRequest struct {
+	SourcedID string    `json:"-"`
+	ClassID   uuid.UUID `json:"class_id"`
+}
+
+type linkOneRosterResponse struct {
+	Error error `json:"error,omitempty"`
+}
+
+func (r linkOneRosterResponse) error() error {
+	return r.Error
+}
+
+func MakeSyncOneRosterEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(syncOneRosterRequest)
+		report, e := s.SyncOneRoster(ctx, req.Bundle, req.DryRun, req.Overwrite)
+		return syncOneRosterResponse{report, e}, nil
+	}
+}
+
+type syncOneRosterRequest struct {
+	Bundle    *RosterBundle
+	DryRun    bool
+	Overwrite bool
+}
+
+type syncOneRosterResponse struct {
+	*SyncReport
+	Error error `json:"error,omitempty"`
+}
+
+func (r syncOneRosterResponse) error() error {
+	return r.Error
+}
@@ -0,0 +1,228 @@
+package unitsvc
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/studiously/unitsvc/models"
+)
+
+// SubjUnitChanges carries a JSON FeedEvent for every change, so that each instance's Feed
+// receives the changes made through all instances.
+const SubjUnitChanges = "units.changes"
+
+// Feed event types.
+const (
+	FeedCreated = "created"
+	FeedUpdated = "updated"
+	FeedDeleted = "deleted"
+	// FeedReordered is an update that moved a unit to another place in its class or to another
+	// parent, possibly along with other changes. Subscribers that keep the units in order should re-sort them.
+	FeedReordered = "reordered"
+	// FeedReset tells a resuming subscriber that it missed events and must reload the class.
+	FeedReset = "reset"
+)
+
+// FeedEvent is a change to a unit, pushed to the subscribers of its class.
+type FeedEvent struct {
+	// ID is assigned by the Feed that delivers the event. Subscribers resume after it.
+	ID        string       `json:"id"`
+	Type      string       `json:"type"`
+	ClassID   uuid.UUID    `json:"class_id"`
+	UnitID    uuid.UUID    `json:"unit_id"`
+	Unit      *models.Unit `json:"unit,omitempty"` // nil for FeedDeleted and FeedReset
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+// FeedEventFromChange returns the event for a change reported to an OnChange callback.
+func FeedEventFromChange(change *models.AuditEntry) FeedEvent {
+	e := FeedEvent{
+		Type:      FeedUpdated,
+		ClassID:   change.ClassID,
+		UnitID:    change.UnitID,
+		Unit:      change.After,
+		RequestID: change.RequestID,
+	}
+	switch change.Action {
+	case models.AuditCreate:
+		e.Type = FeedCreated
+	case models.AuditDelete:
+		e.Type = FeedDeleted
+	default:
+		if change.Before != nil && change.After != nil &&
+			(change.Before.DisplayOrder != change.After.DisplayOrder || !sameParent(change.Before, change.After)) {
+			e.Type = FeedReordered
+		}
+	}
+	return e
+}
+
+// PublishChanges returns an OnChange callback that publishes each change to SubjUnitChanges.
+// Errors are ignored: subscribers that miss an event are no worse off than before streaming.
+func PublishChanges(p Publisher) func(ctx context.Context, change *models.AuditEntry) {
+	return func(_ context.Context, change *models.AuditEntry) {
+		data, err := json.Marshal(FeedEventFromChange(change))
+		if err != nil {
+			return
+		}
+		p.Publish(SubjUnitChanges, data)
+	}
+}
+
+// feedBuffer is how many events a subscriber may fall behind before it is dropped.
+const feedBuffer = 64
+
+// Feed fans out unit changes to subscribers by class. It keeps the newest events so that
+// subscribers can resume after reconnecting. It is safe for concurrent use.
+type Feed struct {
+	mu      sync.Mutex
+	epoch   string // distinguishes this Feed's event IDs from other instances'
+	seq     uint64 // of the newest event
+	backlog []FeedEvent
+	limit   int
+	subs    map[*FeedSubscription]bool
+	closed  bool
+}
+
+// NewFeed returns a Feed that keeps the newest backlog events for resumption.
+func NewFeed(backlog int) *Feed {
+	return &Feed{
+		epoch: uuid.New().String()[:8],
+		limit: backlog,
+		subs:  make(map[*FeedSubscription]bool),
+	}
+}
+
+// OnChange publishes a change. It can be passed to the OnChange option directly when
+// there is a single instance.
+func (f *Feed) OnChange(_ context.Context, change *models.AuditEntry) {
+	f.Publish(FeedEventFromChange(change))
+}
+
+// Receive publishes a JSON FeedEvent received from SubjUnitChanges.
+func (f *Feed) Receive(data []byte) error {
+	var e FeedEvent
+	if err := json.Unmarshal(data, &e); err != nil {
+		return err
+	}
+	f.Publish(e)
+	return nil
+}
+
+// Publish assigns e the next ID and delivers it to the subscribers of its class.
+// Subscribers that have fallen too far behind are dropped.
+func (f *Feed) Publish(e FeedEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.seq++
+	e.ID = f.id(f.seq)
+	if f.limit > 0 {
+		if len(f.backlog) == f.limit {
+			copy(f.backlog, f.backlog[1:])
+			f.backlog = f.backlog[:f.limit-1]
+		}
+		f.backlog = append(f.backlog, e)
+	}
+	for sub := range f.subs {
+		if sub.classID != e.ClassID {
+			continue
+		}
+		select {
+		case sub.c <- e:
+		default:
+			f.drop(sub)
+		}
+	}
+}
+
+// Subscribe returns a subscription to the events of a class. If lastEventID is not empty,
+// the events after it are delivered first, or a FeedReset event if they are no longer kept.
+func (f *Feed) Subscribe(classID uuid.UUID, lastEventID string) *FeedSubscription {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var replay []FeedEvent
+	if lastEventID != "" {
+		missed, ok := f.since(lastEventID)
+		if !ok {
+			missed = []FeedEvent{{ID: f.id(f.seq), Type: FeedReset, ClassID: classID}}
+		}
+		for _, e := range missed {
+			if e.ClassID == classID {
+				replay = append(replay, e)
+			}
+		}
+	}
+	c := make(chan FeedEvent, len(replay)+feedBuffer)
+	for _, e := range replay {
+		c <- e
+	}
+	sub := &FeedSubscription{C: c, c: c, feed: f, classID: classID}
+	if f.closed {
+		close(c)
+		return sub
+	}
+	f.subs[sub] = true
+	return sub
+}
+
+// Close ends every subscription, and those made later, so that streams finish on shutdown.
+func (f *Feed) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	for sub := range f.subs {
+		f.drop(sub)
+	}
+}
+
+func (f *Feed) id(seq uint64) string {
+	return f.epoch + "-" + strconv.FormatUint(seq, 10)
+}
+
+// since returns the kept events after the one with the given ID. It returns false if the
+// ID was not issued by f or events after it are no longer kept.
+func (f *Feed) since(id string) ([]FeedEvent, bool) {
+	i := strings.LastIndex(id, "-")
+	if i < 0 || id[:i] != f.epoch {
+		return nil, false
+	}
+	seq, err := strconv.ParseUint(id[i+1:], 10, 64)
+	if err != nil || seq > f.seq {
+		return nil, false
+	}
+	missed := f.seq - seq
+	if missed > uint64(len(f.backlog)) {
+		return nil, false
+	}
+	return f.backlog[uint64(len(f.backlog))-missed:], true
+}
+
+// drop ends sub. f.mu must be held.
+func (f *Feed) drop(sub *FeedSubscription) {
+	if f.subs[sub] {
+		delete(f.subs, sub)
+		close(sub.c)
+	}
+}
+
+// FeedSubscription receives the events of one class.
+type FeedSubscription struct {
+	// C delivers events in order. It is closed when the subscription ends, including when
+	// the subscriber falls too far behind; it can then resume after the last event received.
+	C <-chan FeedEvent
+
+	c       chan FeedEvent
+	feed    *Feed
+	classID uuid.UUID
+}
+
+// Close ends the subscription.
+func (s *FeedSubscription) Close() {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+	s.feed.drop(s)
+}
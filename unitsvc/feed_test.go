@@ -0,0 +1,165 @@
+package unitsvc_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/studiously/classsvc/models"
+	unitsvcmodels "github.com/studiously/unitsvc/models"
+	"github.com/studiously/unitsvc/unitsvc"
+	unittesting "github.com/studiously/unitsvc/unitsvc/testing"
+)
+
+// receive returns the next event from sub, failing t if none arrives.
+func receive(t *testing.T, sub *unitsvc.FeedSubscription) unitsvc.FeedEvent {
+	select {
+	case e, ok := <-sub.C:
+		if !ok {
+			t.Fatalf("subscription ended, want an event")
+		}
+		return e
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for an event")
+	}
+	panic("unreachable")
+}
+
+func TestFeedByClass(t *testing.T) {
+	feed := unitsvc.NewFeed(10)
+	classID, other := uuid.New(), uuid.New()
+	sub := feed.Subscribe(classID, "")
+	defer sub.Close()
+
+	unitID := uuid.New()
+	feed.Publish(unitsvc.FeedEvent{Type: unitsvc.FeedCreated, ClassID: other, UnitID: uuid.New()})
+	feed.Publish(unitsvc.FeedEvent{Type: unitsvc.FeedCreated, ClassID: classID, UnitID: unitID})
+	e := receive(t, sub)
+	if e.Type != unitsvc.FeedCreated || e.UnitID != unitID || e.ID == "" {
+		t.Fatalf("got event %+v, want creation of %v with an ID", e, unitID)
+	}
+	select {
+	case e := <-sub.C:
+		t.Fatalf("got event %+v of another class", e)
+	default:
+	}
+}
+
+func TestFeedResume(t *testing.T) {
+	feed := unitsvc.NewFeed(3)
+	classID := uuid.New()
+	first := feed.Subscribe(classID, "")
+	for i := 0; i < 3; i++ {
+		feed.Publish(unitsvc.FeedEvent{Type: unitsvc.FeedUpdated, ClassID: classID, UnitID: uuid.New()})
+	}
+	seen := receive(t, first)
+	first.Close()
+
+	resumed := feed.Subscribe(classID, seen.ID)
+	for i := 0; i < 2; i++ {
+		if e := receive(t, resumed); e.Type != unitsvc.FeedUpdated {
+			t.Fatalf("got %s event on resume, want %s", e.Type, unitsvc.FeedUpdated)
+		}
+	}
+	resumed.Close()
+
+	// The first event has fallen out of the backlog.
+	feed.Publish(unitsvc.FeedEvent{Type: unitsvc.FeedUpdated, ClassID: classID, UnitID: uuid.New()})
+	feed.Publish(unitsvc.FeedEvent{Type: unitsvc.FeedUpdated, ClassID: classID, UnitID: uuid.New()})
+	for _, id := range []string{seen.ID, "another-instance-1", "nonsense"} {
+		sub := feed.Subscribe(classID, id)
+		e := receive(t, sub)
+		sub.Close()
+		if e.Type != unitsvc.FeedReset || e.ClassID != classID || e.ID == "" {
+			t.Errorf("resuming after %q: got event %+v, want a reset of the class", id, e)
+		}
+	}
+}
+
+func TestFeedDropsSlowSubscribers(t *testing.T) {
+	feed := unitsvc.NewFeed(0)
+	classID := uuid.New()
+	sub := feed.Subscribe(classID, "")
+	for i := 0; i < 1000; i++ {
+		feed.Publish(unitsvc.FeedEvent{Type: unitsvc.FeedUpdated, ClassID: classID})
+	}
+	n := 0
+	for range sub.C {
+		n++
+	}
+	if n == 0 || n == 1000 {
+		t.Fatalf("slow subscriber received %d of 1000 events, want to be dropped part way", n)
+	}
+	sub.Close()
+}
+
+func TestFeedClose(t *testing.T) {
+	feed := unitsvc.NewFeed(10)
+	sub := feed.Subscribe(uuid.New(), "")
+	feed.Close()
+	if _, ok := <-sub.C; ok {
+		t.Fatalf("got event after Close, want subscription ended")
+	}
+	sub.Close()
+	if _, ok := <-feed.Subscribe(uuid.New(), "").C; ok {
+		t.Fatalf("got event from subscription after Close, want it ended")
+	}
+}
+
+// loopback delivers published changes straight to a Feed, as NATS would.
+type loopback struct {
+	feed *unitsvc.Feed
+}
+
+func (l loopback) Publish(subj string, data []byte) error {
+	if subj != unitsvc.SubjUnitChanges {
+		return nil
+	}
+	return l.feed.Receive(data)
+}
+
+func TestPublishChanges(t *testing.T) {
+	feed := unitsvc.NewFeed(10)
+	cs := unittesting.NewClassService()
+	teacher, classID := uuid.New(), uuid.New()
+	cs.Enroll(teacher, classID, models.UserRoleTeacher)
+	svc := unitsvc.New(unitsvc.NewMemoryRepository(), cs, unitsvc.OnChange(unitsvc.PublishChanges(loopback{feed})))
+	sub := feed.Subscribe(classID, "")
+	defer sub.Close()
+
+	ctx := unitsvc.WithRequestID(unittesting.NewContext(teacher), "publish-changes")
+	if err := svc.CreateUnit(ctx, classID, "Waves"); err != nil {
+		t.Fatalf("CreateUnit: %v", err)
+	}
+	e := receive(t, sub)
+	if e.Type != unitsvc.FeedCreated || e.Unit == nil || e.Unit.Title != "Waves" || e.RequestID != "publish-changes" {
+		t.Fatalf("got event %+v, want creation of %q by request %q", e, "Waves", "publish-changes")
+	}
+}
+
+func TestFeedEventFromChange(t *testing.T) {
+	unit := &unitsvcmodels.Unit{ID: uuid.New(), ClassID: uuid.New(), Title: "Waves", DisplayOrder: 1}
+	renamed, moved, nested := *unit, *unit, *unit
+	renamed.Title = "Sound"
+	moved.DisplayOrder = 2
+	parentID := uuid.New()
+	nested.ParentID = &parentID
+	for _, tt := range []struct {
+		action        string
+		before, after *unitsvcmodels.Unit
+		want          string
+	}{
+		{unitsvcmodels.AuditCreate, nil, unit, unitsvc.FeedCreated},
+		{unitsvcmodels.AuditRename, unit, &renamed, unitsvc.FeedUpdated},
+		{unitsvcmodels.AuditUpdate, unit, &renamed, unitsvc.FeedUpdated},
+		{unitsvcmodels.AuditUpdate, unit, &moved, unitsvc.FeedReordered},
+		{unitsvcmodels.AuditRevert, &moved, unit, unitsvc.FeedReordered},
+		{unitsvcmodels.AuditUpdate, unit, &nested, unitsvc.FeedReordered},
+		{unitsvcmodels.AuditDelete, unit, nil, unitsvc.FeedDeleted},
+	} {
+		change := &unitsvcmodels.AuditEntry{Action: tt.action, ClassID: unit.ClassID, UnitID: unit.ID, Before: tt.before, After: tt.after}
+		if e := unitsvc.FeedEventFromChange(change); e.Type != tt.want || e.Unit != tt.after {
+			t.Errorf("%s from %v to %v: got %s event of %v, want %s event of %v", tt.action, tt.before, tt.after, e.Type, e.Unit, tt.want, tt.after)
+		}
+	}
+}
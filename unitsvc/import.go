@@ -0,0 +1,521 @@
+package unitsvc
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/studiously/unitsvc/models"
+)
+
+// Formats of unit exports and imports.
+const (
+	FormatJSON            = "json"
+	FormatCSV             = "csv"
+	FormatCommonCartridge = "imscc" // IMS Common Cartridge, for learning management systems such as Canvas and Moodle
+)
+
+// csvHeader is the header row of CSV exports. Imports need a title column; id, display_order,
+// description, metadata, parent_id, resources, status, starts_at and ends_at are optional, and
+// other columns are ignored.
+// Spreadsheets and scripts may read the columns by position, so new columns are only ever added
+// at the end.
+var csvHeader = []string{"id", "display_order", "title", "created_at", "description", "metadata", "parent_id", "resources", "status", "starts_at", "ends_at"}
+
+// ImportRow is a unit read from an import file.
+type ImportRow struct {
+	Row          int       // 1 for the first unit in the file
+	ID           uuid.UUID // uuid.Nil to create a unit
+	Title        string
+	DisplayOrder *int              // nil to order the unit by its row
+	Description  *string           // nil to keep the unit's description
+	Metadata     models.Metadata   // nil to keep the unit's metadata
+	Parent       *int              // the Row of the unit to nest it in, 0 for a top-level unit, or nil to keep its parent
+	Resources    models.Resources  // nil to keep the unit's resources
+	Status       models.UnitStatus // "" to keep the unit's status
+	Schedule     *Schedule         // nil to keep the unit's schedule
+}
+
+// Schedule is when a unit is taught. Either time may be nil for a schedule without that end.
+type Schedule struct {
+	StartsAt *time.Time `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at"`
+}
+
+// RowError is a problem with one row of an import file.
+type RowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportError lists the invalid rows of an import. Nothing is imported if any row is invalid.
+type ImportError struct {
+	Rows []RowError
+}
+
+func (e *ImportError) Error() string {
+	return fmt.Sprintf("%d invalid rows in import, starting with row %d: %s %s", len(e.Rows), e.Rows[0].Row, e.Rows[0].Field, e.Rows[0].Message)
+}
+
+// byRow sorts row errors by row.
+type byRow []RowError
+
+func (e byRow) Len() int           { return len(e) }
+func (e byRow) Less(i, j int) bool { return e[i].Row < e[j].Row }
+func (e byRow) Swap(i, j int)      { e[i], e[j] = e[j], e[i] }
+
+// ImportResult reports the changes an import made to a class, or would make in a dry run.
+// Created and updated units are listed as they are after the import.
+type ImportResult struct {
+	DryRun  bool           `json:"dry_run"`
+	Created []*models.Unit `json:"created"`
+	Updated []*models.Unit `json:"updated"`
+	Deleted []*models.Unit `json:"deleted"`
+}
+
+// exportDocument is the JSON format of exports and imports.
+type exportDocument struct {
+	ClassID uuid.UUID    `json:"class_id"`
+	Units   []unitRecord `json:"units"`
+}
+
+type unitRecord struct {
+	ID           string            `json:"id,omitempty"`
+	DisplayOrder *int              `json:"display_order,omitempty"`
+	Title        string            `json:"title"`
+	CreatedAt    *time.Time        `json:"created_at,omitempty"`
+	Description  *string           `json:"description,omitempty"`
+	Metadata     models.Metadata   `json:"metadata"`            // null to keep the unit's metadata on import
+	ParentID     *string           `json:"parent_id,omitempty"` // "" for a top-level unit
+	ParentRow    *int              `json:"parent_row,omitempty"`
+	Resources    models.Resources  `json:"resources"` // null to keep the unit's resources on import
+	Status       models.UnitStatus `json:"status,omitempty"`
+	Schedule     *Schedule         `json:"schedule,omitempty"`
+}
+
+// WriteExport writes the units of a class in format, in the order they are given.
+func WriteExport(w io.Writer, format string, classID uuid.UUID, units []*models.Unit) error {
+	switch format {
+	case FormatJSON:
+		doc := exportDocument{ClassID: classID, Units: []unitRecord{}}
+		for _, u := range units {
+			u := u
+			parentID := exportParentID(u, units)
+			doc.Units = append(doc.Units, unitRecord{u.ID.String(), &u.DisplayOrder, u.Title, &u.CreatedAt, &u.Description,
+				exportMetadata(u.Metadata), &parentID, nil, exportResources(u.Resources), u.Status.OrPublished(), &Schedule{u.StartsAt, u.EndsAt}})
+		}
+		return json.NewEncoder(w).Encode(doc)
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		cw.Write(csvHeader)
+		for _, u := range units {
+			metadata, err := json.Marshal(exportMetadata(u.Metadata))
+			if err != nil {
+				return err
+			}
+			resources, err := json.Marshal(exportResources(u.Resources))
+			if err != nil {
+				return err
+			}
+			cw.Write([]string{u.ID.String(), strconv.Itoa(u.DisplayOrder), u.Title, u.CreatedAt.Format(time.RFC3339), u.Description,
+				string(metadata), exportParentID(u, units), string(resources), string(u.Status.OrPublished()), exportTime(u.StartsAt), exportTime(u.EndsAt)})
+		}
+		cw.Flush()
+		return cw.Error()
+	case FormatCommonCartridge:
+		return writeCartridge(w, classID, units)
+	default:
+		return fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+// exportMetadata returns m, or empty metadata if m is nil, so that exports always list metadata
+// and importing them again clears metadata that was added in the meantime.
+func exportMetadata(m models.Metadata) models.Metadata {
+	if m == nil {
+		return models.Metadata{}
+	}
+	return m
+}
+
+// exportResources returns r, or empty resources if r is nil, for the same reason as exportMetadata.
+func exportResources(r models.Resources) models.Resources {
+	if r == nil {
+		return models.Resources{}
+	}
+	return r
+}
+
+// exportTime formats t for a CSV export, or returns "" if t is nil. Schedules keep their
+// fractional seconds so that importing an export does not change them.
+func exportTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// exportParentID returns the ID of u's parent, or "" if u is a top-level unit or its parent is
+// not one of units, so that every exported parent_id names another unit of the export.
+func exportParentID(u *models.Unit, units []*models.Unit) string {
+	if u.ParentID == nil {
+		return ""
+	}
+	for _, p := range units {
+		if p.ID == *u.ParentID {
+			return p.ID.String()
+		}
+	}
+	return ""
+}
+
+// ParseImport reads the units of an import file in format. It returns ErrBadRequest if the
+// file cannot be read, and an *ImportError if some of its values are malformed.
+func ParseImport(r io.Reader, format string) ([]ImportRow, error) {
+	var records []unitRecord
+	var errs []RowError
+	switch format {
+	case FormatJSON:
+		var doc exportDocument
+		if err := json.NewDecoder(r).Decode(&doc); err != nil {
+			return nil, ErrBadRequest
+		}
+		records = doc.Units
+	case FormatCSV:
+		var err error
+		if records, errs, err = readCSV(r); err != nil {
+			return nil, err
+		}
+	case FormatCommonCartridge:
+		var err error
+		if records, err = readCartridge(r); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrBadRequest
+	}
+
+	rows := make([]ImportRow, 0, len(records))
+	for i, rec := range records {
+		row := ImportRow{Row: i + 1, Title: rec.Title, DisplayOrder: rec.DisplayOrder, Description: rec.Description,
+			Metadata: rec.Metadata, Parent: rec.ParentRow, Resources: rec.Resources, Status: rec.Status, Schedule: rec.Schedule}
+		if rec.ID != "" {
+			id, err := uuid.Parse(rec.ID)
+			if err != nil {
+				errs = append(errs, RowError{row.Row, "id", "is not a UUID"})
+			}
+			row.ID = id
+		}
+		if rec.ParentID != nil && rec.ParentRow == nil {
+			parent, ok := parentRow(records, *rec.ParentID)
+			if !ok {
+				errs = append(errs, RowError{row.Row, "parent_id", "is not the id of another row"})
+			}
+			row.Parent = &parent
+		}
+		rows = append(rows, row)
+	}
+	if len(errs) > 0 {
+		sort.Stable(byRow(errs))
+		return nil, &ImportError{errs}
+	}
+	return rows, nil
+}
+
+// parentRow returns the row of the record with id, or 0 if id is empty.
+func parentRow(records []unitRecord, id string) (int, bool) {
+	if id == "" {
+		return 0, true
+	}
+	for i, rec := range records {
+		if rec.ID != "" && strings.EqualFold(rec.ID, id) {
+			return i + 1, true
+		}
+	}
+	return 0, false
+}
+
+// readCSV reads the records of a CSV file with a header row, and the errors in values that
+// cannot be represented in a record.
+func readCSV(r io.Reader) ([]unitRecord, []RowError, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, nil, ErrBadRequest
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, nil, ErrBadRequest
+	}
+	field := func(fields []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(fields) {
+			return strings.TrimSpace(fields[i])
+		}
+		return ""
+	}
+
+	var records []unitRecord
+	var errs []RowError
+	for {
+		fields, err := cr.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, nil, ErrBadRequest
+		}
+		rec := unitRecord{ID: field(fields, "id"), Title: field(fields, "title")}
+		if s := field(fields, "display_order"); s != "" {
+			order, err := strconv.Atoi(s)
+			if err != nil {
+				errs = append(errs, RowError{len(records) + 1, "display_order", "is not a whole number"})
+			}
+			rec.DisplayOrder = &order
+		}
+		if _, ok := columns["description"]; ok {
+			description := field(fields, "description")
+			rec.Description = &description
+		}
+		if _, ok := columns["metadata"]; ok {
+			rec.Metadata = models.Metadata{}
+			if s := field(fields, "metadata"); s != "" {
+				if err := json.Unmarshal([]byte(s), &rec.Metadata); err != nil {
+					errs = append(errs, RowError{len(records) + 1, "metadata", "is not a JSON object of strings"})
+				}
+			}
+		}
+		if _, ok := columns["parent_id"]; ok {
+			parentID := field(fields, "parent_id")
+			rec.ParentID = &parentID
+		}
+		if _, ok := columns["resources"]; ok {
+			rec.Resources = models.Resources{}
+			if s := field(fields, "resources"); s != "" {
+				if err := json.Unmarshal([]byte(s), &rec.Resources); err != nil {
+					errs = append(errs, RowError{len(records) + 1, "resources", "is not a JSON array of links"})
+				}
+			}
+		}
+		rec.Status = models.UnitStatus(strings.ToLower(field(fields, "status")))
+		_, hasStart := columns["starts_at"]
+		_, hasEnd := columns["ends_at"]
+		if hasStart || hasEnd {
+			rec.Schedule = &Schedule{}
+			var ok bool
+			if rec.Schedule.StartsAt, ok = csvTime(field(fields, "starts_at")); !ok {
+				errs = append(errs, RowError{len(records) + 1, "starts_at", "is not an RFC 3339 time"})
+			}
+			if rec.Schedule.EndsAt, ok = csvTime(field(fields, "ends_at")); !ok {
+				errs = append(errs, RowError{len(records) + 1, "ends_at", "is not an RFC 3339 time"})
+			}
+		}
+		records = append(records, rec)
+	}
+	return records, errs, nil
+}
+
+// csvTime parses the RFC 3339 time in a CSV field, which is nil if the field is empty. It
+// reports whether the field could be parsed.
+func csvTime(s string) (*time.Time, bool) {
+	if s == "" {
+		return nil, true
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil, false
+	}
+	return &t, true
+}
+
+// planImport works out the changes that make the units of a class match rows. Rows with the
+// ID of an existing unit update it, rows without an ID create a unit, and units without a row
+// are deleted. Units whose rows keep their parent are nested in its nearest ancestor that is
+// kept instead if it is deleted. It returns an *ImportError if any row is invalid.
+func planImport(classID uuid.UUID, existing []*models.Unit, rows []ImportRow) (*ImportResult, error) {
+	byID := make(map[uuid.UUID]*models.Unit, len(existing))
+	for _, u := range existing {
+		byID[u.ID] = u
+	}
+	result := &ImportResult{Created: []*models.Unit{}, Updated: []*models.Unit{}, Deleted: []*models.Unit{}}
+	seen := make(map[uuid.UUID]int)
+	units := make([]*models.Unit, len(rows))  // the units as the rows leave them; nil for invalid rows
+	before := make([]*models.Unit, len(rows)) // the units the rows update; nil for created units
+	var errs []RowError
+	for i, row := range rows {
+		title := strings.TrimSpace(row.Title)
+		order := i
+		if row.DisplayOrder != nil {
+			order = *row.DisplayOrder
+		}
+		if title == "" {
+			errs = append(errs, RowError{row.Row, "title", "is required"})
+		}
+		if order < 0 {
+			errs = append(errs, RowError{row.Row, "display_order", "must not be negative"})
+		}
+		if _, ok := row.Metadata[""]; ok {
+			errs = append(errs, RowError{row.Row, "metadata", "must not have an empty key"})
+		}
+		for _, r := range row.Resources {
+			if u, err := url.Parse(r.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				errs = append(errs, RowError{row.Row, "resources", "must be http or https links"})
+				break
+			}
+		}
+		if row.Status != "" && !row.Status.Valid() {
+			errs = append(errs, RowError{row.Row, "status", "must be draft, published or archived"})
+		}
+		var startsAt, endsAt *time.Time
+		if row.Schedule != nil {
+			startsAt, endsAt = utcTime(row.Schedule.StartsAt), utcTime(row.Schedule.EndsAt)
+			if startsAt != nil && endsAt != nil && !endsAt.After(*startsAt) {
+				errs = append(errs, RowError{row.Row, "ends_at", "must be after starts_at"})
+			}
+		}
+		if row.ID == uuid.Nil {
+			unit := &models.Unit{
+				ID:           uuid.New(),
+				ClassID:      classID,
+				Title:        title,
+				DisplayOrder: order,
+				CreatedAt:    time.Now().UTC(),
+				Metadata:     row.Metadata,
+				Resources:    row.Resources,
+				Status:       row.Status.OrPublished(),
+				StartsAt:     startsAt,
+				EndsAt:       endsAt,
+			}
+			if row.Description != nil {
+				unit.Description = strings.TrimSpace(*row.Description)
+			}
+			units[i] = unit
+			continue
+		}
+		if first, ok := seen[row.ID]; ok {
+			errs = append(errs, RowError{row.Row, "id", fmt.Sprintf("is already used by row %d", first)})
+			continue
+		}
+		seen[row.ID] = row.Row
+		unit, ok := byID[row.ID]
+		if !ok {
+			errs = append(errs, RowError{row.Row, "id", "is not a unit of this class; leave it empty to create a unit"})
+			continue
+		}
+		after := *unit
+		after.Title, after.DisplayOrder = title, order
+		if row.Description != nil {
+			after.Description = strings.TrimSpace(*row.Description)
+		}
+		if row.Metadata != nil {
+			after.Metadata = row.Metadata
+		}
+		if row.Resources != nil {
+			after.Resources = row.Resources
+		}
+		if row.Status != "" {
+			after.Status = row.Status
+		}
+		if row.Schedule != nil {
+			after.StartsAt, after.EndsAt = startsAt, endsAt
+		}
+		units[i], before[i] = &after, unit
+	}
+
+	// Parents are resolved once every row has a unit ID, since a row may be nested in a later one.
+	index := make(map[int]int, len(rows))
+	for i, row := range rows {
+		index[row.Row] = i
+	}
+	for i, row := range rows {
+		u := units[i]
+		switch {
+		case u == nil:
+		case row.Parent == nil:
+			u.ParentID = keptAncestor(u.ParentID, byID, seen)
+		case *row.Parent == 0:
+			u.ParentID = nil
+		default:
+			j, ok := index[*row.Parent]
+			if !ok || j == i {
+				errs = append(errs, RowError{row.Row, "parent", "is not another row"})
+			} else if units[j] != nil {
+				parentID := units[j].ID
+				u.ParentID = &parentID
+			}
+		}
+	}
+	parents := make(map[uuid.UUID]*uuid.UUID, len(units))
+	for _, u := range units {
+		if u != nil {
+			parents[u.ID] = u.ParentID
+		}
+	}
+	for i, u := range units {
+		if u == nil {
+			continue
+		}
+		for p, n := u.ParentID, 0; p != nil && n < len(units); p, n = parents[*p], n+1 {
+			if *p == u.ID {
+				errs = append(errs, RowError{rows[i].Row, "parent", "nests the unit inside itself"})
+				break
+			}
+		}
+	}
+	if len(errs) > 0 {
+		sort.Stable(byRow(errs))
+		return nil, &ImportError{errs}
+	}
+
+	for i, u := range units {
+		switch b := before[i]; {
+		case b == nil:
+			result.Created = append(result.Created, u)
+		case u.Title != b.Title || u.DisplayOrder != b.DisplayOrder || u.Description != b.Description ||
+			!u.Metadata.Equal(b.Metadata) || !sameParent(u, b) || !u.Resources.Equal(b.Resources) ||
+			u.Status.OrPublished() != b.Status.OrPublished() || !sameTime(u.StartsAt, b.StartsAt) || !sameTime(u.EndsAt, b.EndsAt):
+			result.Updated = append(result.Updated, u)
+		}
+	}
+	for _, u := range existing {
+		if _, ok := seen[u.ID]; !ok {
+			result.Deleted = append(result.Deleted, u)
+		}
+	}
+	return result, nil
+}
+
+// utcTime returns a copy of t in UTC, or nil if t is nil.
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
+
+// keptAncestor returns parentID if the import keeps that unit, or otherwise the ID of its nearest
+// ancestor that is kept, or nil if none is.
+func keptAncestor(parentID *uuid.UUID, byID map[uuid.UUID]*models.Unit, kept map[uuid.UUID]int) *uuid.UUID {
+	for n := 0; parentID != nil && n <= len(byID); n++ {
+		if _, ok := kept[*parentID]; ok {
+			return parentID
+		}
+		parent, ok := byID[*parentID]
+		if !ok {
+			return nil
+		}
+		parentID = parent.ParentID
+	}
+	return nil
+}
@@ -0,0 +1,306 @@
+package unitsvc_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	unitsvcmodels "github.com/studiously/unitsvc/models"
+	"github.com/studiously/unitsvc/unitsvc"
+	unittesting "github.com/studiously/unitsvc/unitsvc/testing"
+)
+
+// postFile posts an import file to path, which do cannot send because it encodes bodies as JSON.
+func postFile(t *testing.T, s *unittesting.Server, path, token, contentType, file string, wantStatus int) *http.Response {
+	req, err := http.NewRequest("POST", s.URL+path, strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("POST %s: %v", path, err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", contentType)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST %s: %v", path, err)
+	}
+	if resp.StatusCode != wantStatus {
+		resp.Body.Close()
+		t.Fatalf("POST %s: got status %d, want %d", path, resp.StatusCode, wantStatus)
+	}
+	return resp
+}
+
+// exportCSV exports the units of classID as CSV records, including the header.
+func exportCSV(t *testing.T, s *unittesting.Server, token string, classID uuid.UUID) [][]string {
+	resp := do(t, s, "GET", "/units/export?format=csv&classID="+classID.String(), token, nil, http.StatusOK)
+	defer resp.Body.Close()
+	if got := resp.Header.Get("Content-Type"); got != "text/csv; charset=utf-8" {
+		t.Errorf("got Content-Type %q, want text/csv", got)
+	}
+	if got, want := resp.Header.Get("Content-Disposition"), `attachment; filename="units-`+classID.String()+`.csv"`; got != want {
+		t.Errorf("got Content-Disposition %q, want %q", got, want)
+	}
+	records, err := csv.NewReader(resp.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("read CSV export: %v", err)
+	}
+	return records
+}
+
+func TestHTTPExportImportCSV(t *testing.T) {
+	s, teacher, classID := newClass(t)
+	defer s.Close()
+	token := s.Token(teacher, "units")
+	for _, title := range []string{"Cells", "Genetics", "Ecology"} {
+		do(t, s, "POST", "/units/", token, map[string]interface{}{"class_id": classID, "title": title}, http.StatusOK).Body.Close()
+	}
+
+	records := exportCSV(t, s, token, classID)
+	if len(records) != 4 || strings.Join(records[0], ",") != "id,display_order,title,created_at,description,metadata,parent_id,resources,status,starts_at,ends_at" {
+		t.Fatalf("got export %v, want a header and 3 units", records)
+	}
+	if got := fmt.Sprint(records[1][2], records[2][2], records[3][2]); got != "CellsGeneticsEcology" {
+		t.Errorf("got titles %s, want Cells, Genetics and Ecology in order", got)
+	}
+
+	// As edited in a spreadsheet: Ecology is dropped, Genetics renamed, described, nested in Cells
+	// and given a reading, and a scheduled draft unit added. The display_order and ends_at columns
+	// are dropped too, so units are ordered by row.
+	file := "Title,ID,Notes,Description,Metadata,Parent_ID,Resources,Status,Starts_At\n" +
+		"Cells," + records[1][0] + ",,,,,,,\n" +
+		"Heredity," + records[2][0] + `,renamed,How traits are inherited,"{""weeks"":""2""}",` + records[1][0] +
+		`,"[{""title"":""Mendel"",""url"":""https://example.com/mendel""}]",,` + "\n" +
+		"Evolution,,new,,,,,Draft,2026-11-02T09:00:00+01:00\n"
+	path := "/units/import?classID=" + classID.String()
+
+	resp := postFile(t, s, path+"&dryRun=true", token, "text/csv", file, http.StatusOK)
+	var result unitsvc.ImportResult
+	err := json.NewDecoder(resp.Body).Decode(&result)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("decode dry run: %v", err)
+	}
+	if !result.DryRun || len(result.Created) != 1 || len(result.Updated) != 1 || len(result.Deleted) != 1 {
+		t.Fatalf("got dry run %+v, want 1 unit created, updated and deleted", result)
+	}
+	if result.Deleted[0].Title != "Ecology" {
+		t.Errorf("dry run deletes %q, want Ecology", result.Deleted[0].Title)
+	}
+	if got := len(exportCSV(t, s, token, classID)); got != 4 {
+		t.Errorf("got %d records after dry run, want 4", got)
+	}
+
+	postFile(t, s, path, token, "text/csv; charset=utf-8", file, http.StatusOK).Body.Close()
+	records = exportCSV(t, s, token, classID)
+	var got []string
+	for _, rec := range records[1:] {
+		got = append(got, strings.Join([]string{rec[1], rec[2], rec[4], rec[5]}, " "))
+	}
+	want := `[0 Cells  {} 1 Heredity How traits are inherited {"weeks":"2"} 2 Evolution  {}]`
+	if fmt.Sprint(got) != want {
+		t.Errorf("got units %v after import, want %s", got, want)
+	}
+	if records[2][6] != records[1][0] || records[2][7] != `[{"title":"Mendel","url":"https://example.com/mendel"}]` {
+		t.Errorf("got Heredity parent %q and resources %s, want Cells and the Mendel reading", records[2][6], records[2][7])
+	}
+	if records[1][6] != "" || records[3][6] != "" {
+		t.Errorf("got parents %q and %q for Cells and Evolution, want top-level units", records[1][6], records[3][6])
+	}
+	if got := fmt.Sprint(records[2][8:], records[3][8:]); got != "[published  ] [draft 2026-11-02T08:00:00Z ]" {
+		t.Errorf("got status and schedule %s for Heredity and Evolution, want a published unit and a scheduled draft", got)
+	}
+
+	// Files without the description, metadata, parent_id, resources, status and schedule columns
+	// leave them as they are.
+	file = "title,id\n"
+	for _, rec := range records[1:] {
+		file += rec[2] + "," + rec[0] + "\n"
+	}
+	resp = postFile(t, s, path, token, "text/csv", file, http.StatusOK)
+	result = unitsvc.ImportResult{}
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("decode import: %v", err)
+	}
+	if len(result.Created)+len(result.Updated)+len(result.Deleted) != 0 {
+		t.Errorf("import without descriptions made changes: %+v", result)
+	}
+}
+
+func TestHTTPExportJSON(t *testing.T) {
+	s, teacher, classID := newClass(t)
+	defer s.Close()
+	token := s.Token(teacher, "units")
+	do(t, s, "POST", "/units/", token, map[string]interface{}{"class_id": classID, "title": "Cells"}, http.StatusOK).Body.Close()
+
+	resp := do(t, s, "GET", "/units/export?classID="+classID.String(), token, nil, http.StatusOK)
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("read export: %v", err)
+	}
+	var doc struct {
+		ClassID uuid.UUID `json:"class_id"`
+		Units   []struct {
+			ID           uuid.UUID `json:"id"`
+			Title        string    `json:"title"`
+			DisplayOrder int       `json:"display_order"`
+		} `json:"units"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		t.Fatalf("decode export: %v", err)
+	}
+	if doc.ClassID != classID || len(doc.Units) != 1 || doc.Units[0].Title != "Cells" {
+		t.Fatalf("got export %s, want Cells in class %v", body, classID)
+	}
+
+	// The export can be imported as it is.
+	resp = postFile(t, s, "/units/import?classID="+classID.String(), token, "application/json", string(body), http.StatusOK)
+	var result unitsvc.ImportResult
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("decode import: %v", err)
+	}
+	if len(result.Created)+len(result.Updated)+len(result.Deleted) != 0 {
+		t.Errorf("import of export made changes: %+v", result)
+	}
+}
+
+func TestHTTPImportInvalid(t *testing.T) {
+	s, teacher, classID := newClass(t)
+	defer s.Close()
+	token := s.Token(teacher, "units")
+	path := "/units/import?format=csv&classID=" + classID.String()
+
+	resp := postFile(t, s, path, token, "text/plain", "id,display_order,title,metadata\nnonsense,,Cells,\n,first,Genetics,\n,,,\n,,Ecology,weeks=2\n", http.StatusUnprocessableEntity)
+	defer resp.Body.Close()
+	var body struct {
+		Error string             `json:"error"`
+		Rows  []unitsvc.RowError `json:"rows"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	// Malformed values are reported before the rows are checked against the class.
+	if got, want := fmt.Sprint(body.Rows), "[{1 id is not a UUID} {2 display_order is not a whole number} {4 metadata is not a JSON object of strings}]"; got != want {
+		t.Errorf("got row errors %s, want %s", got, want)
+	}
+	postFile(t, s, path, token, "text/csv", ",,\n,,Cells\n", http.StatusBadRequest).Body.Close()
+	postFile(t, s, path, token, "text/csv", "title\n\n   \n", http.StatusUnprocessableEntity).Body.Close()
+	postFile(t, s, path, token, "text/csv", "title\n"+strings.Repeat("x", 1<<20)+"\n", http.StatusBadRequest).Body.Close()
+	postFile(t, s, "/units/import?format=xml&classID="+classID.String(), token, "text/xml", "<units/>", http.StatusBadRequest).Body.Close()
+	do(t, s, "GET", "/units/export?format=xml&classID="+classID.String(), token, nil, http.StatusBadRequest).Body.Close()
+	if units := listUnits(t, s, token, classID); len(units) != 0 {
+		t.Errorf("got %d units after invalid imports, want 0", len(units))
+	}
+}
+
+// canvasManifest is the manifest of a cartridge from another system, with a newer version, and
+// a sub-module, a web link and other content nested in its modules.
+const canvasManifest = `<?xml version="1.0" encoding="UTF-8"?>
+<manifest identifier="g0a1" xmlns="http://www.imsglobal.org/xsd/imsccv1p3/imscp_v1p1">
+  <metadata><schema>IMS Common Cartridge</schema><schemaversion>1.3.0</schemaversion></metadata>
+  <organizations>
+    <organization identifier="org_1" structure="rooted-hierarchy">
+      <item identifier="LearningModules">
+        <item identifier="g0a2"><title>Week 1: Atoms</title>
+          <item identifier="g0a3" identifierref="r1"><title>Reading</title></item>
+          <item identifier="g0a5" identifierref="r2"><title>Periodic table</title></item>
+          <item identifier="g0a6"><title>Ions</title></item>
+        </item>
+        <item identifier="g0a4"><title>Week 2: Molecules</title></item>
+      </item>
+    </organization>
+  </organizations>
+  <resources>
+    <resource identifier="r1" type="webcontent" href="reading.html"><file href="reading.html"/></resource>
+    <resource identifier="r2" type="imswl_xmlv1p3"><file href="links/r2.xml"/></resource>
+  </resources>
+</manifest>`
+
+func TestHTTPCommonCartridge(t *testing.T) {
+	s, teacher, classID := newClass(t)
+	defer s.Close()
+	token := s.Token(teacher, "units")
+	for _, title := range []string{"Cells", "Genetics"} {
+		do(t, s, "POST", "/units/", token, map[string]interface{}{"class_id": classID, "title": title}, http.StatusOK).Body.Close()
+	}
+	path := "/units/import?classID=" + classID.String()
+	records := exportCSV(t, s, token, classID)
+	// Genetics is nested in Cells, with a description and a reading.
+	postFile(t, s, path+"&format=csv", token, "text/csv", "id,title,description,parent_id,resources\n"+
+		records[1][0]+",Cells,,,\n"+
+		records[2][0]+`,Genetics,"Genes,\n& <traits>",`+records[1][0]+`,"[{""title"":""Mendel"",""url"":""https://example.com/mendel""}]"`+"\n",
+		http.StatusOK).Body.Close()
+	importCartridge := func(query string, cartridge []byte) unitsvc.ImportResult {
+		resp := postFile(t, s, path+query, token, "application/zip", string(cartridge), http.StatusOK)
+		defer resp.Body.Close()
+		var result unitsvc.ImportResult
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatalf("decode import: %v", err)
+		}
+		return result
+	}
+
+	resp := do(t, s, "GET", "/units/export?format=imscc&classID="+classID.String(), token, nil, http.StatusOK)
+	exported, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("read export: %v", err)
+	}
+	if got := resp.Header.Get("Content-Type"); got != "application/vnd.ims.imsccv1p1" {
+		t.Errorf("got Content-Type %q, want application/vnd.ims.imsccv1p1", got)
+	}
+	if result := importCartridge("&dryRun=true", exported); len(result.Created)+len(result.Updated)+len(result.Deleted) != 0 {
+		t.Errorf("import of exported cartridge would make changes: %+v", result)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(exported), int64(len(exported)))
+	if err != nil {
+		t.Fatalf("open export: %v", err)
+	}
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	dir := "units/" + records[2][0] + "/"
+	if got, want := fmt.Sprint(names), fmt.Sprint([]string{"imsmanifest.xml", dir + "overview.html", dir + "link1.xml"}); got != want {
+		t.Errorf("got files %s in export, want %s", got, want)
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	f, err := zw.Create("imsmanifest.xml")
+	if err != nil {
+		t.Fatalf("create manifest: %v", err)
+	}
+	f.Write([]byte(canvasManifest))
+	if f, err = zw.Create("links/r2.xml"); err != nil {
+		t.Fatalf("create web link: %v", err)
+	}
+	f.Write([]byte(`<webLink xmlns="http://www.imsglobal.org/xsd/imsccv1p3/imswl_v1p3"><title>Periodic table</title><url href="https://example.com/table"/></webLink>`))
+	zw.Close()
+	result := importCartridge("", buf.Bytes())
+	if len(result.Created) != 3 || len(result.Deleted) != 2 {
+		t.Fatalf("got import %+v, want the 3 modules to replace the 2 units", result)
+	}
+	week1, ions, week2 := result.Created[0], result.Created[1], result.Created[2]
+	if got := fmt.Sprint(week1.Title, ", ", ions.Title, ", ", week2.Title); got != "Week 1: Atoms, Ions, Week 2: Molecules" {
+		t.Errorf("got units %s, want the modules in order", got)
+	}
+	if ions.ParentID == nil || *ions.ParentID != week1.ID || week1.ParentID != nil || week2.ParentID != nil {
+		t.Errorf("got parents %v, %v and %v, want Ions nested in Week 1", week1.ParentID, ions.ParentID, week2.ParentID)
+	}
+	if want := (unitsvcmodels.Resources{{Title: "Periodic table", URL: "https://example.com/table"}}); !week1.Resources.Equal(want) {
+		t.Errorf("got Week 1 resources %v, want %v", week1.Resources, want)
+	}
+
+	postFile(t, s, path, token, "application/zip", "not a zip file", http.StatusBadRequest).Body.Close()
+}
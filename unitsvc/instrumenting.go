@@ -1,32 +1,44 @@
 package unitsvc
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/go-kit/kit/metrics"
 	"github.com/google/uuid"
+	"github.com/gorilla/mux"
 	"github.com/studiously/unitsvc/models"
 )
 
+// InstrumentingMiddleware records each method call. requestCount and requestLatency (in seconds)
+// are labelled with "method" and "error"; inFlight is labelled with "method" only.
 func InstrumentingMiddleware(
 	requestCount metrics.Counter,
 	requestLatency metrics.Histogram,
+	inFlight metrics.Gauge,
 ) Middleware {
 	return func(next Service) Service {
-		return instrumentingMiddleware{requestCount, requestLatency, next}
+		return instrumentingMiddleware{requestCount, requestLatency, inFlight, next}
 	}
 }
 
 type instrumentingMiddleware struct {
 	requestCount   metrics.Counter
 	requestLatency metrics.Histogram
+	inFlight       metrics.Gauge
 	next           Service
 }
 
 func (im instrumentingMiddleware) ListUnits(ctx context.Context, classID uuid.UUID) (units []uuid.UUID, err error) {
+	im.inFlight.With("method", "ListUnits").Add(1)
 	defer func(begin time.Time) {
+		im.inFlight.With("method", "ListUnits").Add(-1)
 		lvs := []string{"method", "ListUnits", "error", fmt.Sprint(err != nil)}
 		im.requestCount.With(lvs...).Add(1)
 		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
@@ -35,7 +47,9 @@ func (im instrumentingMiddleware) ListUnits(ctx context.Context, classID uuid.UU
 }
 
 func (im instrumentingMiddleware) GetUnit(ctx context.Context, unitID uuid.UUID) (unit *models.Unit, err error) {
+	im.inFlight.With("method", "GetUnit").Add(1)
 	defer func(begin time.Time) {
+		im.inFlight.With("method", "GetUnit").Add(-1)
 		lvs := []string{"method", "GetUnit", "error", fmt.Sprint(err != nil)}
 		im.requestCount.With(lvs...).Add(1)
 		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
@@ -43,8 +57,32 @@ func (im instrumentingMiddleware) GetUnit(ctx context.Context, unitID uuid.UUID)
 	return im.next.GetUnit(ctx, unitID)
 }
 
+func (im instrumentingMiddleware) ListMyUnits(ctx context.Context, after uuid.UUID, limit int, filter UnitFilter) (page *ClassUnitsPage, err error) {
+	im.inFlight.With("method", "ListMyUnits").Add(1)
+	defer func(begin time.Time) {
+		im.inFlight.With("method", "ListMyUnits").Add(-1)
+		lvs := []string{"method", "ListMyUnits", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return im.next.ListMyUnits(ctx, after, limit, filter)
+}
+
+func (im instrumentingMiddleware) SearchUnits(ctx context.Context, query string, filter SearchFilter) (matches []*models.UnitMatch, err error) {
+	im.inFlight.With("method", "SearchUnits").Add(1)
+	defer func(begin time.Time) {
+		im.inFlight.With("method", "SearchUnits").Add(-1)
+		lvs := []string{"method", "SearchUnits", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return im.next.SearchUnits(ctx, query, filter)
+}
+
 func (im instrumentingMiddleware) CreateUnit(ctx context.Context, classID uuid.UUID, title string) (err error) {
+	im.inFlight.With("method", "CreateUnit").Add(1)
 	defer func(begin time.Time) {
+		im.inFlight.With("method", "CreateUnit").Add(-1)
 		lvs := []string{"method", "CreateUnit", "error", fmt.Sprint(err != nil)}
 		im.requestCount.With(lvs...).Add(1)
 		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
@@ -53,7 +91,9 @@ func (im instrumentingMiddleware) CreateUnit(ctx context.Context, classID uuid.U
 }
 
 func (im instrumentingMiddleware) RenameUnit(ctx context.Context, unitID uuid.UUID, title string) (err error) {
+	im.inFlight.With("method", "RenameUnit").Add(1)
 	defer func(begin time.Time) {
+		im.inFlight.With("method", "RenameUnit").Add(-1)
 		lvs := []string{"method", "RenameUnit", "error", fmt.Sprint(err != nil)}
 		im.requestCount.With(lvs...).Add(1)
 		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
@@ -62,10 +102,278 @@ func (im instrumentingMiddleware) RenameUnit(ctx context.Context, unitID uuid.UU
 }
 
 func (im instrumentingMiddleware) DeleteUnit(ctx context.Context, unitID uuid.UUID) (err error) {
+	im.inFlight.With("method", "DeleteUnit").Add(1)
 	defer func(begin time.Time) {
+		im.inFlight.With("method", "DeleteUnit").Add(-1)
 		lvs := []string{"method", "DeleteUnit", "error", fmt.Sprint(err != nil)}
 		im.requestCount.With(lvs...).Add(1)
 		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
 	}(time.Now())
 	return im.next.DeleteUnit(ctx, unitID)
 }
+
+func (im instrumentingMiddleware) GetCurrentUnit(ctx context.Context, classID uuid.UUID) (unit *models.Unit, err error) {
+	im.inFlight.With("method", "GetCurrentUnit").Add(1)
+	defer func(begin time.Time) {
+		im.inFlight.With("method", "GetCurrentUnit").Add(-1)
+		lvs := []string{"method", "GetCurrentUnit", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return im.next.GetCurrentUnit(ctx, classID)
+}
+
+func (im instrumentingMiddleware) SetCurrentUnit(ctx context.Context, classID, unitID uuid.UUID) (err error) {
+	im.inFlight.With("method", "SetCurrentUnit").Add(1)
+	defer func(begin time.Time) {
+		im.inFlight.With("method", "SetCurrentUnit").Add(-1)
+		lvs := []string{"method", "SetCurrentUnit", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return im.next.SetCurrentUnit(ctx, classID, unitID)
+}
+
+func (im instrumentingMiddleware) AdvanceUnit(ctx context.Context, classID uuid.UUID) (unit *models.Unit, err error) {
+	im.inFlight.With("method", "AdvanceUnit").Add(1)
+	defer func(begin time.Time) {
+		im.inFlight.With("method", "AdvanceUnit").Add(-1)
+		lvs := []string{"method", "AdvanceUnit", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return im.next.AdvanceUnit(ctx, classID)
+}
+
+func (im instrumentingMiddleware) MarkUnitProgress(ctx context.Context, unitID uuid.UUID, status string) (progress *models.UnitProgress, err error) {
+	im.inFlight.With("method", "MarkUnitProgress").Add(1)
+	defer func(begin time.Time) {
+		im.inFlight.With("method", "MarkUnitProgress").Add(-1)
+		lvs := []string{"method", "MarkUnitProgress", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return im.next.MarkUnitProgress(ctx, unitID, status)
+}
+
+func (im instrumentingMiddleware) GetClassProgress(ctx context.Context, classID uuid.UUID) (progress *ClassProgress, err error) {
+	im.inFlight.With("method", "GetClassProgress").Add(1)
+	defer func(begin time.Time) {
+		im.inFlight.With("method", "GetClassProgress").Add(-1)
+		lvs := []string{"method", "GetClassProgress", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return im.next.GetClassProgress(ctx, classID)
+}
+
+func (im instrumentingMiddleware) UnitHistory(ctx context.Context, unitID uuid.UUID) (entries []*models.AuditEntry, err error) {
+	im.inFlight.With("method", "UnitHistory").Add(1)
+	defer func(begin time.Time) {
+		im.inFlight.With("method", "UnitHistory").Add(-1)
+		lvs := []string{"method", "UnitHistory", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return im.next.UnitHistory(ctx, unitID)
+}
+
+func (im instrumentingMiddleware) ClassAudit(ctx context.Context, classID uuid.UUID) (entries []*models.AuditEntry, err error) {
+	im.inFlight.With("method", "ClassAudit").Add(1)
+	defer func(begin time.Time) {
+		im.inFlight.With("method", "ClassAudit").Add(-1)
+		lvs := []string{"method", "ClassAudit", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return im.next.ClassAudit(ctx, classID)
+}
+
+func (im instrumentingMiddleware) ListUnitVersions(ctx context.Context, unitID uuid.UUID) (versions []*models.UnitVersion, err error) {
+	im.inFlight.With("method", "ListUnitVersions").Add(1)
+	defer func(begin time.Time) {
+		im.inFlight.With("method", "ListUnitVersions").Add(-1)
+		lvs := []string{"method", "ListUnitVersions", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return im.next.ListUnitVersions(ctx, unitID)
+}
+
+func (im instrumentingMiddleware) GetUnitVersion(ctx context.Context, unitID uuid.UUID, version int) (v *models.UnitVersion, err error) {
+	im.inFlight.With("method", "GetUnitVersion").Add(1)
+	defer func(begin time.Time) {
+		im.inFlight.With("method", "GetUnitVersion").Add(-1)
+		lvs := []string{"method", "GetUnitVersion", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return im.next.GetUnitVersion(ctx, unitID, version)
+}
+
+func (im instrumentingMiddleware) RevertUnit(ctx context.Context, unitID uuid.UUID, version int) (err error) {
+	im.inFlight.With("method", "RevertUnit").Add(1)
+	defer func(begin time.Time) {
+		im.inFlight.With("method", "RevertUnit").Add(-1)
+		lvs := []string{"method", "RevertUnit", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return im.next.RevertUnit(ctx, unitID, version)
+}
+
+func (im instrumentingMiddleware) CreateWebhook(ctx context.Context, classID uuid.UUID, url, secret string, events []string) (hook *models.Webhook, err error) {
+	im.inFlight.With("method", "CreateWebhook").Add(1)
+	defer func(begin time.Time) {
+		im.inFlight.With("method", "CreateWebhook").Add(-1)
+		lvs := []string{"method", "CreateWebhook", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return im.next.CreateWebhook(ctx, classID, url, secret, events)
+}
+
+func (im instrumentingMiddleware) ListWebhooks(ctx context.Context, classID uuid.UUID) (hooks []*models.Webhook, err error) {
+	im.inFlight.With("method", "ListWebhooks").Add(1)
+	defer func(begin time.Time) {
+		im.inFlight.With("method", "ListWebhooks").Add(-1)
+		lvs := []string{"method", "ListWebhooks", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return im.next.ListWebhooks(ctx, classID)
+}
+
+func (im instrumentingMiddleware) DeleteWebhook(ctx context.Context, webhookID uuid.UUID) (err error) {
+	im.inFlight.With("method", "DeleteWebhook").Add(1)
+	defer func(begin time.Time) {
+		im.inFlight.With("method", "DeleteWebhook").Add(-1)
+		lvs := []string{"method", "DeleteWebhook", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return im.next.DeleteWebhook(ctx, webhookID)
+}
+
+func (im instrumentingMiddleware) ListDeliveries(ctx context.Context, webhookID uuid.UUID, status string) (deliveries []*models.WebhookDelivery, err error) {
+	im.inFlight.With("method", "ListDeliveries").Add(1)
+	defer func(begin time.Time) {
+		im.inFlight.With("method", "ListDeliveries").Add(-1)
+		lvs := []string{"method", "ListDeliveries", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return im.next.ListDeliveries(ctx, webhookID, status)
+}
+
+func (im instrumentingMiddleware) ExportUnits(ctx context.Context, classID uuid.UUID) (units []*models.Unit, err error) {
+	im.inFlight.With("method", "ExportUnits").Add(1)
+	defer func(begin time.Time) {
+		im.inFlight.With("method", "ExportUnits").Add(-1)
+		lvs := []string{"method", "ExportUnits", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return im.next.ExportUnits(ctx, classID)
+}
+
+func (im instrumentingMiddleware) ImportUnits(ctx context.Context, classID uuid.UUID, rows []ImportRow, dryRun bool) (result *ImportResult, err error) {
+	im.inFlight.With("method", "ImportUnits").Add(1)
+	defer func(begin time.Time) {
+		im.inFlight.With("method", "ImportUnits").Add(-1)
+		lvs := []string{"method", "ImportUnits", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return im.next.ImportUnits(ctx, classID, rows, dryRun)
+}
+
+func (im instrumentingMiddleware) LinkOneRosterClass(ctx context.Context, sourcedID string, classID uuid.UUID) (err error) {
+	im.inFlight.With("method", "LinkOneRosterClass").Add(1)
+	defer func(begin time.Time) {
+		im.inFlight.With("method", "LinkOneRosterClass").Add(-1)
+		lvs := []string{"method", "LinkOneRosterClass", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return im.next.LinkOneRosterClass(ctx, sourcedID, classID)
+}
+
+func (im instrumentingMiddleware) SyncOneRoster(ctx context.Context, bundle *RosterBundle, dryRun, overwrite bool) (report *SyncReport, err error) {
+	im.inFlight.With("method", "SyncOneRoster").Add(1)
+	defer func(begin time.Time) {
+		im.inFlight.With("method", "SyncOneRoster").Add(-1)
+		lvs := []string{"method", "SyncOneRoster", "error", fmt.Sprint(err != nil)}
+		im.requestCount.With(lvs...).Add(1)
+		im.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return im.next.SyncOneRoster(ctx, bundle, dryRun, overwrite)
+}
+
+// InstrumentHTTPHandler records every request served by h. requestCount and requestLatency
+// (in seconds) are labelled with "route", "method" and "code"; inFlight is labelled with "route"
+// and "method". Routes are mux path templates such as "/units/{unitID}", so IDs never become label values.
+func InstrumentHTTPHandler(
+	h http.Handler,
+	requestCount metrics.Counter,
+	requestLatency metrics.Histogram,
+	inFlight metrics.Gauge,
+) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeTemplate(h, r)
+		inFlight.With("route", route, "method", r.Method).Add(1)
+		rec := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+		defer func(begin time.Time) {
+			inFlight.With("route", route, "method", r.Method).Add(-1)
+			lvs := []string{"route", route, "method", r.Method, "code", strconv.Itoa(rec.code)}
+			requestCount.With(lvs...).Add(1)
+			requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+		}(time.Now())
+		h.ServeHTTP(rec, r)
+	})
+}
+
+// routeTemplate returns the path template of the route h would dispatch r to.
+func routeTemplate(h http.Handler, r *http.Request) string {
+	router, ok := h.(*mux.Router)
+	if !ok {
+		return "other"
+	}
+	var match mux.RouteMatch
+	if !router.Match(r, &match) {
+		return "unmatched"
+	}
+	tpl, err := match.Route.GetPathTemplate()
+	if err != nil {
+		return "other"
+	}
+	return tpl
+}
+
+// statusRecorder captures the status code written through it.
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.code = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Hijack lets WebSocket connections through the recorder.
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response does not support hijacking")
+	}
+	r.code = http.StatusSwitchingProtocols
+	return h.Hijack()
+}
+
+// Flush lets streaming responses through the recorder.
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
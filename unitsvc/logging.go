@@ -2,38 +2,44 @@ package unitsvc
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
 	"github.com/google/uuid"
-	"github.com/ory/hydra/oauth2"
-	"github.com/studiously/introspector"
+	"github.com/studiously/unitsvc/logging"
 	"github.com/studiously/unitsvc/models"
+	"github.com/studiously/unitsvc/tracing"
 )
 
-func LoggingMiddleware(logger log.Logger) Middleware {
+// LoggingMiddleware logs every call to logger, at info level or at error level if it fails.
+// Only one in every sampleReads successful ListUnits and GetUnit calls is logged.
+// Successful mutations are also logged to audit, which is never sampled.
+func LoggingMiddleware(logger, audit log.Logger, sampleReads int) Middleware {
 	return func(next Service) Service {
 		return &loggingMiddleware{
-			next:   next,
-			logger: logger,
+			next:    next,
+			logger:  logger,
+			audit:   audit,
+			sampler: logging.NewSampler(sampleReads),
 		}
 	}
 }
 
 type loggingMiddleware struct {
-	next   Service
-	logger log.Logger
+	next    Service
+	logger  log.Logger
+	audit   log.Logger
+	sampler *logging.Sampler
 }
 
 func (mw loggingMiddleware) ListUnits(ctx context.Context, classID uuid.UUID) (units []uuid.UUID, err error) {
 	defer func(begin time.Time) {
-		mw.logger.Log(
+		mw.read(ctx, err,
 			"action", "ListUnits",
-			"user", subj(ctx).String(),
-			"client", cli(ctx),
 			"class", classID.String(),
 			"duration", time.Since(begin),
-			"error", err,
 		)
 	}(time.Now())
 	return mw.next.ListUnits(ctx, classID)
@@ -41,28 +47,53 @@ func (mw loggingMiddleware) ListUnits(ctx context.Context, classID uuid.UUID) (u
 
 func (mw loggingMiddleware) GetUnit(ctx context.Context, unitID uuid.UUID) (unit *models.Unit, err error) {
 	defer func(begin time.Time) {
-		mw.logger.Log(
+		mw.read(ctx, err,
 			"action", "GetUnit",
-			"user", subj(ctx).String(),
-			"client", cli(ctx),
 			"unit", unitID.String(),
 			"duration", time.Since(begin),
-			"error", err,
 		)
 	}(time.Now())
 	return mw.next.GetUnit(ctx, unitID)
 }
 
+func (mw loggingMiddleware) ListMyUnits(ctx context.Context, after uuid.UUID, limit int, filter UnitFilter) (page *ClassUnitsPage, err error) {
+	defer func(begin time.Time) {
+		keyvals := []interface{}{
+			"action", "ListMyUnits",
+			"after", after.String(),
+			"limit", limit,
+			"statuses", len(filter.Statuses),
+			"window", filter.From != nil || filter.To != nil,
+		}
+		if page != nil {
+			keyvals = append(keyvals, "classes", len(page.Classes))
+		}
+		keyvals = append(keyvals, "duration", time.Since(begin))
+		mw.read(ctx, err, keyvals...)
+	}(time.Now())
+	return mw.next.ListMyUnits(ctx, after, limit, filter)
+}
+
+func (mw loggingMiddleware) SearchUnits(ctx context.Context, query string, filter SearchFilter) (matches []*models.UnitMatch, err error) {
+	defer func(begin time.Time) {
+		mw.read(ctx, err,
+			"action", "SearchUnits",
+			"query", query,
+			"classes", len(filter.ClassIDs),
+			"matches", len(matches),
+			"duration", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.SearchUnits(ctx, query, filter)
+}
+
 func (mw loggingMiddleware) CreateUnit(ctx context.Context, classID uuid.UUID, title string) (err error) {
 	defer func(begin time.Time) {
-		mw.logger.Log(
+		mw.write(ctx, err,
 			"action", "CreateUnit",
-			"user", subj(ctx).String(),
-			"client", cli(ctx),
 			"class", classID.String(),
 			"title", title,
 			"duration", time.Since(begin),
-			"error", err,
 		)
 	}(time.Now())
 	return mw.next.CreateUnit(ctx, classID, title)
@@ -70,14 +101,11 @@ func (mw loggingMiddleware) CreateUnit(ctx context.Context, classID uuid.UUID, t
 
 func (mw loggingMiddleware) RenameUnit(ctx context.Context, unitID uuid.UUID, title string) (err error) {
 	defer func(begin time.Time) {
-		mw.logger.Log(
+		mw.write(ctx, err,
 			"action", "RenameUnit",
-			"user", subj(ctx).String(),
-			"client", cli(ctx),
 			"unit", unitID.String(),
 			"title", title,
 			"duration", time.Since(begin),
-			"error", err,
 		)
 	}(time.Now())
 	return mw.next.RenameUnit(ctx, unitID, title)
@@ -85,18 +113,300 @@ func (mw loggingMiddleware) RenameUnit(ctx context.Context, unitID uuid.UUID, ti
 
 func (mw loggingMiddleware) DeleteUnit(ctx context.Context, unitID uuid.UUID) (err error) {
 	defer func(begin time.Time) {
-		mw.logger.Log(
+		mw.write(ctx, err,
 			"action", "DeleteUnit",
-			"user", subj(ctx).String(),
-			"client", cli(ctx),
 			"unit", unitID.String(),
 			"duration", time.Since(begin),
-			"error", err,
 		)
 	}(time.Now())
 	return mw.next.DeleteUnit(ctx, unitID)
 }
 
-func cli(ctx context.Context) string {
-	return ctx.Value(introspector.OAuth2IntrospectionContextKey).(oauth2.Introspection).ClientID
+func (mw loggingMiddleware) GetCurrentUnit(ctx context.Context, classID uuid.UUID) (unit *models.Unit, err error) {
+	defer func(begin time.Time) {
+		mw.read(ctx, err,
+			"action", "GetCurrentUnit",
+			"class", classID.String(),
+			"duration", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.GetCurrentUnit(ctx, classID)
+}
+
+func (mw loggingMiddleware) SetCurrentUnit(ctx context.Context, classID, unitID uuid.UUID) (err error) {
+	defer func(begin time.Time) {
+		mw.write(ctx, err,
+			"action", "SetCurrentUnit",
+			"class", classID.String(),
+			"unit", unitID.String(),
+			"duration", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.SetCurrentUnit(ctx, classID, unitID)
+}
+
+func (mw loggingMiddleware) AdvanceUnit(ctx context.Context, classID uuid.UUID) (unit *models.Unit, err error) {
+	defer func(begin time.Time) {
+		var unitID string
+		if unit != nil {
+			unitID = unit.ID.String()
+		}
+		mw.write(ctx, err,
+			"action", "AdvanceUnit",
+			"class", classID.String(),
+			"unit", unitID,
+			"duration", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.AdvanceUnit(ctx, classID)
+}
+
+func (mw loggingMiddleware) MarkUnitProgress(ctx context.Context, unitID uuid.UUID, status string) (progress *models.UnitProgress, err error) {
+	defer func(begin time.Time) {
+		mw.write(ctx, err,
+			"action", "MarkUnitProgress",
+			"unit", unitID.String(),
+			"status", status,
+			"duration", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.MarkUnitProgress(ctx, unitID, status)
+}
+
+func (mw loggingMiddleware) GetClassProgress(ctx context.Context, classID uuid.UUID) (progress *ClassProgress, err error) {
+	defer func(begin time.Time) {
+		keyvals := []interface{}{
+			"action", "GetClassProgress",
+			"class", classID.String(),
+		}
+		if progress != nil {
+			keyvals = append(keyvals, "students", len(progress.Students))
+		}
+		keyvals = append(keyvals, "duration", time.Since(begin))
+		mw.read(ctx, err, keyvals...)
+	}(time.Now())
+	return mw.next.GetClassProgress(ctx, classID)
+}
+
+func (mw loggingMiddleware) UnitHistory(ctx context.Context, unitID uuid.UUID) (entries []*models.AuditEntry, err error) {
+	defer func(begin time.Time) {
+		mw.read(ctx, err,
+			"action", "UnitHistory",
+			"unit", unitID.String(),
+			"duration", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.UnitHistory(ctx, unitID)
+}
+
+func (mw loggingMiddleware) ClassAudit(ctx context.Context, classID uuid.UUID) (entries []*models.AuditEntry, err error) {
+	defer func(begin time.Time) {
+		mw.read(ctx, err,
+			"action", "ClassAudit",
+			"class", classID.String(),
+			"duration", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.ClassAudit(ctx, classID)
+}
+
+func (mw loggingMiddleware) ListUnitVersions(ctx context.Context, unitID uuid.UUID) (versions []*models.UnitVersion, err error) {
+	defer func(begin time.Time) {
+		mw.read(ctx, err,
+			"action", "ListUnitVersions",
+			"unit", unitID.String(),
+			"duration", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.ListUnitVersions(ctx, unitID)
+}
+
+func (mw loggingMiddleware) GetUnitVersion(ctx context.Context, unitID uuid.UUID, version int) (v *models.UnitVersion, err error) {
+	defer func(begin time.Time) {
+		mw.read(ctx, err,
+			"action", "GetUnitVersion",
+			"unit", unitID.String(),
+			"version", version,
+			"duration", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.GetUnitVersion(ctx, unitID, version)
+}
+
+func (mw loggingMiddleware) RevertUnit(ctx context.Context, unitID uuid.UUID, version int) (err error) {
+	defer func(begin time.Time) {
+		mw.write(ctx, err,
+			"action", "RevertUnit",
+			"unit", unitID.String(),
+			"version", version,
+			"duration", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.RevertUnit(ctx, unitID, version)
+}
+
+func (mw loggingMiddleware) CreateWebhook(ctx context.Context, classID uuid.UUID, url, secret string, events []string) (hook *models.Webhook, err error) {
+	defer func(begin time.Time) {
+		// The URL is not logged, as receivers sometimes authenticate with a token in it.
+		var webhookID string
+		if hook != nil {
+			webhookID = hook.ID.String()
+		}
+		mw.write(ctx, err,
+			"action", "CreateWebhook",
+			"class", classID.String(),
+			"webhook", webhookID,
+			"events", strings.Join(events, ","),
+			"duration", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.CreateWebhook(ctx, classID, url, secret, events)
+}
+
+func (mw loggingMiddleware) ListWebhooks(ctx context.Context, classID uuid.UUID) (hooks []*models.Webhook, err error) {
+	defer func(begin time.Time) {
+		mw.read(ctx, err,
+			"action", "ListWebhooks",
+			"class", classID.String(),
+			"duration", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.ListWebhooks(ctx, classID)
+}
+
+func (mw loggingMiddleware) DeleteWebhook(ctx context.Context, webhookID uuid.UUID) (err error) {
+	defer func(begin time.Time) {
+		mw.write(ctx, err,
+			"action", "DeleteWebhook",
+			"webhook", webhookID.String(),
+			"duration", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.DeleteWebhook(ctx, webhookID)
+}
+
+func (mw loggingMiddleware) ListDeliveries(ctx context.Context, webhookID uuid.UUID, status string) (deliveries []*models.WebhookDelivery, err error) {
+	defer func(begin time.Time) {
+		mw.read(ctx, err,
+			"action", "ListDeliveries",
+			"webhook", webhookID.String(),
+			"status", status,
+			"duration", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.ListDeliveries(ctx, webhookID, status)
+}
+
+func (mw loggingMiddleware) ExportUnits(ctx context.Context, classID uuid.UUID) (units []*models.Unit, err error) {
+	defer func(begin time.Time) {
+		mw.read(ctx, err,
+			"action", "ExportUnits",
+			"class", classID.String(),
+			"units", len(units),
+			"duration", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.ExportUnits(ctx, classID)
+}
+
+func (mw loggingMiddleware) ImportUnits(ctx context.Context, classID uuid.UUID, rows []ImportRow, dryRun bool) (result *ImportResult, err error) {
+	defer func(begin time.Time) {
+		keyvals := []interface{}{
+			"action", "ImportUnits",
+			"class", classID.String(),
+			"rows", len(rows),
+			"dry_run", dryRun,
+		}
+		if result != nil {
+			keyvals = append(keyvals, "created", len(result.Created), "updated", len(result.Updated), "deleted", len(result.Deleted))
+		}
+		keyvals = append(keyvals, "duration", time.Since(begin))
+		if dryRun {
+			mw.read(ctx, err, keyvals...)
+		} else {
+			mw.write(ctx, err, keyvals...)
+		}
+	}(time.Now())
+	return mw.next.ImportUnits(ctx, classID, rows, dryRun)
+}
+
+func (mw loggingMiddleware) LinkOneRosterClass(ctx context.Context, sourcedID string, classID uuid.UUID) (err error) {
+	defer func(begin time.Time) {
+		mw.write(ctx, err,
+			"action", "LinkOneRosterClass",
+			"sourced_id", sourcedID,
+			"class", classID.String(),
+			"duration", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.LinkOneRosterClass(ctx, sourcedID, classID)
+}
+
+func (mw loggingMiddleware) SyncOneRoster(ctx context.Context, bundle *RosterBundle, dryRun, overwrite bool) (report *SyncReport, err error) {
+	defer func(begin time.Time) {
+		keyvals := []interface{}{
+			"action", "SyncOneRoster",
+			"classes", len(bundle.Classes),
+			"delta", bundle.Delta,
+			"dry_run", dryRun,
+			"overwrite", overwrite,
+		}
+		if report != nil {
+			var created, updated, deleted, conflicts, skipped int
+			for _, c := range report.Classes {
+				created, updated, deleted, conflicts = created+len(c.Created), updated+len(c.Updated), deleted+len(c.Deleted), conflicts+len(c.Conflicts)
+				if c.Skipped != "" {
+					skipped++
+				}
+			}
+			keyvals = append(keyvals, "created", created, "updated", updated, "deleted", deleted, "conflicts", conflicts, "skipped", skipped)
+		}
+		keyvals = append(keyvals, "duration", time.Since(begin))
+		if dryRun {
+			mw.read(ctx, err, keyvals...)
+		} else {
+			mw.write(ctx, err, keyvals...)
+		}
+	}(time.Now())
+	return mw.next.SyncOneRoster(ctx, bundle, dryRun, overwrite)
+}
+
+// read logs a read, unless it succeeded and is not sampled.
+func (mw loggingMiddleware) read(ctx context.Context, err error, keyvals ...interface{}) {
+	if err == nil && !mw.sampler.Sample() {
+		return
+	}
+	mw.log(ctx, err, keyvals...)
+}
+
+// write logs a mutation, and audits it if it succeeded.
+func (mw loggingMiddleware) write(ctx context.Context, err error, keyvals ...interface{}) {
+	mw.log(ctx, err, keyvals...)
+	if err == nil {
+		mw.audit.Log(append(contextKeyvals(ctx), keyvals...)...)
+	}
+}
+
+func (mw loggingMiddleware) log(ctx context.Context, err error, keyvals ...interface{}) {
+	logger := level.Info(mw.logger)
+	if err != nil {
+		logger = level.Error(mw.logger)
+	}
+	keyvals = append(contextKeyvals(ctx), keyvals...)
+	logger.Log(append(keyvals, "error", err)...)
+}
+
+// contextKeyvals identifies who made a request and how to correlate it with other logs.
+// Values missing from ctx are logged as empty strings.
+func contextKeyvals(ctx context.Context) []interface{} {
+	var user string
+	if subject, ok := Subject(ctx); ok {
+		user = subject.String()
+	}
+	return []interface{}{
+		"user", user,
+		"client", ClientID(ctx),
+		"request_id", RequestID(ctx),
+		"trace_id", tracing.TraceID(ctx),
+	}
 }
@@ -0,0 +1,78 @@
+package unitsvc_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/google/uuid"
+	"github.com/studiously/classsvc/models"
+	"github.com/studiously/unitsvc/unitsvc"
+	unittesting "github.com/studiously/unitsvc/unitsvc/testing"
+)
+
+// lines records logged lines as maps.
+type lines []map[interface{}]interface{}
+
+func (l *lines) Log(keyvals ...interface{}) error {
+	line := make(map[interface{}]interface{})
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		line[keyvals[i]] = keyvals[i+1]
+	}
+	*l = append(*l, line)
+	return nil
+}
+
+func TestLoggingWithoutIntrospection(t *testing.T) {
+	// Internal jobs call the service with contexts that were never introspected.
+	var logged, audited lines
+	svc := unitsvc.LoggingMiddleware(&logged, &audited, 1)(unitsvc.NewInMemory(unittesting.NewClassService()))
+	svc.ListUnits(context.Background(), uuid.New())
+	if len(logged) != 1 || logged[0]["user"] != "" || logged[0]["client"] != "" {
+		t.Errorf("got log lines %v", logged)
+	}
+}
+
+func TestLoggingAuditAndSampling(t *testing.T) {
+	cs := unittesting.NewClassService()
+	subject, classID := uuid.New(), uuid.New()
+	cs.Enroll(subject, classID, models.UserRoleTeacher)
+	ctx := unitsvc.WithRequestID(unittesting.NewContext(subject), "req-1")
+
+	var logged, audited lines
+	svc := unitsvc.LoggingMiddleware(&logged, &audited, 2)(unitsvc.NewInMemory(cs))
+	if err := svc.CreateUnit(ctx, classID, "Cells"); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.RenameUnit(ctx, uuid.New(), "Missing"); err == nil {
+		t.Fatal("renamed a missing unit")
+	}
+	for i := 0; i < 4; i++ {
+		svc.ListUnits(ctx, classID)
+	}
+
+	if len(audited) != 1 || audited[0]["action"] != "CreateUnit" || audited[0]["user"] != subject.String() || audited[0]["request_id"] != "req-1" {
+		t.Errorf("got audit lines %v, want only the successful CreateUnit", audited)
+	}
+	var reads int
+	for _, line := range logged {
+		if line["action"] == "ListUnits" {
+			reads++
+		}
+		if line["action"] == "RenameUnit" && line[level.Key()] != level.ErrorValue() {
+			t.Errorf("failed rename logged at level %v", line[level.Key()])
+		}
+	}
+	if reads != 2 {
+		t.Errorf("logged %d of 4 reads at 1 in 2", reads)
+	}
+}
+
+func TestClientID(t *testing.T) {
+	if got := unitsvc.ClientID(unittesting.NewContext(uuid.New())); got != unittesting.ClientID {
+		t.Errorf("ClientID = %q, want %q", got, unittesting.ClientID)
+	}
+	if _, ok := unitsvc.Subject(context.Background()); ok {
+		t.Error("Subject reported a user for an unauthenticated context")
+	}
+}
@@ -2,6 +2,7 @@ package unitsvc
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/google/uuid"
 	"github.com/nats-io/go-nats"
@@ -12,16 +13,61 @@ const (
 	SubjCreateUnit = "units.create"
 	SubjRenameUnit = "units.rename"
 	SubjDeleteUnit = "units.delete"
+	SubjRevertUnit = "units.revert"
+	// SubjCurrentUnit is published when a teacher moves a class to another unit, but not when the
+	// class moves because the unit it was on was deleted.
+	SubjCurrentUnit = "units.current"
+	// SubjUnitProgress is published when a student marks their progress through a unit.
+	SubjUnitProgress = "units.progress"
 )
 
-func MessagingMiddleware(nc *nats.Conn) Middleware {
+// EventSubject returns the subject on which MessagingMiddleware publishes a JSON Event for subj.
+//
+// The unversioned subjects carry only the ID as text, as they did before Event was introduced.
+// They are deprecated and will stop being published in a future release.
+func EventSubject(subj string) string {
+	return "v2." + subj
+}
+
+// Event is the JSON body of every message published to an EventSubject.
+type Event struct {
+	// ID is the class ID for SubjCreateUnit and SubjCurrentUnit, and the unit ID otherwise.
+	ID uuid.UUID `json:"id"`
+	// RequestID identifies the request that caused the event, if it is known.
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// EventFromChange returns the subject and event that MessagingMiddleware publishes for a change
+// reported to an OnChange callback.
+func EventFromChange(change *models.AuditEntry) (string, Event) {
+	e := Event{ID: change.UnitID, RequestID: change.RequestID}
+	switch change.Action {
+	case models.AuditCreate:
+		return SubjCreateUnit, Event{ID: change.ClassID, RequestID: change.RequestID}
+	case models.AuditDelete:
+		return SubjDeleteUnit, e
+	case models.AuditRevert:
+		return SubjRevertUnit, e
+	default:
+		return SubjRenameUnit, e
+	}
+}
+
+// Publisher publishes a message to a subject. *nats.Conn satisfies Publisher.
+type Publisher interface {
+	Publish(subj string, data []byte) error
+}
+
+var _ Publisher = (*nats.Conn)(nil)
+
+func MessagingMiddleware(nc Publisher) Middleware {
 	return func(next Service) Service {
 		return messagingMiddleware{nc, next}
 	}
 }
 
 type messagingMiddleware struct {
-	nc   *nats.Conn
+	nc   Publisher
 	next Service
 }
 
@@ -33,11 +79,18 @@ func (mm messagingMiddleware) GetUnit(ctx context.Context, unitID uuid.UUID) (*m
 	return mm.next.GetUnit(ctx, unitID)
 }
 
+func (mm messagingMiddleware) ListMyUnits(ctx context.Context, after uuid.UUID, limit int, filter UnitFilter) (*ClassUnitsPage, error) {
+	return mm.next.ListMyUnits(ctx, after, limit, filter)
+}
+
+func (mm messagingMiddleware) SearchUnits(ctx context.Context, query string, filter SearchFilter) ([]*models.UnitMatch, error) {
+	return mm.next.SearchUnits(ctx, query, filter)
+}
+
 func (mm messagingMiddleware) CreateUnit(ctx context.Context, classID uuid.UUID, title string) (err error) {
 	defer func() {
 		if err == nil {
-			cID, _ := classID.MarshalText()
-			mm.nc.Publish(SubjCreateUnit, cID)
+			mm.publish(ctx, SubjCreateUnit, classID)
 		}
 	}()
 	return mm.next.CreateUnit(ctx, classID, title)
@@ -46,8 +99,7 @@ func (mm messagingMiddleware) CreateUnit(ctx context.Context, classID uuid.UUID,
 func (mm messagingMiddleware) RenameUnit(ctx context.Context, unitID uuid.UUID, title string) (err error) {
 	defer func() {
 		if err == nil {
-			uID, _ := unitID.MarshalText()
-			mm.nc.Publish(SubjRenameUnit, uID)
+			mm.publish(ctx, SubjRenameUnit, unitID)
 		}
 	}()
 	return mm.next.RenameUnit(ctx, unitID, title)
@@ -58,9 +110,145 @@ func (mm messagingMiddleware) DeleteUnit(ctx context.Context, unitID uuid.UUID)
 		if err == nil {
 			// Don't care about errors because worst-case scenario, some data doesn't get deleted.
 			// It's inaccessible and thus irrelevant.
-			uID, _ := unitID.MarshalText()
-			mm.nc.Publish(SubjDeleteUnit, uID)
+			mm.publish(ctx, SubjDeleteUnit, unitID)
+		}
+	}()
+	return mm.next.DeleteUnit(ctx, unitID)
+}
+
+func (mm messagingMiddleware) GetCurrentUnit(ctx context.Context, classID uuid.UUID) (*models.Unit, error) {
+	return mm.next.GetCurrentUnit(ctx, classID)
+}
+
+func (mm messagingMiddleware) SetCurrentUnit(ctx context.Context, classID, unitID uuid.UUID) (err error) {
+	defer func() {
+		if err == nil {
+			mm.publish(ctx, SubjCurrentUnit, classID)
+		}
+	}()
+	return mm.next.SetCurrentUnit(ctx, classID, unitID)
+}
+
+func (mm messagingMiddleware) AdvanceUnit(ctx context.Context, classID uuid.UUID) (unit *models.Unit, err error) {
+	defer func() {
+		if err == nil {
+			mm.publish(ctx, SubjCurrentUnit, classID)
+		}
+	}()
+	return mm.next.AdvanceUnit(ctx, classID)
+}
+
+func (mm messagingMiddleware) MarkUnitProgress(ctx context.Context, unitID uuid.UUID, status string) (progress *models.UnitProgress, err error) {
+	defer func() {
+		if err == nil {
+			mm.publish(ctx, SubjUnitProgress, unitID)
+		}
+	}()
+	return mm.next.MarkUnitProgress(ctx, unitID, status)
+}
+
+func (mm messagingMiddleware) GetClassProgress(ctx context.Context, classID uuid.UUID) (*ClassProgress, error) {
+	return mm.next.GetClassProgress(ctx, classID)
+}
+
+func (mm messagingMiddleware) UnitHistory(ctx context.Context, unitID uuid.UUID) ([]*models.AuditEntry, error) {
+	return mm.next.UnitHistory(ctx, unitID)
+}
+
+func (mm messagingMiddleware) ClassAudit(ctx context.Context, classID uuid.UUID) ([]*models.AuditEntry, error) {
+	return mm.next.ClassAudit(ctx, classID)
+}
+
+func (mm messagingMiddleware) ListUnitVersions(ctx context.Context, unitID uuid.UUID) ([]*models.UnitVersion, error) {
+	return mm.next.ListUnitVersions(ctx, unitID)
+}
+
+func (mm messagingMiddleware) GetUnitVersion(ctx context.Context, unitID uuid.UUID, version int) (*models.UnitVersion, error) {
+	return mm.next.GetUnitVersion(ctx, unitID, version)
+}
+
+func (mm messagingMiddleware) RevertUnit(ctx context.Context, unitID uuid.UUID, version int) (err error) {
+	defer func() {
+		if err == nil {
+			mm.publish(ctx, SubjRevertUnit, unitID)
+		}
+	}()
+	return mm.next.RevertUnit(ctx, unitID, version)
+}
+
+func (mm messagingMiddleware) CreateWebhook(ctx context.Context, classID uuid.UUID, url, secret string, events []string) (*models.Webhook, error) {
+	return mm.next.CreateWebhook(ctx, classID, url, secret, events)
+}
+
+func (mm messagingMiddleware) ListWebhooks(ctx context.Context, classID uuid.UUID) ([]*models.Webhook, error) {
+	return mm.next.ListWebhooks(ctx, classID)
+}
+
+func (mm messagingMiddleware) DeleteWebhook(ctx context.Context, webhookID uuid.UUID) error {
+	return mm.next.DeleteWebhook(ctx, webhookID)
+}
+
+func (mm messagingMiddleware) ListDeliveries(ctx context.Context, webhookID uuid.UUID, status string) ([]*models.WebhookDelivery, error) {
+	return mm.next.ListDeliveries(ctx, webhookID, status)
+}
+
+func (mm messagingMiddleware) ExportUnits(ctx context.Context, classID uuid.UUID) ([]*models.Unit, error) {
+	return mm.next.ExportUnits(ctx, classID)
+}
+
+// ImportUnits publishes the event each unit would have had if it were changed on its own.
+func (mm messagingMiddleware) ImportUnits(ctx context.Context, classID uuid.UUID, rows []ImportRow, dryRun bool) (result *ImportResult, err error) {
+	defer func() {
+		if err != nil || result.DryRun {
+			return
+		}
+		for range result.Created {
+			mm.publish(ctx, SubjCreateUnit, classID)
+		}
+		for _, u := range result.Updated {
+			mm.publish(ctx, SubjRenameUnit, u.ID)
+		}
+		for _, u := range result.Deleted {
+			mm.publish(ctx, SubjDeleteUnit, u.ID)
+		}
+	}()
+	return mm.next.ImportUnits(ctx, classID, rows, dryRun)
+}
+
+func (mm messagingMiddleware) LinkOneRosterClass(ctx context.Context, sourcedID string, classID uuid.UUID) error {
+	return mm.next.LinkOneRosterClass(ctx, sourcedID, classID)
+}
+
+// SyncOneRoster publishes the event each unit would have had if it were changed on its own.
+func (mm messagingMiddleware) SyncOneRoster(ctx context.Context, bundle *RosterBundle, dryRun, overwrite bool) (report *SyncReport, err error) {
+	defer func() {
+		if err != nil || report.DryRun {
+			return
+		}
+		for _, c := range report.Classes {
+			for range c.Created {
+				mm.publish(ctx, SubjCreateUnit, c.ClassID)
+			}
+			for _, u := range c.Updated {
+				mm.publish(ctx, SubjRenameUnit, u.ID)
+			}
+			for _, u := range c.Deleted {
+				mm.publish(ctx, SubjDeleteUnit, u.ID)
+			}
 		}
 	}()
-	return mm.DeleteUnit(ctx, unitID)
+	return mm.next.SyncOneRoster(ctx, bundle, dryRun, overwrite)
+}
+
+// publish sends id to subj as text and as a JSON Event to EventSubject(subj).
+func (mm messagingMiddleware) publish(ctx context.Context, subj string, id uuid.UUID) error {
+	text, _ := id.MarshalText()
+	if err := mm.nc.Publish(subj, text); err != nil {
+		return err
+	}
+	data, err := json.Marshal(Event{ID: id, RequestID: RequestID(ctx)})
+	if err != nil {
+		return err
+	}
+	return mm.nc.Publish(EventSubject(subj), data)
 }
@@ -0,0 +1,91 @@
+package unitsvc
+
+import (
+	"bytes"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/studiously/unitsvc/models"
+)
+
+// Limits on the number of classes in a page of ListMyUnits.
+const (
+	DefaultClassPageSize = 20
+	MaxClassPageSize     = 100
+)
+
+// ClassUnits are the units of one class, in display order.
+type ClassUnits struct {
+	ClassID uuid.UUID      `json:"class_id"`
+	Units   []*models.Unit `json:"units"`
+}
+
+// ClassUnitsPage is a page of the classes a user belongs to, in ascending order of ID, each with
+// its units.
+type ClassUnitsPage struct {
+	Classes []*ClassUnits `json:"classes"`
+	// Next is the after of the next page, or nil on the last page.
+	Next *uuid.UUID `json:"next,omitempty"`
+}
+
+// UnitFilter narrows the units listed by ListMyUnits. The zero filter lists every unit.
+type UnitFilter struct {
+	// Statuses lists only units with one of these statuses; units of any status if empty.
+	Statuses []models.UnitStatus
+	// From and To list only units scheduled to be taught at some time from From until To. Either
+	// may be nil for a window without that end; units without a start or an end are scheduled
+	// without that end, and units with neither are not scheduled. Unscheduled units are listed
+	// only if both are nil.
+	From, To *time.Time
+}
+
+// valid reports whether the filter's statuses are statuses of units and its window does not end
+// before it starts.
+func (f UnitFilter) valid() bool {
+	for _, status := range f.Statuses {
+		if !status.Valid() {
+			return false
+		}
+	}
+	return f.From == nil || f.To == nil || f.From.Before(*f.To)
+}
+
+// matches reports whether u passes the filter.
+func (f UnitFilter) matches(u *models.Unit) bool {
+	if len(f.Statuses) > 0 {
+		found := false
+		for _, status := range f.Statuses {
+			found = found || status.OrPublished() == u.Status.OrPublished()
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.From == nil && f.To == nil {
+		return true
+	}
+	if u.StartsAt == nil && u.EndsAt == nil {
+		return false
+	}
+	return (f.To == nil || u.StartsAt == nil || u.StartsAt.Before(*f.To)) &&
+		(f.From == nil || u.EndsAt == nil || u.EndsAt.After(*f.From))
+}
+
+// pageClassIDs returns up to limit of classIDs after after, in ascending order, and the after of
+// the next page if there are more.
+func pageClassIDs(classIDs []uuid.UUID, after uuid.UUID, limit int) ([]uuid.UUID, *uuid.UUID) {
+	page := make([]uuid.UUID, 0, len(classIDs))
+	for _, classID := range classIDs {
+		if bytes.Compare(classID[:], after[:]) > 0 {
+			page = append(page, classID)
+		}
+	}
+	sort.Slice(page, func(i, j int) bool { return bytes.Compare(page[i][:], page[j][:]) < 0 })
+	if len(page) <= limit {
+		return page, nil
+	}
+	page = page[:limit]
+	next := page[limit-1]
+	return page, &next
+}
@@ -0,0 +1,210 @@
+package unitsvc
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/studiously/unitsvc/models"
+)
+
+// rosterDeleted is the status of OneRoster records that a delta bundle removes.
+const rosterDeleted = "tobedeleted"
+
+// RosterBundle is the part of a OneRoster 1.1 CSV bundle that is synced to units: the categories
+// of the line items of each class. Each category becomes a unit of the class it is linked to.
+type RosterBundle struct {
+	// Delta is set for bundles that only hold the records changed since the last one. Categories
+	// missing from a delta bundle are left as they are, rather than removed.
+	Delta   bool          `json:"delta"`
+	Classes []RosterClass `json:"classes"`
+	// DeletedCategories are the categories that a delta bundle removes from the classes in it.
+	DeletedCategories []string `json:"deleted_categories,omitempty"`
+}
+
+// RosterClass is a class of a OneRoster bundle with the categories of its line items, in the
+// order they first appear.
+type RosterClass struct {
+	SourcedID  string           `json:"sourced_id"`
+	Deleted    bool             `json:"deleted,omitempty"` // marked tobedeleted; its units are left as they are
+	Categories []RosterCategory `json:"categories"`
+}
+
+// RosterCategory is a line item category of a OneRoster bundle.
+type RosterCategory struct {
+	SourcedID string `json:"sourced_id"`
+	// Title is "" if a delta bundle has line items of the category but not the category itself,
+	// which leaves its unit as it is.
+	Title string `json:"title"`
+}
+
+// SyncReport reports the changes a OneRoster sync made to each class of the bundle, or would
+// make in a dry run.
+type SyncReport struct {
+	DryRun  bool         `json:"dry_run"`
+	Classes []*ClassSync `json:"classes"`
+}
+
+// ClassSync reports the changes a OneRoster sync made to one class. Created and updated units
+// are listed as they are after the sync.
+type ClassSync struct {
+	SourcedID string         `json:"sourced_id"`
+	ClassID   uuid.UUID      `json:"class_id"`          // uuid.Nil if the class is not linked
+	Skipped   string         `json:"skipped,omitempty"` // why the class was not synced
+	Created   []*models.Unit `json:"created"`
+	Updated   []*models.Unit `json:"updated"`
+	Deleted   []*models.Unit `json:"deleted"`
+	Conflicts []SyncConflict `json:"conflicts"`
+}
+
+// SyncConflict is a change to a unit that a OneRoster sync did not make because a teacher
+// changed the unit since the last sync.
+type SyncConflict struct {
+	SourcedID string    `json:"sourced_id"` // of the category
+	UnitID    uuid.UUID `json:"unit_id"`
+	Message   string    `json:"message"`
+}
+
+// ParseOneRoster reads the classes, categories and line items of a OneRoster 1.1 CSV bundle: a
+// zip file of manifest.csv, lineItems.csv and, optionally, classes.csv and categories.csv. The
+// bundle is a delta if its manifest says lineItems.csv is. It returns ErrBadRequest if the bundle
+// cannot be read, and an *ImportError if a line item of a bulk bundle refers to a category that
+// is not in it.
+func ParseOneRoster(r io.Reader) (*RosterBundle, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, ErrBadRequest
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, ErrBadRequest
+	}
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[strings.ToLower(path.Base(f.Name))] = f
+	}
+	read := func(name string) ([]map[string]string, error) {
+		f, ok := files[strings.ToLower(name)]
+		if !ok {
+			return nil, nil
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, ErrBadRequest
+		}
+		defer rc.Close()
+		return readRosterCSV(rc)
+	}
+
+	manifest, err := read("manifest.csv")
+	if err != nil {
+		return nil, err
+	}
+	lineItems, err := read("lineItems.csv")
+	if err != nil {
+		return nil, err
+	}
+	if manifest == nil || lineItems == nil {
+		return nil, ErrBadRequest
+	}
+	classes, err := read("classes.csv")
+	if err != nil {
+		return nil, err
+	}
+	categories, err := read("categories.csv")
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &RosterBundle{Classes: []RosterClass{}}
+	for _, rec := range manifest {
+		if rec["propertyName"] == "file.lineItems" {
+			bundle.Delta = strings.EqualFold(rec["value"], "delta")
+		}
+	}
+	titles := make(map[string]string)
+	for _, rec := range categories {
+		if rec["status"] == rosterDeleted {
+			bundle.DeletedCategories = append(bundle.DeletedCategories, rec["sourcedId"])
+			continue
+		}
+		titles[rec["sourcedId"]] = rec["title"]
+	}
+
+	index := make(map[string]int)
+	class := func(sourcedID string) *RosterClass {
+		i, ok := index[sourcedID]
+		if !ok {
+			i = len(bundle.Classes)
+			index[sourcedID] = i
+			bundle.Classes = append(bundle.Classes, RosterClass{SourcedID: sourcedID, Categories: []RosterCategory{}})
+		}
+		return &bundle.Classes[i]
+	}
+	for _, rec := range classes {
+		class(rec["sourcedId"]).Deleted = rec["status"] == rosterDeleted
+	}
+	seen := make(map[[2]string]bool)
+	var errs []RowError
+	for i, rec := range lineItems {
+		classID, categoryID := rec["classSourcedId"], rec["categorySourcedId"]
+		if classID == "" || categoryID == "" {
+			errs = append(errs, RowError{i + 1, "classSourcedId", "and categorySourcedId are required"})
+			continue
+		}
+		if rec["status"] == rosterDeleted {
+			continue
+		}
+		title, ok := titles[categoryID]
+		if !ok && !bundle.Delta {
+			errs = append(errs, RowError{i + 1, "categorySourcedId", "is not in categories.csv"})
+			continue
+		}
+		if seen[[2]string{classID, categoryID}] {
+			continue
+		}
+		seen[[2]string{classID, categoryID}] = true
+		c := class(classID)
+		c.Categories = append(c.Categories, RosterCategory{SourcedID: categoryID, Title: title})
+	}
+	if len(errs) > 0 {
+		return nil, &ImportError{errs}
+	}
+	return bundle, nil
+}
+
+// readRosterCSV reads the records of a OneRoster CSV file as maps from column names to trimmed values.
+func readRosterCSV(r io.Reader) ([]map[string]string, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, ErrBadRequest
+	}
+	// Some exporters begin files with a byte order mark.
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+	records := []map[string]string{}
+	for {
+		fields, err := cr.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, ErrBadRequest
+		}
+		rec := make(map[string]string, len(header))
+		for i, name := range header {
+			if i < len(fields) {
+				rec[strings.TrimSpace(name)] = strings.TrimSpace(fields[i])
+			}
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
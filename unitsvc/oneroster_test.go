@@ -0,0 +1,123 @@
+package unitsvc_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/studiously/unitsvc/unitsvc"
+)
+
+// rosterZip returns a OneRoster CSV bundle of the given files.
+func rosterZip(t *testing.T, files map[string]string) string {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+		f.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close bundle: %v", err)
+	}
+	return buf.String()
+}
+
+const (
+	rosterManifest   = "propertyName,value\nmanifest.version,1.0\noneroster.version,1.1\nfile.lineItems,bulk\nfile.categories,bulk\n"
+	rosterCategories = "sourcedId,status,dateLastModified,title\ncat-hw,active,2017-09-01T00:00:00Z,Homework\ncat-labs,active,2017-09-01T00:00:00Z,Labs\n"
+	rosterLineItems  = "sourcedId,status,dateLastModified,title,description,assignDate,dueDate,classSourcedId,categorySourcedId,gradingPeriodSourcedId\n" +
+		"li-1,active,,Problem Set 1,,,,class-bio,cat-hw,term-1\n" +
+		"li-2,active,,Lab 1,,,,class-bio,cat-labs,term-1\n" +
+		"li-3,active,,Problem Set 2,,,,class-bio,cat-hw,term-1\n" +
+		"li-4,active,,Essay,,,,class-eng,cat-hw,term-1\n"
+)
+
+func TestParseOneRoster(t *testing.T) {
+	bundle, err := unitsvc.ParseOneRoster(bytes.NewBufferString(rosterZip(t, map[string]string{
+		"export/manifest.csv":   rosterManifest,
+		"export/classes.csv":    "\ufeffsourcedId,status,title\nclass-old,tobedeleted,Old\n",
+		"export/categories.csv": rosterCategories,
+		"export/lineItems.csv":  rosterLineItems,
+	})))
+	if err != nil {
+		t.Fatalf("ParseOneRoster: %v", err)
+	}
+	var got []string
+	for _, c := range bundle.Classes {
+		got = append(got, fmt.Sprintf("%s %v %v", c.SourcedID, c.Deleted, c.Categories))
+	}
+	if want := "[class-old true [] class-bio false [{cat-hw Homework} {cat-labs Labs}] class-eng false [{cat-hw Homework}]]"; fmt.Sprint(got) != want {
+		t.Errorf("got classes %v, want %s", got, want)
+	}
+	if bundle.Delta {
+		t.Errorf("got a delta bundle, want bulk")
+	}
+
+	_, err = unitsvc.ParseOneRoster(bytes.NewBufferString(rosterZip(t, map[string]string{
+		"manifest.csv":  rosterManifest,
+		"lineItems.csv": rosterLineItems,
+	})))
+	if ierr, ok := err.(*unitsvc.ImportError); !ok || len(ierr.Rows) != 4 || ierr.Rows[0].Field != "categorySourcedId" {
+		t.Errorf("got error %v for line items without categories, want an *ImportError for each row", err)
+	}
+	for name, files := range map[string]map[string]string{
+		"without manifest":   {"lineItems.csv": rosterLineItems},
+		"without line items": {"manifest.csv": rosterManifest},
+	} {
+		if _, err := unitsvc.ParseOneRoster(bytes.NewBufferString(rosterZip(t, files))); err != unitsvc.ErrBadRequest {
+			t.Errorf("got error %v for bundle %s, want %v", err, name, unitsvc.ErrBadRequest)
+		}
+	}
+}
+
+func TestHTTPOneRosterSync(t *testing.T) {
+	s, teacher, classID := newClass(t)
+	defer s.Close()
+	token := s.Token(teacher, "units")
+	do(t, s, "PUT", "/oneroster/classes/class-bio", token, map[string]interface{}{"class_id": classID}, http.StatusOK).Body.Close()
+	bundle := rosterZip(t, map[string]string{
+		"manifest.csv":   rosterManifest,
+		"categories.csv": rosterCategories,
+		"lineItems.csv":  rosterLineItems,
+	})
+	sync := func(query string) unitsvc.SyncReport {
+		resp := postFile(t, s, "/oneroster/sync"+query, token, "application/zip", bundle, http.StatusOK)
+		defer resp.Body.Close()
+		var report unitsvc.SyncReport
+		if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+			t.Fatalf("decode report: %v", err)
+		}
+		return report
+	}
+
+	report := sync("?dryRun=true")
+	if !report.DryRun || len(report.Classes) != 2 || len(report.Classes[0].Created) != 2 {
+		t.Fatalf("got dry run %+v, want 2 units created in class-bio", report)
+	}
+	if eng := report.Classes[1]; eng.SourcedID != "class-eng" || eng.Skipped == "" {
+		t.Errorf("got %+v for class-eng, want it skipped as it is not linked", eng)
+	}
+	if units := listUnits(t, s, token, classID); len(units) != 0 {
+		t.Errorf("got %d units after dry run, want 0", len(units))
+	}
+
+	sync("")
+	records := exportCSV(t, s, token, classID)
+	if got := fmt.Sprint(records[1][2], ", ", records[2][2]); len(records) != 3 || got != "Homework, Labs" {
+		t.Errorf("got export %v after sync, want Homework and Labs", records)
+	}
+	if report := sync(""); len(report.Classes[0].Created)+len(report.Classes[0].Updated)+len(report.Classes[0].Deleted) != 0 {
+		t.Errorf("second sync made changes: %+v", report.Classes[0])
+	}
+
+	postFile(t, s, "/oneroster/sync", token, "application/zip", "not a zip file", http.StatusBadRequest).Body.Close()
+	invalid := rosterZip(t, map[string]string{"manifest.csv": rosterManifest, "lineItems.csv": rosterLineItems})
+	postFile(t, s, "/oneroster/sync", token, "application/zip", invalid, http.StatusUnprocessableEntity).Body.Close()
+	do(t, s, "PUT", "/oneroster/classes/class-bio", token, map[string]interface{}{"class_id": "nonsense"}, http.StatusBadRequest).Body.Close()
+}
@@ -0,0 +1,89 @@
+package unitsvc
+
+import (
+	"bytes"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/studiously/unitsvc/models"
+)
+
+// ClassProgress is every student's progress through the units of a class.
+type ClassProgress struct {
+	ClassID  uuid.UUID          `json:"class_id"`
+	Units    []*models.Unit     `json:"units"` // in display order
+	Students []*StudentProgress `json:"students"`
+}
+
+// StudentProgress is a student's progress through each unit of a class.
+type StudentProgress struct {
+	UserID uuid.UUID `json:"user_id"`
+	// Units are in the order of ClassProgress.Units. Units the student has never marked are
+	// models.ProgressNotStarted, with a zero UpdatedAt.
+	Units     []*models.UnitProgress `json:"units"`
+	Completed int                    `json:"completed"` // how many of the units the student has completed
+}
+
+// validProgress reports whether status is one of the progress statuses.
+func validProgress(status string) bool {
+	switch status {
+	case models.ProgressNotStarted, models.ProgressInProgress, models.ProgressCompleted:
+		return true
+	default:
+		return false
+	}
+}
+
+// nextProgress returns a student's progress through a unit once they mark it status at t.
+// before is their progress until now, or nil if they have never marked the unit. Marking a unit
+// not started again forgets when it was started and completed.
+func nextProgress(before *models.UnitProgress, unit *models.Unit, userID uuid.UUID, status string, t time.Time) *models.UnitProgress {
+	p := &models.UnitProgress{UnitID: unit.ID, UserID: userID, ClassID: unit.ClassID, Status: status, UpdatedAt: t}
+	if before != nil {
+		p.StartedAt, p.CompletedAt = before.StartedAt, before.CompletedAt
+	}
+	switch status {
+	case models.ProgressNotStarted:
+		p.StartedAt, p.CompletedAt = nil, nil
+	case models.ProgressInProgress:
+		p.CompletedAt = nil
+	case models.ProgressCompleted:
+		if before == nil || before.Status != models.ProgressCompleted {
+			p.CompletedAt = &t
+		}
+	}
+	if status != models.ProgressNotStarted && p.StartedAt == nil {
+		p.StartedAt = &t
+	}
+	return p
+}
+
+// classProgress arranges the progress of a class by student and unit. students are sorted by ID,
+// and progress of anyone else is left out.
+func classProgress(classID uuid.UUID, units []*models.Unit, students []uuid.UUID, progress []*models.UnitProgress) *ClassProgress {
+	sort.Slice(students, func(i, j int) bool { return bytes.Compare(students[i][:], students[j][:]) < 0 })
+	byStudent := make(map[uuid.UUID]map[uuid.UUID]*models.UnitProgress, len(students))
+	for _, p := range progress {
+		if byStudent[p.UserID] == nil {
+			byStudent[p.UserID] = make(map[uuid.UUID]*models.UnitProgress)
+		}
+		byStudent[p.UserID][p.UnitID] = p
+	}
+	res := &ClassProgress{ClassID: classID, Units: units, Students: make([]*StudentProgress, 0, len(students))}
+	for _, student := range students {
+		sp := &StudentProgress{UserID: student, Units: make([]*models.UnitProgress, 0, len(units))}
+		for _, u := range units {
+			p, ok := byStudent[student][u.ID]
+			if !ok {
+				p = &models.UnitProgress{UnitID: u.ID, UserID: student, ClassID: classID, Status: models.ProgressNotStarted}
+			}
+			if p.Status == models.ProgressCompleted {
+				sp.Completed++
+			}
+			sp.Units = append(sp.Units, p)
+		}
+		res.Students = append(res.Students, sp)
+	}
+	return res
+}
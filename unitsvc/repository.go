@@ -0,0 +1,598 @@
+package unitsvc
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/studiously/unitsvc/models"
+)
+
+// Repository is the storage backend behind a Service.
+// Implementations must be safe for concurrent use and return ErrNotFound for missing units.
+type Repository interface {
+	// UnitsByClassID gets all units in a class, sorted by display order.
+	UnitsByClassID(ctx context.Context, classID uuid.UUID) ([]*models.Unit, error)
+	// UnitsByClassIDs gets the units in the given classes that match filter, sorted by class ID and
+	// then display order.
+	UnitsByClassIDs(ctx context.Context, classIDs []uuid.UUID, filter UnitFilter) ([]*models.Unit, error)
+	// UnitByID gets a single unit.
+	UnitByID(ctx context.Context, unitID uuid.UUID) (*models.Unit, error)
+	// SaveUnit inserts a unit, or updates it if a unit with the same ID already exists.
+	SaveUnit(ctx context.Context, unit *models.Unit) error
+	// DeleteUnit deletes a unit. Deleting a unit that does not exist is not an error.
+	DeleteUnit(ctx context.Context, unitID uuid.UUID) error
+	// ClassIDs gets up to limit distinct IDs of the classes that have units, in ascending order,
+	// starting after after. Pass uuid.Nil for the first page, then the last ID of each page.
+	ClassIDs(ctx context.Context, after uuid.UUID, limit int) ([]uuid.UUID, error)
+	// SearchUnits gets up to limit units in the given classes whose titles or descriptions match
+	// query, best match first. Backends without full-text search match units whose titles or
+	// descriptions contain every word of query.
+	SearchUnits(ctx context.Context, query string, classIDs []uuid.UUID, limit int) ([]*models.UnitMatch, error)
+	// AppendAudit records a change to a unit. Entries are never modified once appended.
+	AppendAudit(ctx context.Context, entry *models.AuditEntry) error
+	// AuditByUnitID gets the changes to a unit, oldest first.
+	AuditByUnitID(ctx context.Context, unitID uuid.UUID) ([]*models.AuditEntry, error)
+	// AuditByClassID gets the changes to all units in a class, oldest first.
+	AuditByClassID(ctx context.Context, classID uuid.UUID) ([]*models.AuditEntry, error)
+	// PurgeAudit deletes entries created before t, returning how many were deleted.
+	PurgeAudit(ctx context.Context, t time.Time) (int64, error)
+	// AppendVersion stores a snapshot of a unit. Its version number must not have been used for the unit yet.
+	AppendVersion(ctx context.Context, version *models.UnitVersion) error
+	// VersionsByUnitID gets the stored versions of a unit, oldest first.
+	VersionsByUnitID(ctx context.Context, unitID uuid.UUID) ([]*models.UnitVersion, error)
+	// PruneVersions deletes all but the newest keep versions of a unit.
+	PruneVersions(ctx context.Context, unitID uuid.UUID, keep int) error
+	// SaveWebhook inserts a webhook. Webhooks are never modified once saved.
+	SaveWebhook(ctx context.Context, hook *models.Webhook) error
+	// WebhookByID gets a single webhook.
+	WebhookByID(ctx context.Context, webhookID uuid.UUID) (*models.Webhook, error)
+	// WebhooksByClassID gets the webhooks of a class, oldest first.
+	WebhooksByClassID(ctx context.Context, classID uuid.UUID) ([]*models.Webhook, error)
+	// DeleteWebhook deletes a webhook and its deliveries. Deleting a webhook that does not exist is not an error.
+	DeleteWebhook(ctx context.Context, webhookID uuid.UUID) error
+	// AppendDelivery queues an event for a webhook.
+	AppendDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	// UpdateDelivery records an attempt to deliver an event: its status, attempts, next attempt,
+	// last error, last status code and update time.
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	// DueDeliveries gets up to limit pending deliveries whose next attempt is not after t, soonest first.
+	DueDeliveries(ctx context.Context, t time.Time, limit int) ([]*models.WebhookDelivery, error)
+	// DeliveriesByWebhookID gets the deliveries of a webhook with the given status, or all of
+	// them if status is empty, oldest first.
+	DeliveriesByWebhookID(ctx context.Context, webhookID uuid.UUID, status string) ([]*models.WebhookDelivery, error)
+	// SaveOneRosterClass links a OneRoster class to a class, replacing any link it already has.
+	SaveOneRosterClass(ctx context.Context, link *models.OneRosterClass) error
+	// OneRosterClassBySourcedID gets the link of a OneRoster class.
+	OneRosterClassBySourcedID(ctx context.Context, sourcedID string) (*models.OneRosterClass, error)
+	// OneRosterUnitsByClassID gets the synced categories of a class, sorted by sourcedId.
+	OneRosterUnitsByClassID(ctx context.Context, classID uuid.UUID) ([]*models.OneRosterUnit, error)
+	// SaveOneRosterUnit inserts the unit of a category, or updates it if the category already has one.
+	SaveOneRosterUnit(ctx context.Context, link *models.OneRosterUnit) error
+	// DeleteOneRosterUnit forgets the unit of a category. Deleting a link that does not exist is not an error.
+	DeleteOneRosterUnit(ctx context.Context, classID uuid.UUID, sourcedID string) error
+	// CurrentUnitByClassID gets the unit a class is on.
+	CurrentUnitByClassID(ctx context.Context, classID uuid.UUID) (*models.CurrentUnit, error)
+	// SaveCurrentUnit sets the unit a class is on, replacing the one it was on before.
+	SaveCurrentUnit(ctx context.Context, cu *models.CurrentUnit) error
+	// DeleteCurrentUnit clears the unit a class is on. Clearing a class that is on no unit is not an error.
+	DeleteCurrentUnit(ctx context.Context, classID uuid.UUID) error
+	// UnitProgressByUser gets a student's progress through a unit.
+	UnitProgressByUser(ctx context.Context, unitID, userID uuid.UUID) (*models.UnitProgress, error)
+	// UnitProgressByClassID gets every student's progress through the units of a class, sorted by
+	// student and then unit.
+	UnitProgressByClassID(ctx context.Context, classID uuid.UUID) ([]*models.UnitProgress, error)
+	// SaveUnitProgress inserts a student's progress through a unit, or updates it if they already have some.
+	SaveUnitProgress(ctx context.Context, progress *models.UnitProgress) error
+	// DeleteUnitProgress deletes every student's progress through a unit. Deleting progress that
+	// does not exist is not an error.
+	DeleteUnitProgress(ctx context.Context, unitID uuid.UUID) error
+	// Transact calls fn with a Repository whose operations all run in one transaction.
+	// The transaction is committed if fn returns nil and rolled back otherwise.
+	Transact(ctx context.Context, fn func(Repository) error) error
+}
+
+// NewRepository returns the Repository for a database/sql driver name.
+func NewRepository(driver string, db *sql.DB) (Repository, error) {
+	switch driver {
+	case "postgres":
+		return NewPostgresRepository(db), nil
+	case "mysql":
+		return NewMySQLRepository(db), nil
+	case "sqlite":
+		return NewSQLiteRepository(db), nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", driver)
+	}
+}
+
+// queryer is implemented by both *sql.DB and *sql.Tx.
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// contextDB binds a context to a queryer so that it satisfies models.XODB.
+type contextDB struct {
+	ctx context.Context
+	db  queryer
+}
+
+func (c contextDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return c.db.ExecContext(c.ctx, query, args...)
+}
+
+func (c contextDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return c.db.QueryContext(c.ctx, query, args...)
+}
+
+func (c contextDB) QueryRow(query string, args ...interface{}) *sql.Row {
+	return c.db.QueryRowContext(c.ctx, query, args...)
+}
+
+// tracedQueryer records a span for each statement run in a context that carries one.
+type tracedQueryer struct {
+	db     queryer
+	dbType string
+}
+
+func (t tracedQueryer) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	span, ctx := t.startSpan(ctx, query)
+	res, err := t.db.ExecContext(ctx, query, args...)
+	finishSpan(span, err)
+	return res, err
+}
+
+func (t tracedQueryer) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	span, ctx := t.startSpan(ctx, query)
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	finishSpan(span, err)
+	return rows, err
+}
+
+// QueryRowContext is not traced, as the row is only read by the caller's Scan after it returns.
+// Use queryRow instead.
+func (t tracedQueryer) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return t.db.QueryRowContext(ctx, query, args...)
+}
+
+// queryRow runs a query that returns at most one row and scans it into dest. Its span ends after
+// Scan, which reads the row and reports the query's errors.
+func (t tracedQueryer) queryRow(ctx context.Context, query string, args []interface{}, dest ...interface{}) error {
+	span, ctx := t.startSpan(ctx, query)
+	err := t.db.QueryRowContext(ctx, query, args...).Scan(dest...)
+	finishSpan(span, err)
+	return err
+}
+
+// startSpan starts a child of the span in ctx. It returns a nil span if ctx has none,
+// so that statements outside a traced request do not start traces of their own.
+func (t tracedQueryer) startSpan(ctx context.Context, query string) (opentracing.Span, context.Context) {
+	parent := opentracing.SpanFromContext(ctx)
+	if parent == nil {
+		return nil, ctx
+	}
+	span := parent.Tracer().StartSpan("sql", opentracing.ChildOf(parent.Context()))
+	ext.SpanKindRPCClient.Set(span)
+	ext.DBType.Set(span, t.dbType)
+	ext.DBStatement.Set(span, query)
+	return span, opentracing.ContextWithSpan(ctx, span)
+}
+
+// finishSpan finishes span, marking it as failed if err is set. sql.ErrNoRows only means that a
+// row was not found, so it is not recorded as an error.
+func finishSpan(span opentracing.Span, err error) {
+	if span == nil {
+		return
+	}
+	if err != nil && err != sql.ErrNoRows {
+		ext.Error.Set(span, true)
+		span.SetTag("error.message", err.Error())
+	}
+	span.Finish()
+}
+
+// transact runs fn in a new transaction on db, binding it to a Repository with bind.
+// If db is already a transaction, fn joins it instead.
+func transact(ctx context.Context, db tracedQueryer, bind func(tracedQueryer) Repository, fn func(Repository) error) error {
+	sqlDB, ok := db.db.(*sql.DB)
+	if !ok {
+		return fn(bind(db))
+	}
+	tx, err := sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(bind(tracedQueryer{tx, db.dbType})); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// unitState is a JSON column holding a snapshot of a unit, such as its state before or
+// after a change. A nil unit is stored as NULL.
+type unitState struct {
+	unit **models.Unit
+}
+
+func (s unitState) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*s.unit = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into unit state", src)
+	}
+	*s.unit = new(models.Unit)
+	return json.Unmarshal(data, *s.unit)
+}
+
+// unitStateValue returns the column value for unit.
+func unitStateValue(unit *models.Unit) (interface{}, error) {
+	if unit == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(unit)
+	return string(data), err
+}
+
+// auditColumns are the columns of unit_audit, in the order used by auditArgs and queryAudit.
+const auditColumns = `id, unit_id, class_id, action, actor, client_id, request_id, before_state, after_state, created_at`
+
+// uuidValue passes a UUID to drivers that store its string form.
+func uuidValue(id uuid.UUID) interface{} {
+	return id
+}
+
+// auditArgs returns the values of auditColumns for e, converting UUIDs with id.
+func auditArgs(e *models.AuditEntry, id func(uuid.UUID) interface{}) ([]interface{}, error) {
+	before, err := unitStateValue(e.Before)
+	if err != nil {
+		return nil, err
+	}
+	after, err := unitStateValue(e.After)
+	if err != nil {
+		return nil, err
+	}
+	return []interface{}{id(e.ID), id(e.UnitID), id(e.ClassID), e.Action, id(e.Actor), e.ClientID, e.RequestID, before, after, e.CreatedAt}, nil
+}
+
+// placeholders returns n comma-separated ? placeholders, for IN lists.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// unitFilterSQL returns the conditions that select the units matching filter, each starting with
+// AND, and their arguments. param returns the placeholder of the nth argument of the query; the
+// arguments are numbered from first.
+func unitFilterSQL(filter UnitFilter, first int, param func(n int) string) (string, []interface{}) {
+	var conds string
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return param(first + len(args) - 1)
+	}
+	if len(filter.Statuses) > 0 {
+		params := make([]string, 0, len(filter.Statuses))
+		for _, status := range filter.Statuses {
+			params = append(params, arg(string(status.OrPublished())))
+		}
+		conds += ` AND status IN (` + strings.Join(params, ", ") + `)`
+	}
+	if filter.From != nil || filter.To != nil {
+		conds += ` AND (starts_at IS NOT NULL OR ends_at IS NOT NULL)`
+	}
+	if filter.To != nil {
+		conds += ` AND (starts_at IS NULL OR starts_at < ` + arg(filter.To.UTC()) + `)`
+	}
+	if filter.From != nil {
+		conds += ` AND (ends_at IS NULL OR ends_at > ` + arg(filter.From.UTC()) + `)`
+	}
+	return conds, args
+}
+
+// unitColumns are the columns of units, in the order used by unitFields.
+const unitColumns = `id, class_id, title, display_order, created_at, description, metadata, parent_id, resources, status, starts_at, ends_at`
+
+// unitFields returns the scan destinations of unitColumns for u.
+func unitFields(u *models.Unit) []interface{} {
+	return []interface{}{&u.ID, &u.ClassID, &u.Title, &u.DisplayOrder, &u.CreatedAt, &u.Description, &u.Metadata, &u.ParentID, &u.Resources, &u.Status, &u.StartsAt, &u.EndsAt}
+}
+
+// queryUnits runs a query that selects unitColumns.
+func queryUnits(ctx context.Context, db queryer, query string, args ...interface{}) ([]*models.Unit, error) {
+	q, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+
+	res := []*models.Unit{}
+	for q.Next() {
+		var u models.Unit
+		if err := q.Scan(unitFields(&u)...); err != nil {
+			return nil, err
+		}
+		res = append(res, &u)
+	}
+	return res, q.Err()
+}
+
+func queryClassIDs(ctx context.Context, db queryer, query string, args ...interface{}) ([]uuid.UUID, error) {
+	q, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+
+	res := []uuid.UUID{}
+	for q.Next() {
+		var id uuid.UUID
+		if err := q.Scan(&id); err != nil {
+			return nil, err
+		}
+		res = append(res, id)
+	}
+	return res, q.Err()
+}
+
+// queryAudit runs a query that selects auditColumns.
+func queryAudit(ctx context.Context, db queryer, query string, args ...interface{}) ([]*models.AuditEntry, error) {
+	q, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+
+	res := []*models.AuditEntry{}
+	for q.Next() {
+		var e models.AuditEntry
+		err := q.Scan(&e.ID, &e.UnitID, &e.ClassID, &e.Action, &e.Actor, &e.ClientID, &e.RequestID, unitState{&e.Before}, unitState{&e.After}, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &e)
+	}
+	return res, q.Err()
+}
+
+// versionColumns are the columns of unit_versions, in the order used by versionArgs and queryVersions.
+const versionColumns = `unit_id, version, state, author, created_at`
+
+// versionArgs returns the values of versionColumns for v, converting UUIDs with id.
+func versionArgs(v *models.UnitVersion, id func(uuid.UUID) interface{}) ([]interface{}, error) {
+	state, err := unitStateValue(v.Unit)
+	if err != nil {
+		return nil, err
+	}
+	return []interface{}{id(v.UnitID), v.Version, state, id(v.Author), v.CreatedAt}, nil
+}
+
+// queryVersions runs a query that selects versionColumns.
+func queryVersions(ctx context.Context, db queryer, query string, args ...interface{}) ([]*models.UnitVersion, error) {
+	q, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+
+	res := []*models.UnitVersion{}
+	for q.Next() {
+		var v models.UnitVersion
+		if err := q.Scan(&v.UnitID, &v.Version, unitState{&v.Unit}, &v.Author, &v.CreatedAt); err != nil {
+			return nil, err
+		}
+		res = append(res, &v)
+	}
+	return res, q.Err()
+}
+
+// pruneVersions deletes all but the newest keep versions of a unit. The newest version is
+// looked up first because MySQL cannot delete from a table it selects from in a subquery.
+// maxQuery and deleteQuery are parameterized by the unit ID, and then the unit ID and the
+// newest version to delete.
+func pruneVersions(ctx context.Context, db tracedQueryer, maxQuery, deleteQuery string, unitID interface{}, keep int) error {
+	var newest int
+	if err := db.queryRow(ctx, maxQuery, []interface{}{unitID}, &newest); err != nil {
+		return err
+	}
+	if newest <= keep {
+		return nil
+	}
+	_, err := db.ExecContext(ctx, deleteQuery, unitID, newest-keep)
+	return err
+}
+
+// webhookColumns are the columns of webhooks, in the order used by webhookArgs and queryWebhooks.
+const webhookColumns = `id, class_id, url, secret, events, created_by, created_at`
+
+// webhookArgs returns the values of webhookColumns for h, converting UUIDs with id.
+// Events are stored as a comma-separated list.
+func webhookArgs(h *models.Webhook, id func(uuid.UUID) interface{}) []interface{} {
+	return []interface{}{id(h.ID), id(h.ClassID), h.URL, h.Secret, strings.Join(h.Events, ","), id(h.CreatedBy), h.CreatedAt}
+}
+
+// queryWebhooks runs a query that selects webhookColumns.
+func queryWebhooks(ctx context.Context, db queryer, query string, args ...interface{}) ([]*models.Webhook, error) {
+	q, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+
+	res := []*models.Webhook{}
+	for q.Next() {
+		var (
+			h      models.Webhook
+			events string
+		)
+		if err := q.Scan(&h.ID, &h.ClassID, &h.URL, &h.Secret, &events, &h.CreatedBy, &h.CreatedAt); err != nil {
+			return nil, err
+		}
+		h.Events = []string{}
+		if events != "" {
+			h.Events = strings.Split(events, ",")
+		}
+		res = append(res, &h)
+	}
+	return res, q.Err()
+}
+
+// webhookByID runs a query that selects the webhookColumns of a single webhook.
+func webhookByID(ctx context.Context, db queryer, query string, webhookID interface{}) (*models.Webhook, error) {
+	hooks, err := queryWebhooks(ctx, db, query, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if len(hooks) == 0 {
+		return nil, ErrNotFound
+	}
+	return hooks[0], nil
+}
+
+// deliveryColumns are the columns of webhook_deliveries, in the order used by deliveryArgs and queryDeliveries.
+const deliveryColumns = `id, webhook_id, event, payload, status, attempts, next_attempt_at, last_error, last_status_code, created_at, updated_at`
+
+// deliveryArgs returns the values of deliveryColumns for d, converting UUIDs with id.
+func deliveryArgs(d *models.WebhookDelivery, id func(uuid.UUID) interface{}) []interface{} {
+	return []interface{}{id(d.ID), id(d.WebhookID), d.Event, string(d.Payload), d.Status, d.Attempts, d.NextAttemptAt, d.LastError, d.LastStatusCode, d.CreatedAt, d.UpdatedAt}
+}
+
+// queryDeliveries runs a query that selects deliveryColumns.
+func queryDeliveries(ctx context.Context, db queryer, query string, args ...interface{}) ([]*models.WebhookDelivery, error) {
+	q, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+
+	res := []*models.WebhookDelivery{}
+	for q.Next() {
+		var (
+			d       models.WebhookDelivery
+			payload string
+		)
+		err := q.Scan(&d.ID, &d.WebhookID, &d.Event, &payload, &d.Status, &d.Attempts, &d.NextAttemptAt, &d.LastError, &d.LastStatusCode, &d.CreatedAt, &d.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		d.Payload = json.RawMessage(payload)
+		res = append(res, &d)
+	}
+	return res, q.Err()
+}
+
+// oneRosterClassColumns are the columns of oneroster_classes, in the order used by oneRosterClassBySourcedID.
+const oneRosterClassColumns = `sourced_id, class_id, created_at`
+
+// oneRosterClassBySourcedID runs a query that selects the oneRosterClassColumns of a single link.
+func oneRosterClassBySourcedID(ctx context.Context, db tracedQueryer, query string, sourcedID string) (*models.OneRosterClass, error) {
+	var c models.OneRosterClass
+	err := db.queryRow(ctx, query, []interface{}{sourcedID}, &c.SourcedID, &c.ClassID, &c.CreatedAt)
+	switch err {
+	case nil:
+		return &c, nil
+	case sql.ErrNoRows:
+		return nil, ErrNotFound
+	default:
+		return nil, err
+	}
+}
+
+// currentUnitColumns are the columns of current_units, in the order used by currentUnitByClassID.
+const currentUnitColumns = `class_id, unit_id, updated_by, updated_at`
+
+// currentUnitByClassID runs a query that selects the currentUnitColumns of a single class. classID
+// is passed as the query's only argument, already converted to the backend's UUID representation.
+func currentUnitByClassID(ctx context.Context, db tracedQueryer, query string, classID interface{}) (*models.CurrentUnit, error) {
+	var cu models.CurrentUnit
+	err := db.queryRow(ctx, query, []interface{}{classID}, &cu.ClassID, &cu.UnitID, &cu.UpdatedBy, &cu.UpdatedAt)
+	switch err {
+	case nil:
+		return &cu, nil
+	case sql.ErrNoRows:
+		return nil, ErrNotFound
+	default:
+		return nil, err
+	}
+}
+
+// unitProgressColumns are the columns of unit_progress, in the order used by unitProgressArgs and
+// queryUnitProgress.
+const unitProgressColumns = `unit_id, user_id, class_id, status, started_at, completed_at, updated_at`
+
+// unitProgressArgs returns the values of unitProgressColumns for p, converting UUIDs with id.
+func unitProgressArgs(p *models.UnitProgress, id func(uuid.UUID) interface{}) []interface{} {
+	return []interface{}{id(p.UnitID), id(p.UserID), id(p.ClassID), p.Status, p.StartedAt, p.CompletedAt, p.UpdatedAt}
+}
+
+// queryUnitProgress runs a query that selects unitProgressColumns.
+func queryUnitProgress(ctx context.Context, db queryer, query string, args ...interface{}) ([]*models.UnitProgress, error) {
+	q, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+
+	res := []*models.UnitProgress{}
+	for q.Next() {
+		var p models.UnitProgress
+		if err := q.Scan(&p.UnitID, &p.UserID, &p.ClassID, &p.Status, &p.StartedAt, &p.CompletedAt, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		res = append(res, &p)
+	}
+	return res, q.Err()
+}
+
+// oneUnitProgress returns the only progress in res, or ErrNotFound if there is none.
+func oneUnitProgress(res []*models.UnitProgress, err error) (*models.UnitProgress, error) {
+	if err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return nil, ErrNotFound
+	}
+	return res[0], nil
+}
+
+// oneRosterUnitColumns are the columns of oneroster_units, in the order used by oneRosterUnitArgs
+// and queryOneRosterUnits.
+const oneRosterUnitColumns = `class_id, sourced_id, unit_id, title, synced_at`
+
+// oneRosterUnitArgs returns the values of oneRosterUnitColumns for u, converting UUIDs with id.
+func oneRosterUnitArgs(u *models.OneRosterUnit, id func(uuid.UUID) interface{}) []interface{} {
+	return []interface{}{id(u.ClassID), u.SourcedID, id(u.UnitID), u.Title, u.SyncedAt}
+}
+
+// queryOneRosterUnits runs a query that selects oneRosterUnitColumns.
+func queryOneRosterUnits(ctx context.Context, db queryer, query string, args ...interface{}) ([]*models.OneRosterUnit, error) {
+	q, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+
+	res := []*models.OneRosterUnit{}
+	for q.Next() {
+		var u models.OneRosterUnit
+		if err := q.Scan(&u.ClassID, &u.SourcedID, &u.UnitID, &u.Title, &u.SyncedAt); err != nil {
+			return nil, err
+		}
+		res = append(res, &u)
+	}
+	return res, q.Err()
+}
@@ -0,0 +1,784 @@
+package unitsvc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/studiously/classsvc/classsvc"
+	"github.com/studiously/unitsvc/models"
+)
+
+// NewInMemory returns a Service that keeps units in memory and authorizes requests against cs.
+// It is intended for tests and demos; units are lost when the process exits.
+func NewInMemory(cs classsvc.Service) Service {
+	return New(NewMemoryRepository(), cs)
+}
+
+// NewMemoryRepository returns a Repository that keeps units in memory.
+func NewMemoryRepository() Repository {
+	return &memoryRepository{store: newMemoryStore()}
+}
+
+// memoryRepository guards a store with a single mutex. Transactions hold the mutex for their
+// whole duration and change the store in place, keeping an undo log of the changes so they can
+// be reverted if the transaction fails.
+type memoryRepository struct {
+	mu    sync.Mutex
+	store *memoryStore
+}
+
+func (r *memoryRepository) UnitsByClassID(ctx context.Context, classID uuid.UUID) ([]*models.Unit, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.UnitsByClassID(ctx, classID)
+}
+
+func (r *memoryRepository) UnitsByClassIDs(ctx context.Context, classIDs []uuid.UUID, filter UnitFilter) ([]*models.Unit, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.UnitsByClassIDs(ctx, classIDs, filter)
+}
+
+func (r *memoryRepository) UnitByID(ctx context.Context, unitID uuid.UUID) (*models.Unit, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.UnitByID(ctx, unitID)
+}
+
+func (r *memoryRepository) SaveUnit(ctx context.Context, unit *models.Unit) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.SaveUnit(ctx, unit)
+}
+
+func (r *memoryRepository) DeleteUnit(ctx context.Context, unitID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.DeleteUnit(ctx, unitID)
+}
+
+func (r *memoryRepository) ClassIDs(ctx context.Context, after uuid.UUID, limit int) ([]uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.ClassIDs(ctx, after, limit)
+}
+
+func (r *memoryRepository) SearchUnits(ctx context.Context, query string, classIDs []uuid.UUID, limit int) ([]*models.UnitMatch, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.SearchUnits(ctx, query, classIDs, limit)
+}
+
+func (r *memoryRepository) AppendAudit(ctx context.Context, entry *models.AuditEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.AppendAudit(ctx, entry)
+}
+
+func (r *memoryRepository) AuditByUnitID(ctx context.Context, unitID uuid.UUID) ([]*models.AuditEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.AuditByUnitID(ctx, unitID)
+}
+
+func (r *memoryRepository) AuditByClassID(ctx context.Context, classID uuid.UUID) ([]*models.AuditEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.AuditByClassID(ctx, classID)
+}
+
+func (r *memoryRepository) PurgeAudit(ctx context.Context, t time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.PurgeAudit(ctx, t)
+}
+
+func (r *memoryRepository) AppendVersion(ctx context.Context, version *models.UnitVersion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.AppendVersion(ctx, version)
+}
+
+func (r *memoryRepository) VersionsByUnitID(ctx context.Context, unitID uuid.UUID) ([]*models.UnitVersion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.VersionsByUnitID(ctx, unitID)
+}
+
+func (r *memoryRepository) PruneVersions(ctx context.Context, unitID uuid.UUID, keep int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.PruneVersions(ctx, unitID, keep)
+}
+
+func (r *memoryRepository) SaveWebhook(ctx context.Context, hook *models.Webhook) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.SaveWebhook(ctx, hook)
+}
+
+func (r *memoryRepository) WebhookByID(ctx context.Context, webhookID uuid.UUID) (*models.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.WebhookByID(ctx, webhookID)
+}
+
+func (r *memoryRepository) WebhooksByClassID(ctx context.Context, classID uuid.UUID) ([]*models.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.WebhooksByClassID(ctx, classID)
+}
+
+func (r *memoryRepository) DeleteWebhook(ctx context.Context, webhookID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.DeleteWebhook(ctx, webhookID)
+}
+
+func (r *memoryRepository) AppendDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.AppendDelivery(ctx, delivery)
+}
+
+func (r *memoryRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.UpdateDelivery(ctx, delivery)
+}
+
+func (r *memoryRepository) DueDeliveries(ctx context.Context, t time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.DueDeliveries(ctx, t, limit)
+}
+
+func (r *memoryRepository) DeliveriesByWebhookID(ctx context.Context, webhookID uuid.UUID, status string) ([]*models.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.DeliveriesByWebhookID(ctx, webhookID, status)
+}
+
+func (r *memoryRepository) SaveOneRosterClass(ctx context.Context, link *models.OneRosterClass) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.SaveOneRosterClass(ctx, link)
+}
+
+func (r *memoryRepository) OneRosterClassBySourcedID(ctx context.Context, sourcedID string) (*models.OneRosterClass, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.OneRosterClassBySourcedID(ctx, sourcedID)
+}
+
+func (r *memoryRepository) OneRosterUnitsByClassID(ctx context.Context, classID uuid.UUID) ([]*models.OneRosterUnit, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.OneRosterUnitsByClassID(ctx, classID)
+}
+
+func (r *memoryRepository) SaveOneRosterUnit(ctx context.Context, link *models.OneRosterUnit) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.SaveOneRosterUnit(ctx, link)
+}
+
+func (r *memoryRepository) DeleteOneRosterUnit(ctx context.Context, classID uuid.UUID, sourcedID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.DeleteOneRosterUnit(ctx, classID, sourcedID)
+}
+
+func (r *memoryRepository) CurrentUnitByClassID(ctx context.Context, classID uuid.UUID) (*models.CurrentUnit, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.CurrentUnitByClassID(ctx, classID)
+}
+
+func (r *memoryRepository) SaveCurrentUnit(ctx context.Context, cu *models.CurrentUnit) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.SaveCurrentUnit(ctx, cu)
+}
+
+func (r *memoryRepository) DeleteCurrentUnit(ctx context.Context, classID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.DeleteCurrentUnit(ctx, classID)
+}
+
+func (r *memoryRepository) UnitProgressByUser(ctx context.Context, unitID, userID uuid.UUID) (*models.UnitProgress, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.UnitProgressByUser(ctx, unitID, userID)
+}
+
+func (r *memoryRepository) UnitProgressByClassID(ctx context.Context, classID uuid.UUID) ([]*models.UnitProgress, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.UnitProgressByClassID(ctx, classID)
+}
+
+func (r *memoryRepository) SaveUnitProgress(ctx context.Context, progress *models.UnitProgress) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.SaveUnitProgress(ctx, progress)
+}
+
+func (r *memoryRepository) DeleteUnitProgress(ctx context.Context, unitID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.store.DeleteUnitProgress(ctx, unitID)
+}
+
+func (r *memoryRepository) Transact(ctx context.Context, fn func(Repository) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var undo []func()
+	r.store.undo = &undo
+	committed := false
+	defer func() {
+		r.store.undo = nil
+		if !committed {
+			for i := len(undo) - 1; i >= 0; i-- {
+				undo[i]()
+			}
+		}
+	}()
+	if err := fn(r.store); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+// memoryStore is the unsynchronized Repository used by memoryRepository, both for single
+// operations and inside transactions. Values are stored and returned as copies so callers
+// never share memory with the repository.
+type memoryStore struct {
+	units         map[uuid.UUID]models.Unit
+	audit         []models.AuditEntry
+	versions      map[uuid.UUID][]models.UnitVersion // oldest first
+	webhooks      []models.Webhook                   // oldest first
+	deliveries    []models.WebhookDelivery           // oldest first
+	rosterClasses map[string]models.OneRosterClass
+	rosterUnits   map[rosterUnitKey]models.OneRosterUnit
+	currentUnits  map[uuid.UUID]models.CurrentUnit // by class ID
+	progress      map[progressKey]models.UnitProgress
+
+	// undo collects the functions that revert each change made by the transaction in
+	// progress, in the order the changes were made. It is nil outside transactions.
+	undo *[]func()
+}
+
+// rosterUnitKey identifies the synced category of a class.
+type rosterUnitKey struct {
+	classID   uuid.UUID
+	sourcedID string
+}
+
+// progressKey identifies a student's progress through a unit.
+type progressKey struct {
+	unitID uuid.UUID
+	userID uuid.UUID
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		units:         make(map[uuid.UUID]models.Unit),
+		versions:      make(map[uuid.UUID][]models.UnitVersion),
+		rosterClasses: make(map[string]models.OneRosterClass),
+		rosterUnits:   make(map[rosterUnitKey]models.OneRosterUnit),
+		currentUnits:  make(map[uuid.UUID]models.CurrentUnit),
+		progress:      make(map[progressKey]models.UnitProgress),
+	}
+}
+
+// logUndo records fn to revert a change if the transaction in progress is rolled back.
+func (m *memoryStore) logUndo(fn func()) {
+	if m.undo != nil {
+		*m.undo = append(*m.undo, fn)
+	}
+}
+
+func (m *memoryStore) UnitsByClassID(_ context.Context, classID uuid.UUID) ([]*models.Unit, error) {
+	res := []*models.Unit{}
+	for _, u := range m.units {
+		if u.ClassID == classID {
+			res = append(res, copyUnit(&u))
+		}
+	}
+	sortUnits(res)
+	return res, nil
+}
+
+func (m *memoryStore) UnitsByClassIDs(_ context.Context, classIDs []uuid.UUID, filter UnitFilter) ([]*models.Unit, error) {
+	classes := make(map[uuid.UUID]bool, len(classIDs))
+	for _, classID := range classIDs {
+		classes[classID] = true
+	}
+	res := []*models.Unit{}
+	for _, u := range m.units {
+		if classes[u.ClassID] && filter.matches(&u) {
+			res = append(res, copyUnit(&u))
+		}
+	}
+	sortUnits(res)
+	sort.SliceStable(res, func(i, j int) bool { return bytes.Compare(res[i].ClassID[:], res[j].ClassID[:]) < 0 })
+	return res, nil
+}
+
+func (m *memoryStore) UnitByID(_ context.Context, unitID uuid.UUID) (*models.Unit, error) {
+	u, ok := m.units[unitID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyUnit(&u), nil
+}
+
+func (m *memoryStore) SaveUnit(_ context.Context, unit *models.Unit) error {
+	m.logUnit(unit.ID)
+	stored := copyUnit(unit)
+	stored.Status = stored.Status.OrPublished() // as the databases store it
+	m.units[unit.ID] = *stored
+	return nil
+}
+
+func (m *memoryStore) DeleteUnit(_ context.Context, unitID uuid.UUID) error {
+	m.logUnit(unitID)
+	delete(m.units, unitID)
+	return nil
+}
+
+func (m *memoryStore) ClassIDs(_ context.Context, after uuid.UUID, limit int) ([]uuid.UUID, error) {
+	seen := make(map[uuid.UUID]bool)
+	res := []uuid.UUID{}
+	for _, u := range m.units {
+		if !seen[u.ClassID] && bytes.Compare(u.ClassID[:], after[:]) > 0 {
+			seen[u.ClassID] = true
+			res = append(res, u.ClassID)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return bytes.Compare(res[i][:], res[j][:]) < 0 })
+	if len(res) > limit {
+		res = res[:limit]
+	}
+	return res, nil
+}
+
+func (m *memoryStore) SearchUnits(_ context.Context, query string, classIDs []uuid.UUID, limit int) ([]*models.UnitMatch, error) {
+	classes := make(map[uuid.UUID]bool, len(classIDs))
+	for _, classID := range classIDs {
+		classes[classID] = true
+	}
+	var units []*models.Unit
+	for _, u := range m.units {
+		if classes[u.ClassID] {
+			units = append(units, copyUnit(&u))
+		}
+	}
+	return matchUnits(units, searchTerms(query), limit), nil
+}
+
+func (m *memoryStore) AppendAudit(_ context.Context, entry *models.AuditEntry) error {
+	e := *entry
+	e.Before, e.After = copyUnit(entry.Before), copyUnit(entry.After)
+	n := len(m.audit)
+	m.logUndo(func() { m.audit = m.audit[:n] })
+	m.audit = append(m.audit, e)
+	return nil
+}
+
+func (m *memoryStore) AuditByUnitID(_ context.Context, unitID uuid.UUID) ([]*models.AuditEntry, error) {
+	return m.filterAudit(func(e *models.AuditEntry) bool { return e.UnitID == unitID }), nil
+}
+
+func (m *memoryStore) AuditByClassID(_ context.Context, classID uuid.UUID) ([]*models.AuditEntry, error) {
+	return m.filterAudit(func(e *models.AuditEntry) bool { return e.ClassID == classID }), nil
+}
+
+func (m *memoryStore) PurgeAudit(_ context.Context, t time.Time) (int64, error) {
+	kept := m.audit[:0:0]
+	for _, e := range m.audit {
+		if !e.CreatedAt.Before(t) {
+			kept = append(kept, e)
+		}
+	}
+	purged := int64(len(m.audit) - len(kept))
+	old := m.audit
+	m.logUndo(func() { m.audit = old })
+	m.audit = kept
+	return purged, nil
+}
+
+// filterAudit returns copies of the entries matching keep, in the order they were appended.
+func (m *memoryStore) filterAudit(keep func(*models.AuditEntry) bool) []*models.AuditEntry {
+	res := []*models.AuditEntry{}
+	for i := range m.audit {
+		if keep(&m.audit[i]) {
+			e := m.audit[i]
+			e.Before, e.After = copyUnit(e.Before), copyUnit(e.After)
+			res = append(res, &e)
+		}
+	}
+	return res
+}
+
+func (m *memoryStore) AppendVersion(_ context.Context, version *models.UnitVersion) error {
+	for _, v := range m.versions[version.UnitID] {
+		if v.Version == version.Version {
+			return fmt.Errorf("version %d of unit %v already exists", version.Version, version.UnitID)
+		}
+	}
+	v := *version
+	v.Unit, v.Changes = copyUnit(version.Unit), nil
+	m.logVersions(v.UnitID)
+	m.versions[v.UnitID] = append(m.versions[v.UnitID], v)
+	return nil
+}
+
+func (m *memoryStore) VersionsByUnitID(_ context.Context, unitID uuid.UUID) ([]*models.UnitVersion, error) {
+	res := []*models.UnitVersion{}
+	for _, v := range m.versions[unitID] {
+		v := v
+		v.Unit = copyUnit(v.Unit)
+		res = append(res, &v)
+	}
+	return res, nil
+}
+
+func (m *memoryStore) PruneVersions(_ context.Context, unitID uuid.UUID, keep int) error {
+	vs := m.versions[unitID]
+	m.logVersions(unitID)
+	switch {
+	case keep <= 0:
+		delete(m.versions, unitID)
+	case len(vs) > keep:
+		m.versions[unitID] = vs[len(vs)-keep:]
+	}
+	return nil
+}
+
+func (m *memoryStore) SaveWebhook(_ context.Context, hook *models.Webhook) error {
+	for _, h := range m.webhooks {
+		if h.ID == hook.ID {
+			return fmt.Errorf("webhook %v already exists", hook.ID)
+		}
+	}
+	h := *hook
+	h.Events = append([]string{}, hook.Events...)
+	n := len(m.webhooks)
+	m.logUndo(func() { m.webhooks = m.webhooks[:n] })
+	m.webhooks = append(m.webhooks, h)
+	return nil
+}
+
+func (m *memoryStore) WebhookByID(_ context.Context, webhookID uuid.UUID) (*models.Webhook, error) {
+	for _, h := range m.webhooks {
+		if h.ID == webhookID {
+			h.Events = append([]string{}, h.Events...)
+			return &h, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m *memoryStore) WebhooksByClassID(_ context.Context, classID uuid.UUID) ([]*models.Webhook, error) {
+	res := []*models.Webhook{}
+	for _, h := range m.webhooks {
+		if h.ClassID == classID {
+			h := h
+			h.Events = append([]string{}, h.Events...)
+			res = append(res, &h)
+		}
+	}
+	return res, nil
+}
+
+func (m *memoryStore) DeleteWebhook(_ context.Context, webhookID uuid.UUID) error {
+	hooks := m.webhooks[:0:0]
+	for _, h := range m.webhooks {
+		if h.ID != webhookID {
+			hooks = append(hooks, h)
+		}
+	}
+	deliveries := m.deliveries[:0:0]
+	for _, d := range m.deliveries {
+		if d.WebhookID != webhookID {
+			deliveries = append(deliveries, d)
+		}
+	}
+	oldHooks, oldDeliveries := m.webhooks, m.deliveries
+	m.logUndo(func() { m.webhooks, m.deliveries = oldHooks, oldDeliveries })
+	m.webhooks, m.deliveries = hooks, deliveries
+	return nil
+}
+
+func (m *memoryStore) AppendDelivery(_ context.Context, delivery *models.WebhookDelivery) error {
+	for _, d := range m.deliveries {
+		if d.ID == delivery.ID {
+			return fmt.Errorf("delivery %v already exists", delivery.ID)
+		}
+	}
+	d := *delivery
+	d.Payload = append(json.RawMessage{}, delivery.Payload...)
+	n := len(m.deliveries)
+	m.logUndo(func() { m.deliveries = m.deliveries[:n] })
+	m.deliveries = append(m.deliveries, d)
+	return nil
+}
+
+func (m *memoryStore) UpdateDelivery(_ context.Context, delivery *models.WebhookDelivery) error {
+	for i := range m.deliveries {
+		if d := &m.deliveries[i]; d.ID == delivery.ID {
+			i, old := i, *d
+			m.logUndo(func() { m.deliveries[i] = old })
+			d.Status, d.Attempts, d.NextAttemptAt = delivery.Status, delivery.Attempts, delivery.NextAttemptAt
+			d.LastError, d.LastStatusCode, d.UpdatedAt = delivery.LastError, delivery.LastStatusCode, delivery.UpdatedAt
+		}
+	}
+	return nil
+}
+
+func (m *memoryStore) DueDeliveries(_ context.Context, t time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	res := m.filterDeliveries(func(d *models.WebhookDelivery) bool {
+		return d.Status == models.DeliveryPending && !d.NextAttemptAt.After(t)
+	})
+	sort.SliceStable(res, func(i, j int) bool { return res[i].NextAttemptAt.Before(res[j].NextAttemptAt) })
+	if len(res) > limit {
+		res = res[:limit]
+	}
+	return res, nil
+}
+
+func (m *memoryStore) DeliveriesByWebhookID(_ context.Context, webhookID uuid.UUID, status string) ([]*models.WebhookDelivery, error) {
+	return m.filterDeliveries(func(d *models.WebhookDelivery) bool {
+		return d.WebhookID == webhookID && (status == "" || d.Status == status)
+	}), nil
+}
+
+// filterDeliveries returns copies of the deliveries matching keep, in the order they were appended.
+func (m *memoryStore) filterDeliveries(keep func(*models.WebhookDelivery) bool) []*models.WebhookDelivery {
+	res := []*models.WebhookDelivery{}
+	for i := range m.deliveries {
+		if keep(&m.deliveries[i]) {
+			d := m.deliveries[i]
+			d.Payload = append(json.RawMessage{}, d.Payload...)
+			res = append(res, &d)
+		}
+	}
+	return res
+}
+
+func (m *memoryStore) SaveOneRosterClass(_ context.Context, link *models.OneRosterClass) error {
+	if c, ok := m.rosterClasses[link.SourcedID]; ok {
+		old := c
+		m.logUndo(func() { m.rosterClasses[link.SourcedID] = old })
+		c.ClassID = link.ClassID
+		m.rosterClasses[link.SourcedID] = c
+		return nil
+	}
+	m.logUndo(func() { delete(m.rosterClasses, link.SourcedID) })
+	m.rosterClasses[link.SourcedID] = *link
+	return nil
+}
+
+func (m *memoryStore) OneRosterClassBySourcedID(_ context.Context, sourcedID string) (*models.OneRosterClass, error) {
+	c, ok := m.rosterClasses[sourcedID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &c, nil
+}
+
+func (m *memoryStore) OneRosterUnitsByClassID(_ context.Context, classID uuid.UUID) ([]*models.OneRosterUnit, error) {
+	res := []*models.OneRosterUnit{}
+	for key, u := range m.rosterUnits {
+		if key.classID == classID {
+			u := u
+			res = append(res, &u)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].SourcedID < res[j].SourcedID })
+	return res, nil
+}
+
+func (m *memoryStore) SaveOneRosterUnit(_ context.Context, link *models.OneRosterUnit) error {
+	m.logRosterUnit(rosterUnitKey{link.ClassID, link.SourcedID})
+	m.rosterUnits[rosterUnitKey{link.ClassID, link.SourcedID}] = *link
+	return nil
+}
+
+func (m *memoryStore) DeleteOneRosterUnit(_ context.Context, classID uuid.UUID, sourcedID string) error {
+	m.logRosterUnit(rosterUnitKey{classID, sourcedID})
+	delete(m.rosterUnits, rosterUnitKey{classID, sourcedID})
+	return nil
+}
+
+func (m *memoryStore) CurrentUnitByClassID(_ context.Context, classID uuid.UUID) (*models.CurrentUnit, error) {
+	cu, ok := m.currentUnits[classID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &cu, nil
+}
+
+func (m *memoryStore) SaveCurrentUnit(_ context.Context, cu *models.CurrentUnit) error {
+	m.logCurrentUnit(cu.ClassID)
+	m.currentUnits[cu.ClassID] = *cu
+	return nil
+}
+
+func (m *memoryStore) DeleteCurrentUnit(_ context.Context, classID uuid.UUID) error {
+	m.logCurrentUnit(classID)
+	delete(m.currentUnits, classID)
+	return nil
+}
+
+func (m *memoryStore) UnitProgressByUser(_ context.Context, unitID, userID uuid.UUID) (*models.UnitProgress, error) {
+	p, ok := m.progress[progressKey{unitID, userID}]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyProgress(p), nil
+}
+
+func (m *memoryStore) UnitProgressByClassID(_ context.Context, classID uuid.UUID) ([]*models.UnitProgress, error) {
+	res := []*models.UnitProgress{}
+	for _, p := range m.progress {
+		if p.ClassID == classID {
+			res = append(res, copyProgress(p))
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if c := bytes.Compare(res[i].UserID[:], res[j].UserID[:]); c != 0 {
+			return c < 0
+		}
+		return bytes.Compare(res[i].UnitID[:], res[j].UnitID[:]) < 0
+	})
+	return res, nil
+}
+
+func (m *memoryStore) SaveUnitProgress(_ context.Context, progress *models.UnitProgress) error {
+	m.logProgress(progressKey{progress.UnitID, progress.UserID})
+	m.progress[progressKey{progress.UnitID, progress.UserID}] = *copyProgress(*progress)
+	return nil
+}
+
+func (m *memoryStore) DeleteUnitProgress(_ context.Context, unitID uuid.UUID) error {
+	for key := range m.progress {
+		if key.unitID == unitID {
+			m.logProgress(key)
+			delete(m.progress, key)
+		}
+	}
+	return nil
+}
+
+// Transact joins the transaction that is already in progress.
+func (m *memoryStore) Transact(_ context.Context, fn func(Repository) error) error {
+	return fn(m)
+}
+
+// logUnit records how to restore the unit with the given ID to its current state.
+func (m *memoryStore) logUnit(id uuid.UUID) {
+	u, ok := m.units[id]
+	m.logUndo(func() {
+		if ok {
+			m.units[id] = u
+		} else {
+			delete(m.units, id)
+		}
+	})
+}
+
+// logVersions records how to restore the versions of a unit to their current state. Versions
+// are only ever appended or pruned from the front, so the slice itself can be kept.
+func (m *memoryStore) logVersions(unitID uuid.UUID) {
+	vs, ok := m.versions[unitID]
+	m.logUndo(func() {
+		if ok {
+			m.versions[unitID] = vs
+		} else {
+			delete(m.versions, unitID)
+		}
+	})
+}
+
+// logRosterUnit records how to restore a synced category to its current state.
+func (m *memoryStore) logRosterUnit(key rosterUnitKey) {
+	ru, ok := m.rosterUnits[key]
+	m.logUndo(func() {
+		if ok {
+			m.rosterUnits[key] = ru
+		} else {
+			delete(m.rosterUnits, key)
+		}
+	})
+}
+
+// logCurrentUnit records how to restore the current unit of a class to its current state.
+func (m *memoryStore) logCurrentUnit(classID uuid.UUID) {
+	cu, ok := m.currentUnits[classID]
+	m.logUndo(func() {
+		if ok {
+			m.currentUnits[classID] = cu
+		} else {
+			delete(m.currentUnits, classID)
+		}
+	})
+}
+
+// logProgress records how to restore a student's progress through a unit to its current state.
+func (m *memoryStore) logProgress(key progressKey) {
+	p, ok := m.progress[key]
+	m.logUndo(func() {
+		if ok {
+			m.progress[key] = p
+		} else {
+			delete(m.progress, key)
+		}
+	})
+}
+
+// copyProgress copies p along with its timestamps.
+func copyProgress(p models.UnitProgress) *models.UnitProgress {
+	if p.StartedAt != nil {
+		t := *p.StartedAt
+		p.StartedAt = &t
+	}
+	if p.CompletedAt != nil {
+		t := *p.CompletedAt
+		p.CompletedAt = &t
+	}
+	return &p
+}
+
+// copyUnit copies u along with its metadata, parent, resources and schedule.
+func copyUnit(u *models.Unit) *models.Unit {
+	if u == nil {
+		return nil
+	}
+	c := *u
+	c.Metadata = u.Metadata.Copy()
+	if u.ParentID != nil {
+		id := *u.ParentID
+		c.ParentID = &id
+	}
+	c.Resources = u.Resources.Copy()
+	if u.StartsAt != nil {
+		t := *u.StartsAt
+		c.StartsAt = &t
+	}
+	if u.EndsAt != nil {
+		t := *u.EndsAt
+		c.EndsAt = &t
+	}
+	return &c
+}
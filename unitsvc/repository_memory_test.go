@@ -0,0 +1,21 @@
+package unitsvc_test
+
+import (
+	"testing"
+
+	"github.com/studiously/classsvc/classsvc"
+	"github.com/studiously/unitsvc/unitsvc"
+	"github.com/studiously/unitsvc/unitsvctest"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	unitsvctest.RunRepositoryConformance(t, func(t *testing.T) unitsvc.Repository {
+		return unitsvc.NewMemoryRepository()
+	})
+}
+
+func TestInMemoryService(t *testing.T) {
+	unitsvctest.RunConformance(t, func(t *testing.T, cs classsvc.Service) unitsvc.Service {
+		return unitsvc.NewInMemory(cs)
+	})
+}
@@ -0,0 +1,308 @@
+package unitsvc
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+	"github.com/studiously/unitsvc/models"
+)
+
+// NewMySQLRepository returns a Repository backed by a MySQL database.
+// UUIDs are stored as BINARY(16), so the DSN must set parseTime=true for created_at to scan.
+func NewMySQLRepository(db *sql.DB) Repository {
+	return &mysqlRepository{tracedQueryer{db, "mysql"}}
+}
+
+type mysqlRepository struct {
+	db tracedQueryer
+}
+
+func (r *mysqlRepository) UnitsByClassID(ctx context.Context, classID uuid.UUID) ([]*models.Unit, error) {
+	const sqlstr = `SELECT ` + unitColumns + ` FROM units ` +
+		`WHERE class_id = ? ` +
+		`ORDER BY display_order, created_at`
+	q, err := r.db.QueryContext(ctx, sqlstr, binaryUUID(classID))
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+
+	res := []*models.Unit{}
+	for q.Next() {
+		var u models.Unit
+		if err := q.Scan(unitFields(&u)...); err != nil {
+			return nil, err
+		}
+		res = append(res, &u)
+	}
+	return res, q.Err()
+}
+
+func (r *mysqlRepository) UnitsByClassIDs(ctx context.Context, classIDs []uuid.UUID, filter UnitFilter) ([]*models.Unit, error) {
+	if len(classIDs) == 0 {
+		return []*models.Unit{}, nil
+	}
+	args := make([]interface{}, 0, len(classIDs))
+	for _, classID := range classIDs {
+		args = append(args, binaryUUID(classID))
+	}
+	conds, filterArgs := unitFilterSQL(filter, len(args)+1, func(int) string { return "?" })
+	sqlstr := `SELECT ` + unitColumns + ` FROM units ` +
+		`WHERE class_id IN (` + placeholders(len(classIDs)) + `)` + conds + ` ` +
+		`ORDER BY class_id, display_order, created_at`
+	return queryUnits(ctx, r.db, sqlstr, append(args, filterArgs...)...)
+}
+
+func (r *mysqlRepository) UnitByID(ctx context.Context, unitID uuid.UUID) (*models.Unit, error) {
+	const sqlstr = `SELECT ` + unitColumns + ` FROM units ` +
+		`WHERE id = ?`
+	var u models.Unit
+	err := r.db.queryRow(ctx, sqlstr, []interface{}{binaryUUID(unitID)}, unitFields(&u)...)
+	switch err {
+	case nil:
+		return &u, nil
+	case sql.ErrNoRows:
+		return nil, ErrNotFound
+	default:
+		return nil, err
+	}
+}
+
+func (r *mysqlRepository) SaveUnit(ctx context.Context, unit *models.Unit) error {
+	const sqlstr = `INSERT INTO units (` + unitColumns + `) ` +
+		`VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ` +
+		`ON DUPLICATE KEY UPDATE ` +
+		`class_id = VALUES(class_id), title = VALUES(title), display_order = VALUES(display_order), ` +
+		`description = VALUES(description), metadata = VALUES(metadata), parent_id = VALUES(parent_id), resources = VALUES(resources), ` +
+		`status = VALUES(status), starts_at = VALUES(starts_at), ends_at = VALUES(ends_at)`
+	var parentID []byte
+	if unit.ParentID != nil {
+		parentID = binaryUUID(*unit.ParentID)
+	}
+	_, err := r.db.ExecContext(ctx, sqlstr, binaryUUID(unit.ID), binaryUUID(unit.ClassID), unit.Title, unit.DisplayOrder, unit.CreatedAt, unit.Description, unit.Metadata, parentID, unit.Resources,
+		unit.Status, unit.StartsAt, unit.EndsAt)
+	return err
+}
+
+func (r *mysqlRepository) DeleteUnit(ctx context.Context, unitID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM units WHERE id = ?`, binaryUUID(unitID))
+	return err
+}
+
+func (r *mysqlRepository) ClassIDs(ctx context.Context, after uuid.UUID, limit int) ([]uuid.UUID, error) {
+	const sqlstr = `SELECT DISTINCT class_id FROM units ` +
+		`WHERE class_id > ? ` +
+		`ORDER BY class_id LIMIT ?`
+	return queryClassIDs(ctx, r.db, sqlstr, binaryUUID(after), limit)
+}
+
+func (r *mysqlRepository) SearchUnits(ctx context.Context, query string, classIDs []uuid.UUID, limit int) ([]*models.UnitMatch, error) {
+	terms := searchTerms(query)
+	if len(classIDs) == 0 || len(terms) == 0 {
+		return []*models.UnitMatch{}, nil
+	}
+	sqlstr, args := likeSearchQuery(unitColumns, "units", classIDs, terms, func(id uuid.UUID) interface{} { return binaryUUID(id) })
+	units, err := queryUnits(ctx, r.db, sqlstr, args...)
+	if err != nil {
+		return nil, err
+	}
+	return matchUnits(units, terms, limit), nil
+}
+
+func (r *mysqlRepository) AppendAudit(ctx context.Context, entry *models.AuditEntry) error {
+	args, err := auditArgs(entry, func(id uuid.UUID) interface{} { return binaryUUID(id) })
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, `INSERT INTO unit_audit (`+auditColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, args...)
+	return err
+}
+
+func (r *mysqlRepository) AuditByUnitID(ctx context.Context, unitID uuid.UUID) ([]*models.AuditEntry, error) {
+	const sqlstr = `SELECT ` + auditColumns + ` FROM unit_audit ` +
+		`WHERE unit_id = ? ` +
+		`ORDER BY created_at`
+	return queryAudit(ctx, r.db, sqlstr, binaryUUID(unitID))
+}
+
+func (r *mysqlRepository) AuditByClassID(ctx context.Context, classID uuid.UUID) ([]*models.AuditEntry, error) {
+	const sqlstr = `SELECT ` + auditColumns + ` FROM unit_audit ` +
+		`WHERE class_id = ? ` +
+		`ORDER BY created_at`
+	return queryAudit(ctx, r.db, sqlstr, binaryUUID(classID))
+}
+
+func (r *mysqlRepository) PurgeAudit(ctx context.Context, t time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM unit_audit WHERE created_at < ?`, t)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (r *mysqlRepository) AppendVersion(ctx context.Context, version *models.UnitVersion) error {
+	args, err := versionArgs(version, func(id uuid.UUID) interface{} { return binaryUUID(id) })
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, `INSERT INTO unit_versions (`+versionColumns+`) VALUES (?, ?, ?, ?, ?)`, args...)
+	return err
+}
+
+func (r *mysqlRepository) VersionsByUnitID(ctx context.Context, unitID uuid.UUID) ([]*models.UnitVersion, error) {
+	const sqlstr = `SELECT ` + versionColumns + ` FROM unit_versions ` +
+		`WHERE unit_id = ? ` +
+		`ORDER BY version`
+	return queryVersions(ctx, r.db, sqlstr, binaryUUID(unitID))
+}
+
+func (r *mysqlRepository) PruneVersions(ctx context.Context, unitID uuid.UUID, keep int) error {
+	return pruneVersions(ctx, r.db,
+		`SELECT COALESCE(MAX(version), 0) FROM unit_versions WHERE unit_id = ?`,
+		`DELETE FROM unit_versions WHERE unit_id = ? AND version <= ?`,
+		binaryUUID(unitID), keep)
+}
+
+func (r *mysqlRepository) SaveWebhook(ctx context.Context, hook *models.Webhook) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO webhooks (`+webhookColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?)`, webhookArgs(hook, func(id uuid.UUID) interface{} { return binaryUUID(id) })...)
+	return err
+}
+
+func (r *mysqlRepository) WebhookByID(ctx context.Context, webhookID uuid.UUID) (*models.Webhook, error) {
+	const sqlstr = `SELECT ` + webhookColumns + ` FROM webhooks ` +
+		`WHERE id = ?`
+	return webhookByID(ctx, r.db, sqlstr, binaryUUID(webhookID))
+}
+
+func (r *mysqlRepository) WebhooksByClassID(ctx context.Context, classID uuid.UUID) ([]*models.Webhook, error) {
+	const sqlstr = `SELECT ` + webhookColumns + ` FROM webhooks ` +
+		`WHERE class_id = ? ` +
+		`ORDER BY created_at`
+	return queryWebhooks(ctx, r.db, sqlstr, binaryUUID(classID))
+}
+
+func (r *mysqlRepository) DeleteWebhook(ctx context.Context, webhookID uuid.UUID) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM webhook_deliveries WHERE webhook_id = ?`, binaryUUID(webhookID)); err != nil {
+		return err
+	}
+	_, err := r.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id = ?`, binaryUUID(webhookID))
+	return err
+}
+
+func (r *mysqlRepository) AppendDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO webhook_deliveries (`+deliveryColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, deliveryArgs(delivery, func(id uuid.UUID) interface{} { return binaryUUID(id) })...)
+	return err
+}
+
+func (r *mysqlRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	const sqlstr = `UPDATE webhook_deliveries SET ` +
+		`status = ?, attempts = ?, next_attempt_at = ?, last_error = ?, last_status_code = ?, updated_at = ? ` +
+		`WHERE id = ?`
+	_, err := r.db.ExecContext(ctx, sqlstr, delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastError, delivery.LastStatusCode, delivery.UpdatedAt, binaryUUID(delivery.ID))
+	return err
+}
+
+func (r *mysqlRepository) DueDeliveries(ctx context.Context, t time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	const sqlstr = `SELECT ` + deliveryColumns + ` FROM webhook_deliveries ` +
+		`WHERE status = '` + models.DeliveryPending + `' AND next_attempt_at <= ? ` +
+		`ORDER BY next_attempt_at ` +
+		`LIMIT ?`
+	return queryDeliveries(ctx, r.db, sqlstr, t, limit)
+}
+
+func (r *mysqlRepository) DeliveriesByWebhookID(ctx context.Context, webhookID uuid.UUID, status string) ([]*models.WebhookDelivery, error) {
+	const sqlstr = `SELECT ` + deliveryColumns + ` FROM webhook_deliveries ` +
+		`WHERE webhook_id = ? AND (? = '' OR status = ?) ` +
+		`ORDER BY created_at`
+	return queryDeliveries(ctx, r.db, sqlstr, binaryUUID(webhookID), status, status)
+}
+
+func (r *mysqlRepository) SaveOneRosterClass(ctx context.Context, link *models.OneRosterClass) error {
+	const sqlstr = `INSERT INTO oneroster_classes (` + oneRosterClassColumns + `) VALUES (?, ?, ?) ` +
+		`ON DUPLICATE KEY UPDATE class_id = VALUES(class_id)`
+	_, err := r.db.ExecContext(ctx, sqlstr, link.SourcedID, binaryUUID(link.ClassID), link.CreatedAt)
+	return err
+}
+
+func (r *mysqlRepository) OneRosterClassBySourcedID(ctx context.Context, sourcedID string) (*models.OneRosterClass, error) {
+	const sqlstr = `SELECT ` + oneRosterClassColumns + ` FROM oneroster_classes ` +
+		`WHERE sourced_id = ?`
+	return oneRosterClassBySourcedID(ctx, r.db, sqlstr, sourcedID)
+}
+
+func (r *mysqlRepository) OneRosterUnitsByClassID(ctx context.Context, classID uuid.UUID) ([]*models.OneRosterUnit, error) {
+	const sqlstr = `SELECT ` + oneRosterUnitColumns + ` FROM oneroster_units ` +
+		`WHERE class_id = ? ` +
+		`ORDER BY sourced_id`
+	return queryOneRosterUnits(ctx, r.db, sqlstr, binaryUUID(classID))
+}
+
+func (r *mysqlRepository) SaveOneRosterUnit(ctx context.Context, link *models.OneRosterUnit) error {
+	const sqlstr = `INSERT INTO oneroster_units (` + oneRosterUnitColumns + `) VALUES (?, ?, ?, ?, ?) ` +
+		`ON DUPLICATE KEY UPDATE ` +
+		`unit_id = VALUES(unit_id), title = VALUES(title), synced_at = VALUES(synced_at)`
+	_, err := r.db.ExecContext(ctx, sqlstr, oneRosterUnitArgs(link, func(id uuid.UUID) interface{} { return binaryUUID(id) })...)
+	return err
+}
+
+func (r *mysqlRepository) DeleteOneRosterUnit(ctx context.Context, classID uuid.UUID, sourcedID string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM oneroster_units WHERE class_id = ? AND sourced_id = ?`, binaryUUID(classID), sourcedID)
+	return err
+}
+
+func (r *mysqlRepository) CurrentUnitByClassID(ctx context.Context, classID uuid.UUID) (*models.CurrentUnit, error) {
+	const sqlstr = `SELECT ` + currentUnitColumns + ` FROM current_units ` +
+		`WHERE class_id = ?`
+	return currentUnitByClassID(ctx, r.db, sqlstr, binaryUUID(classID))
+}
+
+func (r *mysqlRepository) SaveCurrentUnit(ctx context.Context, cu *models.CurrentUnit) error {
+	const sqlstr = `INSERT INTO current_units (` + currentUnitColumns + `) VALUES (?, ?, ?, ?) ` +
+		`ON DUPLICATE KEY UPDATE unit_id = VALUES(unit_id), updated_by = VALUES(updated_by), updated_at = VALUES(updated_at)`
+	_, err := r.db.ExecContext(ctx, sqlstr, binaryUUID(cu.ClassID), binaryUUID(cu.UnitID), binaryUUID(cu.UpdatedBy), cu.UpdatedAt)
+	return err
+}
+
+func (r *mysqlRepository) DeleteCurrentUnit(ctx context.Context, classID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM current_units WHERE class_id = ?`, binaryUUID(classID))
+	return err
+}
+
+func (r *mysqlRepository) UnitProgressByUser(ctx context.Context, unitID, userID uuid.UUID) (*models.UnitProgress, error) {
+	const sqlstr = `SELECT ` + unitProgressColumns + ` FROM unit_progress ` +
+		`WHERE unit_id = ? AND user_id = ?`
+	return oneUnitProgress(queryUnitProgress(ctx, r.db, sqlstr, binaryUUID(unitID), binaryUUID(userID)))
+}
+
+func (r *mysqlRepository) UnitProgressByClassID(ctx context.Context, classID uuid.UUID) ([]*models.UnitProgress, error) {
+	const sqlstr = `SELECT ` + unitProgressColumns + ` FROM unit_progress ` +
+		`WHERE class_id = ? ` +
+		`ORDER BY user_id, unit_id`
+	return queryUnitProgress(ctx, r.db, sqlstr, binaryUUID(classID))
+}
+
+func (r *mysqlRepository) SaveUnitProgress(ctx context.Context, progress *models.UnitProgress) error {
+	const sqlstr = `INSERT INTO unit_progress (` + unitProgressColumns + `) VALUES (?, ?, ?, ?, ?, ?, ?) ` +
+		`ON DUPLICATE KEY UPDATE ` +
+		`status = VALUES(status), started_at = VALUES(started_at), completed_at = VALUES(completed_at), updated_at = VALUES(updated_at)`
+	_, err := r.db.ExecContext(ctx, sqlstr, unitProgressArgs(progress, func(id uuid.UUID) interface{} { return binaryUUID(id) })...)
+	return err
+}
+
+func (r *mysqlRepository) DeleteUnitProgress(ctx context.Context, unitID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM unit_progress WHERE unit_id = ?`, binaryUUID(unitID))
+	return err
+}
+
+func (r *mysqlRepository) Transact(ctx context.Context, fn func(Repository) error) error {
+	return transact(ctx, r.db, func(db tracedQueryer) Repository { return &mysqlRepository{db} }, fn)
+}
+
+// binaryUUID converts a UUID to its 16-byte form for BINARY(16) columns.
+// uuid.UUID's own driver.Valuer produces the 36-character string form instead.
+func binaryUUID(id uuid.UUID) []byte {
+	return id[:]
+}
@@ -0,0 +1,25 @@
+package unitsvc_test
+
+import (
+	"testing"
+
+	"github.com/studiously/classsvc/classsvc"
+	"github.com/studiously/unitsvc/unitsvc"
+	"github.com/studiously/unitsvc/unitsvctest"
+)
+
+func TestMySQLRepository(t *testing.T) {
+	db := openTestDB(t, "mysql", "UNITSVC_TEST_MYSQL")
+	defer db.Close()
+	unitsvctest.RunRepositoryConformance(t, func(t *testing.T) unitsvc.Repository {
+		return unitsvc.NewMySQLRepository(db)
+	})
+}
+
+func TestMySQLService(t *testing.T) {
+	db := openTestDB(t, "mysql", "UNITSVC_TEST_MYSQL")
+	defer db.Close()
+	unitsvctest.RunConformance(t, func(t *testing.T, cs classsvc.Service) unitsvc.Service {
+		return unitsvc.New(unitsvc.NewMySQLRepository(db), cs)
+	})
+}
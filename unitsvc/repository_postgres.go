@@ -0,0 +1,313 @@
+package unitsvc
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/studiously/unitsvc/models"
+)
+
+// NewPostgresRepository returns a Repository backed by the xo models in a PostgreSQL database.
+func NewPostgresRepository(db *sql.DB) Repository {
+	return &postgresRepository{tracedQueryer{db, "postgresql"}}
+}
+
+type postgresRepository struct {
+	db tracedQueryer
+}
+
+func (r *postgresRepository) UnitsByClassID(ctx context.Context, classID uuid.UUID) ([]*models.Unit, error) {
+	units, err := models.UnitsByClassID(contextDB{ctx, r.db}, classID)
+	if err != nil {
+		return nil, err
+	}
+	sortUnits(units)
+	return units, nil
+}
+
+func (r *postgresRepository) UnitsByClassIDs(ctx context.Context, classIDs []uuid.UUID, filter UnitFilter) ([]*models.Unit, error) {
+	ids := make([]string, 0, len(classIDs))
+	for _, classID := range classIDs {
+		ids = append(ids, classID.String())
+	}
+	conds, args := unitFilterSQL(filter, 2, func(n int) string { return "$" + strconv.Itoa(n) })
+	sqlstr := `SELECT ` + unitColumns + ` FROM public.units ` +
+		`WHERE class_id = ANY($1::uuid[])` + conds + ` ` +
+		`ORDER BY class_id, display_order, created_at`
+	return queryUnits(ctx, r.db, sqlstr, append([]interface{}{pq.Array(ids)}, args...)...)
+}
+
+func (r *postgresRepository) UnitByID(ctx context.Context, unitID uuid.UUID) (*models.Unit, error) {
+	// models.UnitByID reads the row with QueryRow(...).Scan, which tracedQueryer cannot trace, so
+	// the span for its statement is started here.
+	const sqlstr = `SELECT ` + unitColumns + ` FROM public.units WHERE id = $1`
+	span, ctx := r.db.startSpan(ctx, sqlstr)
+	unit, err := models.UnitByID(contextDB{ctx, r.db}, unitID)
+	finishSpan(span, err)
+	switch err {
+	case nil:
+		return unit, nil
+	case sql.ErrNoRows:
+		return nil, ErrNotFound
+	default:
+		return nil, err
+	}
+}
+
+func (r *postgresRepository) SaveUnit(ctx context.Context, unit *models.Unit) error {
+	if unit.Exists() {
+		return unit.Update(contextDB{ctx, r.db})
+	}
+	return unit.Upsert(contextDB{ctx, r.db})
+}
+
+func (r *postgresRepository) DeleteUnit(ctx context.Context, unitID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM public.units WHERE id = $1`, unitID)
+	return err
+}
+
+func (r *postgresRepository) ClassIDs(ctx context.Context, after uuid.UUID, limit int) ([]uuid.UUID, error) {
+	const sqlstr = `SELECT DISTINCT class_id FROM public.units ` +
+		`WHERE class_id > $1 ` +
+		`ORDER BY class_id LIMIT $2`
+	return queryClassIDs(ctx, r.db, sqlstr, after, limit)
+}
+
+// SearchUnits uses the full-text index on titles and descriptions, so words match their other
+// forms, as "cell" matches "Cells", rarer words rank higher, and matches in titles rank above
+// matches in descriptions.
+func (r *postgresRepository) SearchUnits(ctx context.Context, query string, classIDs []uuid.UUID, limit int) ([]*models.UnitMatch, error) {
+	res := []*models.UnitMatch{}
+	if len(classIDs) == 0 {
+		return res, nil
+	}
+	ids := make([]string, 0, len(classIDs))
+	for _, classID := range classIDs {
+		ids = append(ids, classID.String())
+	}
+	const sqlstr = `SELECT ` + unitColumns + `, ts_rank(search, q) AS rank, ` +
+		`ts_headline('pg_catalog.english', title, q, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true') ` +
+		`FROM public.units, plainto_tsquery('pg_catalog.english', $1) q ` +
+		`WHERE search @@ q AND class_id = ANY($3::uuid[]) ` +
+		`ORDER BY rank DESC, title, id LIMIT $2`
+	q, err := r.db.QueryContext(ctx, sqlstr, query, limit, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+
+	for q.Next() {
+		var m models.UnitMatch
+		var u models.Unit
+		if err := q.Scan(append(unitFields(&u), &m.Rank, &m.Snippet)...); err != nil {
+			return nil, err
+		}
+		m.Unit = &u
+		res = append(res, &m)
+	}
+	return res, q.Err()
+}
+
+func (r *postgresRepository) AppendAudit(ctx context.Context, entry *models.AuditEntry) error {
+	args, err := auditArgs(entry, uuidValue)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, `INSERT INTO public.unit_audit (`+auditColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`, args...)
+	return err
+}
+
+func (r *postgresRepository) AuditByUnitID(ctx context.Context, unitID uuid.UUID) ([]*models.AuditEntry, error) {
+	const sqlstr = `SELECT ` + auditColumns + ` FROM public.unit_audit ` +
+		`WHERE unit_id = $1 ` +
+		`ORDER BY created_at`
+	return queryAudit(ctx, r.db, sqlstr, unitID)
+}
+
+func (r *postgresRepository) AuditByClassID(ctx context.Context, classID uuid.UUID) ([]*models.AuditEntry, error) {
+	const sqlstr = `SELECT ` + auditColumns + ` FROM public.unit_audit ` +
+		`WHERE class_id = $1 ` +
+		`ORDER BY created_at`
+	return queryAudit(ctx, r.db, sqlstr, classID)
+}
+
+func (r *postgresRepository) PurgeAudit(ctx context.Context, t time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM public.unit_audit WHERE created_at < $1`, t)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (r *postgresRepository) AppendVersion(ctx context.Context, version *models.UnitVersion) error {
+	args, err := versionArgs(version, uuidValue)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, `INSERT INTO public.unit_versions (`+versionColumns+`) VALUES ($1, $2, $3, $4, $5)`, args...)
+	return err
+}
+
+func (r *postgresRepository) VersionsByUnitID(ctx context.Context, unitID uuid.UUID) ([]*models.UnitVersion, error) {
+	const sqlstr = `SELECT ` + versionColumns + ` FROM public.unit_versions ` +
+		`WHERE unit_id = $1 ` +
+		`ORDER BY version`
+	return queryVersions(ctx, r.db, sqlstr, unitID)
+}
+
+func (r *postgresRepository) PruneVersions(ctx context.Context, unitID uuid.UUID, keep int) error {
+	return pruneVersions(ctx, r.db,
+		`SELECT COALESCE(MAX(version), 0) FROM public.unit_versions WHERE unit_id = $1`,
+		`DELETE FROM public.unit_versions WHERE unit_id = $1 AND version <= $2`,
+		unitID, keep)
+}
+
+func (r *postgresRepository) SaveWebhook(ctx context.Context, hook *models.Webhook) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO public.webhooks (`+webhookColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7)`, webhookArgs(hook, uuidValue)...)
+	return err
+}
+
+func (r *postgresRepository) WebhookByID(ctx context.Context, webhookID uuid.UUID) (*models.Webhook, error) {
+	const sqlstr = `SELECT ` + webhookColumns + ` FROM public.webhooks ` +
+		`WHERE id = $1`
+	return webhookByID(ctx, r.db, sqlstr, webhookID)
+}
+
+func (r *postgresRepository) WebhooksByClassID(ctx context.Context, classID uuid.UUID) ([]*models.Webhook, error) {
+	const sqlstr = `SELECT ` + webhookColumns + ` FROM public.webhooks ` +
+		`WHERE class_id = $1 ` +
+		`ORDER BY created_at`
+	return queryWebhooks(ctx, r.db, sqlstr, classID)
+}
+
+func (r *postgresRepository) DeleteWebhook(ctx context.Context, webhookID uuid.UUID) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM public.webhook_deliveries WHERE webhook_id = $1`, webhookID); err != nil {
+		return err
+	}
+	_, err := r.db.ExecContext(ctx, `DELETE FROM public.webhooks WHERE id = $1`, webhookID)
+	return err
+}
+
+func (r *postgresRepository) AppendDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO public.webhook_deliveries (`+deliveryColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`, deliveryArgs(delivery, uuidValue)...)
+	return err
+}
+
+func (r *postgresRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	const sqlstr = `UPDATE public.webhook_deliveries SET ` +
+		`status = $1, attempts = $2, next_attempt_at = $3, last_error = $4, last_status_code = $5, updated_at = $6 ` +
+		`WHERE id = $7`
+	_, err := r.db.ExecContext(ctx, sqlstr, delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastError, delivery.LastStatusCode, delivery.UpdatedAt, delivery.ID)
+	return err
+}
+
+func (r *postgresRepository) DueDeliveries(ctx context.Context, t time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	const sqlstr = `SELECT ` + deliveryColumns + ` FROM public.webhook_deliveries ` +
+		`WHERE status = '` + models.DeliveryPending + `' AND next_attempt_at <= $1 ` +
+		`ORDER BY next_attempt_at ` +
+		`LIMIT $2`
+	return queryDeliveries(ctx, r.db, sqlstr, t, limit)
+}
+
+func (r *postgresRepository) DeliveriesByWebhookID(ctx context.Context, webhookID uuid.UUID, status string) ([]*models.WebhookDelivery, error) {
+	const sqlstr = `SELECT ` + deliveryColumns + ` FROM public.webhook_deliveries ` +
+		`WHERE webhook_id = $1 AND ($2 = '' OR status = $3) ` +
+		`ORDER BY created_at`
+	return queryDeliveries(ctx, r.db, sqlstr, webhookID, status, status)
+}
+
+func (r *postgresRepository) SaveOneRosterClass(ctx context.Context, link *models.OneRosterClass) error {
+	const sqlstr = `INSERT INTO public.oneroster_classes (` + oneRosterClassColumns + `) VALUES ($1, $2, $3) ` +
+		`ON CONFLICT (sourced_id) DO UPDATE SET class_id = EXCLUDED.class_id`
+	_, err := r.db.ExecContext(ctx, sqlstr, link.SourcedID, link.ClassID, link.CreatedAt)
+	return err
+}
+
+func (r *postgresRepository) OneRosterClassBySourcedID(ctx context.Context, sourcedID string) (*models.OneRosterClass, error) {
+	const sqlstr = `SELECT ` + oneRosterClassColumns + ` FROM public.oneroster_classes ` +
+		`WHERE sourced_id = $1`
+	return oneRosterClassBySourcedID(ctx, r.db, sqlstr, sourcedID)
+}
+
+func (r *postgresRepository) OneRosterUnitsByClassID(ctx context.Context, classID uuid.UUID) ([]*models.OneRosterUnit, error) {
+	const sqlstr = `SELECT ` + oneRosterUnitColumns + ` FROM public.oneroster_units ` +
+		`WHERE class_id = $1 ` +
+		`ORDER BY sourced_id`
+	return queryOneRosterUnits(ctx, r.db, sqlstr, classID)
+}
+
+func (r *postgresRepository) SaveOneRosterUnit(ctx context.Context, link *models.OneRosterUnit) error {
+	const sqlstr = `INSERT INTO public.oneroster_units (` + oneRosterUnitColumns + `) VALUES ($1, $2, $3, $4, $5) ` +
+		`ON CONFLICT (class_id, sourced_id) DO UPDATE SET ` +
+		`unit_id = EXCLUDED.unit_id, title = EXCLUDED.title, synced_at = EXCLUDED.synced_at`
+	_, err := r.db.ExecContext(ctx, sqlstr, oneRosterUnitArgs(link, uuidValue)...)
+	return err
+}
+
+func (r *postgresRepository) DeleteOneRosterUnit(ctx context.Context, classID uuid.UUID, sourcedID string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM public.oneroster_units WHERE class_id = $1 AND sourced_id = $2`, classID, sourcedID)
+	return err
+}
+
+func (r *postgresRepository) CurrentUnitByClassID(ctx context.Context, classID uuid.UUID) (*models.CurrentUnit, error) {
+	const sqlstr = `SELECT ` + currentUnitColumns + ` FROM public.current_units ` +
+		`WHERE class_id = $1`
+	return currentUnitByClassID(ctx, r.db, sqlstr, classID)
+}
+
+func (r *postgresRepository) SaveCurrentUnit(ctx context.Context, cu *models.CurrentUnit) error {
+	const sqlstr = `INSERT INTO public.current_units (` + currentUnitColumns + `) VALUES ($1, $2, $3, $4) ` +
+		`ON CONFLICT (class_id) DO UPDATE SET unit_id = EXCLUDED.unit_id, updated_by = EXCLUDED.updated_by, updated_at = EXCLUDED.updated_at`
+	_, err := r.db.ExecContext(ctx, sqlstr, cu.ClassID, cu.UnitID, cu.UpdatedBy, cu.UpdatedAt)
+	return err
+}
+
+func (r *postgresRepository) DeleteCurrentUnit(ctx context.Context, classID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM public.current_units WHERE class_id = $1`, classID)
+	return err
+}
+
+func (r *postgresRepository) UnitProgressByUser(ctx context.Context, unitID, userID uuid.UUID) (*models.UnitProgress, error) {
+	const sqlstr = `SELECT ` + unitProgressColumns + ` FROM public.unit_progress ` +
+		`WHERE unit_id = $1 AND user_id = $2`
+	return oneUnitProgress(queryUnitProgress(ctx, r.db, sqlstr, unitID, userID))
+}
+
+func (r *postgresRepository) UnitProgressByClassID(ctx context.Context, classID uuid.UUID) ([]*models.UnitProgress, error) {
+	const sqlstr = `SELECT ` + unitProgressColumns + ` FROM public.unit_progress ` +
+		`WHERE class_id = $1 ` +
+		`ORDER BY user_id, unit_id`
+	return queryUnitProgress(ctx, r.db, sqlstr, classID)
+}
+
+func (r *postgresRepository) SaveUnitProgress(ctx context.Context, progress *models.UnitProgress) error {
+	const sqlstr = `INSERT INTO public.unit_progress (` + unitProgressColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7) ` +
+		`ON CONFLICT (unit_id, user_id) DO UPDATE SET ` +
+		`status = EXCLUDED.status, started_at = EXCLUDED.started_at, completed_at = EXCLUDED.completed_at, updated_at = EXCLUDED.updated_at`
+	_, err := r.db.ExecContext(ctx, sqlstr, unitProgressArgs(progress, uuidValue)...)
+	return err
+}
+
+func (r *postgresRepository) DeleteUnitProgress(ctx context.Context, unitID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM public.unit_progress WHERE unit_id = $1`, unitID)
+	return err
+}
+
+func (r *postgresRepository) Transact(ctx context.Context, fn func(Repository) error) error {
+	return transact(ctx, r.db, func(db tracedQueryer) Repository { return &postgresRepository{db} }, fn)
+}
+
+// sortUnits sorts units by display order, breaking ties by creation time.
+func sortUnits(units []*models.Unit) {
+	sort.SliceStable(units, func(i, j int) bool {
+		if units[i].DisplayOrder != units[j].DisplayOrder {
+			return units[i].DisplayOrder < units[j].DisplayOrder
+		}
+		return units[i].CreatedAt.Before(units[j].CreatedAt)
+	})
+}
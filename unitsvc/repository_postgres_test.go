@@ -0,0 +1,45 @@
+package unitsvc_test
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/studiously/classsvc/classsvc"
+	"github.com/studiously/unitsvc/ddl"
+	"github.com/studiously/unitsvc/unitsvc"
+	"github.com/studiously/unitsvc/unitsvctest"
+)
+
+// openTestDB connects to the database named by the env variable and applies migrations,
+// skipping the test when the variable is unset.
+func openTestDB(t *testing.T, driver, env string) *sql.DB {
+	config := os.Getenv(env)
+	if config == "" {
+		t.Skipf("%s not set; skipping %s tests", env, driver)
+	}
+	db, err := sql.Open(driver, config)
+	if err != nil {
+		t.Fatalf("open %s: %v", driver, err)
+	}
+	if err := ddl.Migrate(driver, db); err != nil {
+		t.Fatalf("migrate %s: %v", driver, err)
+	}
+	return db
+}
+
+func TestPostgresRepository(t *testing.T) {
+	db := openTestDB(t, "postgres", "UNITSVC_TEST_POSTGRES")
+	defer db.Close()
+	unitsvctest.RunRepositoryConformance(t, func(t *testing.T) unitsvc.Repository {
+		return unitsvc.NewPostgresRepository(db)
+	})
+}
+
+func TestPostgresService(t *testing.T) {
+	db := openTestDB(t, "postgres", "UNITSVC_TEST_POSTGRES")
+	defer db.Close()
+	unitsvctest.RunConformance(t, func(t *testing.T, cs classsvc.Service) unitsvc.Service {
+		return unitsvc.New(unitsvc.NewPostgresRepository(db), cs)
+	})
+}
@@ -0,0 +1,300 @@
+package unitsvc
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/studiously/unitsvc/models"
+	_ "modernc.org/sqlite"
+)
+
+// NewSQLiteRepository returns a Repository backed by a SQLite database opened with the
+// pure-Go "sqlite" driver. SQLite allows a single writer at a time, so callers should limit db to
+// one open connection with SetMaxOpenConns(1) to avoid "database is locked" errors under
+// concurrent writes.
+func NewSQLiteRepository(db *sql.DB) Repository {
+	return &sqliteRepository{tracedQueryer{db, "sqlite"}}
+}
+
+type sqliteRepository struct {
+	db tracedQueryer
+}
+
+func (r *sqliteRepository) UnitsByClassID(ctx context.Context, classID uuid.UUID) ([]*models.Unit, error) {
+	const sqlstr = `SELECT ` + unitColumns + ` FROM units ` +
+		`WHERE class_id = ? ` +
+		`ORDER BY display_order, created_at`
+	q, err := r.db.QueryContext(ctx, sqlstr, classID)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+
+	res := []*models.Unit{}
+	for q.Next() {
+		var u models.Unit
+		if err := q.Scan(unitFields(&u)...); err != nil {
+			return nil, err
+		}
+		res = append(res, &u)
+	}
+	return res, q.Err()
+}
+
+func (r *sqliteRepository) UnitsByClassIDs(ctx context.Context, classIDs []uuid.UUID, filter UnitFilter) ([]*models.Unit, error) {
+	if len(classIDs) == 0 {
+		return []*models.Unit{}, nil
+	}
+	args := make([]interface{}, 0, len(classIDs))
+	for _, classID := range classIDs {
+		args = append(args, classID)
+	}
+	conds, filterArgs := unitFilterSQL(filter, len(args)+1, func(int) string { return "?" })
+	sqlstr := `SELECT ` + unitColumns + ` FROM units ` +
+		`WHERE class_id IN (` + placeholders(len(classIDs)) + `)` + conds + ` ` +
+		`ORDER BY class_id, display_order, created_at`
+	return queryUnits(ctx, r.db, sqlstr, append(args, filterArgs...)...)
+}
+
+func (r *sqliteRepository) UnitByID(ctx context.Context, unitID uuid.UUID) (*models.Unit, error) {
+	const sqlstr = `SELECT ` + unitColumns + ` FROM units ` +
+		`WHERE id = ?`
+	var u models.Unit
+	err := r.db.queryRow(ctx, sqlstr, []interface{}{unitID}, unitFields(&u)...)
+	switch err {
+	case nil:
+		return &u, nil
+	case sql.ErrNoRows:
+		return nil, ErrNotFound
+	default:
+		return nil, err
+	}
+}
+
+func (r *sqliteRepository) SaveUnit(ctx context.Context, unit *models.Unit) error {
+	const sqlstr = `INSERT INTO units (` + unitColumns + `) ` +
+		`VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ` +
+		`ON CONFLICT (id) DO UPDATE SET ` +
+		`class_id = excluded.class_id, title = excluded.title, display_order = excluded.display_order, ` +
+		`description = excluded.description, metadata = excluded.metadata, parent_id = excluded.parent_id, resources = excluded.resources, ` +
+		`status = excluded.status, starts_at = excluded.starts_at, ends_at = excluded.ends_at`
+	_, err := r.db.ExecContext(ctx, sqlstr, unit.ID, unit.ClassID, unit.Title, unit.DisplayOrder, unit.CreatedAt, unit.Description, unit.Metadata, unit.ParentID, unit.Resources,
+		unit.Status, unit.StartsAt, unit.EndsAt)
+	return err
+}
+
+func (r *sqliteRepository) DeleteUnit(ctx context.Context, unitID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM units WHERE id = ?`, unitID)
+	return err
+}
+
+func (r *sqliteRepository) ClassIDs(ctx context.Context, after uuid.UUID, limit int) ([]uuid.UUID, error) {
+	const sqlstr = `SELECT DISTINCT class_id FROM units ` +
+		`WHERE class_id > ? ` +
+		`ORDER BY class_id LIMIT ?`
+	return queryClassIDs(ctx, r.db, sqlstr, after, limit)
+}
+
+func (r *sqliteRepository) SearchUnits(ctx context.Context, query string, classIDs []uuid.UUID, limit int) ([]*models.UnitMatch, error) {
+	terms := searchTerms(query)
+	if len(classIDs) == 0 || len(terms) == 0 {
+		return []*models.UnitMatch{}, nil
+	}
+	sqlstr, args := likeSearchQuery(unitColumns, "units", classIDs, terms, uuidValue)
+	units, err := queryUnits(ctx, r.db, sqlstr, args...)
+	if err != nil {
+		return nil, err
+	}
+	return matchUnits(units, terms, limit), nil
+}
+
+func (r *sqliteRepository) AppendAudit(ctx context.Context, entry *models.AuditEntry) error {
+	args, err := auditArgs(entry, uuidValue)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, `INSERT INTO unit_audit (`+auditColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, args...)
+	return err
+}
+
+func (r *sqliteRepository) AuditByUnitID(ctx context.Context, unitID uuid.UUID) ([]*models.AuditEntry, error) {
+	const sqlstr = `SELECT ` + auditColumns + ` FROM unit_audit ` +
+		`WHERE unit_id = ? ` +
+		`ORDER BY created_at, rowid`
+	return queryAudit(ctx, r.db, sqlstr, unitID)
+}
+
+func (r *sqliteRepository) AuditByClassID(ctx context.Context, classID uuid.UUID) ([]*models.AuditEntry, error) {
+	const sqlstr = `SELECT ` + auditColumns + ` FROM unit_audit ` +
+		`WHERE class_id = ? ` +
+		`ORDER BY created_at, rowid`
+	return queryAudit(ctx, r.db, sqlstr, classID)
+}
+
+func (r *sqliteRepository) PurgeAudit(ctx context.Context, t time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM unit_audit WHERE created_at < ?`, t)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (r *sqliteRepository) AppendVersion(ctx context.Context, version *models.UnitVersion) error {
+	args, err := versionArgs(version, uuidValue)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, `INSERT INTO unit_versions (`+versionColumns+`) VALUES (?, ?, ?, ?, ?)`, args...)
+	return err
+}
+
+func (r *sqliteRepository) VersionsByUnitID(ctx context.Context, unitID uuid.UUID) ([]*models.UnitVersion, error) {
+	const sqlstr = `SELECT ` + versionColumns + ` FROM unit_versions ` +
+		`WHERE unit_id = ? ` +
+		`ORDER BY version`
+	return queryVersions(ctx, r.db, sqlstr, unitID)
+}
+
+func (r *sqliteRepository) PruneVersions(ctx context.Context, unitID uuid.UUID, keep int) error {
+	return pruneVersions(ctx, r.db,
+		`SELECT COALESCE(MAX(version), 0) FROM unit_versions WHERE unit_id = ?`,
+		`DELETE FROM unit_versions WHERE unit_id = ? AND version <= ?`,
+		unitID, keep)
+}
+
+func (r *sqliteRepository) SaveWebhook(ctx context.Context, hook *models.Webhook) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO webhooks (`+webhookColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?)`, webhookArgs(hook, uuidValue)...)
+	return err
+}
+
+func (r *sqliteRepository) WebhookByID(ctx context.Context, webhookID uuid.UUID) (*models.Webhook, error) {
+	const sqlstr = `SELECT ` + webhookColumns + ` FROM webhooks ` +
+		`WHERE id = ?`
+	return webhookByID(ctx, r.db, sqlstr, webhookID)
+}
+
+func (r *sqliteRepository) WebhooksByClassID(ctx context.Context, classID uuid.UUID) ([]*models.Webhook, error) {
+	const sqlstr = `SELECT ` + webhookColumns + ` FROM webhooks ` +
+		`WHERE class_id = ? ` +
+		`ORDER BY created_at, rowid`
+	return queryWebhooks(ctx, r.db, sqlstr, classID)
+}
+
+func (r *sqliteRepository) DeleteWebhook(ctx context.Context, webhookID uuid.UUID) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM webhook_deliveries WHERE webhook_id = ?`, webhookID); err != nil {
+		return err
+	}
+	_, err := r.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id = ?`, webhookID)
+	return err
+}
+
+func (r *sqliteRepository) AppendDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO webhook_deliveries (`+deliveryColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, deliveryArgs(delivery, uuidValue)...)
+	return err
+}
+
+func (r *sqliteRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	const sqlstr = `UPDATE webhook_deliveries SET ` +
+		`status = ?, attempts = ?, next_attempt_at = ?, last_error = ?, last_status_code = ?, updated_at = ? ` +
+		`WHERE id = ?`
+	_, err := r.db.ExecContext(ctx, sqlstr, delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastError, delivery.LastStatusCode, delivery.UpdatedAt, delivery.ID)
+	return err
+}
+
+func (r *sqliteRepository) DueDeliveries(ctx context.Context, t time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	const sqlstr = `SELECT ` + deliveryColumns + ` FROM webhook_deliveries ` +
+		`WHERE status = '` + models.DeliveryPending + `' AND next_attempt_at <= ? ` +
+		`ORDER BY next_attempt_at ` +
+		`LIMIT ?`
+	return queryDeliveries(ctx, r.db, sqlstr, t, limit)
+}
+
+func (r *sqliteRepository) DeliveriesByWebhookID(ctx context.Context, webhookID uuid.UUID, status string) ([]*models.WebhookDelivery, error) {
+	const sqlstr = `SELECT ` + deliveryColumns + ` FROM webhook_deliveries ` +
+		`WHERE webhook_id = ? AND (? = '' OR status = ?) ` +
+		`ORDER BY created_at, rowid`
+	return queryDeliveries(ctx, r.db, sqlstr, webhookID, status, status)
+}
+
+func (r *sqliteRepository) SaveOneRosterClass(ctx context.Context, link *models.OneRosterClass) error {
+	const sqlstr = `INSERT INTO oneroster_classes (` + oneRosterClassColumns + `) VALUES (?, ?, ?) ` +
+		`ON CONFLICT (sourced_id) DO UPDATE SET class_id = excluded.class_id`
+	_, err := r.db.ExecContext(ctx, sqlstr, link.SourcedID, link.ClassID, link.CreatedAt)
+	return err
+}
+
+func (r *sqliteRepository) OneRosterClassBySourcedID(ctx context.Context, sourcedID string) (*models.OneRosterClass, error) {
+	const sqlstr = `SELECT ` + oneRosterClassColumns + ` FROM oneroster_classes ` +
+		`WHERE sourced_id = ?`
+	return oneRosterClassBySourcedID(ctx, r.db, sqlstr, sourcedID)
+}
+
+func (r *sqliteRepository) OneRosterUnitsByClassID(ctx context.Context, classID uuid.UUID) ([]*models.OneRosterUnit, error) {
+	const sqlstr = `SELECT ` + oneRosterUnitColumns + ` FROM oneroster_units ` +
+		`WHERE class_id = ? ` +
+		`ORDER BY sourced_id`
+	return queryOneRosterUnits(ctx, r.db, sqlstr, classID)
+}
+
+func (r *sqliteRepository) SaveOneRosterUnit(ctx context.Context, link *models.OneRosterUnit) error {
+	const sqlstr = `INSERT INTO oneroster_units (` + oneRosterUnitColumns + `) VALUES (?, ?, ?, ?, ?) ` +
+		`ON CONFLICT (class_id, sourced_id) DO UPDATE SET ` +
+		`unit_id = excluded.unit_id, title = excluded.title, synced_at = excluded.synced_at`
+	_, err := r.db.ExecContext(ctx, sqlstr, oneRosterUnitArgs(link, uuidValue)...)
+	return err
+}
+
+func (r *sqliteRepository) DeleteOneRosterUnit(ctx context.Context, classID uuid.UUID, sourcedID string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM oneroster_units WHERE class_id = ? AND sourced_id = ?`, classID, sourcedID)
+	return err
+}
+
+func (r *sqliteRepository) CurrentUnitByClassID(ctx context.Context, classID uuid.UUID) (*models.CurrentUnit, error) {
+	const sqlstr = `SELECT ` + currentUnitColumns + ` FROM current_units ` +
+		`WHERE class_id = ?`
+	return currentUnitByClassID(ctx, r.db, sqlstr, classID)
+}
+
+func (r *sqliteRepository) SaveCurrentUnit(ctx context.Context, cu *models.CurrentUnit) error {
+	const sqlstr = `INSERT INTO current_units (` + currentUnitColumns + `) VALUES (?, ?, ?, ?) ` +
+		`ON CONFLICT (class_id) DO UPDATE SET unit_id = excluded.unit_id, updated_by = excluded.updated_by, updated_at = excluded.updated_at`
+	_, err := r.db.ExecContext(ctx, sqlstr, cu.ClassID, cu.UnitID, cu.UpdatedBy, cu.UpdatedAt)
+	return err
+}
+
+func (r *sqliteRepository) DeleteCurrentUnit(ctx context.Context, classID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM current_units WHERE class_id = ?`, classID)
+	return err
+}
+
+func (r *sqliteRepository) UnitProgressByUser(ctx context.Context, unitID, userID uuid.UUID) (*models.UnitProgress, error) {
+	const sqlstr = `SELECT ` + unitProgressColumns + ` FROM unit_progress ` +
+		`WHERE unit_id = ? AND user_id = ?`
+	return oneUnitProgress(queryUnitProgress(ctx, r.db, sqlstr, unitID, userID))
+}
+
+func (r *sqliteRepository) UnitProgressByClassID(ctx context.Context, classID uuid.UUID) ([]*models.UnitProgress, error) {
+	const sqlstr = `SELECT ` + unitProgressColumns + ` FROM unit_progress ` +
+		`WHERE class_id = ? ` +
+		`ORDER BY user_id, unit_id`
+	return queryUnitProgress(ctx, r.db, sqlstr, classID)
+}
+
+func (r *sqliteRepository) SaveUnitProgress(ctx context.Context, progress *models.UnitProgress) error {
+	const sqlstr = `INSERT INTO unit_progress (` + unitProgressColumns + `) VALUES (?, ?, ?, ?, ?, ?, ?) ` +
+		`ON CONFLICT (unit_id, user_id) DO UPDATE SET ` +
+		`status = excluded.status, started_at = excluded.started_at, completed_at = excluded.completed_at, updated_at = excluded.updated_at`
+	_, err := r.db.ExecContext(ctx, sqlstr, unitProgressArgs(progress, uuidValue)...)
+	return err
+}
+
+func (r *sqliteRepository) DeleteUnitProgress(ctx context.Context, unitID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM unit_progress WHERE unit_id = ?`, unitID)
+	return err
+}
+
+func (r *sqliteRepository) Transact(ctx context.Context, fn func(Repository) error) error {
+	return transact(ctx, r.db, func(db tracedQueryer) Repository { return &sqliteRepository{db} }, fn)
+}
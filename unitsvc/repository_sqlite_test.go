@@ -0,0 +1,100 @@
+package unitsvc_test
+
+import (
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/google/uuid"
+	"github.com/opentracing/opentracing-go"
+	"github.com/studiously/classsvc/classsvc"
+	"github.com/studiously/unitsvc/ddl"
+	"github.com/studiously/unitsvc/tracing"
+	"github.com/studiously/unitsvc/unitsvc"
+	"github.com/studiously/unitsvc/unitsvctest"
+)
+
+// openSQLiteDB creates a migrated SQLite database in a temporary directory.
+// The returned function closes the database and removes the directory.
+func openSQLiteDB(t *testing.T) (*sql.DB, func()) {
+	dir, err := ioutil.TempDir("", "unitsvc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite", filepath.Join(dir, "units.db"))
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("open sqlite: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	cleanup := func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+	if err := ddl.Migrate("sqlite", db); err != nil {
+		cleanup()
+		t.Fatalf("migrate sqlite: %v", err)
+	}
+	return db, cleanup
+}
+
+func TestSQLiteRepository(t *testing.T) {
+	db, cleanup := openSQLiteDB(t)
+	defer cleanup()
+	unitsvctest.RunRepositoryConformance(t, func(t *testing.T) unitsvc.Repository {
+		return unitsvc.NewSQLiteRepository(db)
+	})
+}
+
+func TestSQLiteService(t *testing.T) {
+	db, cleanup := openSQLiteDB(t)
+	defer cleanup()
+	unitsvctest.RunConformance(t, func(t *testing.T, cs classsvc.Service) unitsvc.Service {
+		return unitsvc.New(unitsvc.NewSQLiteRepository(db), cs)
+	})
+}
+
+func TestSQLiteRepositoryTracing(t *testing.T) {
+	db, cleanup := openSQLiteDB(t)
+	defer cleanup()
+	repo := unitsvc.NewSQLiteRepository(db)
+
+	var statements []interface{}
+	var failed bool
+	tracer := tracing.NewLogTracer(log.LoggerFunc(func(keyvals ...interface{}) error {
+		for i := 0; i+1 < len(keyvals); i += 2 {
+			switch keyvals[i] {
+			case "db.statement":
+				statements = append(statements, keyvals[i+1])
+			case "error":
+				failed = true
+			}
+		}
+		return nil
+	}))
+
+	// Statements outside a traced request are not recorded.
+	if _, err := repo.UnitsByClassID(context.Background(), uuid.New()); err != nil {
+		t.Fatal(err)
+	}
+	span := tracer.StartSpan("ListUnits")
+	ctx := opentracing.ContextWithSpan(context.Background(), span)
+	if _, err := repo.UnitsByClassID(ctx, uuid.New()); err != nil {
+		t.Fatal(err)
+	}
+	// Single-row lookups are recorded too, and a missing row is not an error.
+	if _, err := repo.UnitByID(ctx, uuid.New()); err != unitsvc.ErrNotFound {
+		t.Fatalf("got error %v, want ErrNotFound", err)
+	}
+	span.Finish()
+	if len(statements) != 2 {
+		t.Fatalf("recorded statements %v, want two", statements)
+	}
+	if failed {
+		t.Error("missing row recorded as an error")
+	}
+}
@@ -0,0 +1,77 @@
+package unitsvc
+
+import (
+	"context"
+	"net/http"
+
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the ID that correlates a request's log lines, error response and events.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the request IDs accepted from clients.
+const maxRequestIDLength = 128
+
+type requestIDContextKey struct{}
+
+// WithRequestID returns a copy of ctx carrying a request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, or "" if there is none.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey{}).(string)
+	return id
+}
+
+// RequestIDFromHTTPRequest puts the request's X-Request-ID in the context,
+// generating one if the header is missing or malformed.
+func RequestIDFromHTTPRequest() httptransport.RequestFunc {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.New().String()
+		}
+		return WithRequestID(ctx, id)
+	}
+}
+
+// RequestIDToHTTPResponse echoes the context's request ID in the X-Request-ID response header.
+// encodeError does the same for failed requests, which skip ServerAfter functions.
+func RequestIDToHTTPResponse() httptransport.ServerResponseFunc {
+	return func(ctx context.Context, w http.ResponseWriter) context.Context {
+		if id := RequestID(ctx); id != "" {
+			w.Header().Set(RequestIDHeader, id)
+		}
+		return ctx
+	}
+}
+
+// RequestIDToHTTPRequest forwards the context's request ID in the X-Request-ID header.
+func RequestIDToHTTPRequest() httptransport.RequestFunc {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		if id := RequestID(ctx); id != "" {
+			r.Header.Set(RequestIDHeader, id)
+		}
+		return ctx
+	}
+}
+
+// validRequestID accepts IDs of up to maxRequestIDLength letters, digits, '-', '_' and '.',
+// so that client-supplied IDs cannot forge log fields or response headers.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
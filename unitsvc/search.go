@@ -0,0 +1,125 @@
+package unitsvc
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/studiously/unitsvc/models"
+)
+
+// Limits on the number of matches a search returns.
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+// SearchFilter narrows a search.
+type SearchFilter struct {
+	ClassIDs []uuid.UUID // only search these of the user's classes; all of them if empty
+	Limit    int         // at most this many matches; DefaultSearchLimit if zero, and never more than MaxSearchLimit
+}
+
+// searchTerms splits a query into the lowercase words that units must contain. Punctuation
+// separates words, so terms never contain LIKE wildcards.
+func searchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// likeSearchQuery returns a query for the units in classIDs whose titles or descriptions contain
+// every term, for
+// backends without full-text search. Matches are ranked by matchUnits. classIDs must not be empty,
+// and id converts UUIDs for the driver.
+func likeSearchQuery(columns, table string, classIDs []uuid.UUID, terms []string, id func(uuid.UUID) interface{}) (string, []interface{}) {
+	args := make([]interface{}, 0, len(classIDs)+len(terms))
+	for _, classID := range classIDs {
+		args = append(args, id(classID))
+	}
+	query := `SELECT ` + columns + ` FROM ` + table + ` ` +
+		`WHERE class_id IN (` + placeholders(len(classIDs)) + `)`
+	for _, term := range terms {
+		query += ` AND (title LIKE ? OR description LIKE ?)`
+		args = append(args, "%"+term+"%", "%"+term+"%")
+	}
+	return query, args
+}
+
+// matchUnits returns up to limit of the units whose titles or descriptions contain every term,
+// best match first. Units rank higher the more of their title's words are the terms, and words
+// that only contain a term count half; matches in the description count half as much again.
+func matchUnits(units []*models.Unit, terms []string, limit int) []*models.UnitMatch {
+	res := []*models.UnitMatch{}
+	if len(terms) == 0 {
+		return res
+	}
+	highlight := highlighter(terms)
+	for _, u := range units {
+		title, description := strings.ToLower(u.Title), strings.ToLower(u.Description)
+		matched := true
+		for _, term := range terms {
+			if !strings.Contains(title, term) && !strings.Contains(description, term) {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+		res = append(res, &models.UnitMatch{
+			Unit:    u,
+			Rank:    matchRank(title, terms) + matchRank(description, terms)/2,
+			Snippet: highlight.ReplaceAllString(u.Title, "<mark>$0</mark>"),
+		})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		a, b := res[i], res[j]
+		if a.Rank != b.Rank {
+			return a.Rank > b.Rank
+		}
+		if a.Unit.Title != b.Unit.Title {
+			return a.Unit.Title < b.Unit.Title
+		}
+		return bytes.Compare(a.Unit.ID[:], b.Unit.ID[:]) < 0
+	})
+	if len(res) > limit {
+		res = res[:limit]
+	}
+	return res
+}
+
+// matchRank returns the share of the words of text that are terms, where words that only contain
+// a term count half.
+func matchRank(text string, terms []string) float64 {
+	words := searchTerms(text)
+	if len(words) == 0 {
+		return 0
+	}
+	var rank float64
+	for _, word := range words {
+		for _, term := range terms {
+			if word == term {
+				rank++
+				break
+			} else if strings.Contains(word, term) {
+				rank += 0.5
+				break
+			}
+		}
+	}
+	return rank / float64(len(words))
+}
+
+// highlighter matches every term in a title, ignoring case, longest terms first.
+func highlighter(terms []string) *regexp.Regexp {
+	quoted := make([]string, 0, len(terms))
+	for _, term := range terms {
+		quoted = append(quoted, regexp.QuoteMeta(term))
+	}
+	sort.Slice(quoted, func(i, j int) bool { return len(quoted[i]) > len(quoted[j]) })
+	return regexp.MustCompile(`(?i)` + strings.Join(quoted, "|"))
+}
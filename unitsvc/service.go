@@ -9,7 +9,8 @@ import (
 )
 
 var (
-	ErrNotFound = errors.New("the requested resource could not be found, or the user is not allowed to view it")
+	ErrNotFound  = errors.New("the requested resource could not be found, or the user is not allowed to view it")
+	ErrForbidden = errors.New("the user is not allowed to perform this action")
 )
 
 type Middleware func(Service) Service
@@ -17,7 +18,71 @@ type Middleware func(Service) Service
 type Service interface {
 	ListUnits(ctx context.Context, classID uuid.UUID) ([]uuid.UUID, error)
 	GetUnit(ctx context.Context, unitID uuid.UUID) (*models.Unit, error)
+	// ListMyUnits gets a page of up to limit of the classes the user belongs to, starting after after,
+	// each with its units that match filter. Pass uuid.Nil for the first page, then the Next of each
+	// page. The classes are looked up once per page.
+	ListMyUnits(ctx context.Context, after uuid.UUID, limit int, filter UnitFilter) (*ClassUnitsPage, error)
 	CreateUnit(ctx context.Context, classID uuid.UUID, title string) error
 	RenameUnit(ctx context.Context, unitID uuid.UUID, title string) error
 	DeleteUnit(ctx context.Context, unitID uuid.UUID) error
+	// SearchUnits finds the units whose titles or descriptions match query in the classes the user
+	// belongs to, best match first. The classes are looked up once per search.
+	SearchUnits(ctx context.Context, query string, filter SearchFilter) ([]*models.UnitMatch, error)
+	// GetCurrentUnit gets the unit a class is on, or returns ErrNotFound if it is on none.
+	GetCurrentUnit(ctx context.Context, classID uuid.UUID) (*models.Unit, error)
+	// SetCurrentUnit moves a class to one of its units, or to none if unitID is uuid.Nil. It returns
+	// ErrBadRequest if the unit is not in the class. Only teachers of the class may move it.
+	SetCurrentUnit(ctx context.Context, classID, unitID uuid.UUID) error
+	// AdvanceUnit moves a class to the unit after the one it is on in display order, or to its first
+	// unit if it is on none, and returns that unit. It returns ErrNotFound if the class has no units,
+	// and ErrBadRequest if it is already on the last one. Only teachers of the class may advance it.
+	// A class on a unit that is deleted moves to the unit that took its place, or to none if it was
+	// the only one.
+	AdvanceUnit(ctx context.Context, classID uuid.UUID) (*models.Unit, error)
+	// MarkUnitProgress records how far the user has got through a unit, returning their progress. status
+	// is one of models.ProgressNotStarted, models.ProgressInProgress and models.ProgressCompleted. Only
+	// students of the unit's class may mark units.
+	MarkUnitProgress(ctx context.Context, unitID uuid.UUID, status string) (*models.UnitProgress, error)
+	// GetClassProgress gets the progress of every student of a class through each of its units.
+	// Only teachers of the class may view it.
+	GetClassProgress(ctx context.Context, classID uuid.UUID) (*ClassProgress, error)
+	// UnitHistory gets the changes made to a unit, oldest first, even after it was deleted.
+	// Only teachers of the unit's class may view them.
+	UnitHistory(ctx context.Context, unitID uuid.UUID) ([]*models.AuditEntry, error)
+	// ClassAudit gets the changes made to all units in a class, oldest first.
+	// Only teachers of the class may view them.
+	ClassAudit(ctx context.Context, classID uuid.UUID) ([]*models.AuditEntry, error)
+	// ListUnitVersions gets the stored versions of a unit, oldest first, each with its changes from the one before.
+	// Only teachers of the unit's class may view them.
+	ListUnitVersions(ctx context.Context, unitID uuid.UUID) ([]*models.UnitVersion, error)
+	// GetUnitVersion gets one stored version of a unit with its changes from the one before.
+	// Only teachers of the unit's class may view it.
+	GetUnitVersion(ctx context.Context, unitID uuid.UUID, version int) (*models.UnitVersion, error)
+	// RevertUnit restores a unit's content to how it was at a stored version, which becomes the unit's newest version.
+	// The unit keeps its place in the class. Only teachers of the unit's class may revert it.
+	RevertUnit(ctx context.Context, unitID uuid.UUID, version int) error
+	// CreateWebhook subscribes a URL to the events of a class, signing deliveries with secret.
+	// events lists the subjects to deliver, or is empty for all of them. Only teachers of the class may create webhooks.
+	CreateWebhook(ctx context.Context, classID uuid.UUID, url, secret string, events []string) (*models.Webhook, error)
+	// ListWebhooks gets the webhooks of a class, oldest first. Only teachers of the class may view them.
+	ListWebhooks(ctx context.Context, classID uuid.UUID) ([]*models.Webhook, error)
+	// DeleteWebhook deletes a webhook and its deliveries. Only teachers of its class may delete it.
+	DeleteWebhook(ctx context.Context, webhookID uuid.UUID) error
+	// ListDeliveries gets the deliveries of a webhook with the given status, or all of them if status
+	// is empty, oldest first. Only teachers of its class may view them.
+	ListDeliveries(ctx context.Context, webhookID uuid.UUID, status string) ([]*models.WebhookDelivery, error)
+	// ExportUnits gets the units of a class in display order, for writing with WriteExport.
+	ExportUnits(ctx context.Context, classID uuid.UUID) ([]*models.Unit, error)
+	// ImportUnits makes the units of a class match rows in one transaction, creating, updating and
+	// deleting units as needed. A dry run reports the changes without making them. It returns an
+	// *ImportError, and changes nothing, if any row is invalid. Only teachers of the class may import units.
+	ImportUnits(ctx context.Context, classID uuid.UUID, rows []ImportRow, dryRun bool) (*ImportResult, error)
+	// LinkOneRosterClass syncs a OneRoster class, identified by its sourcedId, to a class. Only teachers
+	// of the class, and of the class it was linked to before, may link it.
+	LinkOneRosterClass(ctx context.Context, sourcedID string, classID uuid.UUID) error
+	// SyncOneRoster makes the units of each linked class in a OneRoster bundle match its categories in
+	// one transaction. Units that a teacher renamed or deleted since the last sync are left as they are
+	// and reported as conflicts, unless overwrite is set. Classes that are not linked, or that the user
+	// does not teach, are reported as skipped. A dry run reports the changes without making them.
+	SyncOneRoster(ctx context.Context, bundle *RosterBundle, dryRun, overwrite bool) (*SyncReport, error)
 }
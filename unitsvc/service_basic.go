@@ -0,0 +1,1111 @@
+package unitsvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/studiously/classsvc/classsvc"
+	classmodels "github.com/studiously/classsvc/models"
+	"github.com/studiously/unitsvc/models"
+)
+
+// New returns a Service that stores units in repo and authorizes requests against cs.
+func New(repo Repository, cs classsvc.Service, options ...Option) Service {
+	s := &basicService{
+		repo: repo,
+		cs:   cs,
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// Option configures a Service returned by New.
+type Option func(*basicService)
+
+// MaxVersions limits how many versions are kept for each unit. The oldest versions are
+// deleted as new ones are made. Zero, the default, keeps every version.
+func MaxVersions(n int) Option {
+	return func(s *basicService) {
+		s.maxVersions = n
+	}
+}
+
+// SkipAuthorization lets every request act on every class, for tools that operators run against
+// the database directly. cs is not used and may be nil.
+func SkipAuthorization() Option {
+	return func(s *basicService) {
+		s.skipAuthorization = true
+	}
+}
+
+// OnChange calls fn with the audit entry of every change to a unit once it is committed.
+func OnChange(fn func(ctx context.Context, change *models.AuditEntry)) Option {
+	return func(s *basicService) {
+		s.onChange = append(s.onChange, fn)
+	}
+}
+
+type basicService struct {
+	repo              Repository
+	cs                classsvc.Service
+	maxVersions       int
+	skipAuthorization bool
+	onChange          []func(ctx context.Context, change *models.AuditEntry)
+}
+
+func (s *basicService) ListUnits(ctx context.Context, classID uuid.UUID) ([]uuid.UUID, error) {
+	if err := s.authorize(ctx, classID); err != nil {
+		return nil, err
+	}
+	units, err := s.repo.UnitsByClassID(ctx, classID)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]uuid.UUID, 0, len(units))
+	for _, u := range units {
+		results = append(results, u.ID)
+	}
+	return results, nil
+}
+
+// ListMyUnits pages through every class with units when authorization is skipped.
+func (s *basicService) ListMyUnits(ctx context.Context, after uuid.UUID, limit int, filter UnitFilter) (*ClassUnitsPage, error) {
+	if limit < 0 || !filter.valid() {
+		return nil, ErrBadRequest
+	} else if limit == 0 {
+		limit = DefaultClassPageSize
+	} else if limit > MaxClassPageSize {
+		limit = MaxClassPageSize
+	}
+	var classes []uuid.UUID
+	var err error
+	if s.skipAuthorization {
+		classes, err = s.repo.ClassIDs(ctx, after, limit+1)
+	} else {
+		classes, err = s.cs.ListClasses(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	classIDs, next := pageClassIDs(classes, after, limit)
+	units, err := s.repo.UnitsByClassIDs(ctx, classIDs, filter)
+	if err != nil {
+		return nil, err
+	}
+	page := &ClassUnitsPage{Classes: make([]*ClassUnits, 0, len(classIDs)), Next: next}
+	byClass := make(map[uuid.UUID]*ClassUnits, len(classIDs))
+	for _, classID := range classIDs {
+		c := &ClassUnits{ClassID: classID, Units: []*models.Unit{}}
+		page.Classes = append(page.Classes, c)
+		byClass[classID] = c
+	}
+	for _, u := range units {
+		if c, ok := byClass[u.ClassID]; ok {
+			c.Units = append(c.Units, u)
+		}
+	}
+	return page, nil
+}
+
+func (s *basicService) CreateUnit(ctx context.Context, classID uuid.UUID, title string) error {
+	if err := s.authorize(ctx, classID); err != nil {
+		return err
+	}
+	return s.change(ctx, func(repo Repository) (*models.AuditEntry, error) {
+		units, err := repo.UnitsByClassID(ctx, classID)
+		if err != nil {
+			return nil, err
+		}
+		unit := &models.Unit{
+			ID:           uuid.New(),
+			ClassID:      classID,
+			Title:        title,
+			DisplayOrder: nextDisplayOrder(units),
+			CreatedAt:    time.Now().UTC(),
+		}
+		if err := repo.SaveUnit(ctx, unit); err != nil {
+			return nil, err
+		}
+		if err := s.appendVersion(ctx, repo, nil, unit); err != nil {
+			return nil, err
+		}
+		return newAuditEntry(ctx, models.AuditCreate, nil, unit), nil
+	})
+}
+
+func (s *basicService) RenameUnit(ctx context.Context, unitID uuid.UUID, title string) error {
+	unit, err := s.repo.UnitByID(ctx, unitID)
+	if err != nil {
+		return err
+	}
+	if err := s.authorize(ctx, unit.ClassID); err != nil {
+		return err
+	}
+	return s.change(ctx, func(repo Repository) (*models.AuditEntry, error) {
+		before, err := repo.UnitByID(ctx, unitID)
+		if err != nil {
+			return nil, err
+		}
+		after := *before
+		after.Title = title
+		if err := repo.SaveUnit(ctx, &after); err != nil {
+			return nil, err
+		}
+		if err := s.appendVersion(ctx, repo, before, &after); err != nil {
+			return nil, err
+		}
+		return newAuditEntry(ctx, models.AuditRename, before, &after), nil
+	})
+}
+
+func (s *basicService) DeleteUnit(ctx context.Context, unitID uuid.UUID) error {
+	unit, err := s.repo.UnitByID(ctx, unitID)
+	if err != nil {
+		return err
+	}
+	if err := s.authorize(ctx, unit.ClassID); err != nil {
+		return err
+	}
+	return s.change(ctx, func(repo Repository) (*models.AuditEntry, error) {
+		before, err := repo.UnitByID(ctx, unitID)
+		if err != nil {
+			return nil, err
+		}
+		if err := repo.DeleteUnit(ctx, unitID); err != nil {
+			return nil, err
+		}
+		if err := repo.PruneVersions(ctx, unitID, 0); err != nil {
+			return nil, err
+		}
+		return newAuditEntry(ctx, models.AuditDelete, before, nil), nil
+	})
+}
+
+func (s *basicService) GetUnit(ctx context.Context, unitID uuid.UUID) (*models.Unit, error) {
+	unit, err := s.repo.UnitByID(ctx, unitID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, unit.ClassID); err != nil {
+		return nil, err
+	}
+	return unit, nil
+}
+
+// SearchUnits searches every class in filter when authorization is skipped, as there are no
+// classes of the user's to search.
+func (s *basicService) SearchUnits(ctx context.Context, query string, filter SearchFilter) ([]*models.UnitMatch, error) {
+	if len(searchTerms(query)) == 0 || filter.Limit < 0 {
+		return nil, ErrBadRequest
+	}
+	limit := filter.Limit
+	if limit == 0 {
+		limit = DefaultSearchLimit
+	} else if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+	classIDs := filter.ClassIDs
+	if !s.skipAuthorization {
+		classes, err := s.cs.ListClasses(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(filter.ClassIDs) > 0 {
+			wanted := make(map[uuid.UUID]bool, len(filter.ClassIDs))
+			for _, classID := range filter.ClassIDs {
+				wanted[classID] = true
+			}
+			classIDs = nil
+			for _, class := range classes {
+				if wanted[class] {
+					classIDs = append(classIDs, class)
+				}
+			}
+		} else {
+			classIDs = classes
+		}
+	}
+	return s.repo.SearchUnits(ctx, query, classIDs, limit)
+}
+
+func (s *basicService) GetCurrentUnit(ctx context.Context, classID uuid.UUID) (*models.Unit, error) {
+	if err := s.authorize(ctx, classID); err != nil {
+		return nil, err
+	}
+	cu, err := s.repo.CurrentUnitByClassID(ctx, classID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.UnitByID(ctx, cu.UnitID)
+}
+
+func (s *basicService) SetCurrentUnit(ctx context.Context, classID, unitID uuid.UUID) error {
+	if err := s.authorizeTeacher(ctx, classID); err != nil {
+		return err
+	}
+	return s.repo.Transact(ctx, func(repo Repository) error {
+		if unitID == uuid.Nil {
+			if err := repo.DeleteCurrentUnit(ctx, classID); err != nil {
+				return err
+			}
+			return enqueueCurrentUnit(ctx, repo, classID)
+		}
+		unit, err := repo.UnitByID(ctx, unitID)
+		if err == ErrNotFound || err == nil && unit.ClassID != classID {
+			return ErrBadRequest
+		} else if err != nil {
+			return err
+		}
+		if err := repo.SaveCurrentUnit(ctx, newCurrentUnit(ctx, unit)); err != nil {
+			return err
+		}
+		return enqueueCurrentUnit(ctx, repo, classID)
+	})
+}
+
+func (s *basicService) AdvanceUnit(ctx context.Context, classID uuid.UUID) (*models.Unit, error) {
+	if err := s.authorizeTeacher(ctx, classID); err != nil {
+		return nil, err
+	}
+	var next *models.Unit
+	err := s.repo.Transact(ctx, func(repo Repository) error {
+		units, err := repo.UnitsByClassID(ctx, classID)
+		if err != nil {
+			return err
+		}
+		if len(units) == 0 {
+			return ErrNotFound
+		}
+		var current uuid.UUID
+		cu, err := repo.CurrentUnitByClassID(ctx, classID)
+		if err == nil {
+			current = cu.UnitID
+		} else if err != ErrNotFound {
+			return err
+		}
+		if next = unitAfter(units, current); next == nil {
+			return ErrBadRequest
+		}
+		if err := repo.SaveCurrentUnit(ctx, newCurrentUnit(ctx, next)); err != nil {
+			return err
+		}
+		return enqueueCurrentUnit(ctx, repo, classID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+func (s *basicService) MarkUnitProgress(ctx context.Context, unitID uuid.UUID, status string) (*models.UnitProgress, error) {
+	if !validProgress(status) {
+		return nil, ErrBadRequest
+	}
+	unit, err := s.repo.UnitByID(ctx, unitID)
+	if err != nil {
+		return nil, err
+	}
+	subject, ok := Subject(ctx)
+	if !ok {
+		return nil, ErrForbidden
+	}
+	if err := s.authorizeStudent(ctx, unit.ClassID, subject); err != nil {
+		return nil, err
+	}
+	var progress *models.UnitProgress
+	err = s.repo.Transact(ctx, func(repo Repository) error {
+		// The unit is read again so that progress is never saved for a unit deleted meanwhile.
+		unit, err := repo.UnitByID(ctx, unitID)
+		if err != nil {
+			return err
+		}
+		before, err := repo.UnitProgressByUser(ctx, unitID, subject)
+		if err == ErrNotFound {
+			before = nil
+		} else if err != nil {
+			return err
+		}
+		progress = nextProgress(before, unit, subject, status, time.Now().UTC())
+		if err := repo.SaveUnitProgress(ctx, progress); err != nil {
+			return err
+		}
+		event := Event{ID: unitID, RequestID: RequestID(ctx)}
+		return enqueueEvent(ctx, repo, unit.ClassID, SubjUnitProgress, event, progress.UpdatedAt)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return progress, nil
+}
+
+// GetClassProgress reports the students with progress in the class when authorization is skipped,
+// as there is no classsvc to list the others.
+func (s *basicService) GetClassProgress(ctx context.Context, classID uuid.UUID) (*ClassProgress, error) {
+	if err := s.authorizeTeacher(ctx, classID); err != nil {
+		return nil, err
+	}
+	units, err := s.repo.UnitsByClassID(ctx, classID)
+	if err != nil {
+		return nil, err
+	}
+	progress, err := s.repo.UnitProgressByClassID(ctx, classID)
+	if err != nil {
+		return nil, err
+	}
+	var students []uuid.UUID
+	if s.skipAuthorization {
+		seen := make(map[uuid.UUID]bool)
+		for _, p := range progress {
+			if !seen[p.UserID] {
+				seen[p.UserID] = true
+				students = append(students, p.UserID)
+			}
+		}
+	} else {
+		members, err := s.cs.ListMembers(ctx, classID)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range members {
+			if m.Role == classmodels.UserRoleStudent {
+				students = append(students, m.UserID)
+			}
+		}
+	}
+	return classProgress(classID, units, students, progress), nil
+}
+
+func (s *basicService) UnitHistory(ctx context.Context, unitID uuid.UUID) ([]*models.AuditEntry, error) {
+	entries, err := s.repo.AuditByUnitID(ctx, unitID)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, ErrNotFound
+	}
+	if err := s.authorizeTeacher(ctx, entries[0].ClassID); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (s *basicService) ClassAudit(ctx context.Context, classID uuid.UUID) ([]*models.AuditEntry, error) {
+	if err := s.authorizeTeacher(ctx, classID); err != nil {
+		return nil, err
+	}
+	return s.repo.AuditByClassID(ctx, classID)
+}
+
+func (s *basicService) ListUnitVersions(ctx context.Context, unitID uuid.UUID) ([]*models.UnitVersion, error) {
+	unit, err := s.repo.UnitByID(ctx, unitID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeTeacher(ctx, unit.ClassID); err != nil {
+		return nil, err
+	}
+	versions, err := s.repo.VersionsByUnitID(ctx, unitID)
+	if err != nil {
+		return nil, err
+	}
+	for i := 1; i < len(versions); i++ {
+		versions[i].Changes = diffUnits(versions[i-1].Unit, versions[i].Unit)
+	}
+	return versions, nil
+}
+
+func (s *basicService) GetUnitVersion(ctx context.Context, unitID uuid.UUID, version int) (*models.UnitVersion, error) {
+	versions, err := s.ListUnitVersions(ctx, unitID)
+	if err != nil {
+		return nil, err
+	}
+	v := findVersion(versions, version)
+	if v == nil {
+		return nil, ErrNotFound
+	}
+	return v, nil
+}
+
+func (s *basicService) RevertUnit(ctx context.Context, unitID uuid.UUID, version int) error {
+	unit, err := s.repo.UnitByID(ctx, unitID)
+	if err != nil {
+		return err
+	}
+	if err := s.authorizeTeacher(ctx, unit.ClassID); err != nil {
+		return err
+	}
+	return s.change(ctx, func(repo Repository) (*models.AuditEntry, error) {
+		before, err := repo.UnitByID(ctx, unitID)
+		if err != nil {
+			return nil, err
+		}
+		versions, err := repo.VersionsByUnitID(ctx, unitID)
+		if err != nil {
+			return nil, err
+		}
+		target := findVersion(versions, version)
+		if target == nil {
+			return nil, ErrNotFound
+		}
+		after := *target.Unit
+		after.ID, after.ClassID, after.DisplayOrder, after.CreatedAt = before.ID, before.ClassID, before.DisplayOrder, before.CreatedAt
+		// The unit stays where it is in the class; its parent may no longer exist.
+		after.ParentID = before.ParentID
+		if err := repo.SaveUnit(ctx, &after); err != nil {
+			return nil, err
+		}
+		if err := s.appendVersion(ctx, repo, before, &after); err != nil {
+			return nil, err
+		}
+		return newAuditEntry(ctx, models.AuditRevert, before, &after), nil
+	})
+}
+
+func (s *basicService) CreateWebhook(ctx context.Context, classID uuid.UUID, url, secret string, events []string) (*models.Webhook, error) {
+	if err := s.authorizeTeacher(ctx, classID); err != nil {
+		return nil, err
+	}
+	creator, _ := Subject(ctx)
+	if events == nil {
+		events = []string{}
+	}
+	hook := &models.Webhook{
+		ID:        uuid.New(),
+		ClassID:   classID,
+		URL:       url,
+		Secret:    secret,
+		Events:    events,
+		CreatedBy: creator,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := validateWebhook(hook); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SaveWebhook(ctx, hook); err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+func (s *basicService) ListWebhooks(ctx context.Context, classID uuid.UUID) ([]*models.Webhook, error) {
+	if err := s.authorizeTeacher(ctx, classID); err != nil {
+		return nil, err
+	}
+	return s.repo.WebhooksByClassID(ctx, classID)
+}
+
+func (s *basicService) DeleteWebhook(ctx context.Context, webhookID uuid.UUID) error {
+	hook, err := s.repo.WebhookByID(ctx, webhookID)
+	if err != nil {
+		return err
+	}
+	if err := s.authorizeTeacher(ctx, hook.ClassID); err != nil {
+		return err
+	}
+	return s.repo.Transact(ctx, func(repo Repository) error {
+		return repo.DeleteWebhook(ctx, webhookID)
+	})
+}
+
+func (s *basicService) ListDeliveries(ctx context.Context, webhookID uuid.UUID, status string) ([]*models.WebhookDelivery, error) {
+	hook, err := s.repo.WebhookByID(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeTeacher(ctx, hook.ClassID); err != nil {
+		return nil, err
+	}
+	return s.repo.DeliveriesByWebhookID(ctx, webhookID, status)
+}
+
+func (s *basicService) ExportUnits(ctx context.Context, classID uuid.UUID) ([]*models.Unit, error) {
+	if err := s.authorize(ctx, classID); err != nil {
+		return nil, err
+	}
+	return s.repo.UnitsByClassID(ctx, classID)
+}
+
+func (s *basicService) ImportUnits(ctx context.Context, classID uuid.UUID, rows []ImportRow, dryRun bool) (*ImportResult, error) {
+	if err := s.authorizeTeacher(ctx, classID); err != nil {
+		return nil, err
+	}
+	if dryRun {
+		units, err := s.repo.UnitsByClassID(ctx, classID)
+		if err != nil {
+			return nil, err
+		}
+		result, err := planImport(classID, units, rows)
+		if err != nil {
+			return nil, err
+		}
+		result.DryRun = true
+		return result, nil
+	}
+	var result *ImportResult
+	err := s.changes(ctx, func(repo Repository) ([]*models.AuditEntry, error) {
+		units, err := repo.UnitsByClassID(ctx, classID)
+		if err != nil {
+			return nil, err
+		}
+		if result, err = planImport(classID, units, rows); err != nil {
+			return nil, err
+		}
+		before := make(map[uuid.UUID]*models.Unit, len(units))
+		for _, u := range units {
+			before[u.ID] = u
+		}
+		var entries []*models.AuditEntry
+		for _, u := range result.Created {
+			if err := repo.SaveUnit(ctx, u); err != nil {
+				return nil, err
+			}
+			if err := s.appendVersion(ctx, repo, nil, u); err != nil {
+				return nil, err
+			}
+			entries = append(entries, newAuditEntry(ctx, models.AuditCreate, nil, u))
+		}
+		for _, u := range result.Updated {
+			if err := repo.SaveUnit(ctx, u); err != nil {
+				return nil, err
+			}
+			if err := s.appendVersion(ctx, repo, before[u.ID], u); err != nil {
+				return nil, err
+			}
+			entries = append(entries, newAuditEntry(ctx, models.AuditUpdate, before[u.ID], u))
+		}
+		for _, u := range result.Deleted {
+			if err := repo.DeleteUnit(ctx, u.ID); err != nil {
+				return nil, err
+			}
+			if err := repo.PruneVersions(ctx, u.ID, 0); err != nil {
+				return nil, err
+			}
+			entries = append(entries, newAuditEntry(ctx, models.AuditDelete, u, nil))
+		}
+		return entries, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *basicService) LinkOneRosterClass(ctx context.Context, sourcedID string, classID uuid.UUID) error {
+	if sourcedID == "" {
+		return ErrBadRequest
+	}
+	if err := s.authorizeTeacher(ctx, classID); err != nil {
+		return err
+	}
+	link, err := s.repo.OneRosterClassBySourcedID(ctx, sourcedID)
+	switch err {
+	case nil:
+		if link.ClassID == classID {
+			return nil
+		}
+		if err := s.authorizeTeacher(ctx, link.ClassID); err == ErrNotFound {
+			return ErrForbidden
+		} else if err != nil {
+			return err
+		}
+	case ErrNotFound:
+	default:
+		return err
+	}
+	return s.repo.SaveOneRosterClass(ctx, &models.OneRosterClass{SourcedID: sourcedID, ClassID: classID, CreatedAt: time.Now().UTC()})
+}
+
+func (s *basicService) SyncOneRoster(ctx context.Context, bundle *RosterBundle, dryRun, overwrite bool) (*SyncReport, error) {
+	report := &SyncReport{DryRun: dryRun, Classes: []*ClassSync{}}
+	var synced []*ClassSync
+	classes := make(map[*ClassSync]RosterClass)
+	for _, rc := range bundle.Classes {
+		cs := &ClassSync{SourcedID: rc.SourcedID, Created: []*models.Unit{}, Updated: []*models.Unit{}, Deleted: []*models.Unit{}, Conflicts: []SyncConflict{}}
+		report.Classes = append(report.Classes, cs)
+		link, err := s.repo.OneRosterClassBySourcedID(ctx, rc.SourcedID)
+		if err == ErrNotFound {
+			cs.Skipped = "the class is not linked to a class of this service"
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		cs.ClassID = link.ClassID
+		switch err := s.authorizeTeacher(ctx, link.ClassID); err {
+		case nil:
+		case ErrNotFound, ErrForbidden:
+			cs.Skipped = "only teachers of the class may sync it"
+			continue
+		default:
+			return nil, err
+		}
+		if rc.Deleted {
+			cs.Skipped = "the class is marked tobedeleted, so its units are left as they are"
+			continue
+		}
+		synced = append(synced, cs)
+		classes[cs] = rc
+	}
+
+	sync := func(repo Repository) ([]*models.AuditEntry, error) {
+		var entries []*models.AuditEntry
+		for _, cs := range synced {
+			e, err := s.syncClass(ctx, repo, cs, classes[cs], bundle, overwrite)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, e...)
+		}
+		return entries, nil
+	}
+	var err error
+	if dryRun {
+		// A dry run syncs in a transaction that is always rolled back, so that it reports exactly
+		// what the sync would do.
+		err = s.repo.Transact(ctx, func(repo Repository) error {
+			if _, err := sync(repo); err != nil {
+				return err
+			}
+			return errDryRun
+		})
+		if err == errDryRun {
+			err = nil
+		}
+	} else {
+		err = s.changes(ctx, sync)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// errDryRun rolls back the transaction of a dry run.
+var errDryRun = errors.New("dry run")
+
+// syncClass makes the units of a linked class match the categories of rc, recording the changes in cs.
+func (s *basicService) syncClass(ctx context.Context, repo Repository, cs *ClassSync, rc RosterClass, bundle *RosterBundle, overwrite bool) ([]*models.AuditEntry, error) {
+	units, err := repo.UnitsByClassID(ctx, cs.ClassID)
+	if err != nil {
+		return nil, err
+	}
+	links, err := repo.OneRosterUnitsByClassID(ctx, cs.ClassID)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]*models.Unit, len(units))
+	for _, u := range units {
+		byID[u.ID] = u
+	}
+	linked := make(map[string]*models.OneRosterUnit, len(links))
+	for _, l := range links {
+		linked[l.SourcedID] = l
+	}
+	now := time.Now().UTC()
+	order := nextDisplayOrder(units)
+	conflict := func(sourcedID string, unitID uuid.UUID, format string, args ...interface{}) {
+		cs.Conflicts = append(cs.Conflicts, SyncConflict{sourcedID, unitID, fmt.Sprintf(format, args...)})
+	}
+
+	var entries []*models.AuditEntry
+	listed := make(map[string]bool, len(rc.Categories))
+	for _, cat := range rc.Categories {
+		listed[cat.SourcedID] = true
+		title := strings.TrimSpace(cat.Title)
+		link, ok := linked[cat.SourcedID]
+		var unit *models.Unit
+		if ok {
+			unit = byID[link.UnitID]
+		}
+		switch {
+		case title == "" && unit == nil:
+			conflict(cat.SourcedID, uuid.Nil, "the category is not in the bundle, so it has no title to create a unit with")
+			continue
+		case title == "":
+			continue
+		case ok && unit == nil && !overwrite:
+			conflict(cat.SourcedID, link.UnitID, "the unit was deleted by a teacher, so it was not created again")
+			continue
+		case unit == nil:
+			unit = &models.Unit{ID: uuid.New(), ClassID: cs.ClassID, Title: title, DisplayOrder: order, CreatedAt: now}
+			order++
+			if err := repo.SaveUnit(ctx, unit); err != nil {
+				return nil, err
+			}
+			if err := s.appendVersion(ctx, repo, nil, unit); err != nil {
+				return nil, err
+			}
+			entries = append(entries, newAuditEntry(ctx, models.AuditCreate, nil, unit))
+			cs.Created = append(cs.Created, unit)
+		case unit.Title == title:
+		case unit.Title != link.Title && !overwrite:
+			conflict(cat.SourcedID, unit.ID, "the unit was renamed to %q by a teacher, so it was not renamed to %q", unit.Title, title)
+			continue
+		default:
+			after := *unit
+			after.Title = title
+			if err := repo.SaveUnit(ctx, &after); err != nil {
+				return nil, err
+			}
+			if err := s.appendVersion(ctx, repo, unit, &after); err != nil {
+				return nil, err
+			}
+			entries = append(entries, newAuditEntry(ctx, models.AuditUpdate, unit, &after))
+			cs.Updated = append(cs.Updated, &after)
+		}
+		err := repo.SaveOneRosterUnit(ctx, &models.OneRosterUnit{ClassID: cs.ClassID, SourcedID: cat.SourcedID, UnitID: unit.ID, Title: title, SyncedAt: now})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Bulk bundles list every category of the class, while delta bundles list the ones they remove.
+	removed := make(map[string]bool)
+	if bundle.Delta {
+		for _, id := range bundle.DeletedCategories {
+			removed[id] = true
+		}
+	}
+	for _, link := range links {
+		if listed[link.SourcedID] || bundle.Delta && !removed[link.SourcedID] {
+			continue
+		}
+		if unit, ok := byID[link.UnitID]; ok {
+			if unit.Title != link.Title && !overwrite {
+				conflict(link.SourcedID, unit.ID, "the unit was renamed to %q by a teacher, so it was kept although its category was removed", unit.Title)
+			} else {
+				if err := repo.DeleteUnit(ctx, unit.ID); err != nil {
+					return nil, err
+				}
+				if err := repo.PruneVersions(ctx, unit.ID, 0); err != nil {
+					return nil, err
+				}
+				entries = append(entries, newAuditEntry(ctx, models.AuditDelete, unit, nil))
+				cs.Deleted = append(cs.Deleted, unit)
+			}
+		}
+		if err := repo.DeleteOneRosterUnit(ctx, cs.ClassID, link.SourcedID); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// authorize returns ErrNotFound unless the current user is enrolled in the class.
+func (s *basicService) authorize(ctx context.Context, classID uuid.UUID) error {
+	if s.skipAuthorization {
+		return nil
+	}
+	classes, err := s.cs.ListClasses(ctx)
+	if err != nil {
+		return err
+	}
+	for _, class := range classes {
+		if class == classID {
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// authorizeTeacher returns ErrNotFound unless the current user is enrolled in the class,
+// and ErrForbidden unless they teach it.
+func (s *basicService) authorizeTeacher(ctx context.Context, classID uuid.UUID) error {
+	if s.skipAuthorization {
+		return nil
+	}
+	if err := s.authorize(ctx, classID); err != nil {
+		return err
+	}
+	subject, ok := Subject(ctx)
+	if !ok {
+		return ErrForbidden
+	}
+	member, err := s.cs.GetMember(ctx, classID, subject)
+	if err != nil {
+		return err
+	}
+	if member.Role != classmodels.UserRoleTeacher {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// authorizeStudent returns ErrNotFound unless userID is enrolled in the class, and ErrForbidden
+// unless they are a student of it.
+func (s *basicService) authorizeStudent(ctx context.Context, classID, userID uuid.UUID) error {
+	if s.skipAuthorization {
+		return nil
+	}
+	if err := s.authorize(ctx, classID); err != nil {
+		return err
+	}
+	members, err := s.cs.ListMembers(ctx, classID)
+	if err != nil {
+		return err
+	}
+	for _, m := range members {
+		if m.UserID == userID {
+			if m.Role != classmodels.UserRoleStudent {
+				return ErrForbidden
+			}
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// newAuditEntry records a change to a unit by the current user. before is nil for
+// models.AuditCreate and after is nil for models.AuditDelete.
+func newAuditEntry(ctx context.Context, action string, before, after *models.Unit) *models.AuditEntry {
+	unit := after
+	if unit == nil {
+		unit = before
+	}
+	actor, _ := Subject(ctx)
+	return &models.AuditEntry{
+		ID:        uuid.New(),
+		UnitID:    unit.ID,
+		ClassID:   unit.ClassID,
+		Action:    action,
+		Actor:     actor,
+		ClientID:  ClientID(ctx),
+		RequestID: RequestID(ctx),
+		Before:    before,
+		After:     after,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// change runs fn in a transaction, appending the audit entry it returns for the change it made
+// and queueing the change for the class's webhooks. A class on a unit that fn deleted is moved
+// off it, the units nested in it move up to its parent, and students' progress through it is
+// deleted. Once the transaction commits, the entry is passed to the OnChange callbacks, followed
+// by the entries for the units that moved up.
+func (s *basicService) change(ctx context.Context, fn func(repo Repository) (*models.AuditEntry, error)) error {
+	return s.changes(ctx, func(repo Repository) ([]*models.AuditEntry, error) {
+		entry, err := fn(repo)
+		if err != nil {
+			return nil, err
+		}
+		return []*models.AuditEntry{entry}, nil
+	})
+}
+
+// changes is like change, for fn that change several units in one transaction.
+func (s *basicService) changes(ctx context.Context, fn func(repo Repository) ([]*models.AuditEntry, error)) error {
+	var entries []*models.AuditEntry
+	err := s.repo.Transact(ctx, func(repo Repository) error {
+		var err error
+		if entries, err = fn(repo); err != nil {
+			return err
+		}
+		// Entries for the children of deleted units are appended as the loop goes.
+		for i := 0; i < len(entries); i++ {
+			entry := entries[i]
+			if err := repo.AppendAudit(ctx, entry); err != nil {
+				return err
+			}
+			if err := enqueueDeliveries(ctx, repo, entry); err != nil {
+				return err
+			}
+			if entry.Action == models.AuditDelete {
+				if err := moveOffDeletedUnit(ctx, repo, entry); err != nil {
+					return err
+				}
+				if err := repo.DeleteUnitProgress(ctx, entry.UnitID); err != nil {
+					return err
+				}
+				moved, err := s.moveChildrenUp(ctx, repo, entry.Before)
+				if err != nil {
+					return err
+				}
+				entries = append(entries, moved...)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		for _, fn := range s.onChange {
+			fn(ctx, entry)
+		}
+	}
+	return nil
+}
+
+// moveOffDeletedUnit moves the class of a deleted unit to the unit that took its place, if the
+// class was on it.
+func moveOffDeletedUnit(ctx context.Context, repo Repository, deleted *models.AuditEntry) error {
+	cu, err := repo.CurrentUnitByClassID(ctx, deleted.ClassID)
+	if err == ErrNotFound || err == nil && cu.UnitID != deleted.UnitID {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	units, err := repo.UnitsByClassID(ctx, deleted.ClassID)
+	if err != nil {
+		return err
+	}
+	unit := replacementUnit(units, deleted.Before)
+	if unit == nil {
+		return repo.DeleteCurrentUnit(ctx, deleted.ClassID)
+	}
+	return repo.SaveCurrentUnit(ctx, newCurrentUnit(ctx, unit))
+}
+
+// moveChildrenUp nests the units that were nested in a deleted unit in its parent instead, or
+// makes them top-level units if it had none, and returns the audit entries for the moves.
+func (s *basicService) moveChildrenUp(ctx context.Context, repo Repository, deleted *models.Unit) ([]*models.AuditEntry, error) {
+	units, err := repo.UnitsByClassID(ctx, deleted.ClassID)
+	if err != nil {
+		return nil, err
+	}
+	var entries []*models.AuditEntry
+	for _, u := range units {
+		if u.ParentID == nil || *u.ParentID != deleted.ID {
+			continue
+		}
+		after := *u
+		after.ParentID = deleted.ParentID
+		if err := repo.SaveUnit(ctx, &after); err != nil {
+			return nil, err
+		}
+		if err := s.appendVersion(ctx, repo, u, &after); err != nil {
+			return nil, err
+		}
+		entries = append(entries, newAuditEntry(ctx, models.AuditUpdate, u, &after))
+	}
+	return entries, nil
+}
+
+// newCurrentUnit records that the current user moved unit's class to it.
+func newCurrentUnit(ctx context.Context, unit *models.Unit) *models.CurrentUnit {
+	actor, _ := Subject(ctx)
+	return &models.CurrentUnit{
+		ClassID:   unit.ClassID,
+		UnitID:    unit.ID,
+		UpdatedBy: actor,
+		UpdatedAt: time.Now().UTC(),
+	}
+}
+
+// enqueueCurrentUnit queues the SubjCurrentUnit event that MessagingMiddleware publishes when the
+// current user moves a class to another unit, for the class's webhooks.
+func enqueueCurrentUnit(ctx context.Context, repo Repository, classID uuid.UUID) error {
+	return enqueueEvent(ctx, repo, classID, SubjCurrentUnit, Event{ID: classID, RequestID: RequestID(ctx)}, time.Now().UTC())
+}
+
+// appendVersion records after as the newest version of a unit, then prunes the oldest versions
+// beyond s.maxVersions. before is the unit's state until now, or nil if it was just created.
+func (s *basicService) appendVersion(ctx context.Context, repo Repository, before, after *models.Unit) error {
+	versions, err := repo.VersionsByUnitID(ctx, after.ID)
+	if err != nil {
+		return err
+	}
+	next := 1
+	if n := len(versions); n > 0 {
+		next = versions[n-1].Version + 1
+	} else if before != nil {
+		// Units created before versions were recorded start with the state they had until now.
+		if err := repo.AppendVersion(ctx, newUnitVersion(ctx, before, next)); err != nil {
+			return err
+		}
+		next++
+	}
+	if err := repo.AppendVersion(ctx, newUnitVersion(ctx, after, next)); err != nil {
+		return err
+	}
+	if s.maxVersions > 0 {
+		return repo.PruneVersions(ctx, after.ID, s.maxVersions)
+	}
+	return nil
+}
+
+// newUnitVersion snapshots unit as the given version, made by the current user.
+func newUnitVersion(ctx context.Context, unit *models.Unit, version int) *models.UnitVersion {
+	author, _ := Subject(ctx)
+	return &models.UnitVersion{
+		UnitID:    unit.ID,
+		Version:   version,
+		Unit:      unit,
+		Author:    author,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+func findVersion(versions []*models.UnitVersion, version int) *models.UnitVersion {
+	for _, v := range versions {
+		if v.Version == version {
+			return v
+		}
+	}
+	return nil
+}
+
+// diffUnits returns the content fields that differ between two versions of a unit.
+func diffUnits(before, after *models.Unit) []models.FieldChange {
+	var changes []models.FieldChange
+	if before.Title != after.Title {
+		changes = append(changes, models.FieldChange{Field: "title", Before: before.Title, After: after.Title})
+	}
+	if before.DisplayOrder != after.DisplayOrder {
+		changes = append(changes, models.FieldChange{Field: "display_order", Before: before.DisplayOrder, After: after.DisplayOrder})
+	}
+	if before.Description != after.Description {
+		changes = append(changes, models.FieldChange{Field: "description", Before: before.Description, After: after.Description})
+	}
+	if !before.Metadata.Equal(after.Metadata) {
+		changes = append(changes, models.FieldChange{Field: "metadata", Before: before.Metadata, After: after.Metadata})
+	}
+	if !sameParent(before, after) {
+		changes = append(changes, models.FieldChange{Field: "parent_id", Before: before.ParentID, After: after.ParentID})
+	}
+	if !before.Resources.Equal(after.Resources) {
+		changes = append(changes, models.FieldChange{Field: "resources", Before: before.Resources, After: after.Resources})
+	}
+	if before.Status.OrPublished() != after.Status.OrPublished() {
+		changes = append(changes, models.FieldChange{Field: "status", Before: before.Status.OrPublished(), After: after.Status.OrPublished()})
+	}
+	if !sameTime(before.StartsAt, after.StartsAt) {
+		changes = append(changes, models.FieldChange{Field: "starts_at", Before: before.StartsAt, After: after.StartsAt})
+	}
+	if !sameTime(before.EndsAt, after.EndsAt) {
+		changes = append(changes, models.FieldChange{Field: "ends_at", Before: before.EndsAt, After: after.EndsAt})
+	}
+	return changes
+}
+
+// sameParent reports whether two units are nested in the same unit, or are both top-level units.
+func sameParent(a, b *models.Unit) bool {
+	if a.ParentID == nil || b.ParentID == nil {
+		return a.ParentID == b.ParentID
+	}
+	return *a.ParentID == *b.ParentID
+}
+
+// sameTime reports whether a and b are the same instant, or are both nil.
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// nextDisplayOrder returns the display order that places a new unit after all of units.
+func nextDisplayOrder(units []*models.Unit) int {
+	next := 0
+	for _, u := range units {
+		if u.DisplayOrder >= next {
+			next = u.DisplayOrder + 1
+		}
+	}
+	return next
+}
@@ -0,0 +1,155 @@
+package unitsvc_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/studiously/classsvc/models"
+	unitsvcmodels "github.com/studiously/unitsvc/models"
+	"github.com/studiously/unitsvc/unitsvc"
+	unittesting "github.com/studiously/unitsvc/unitsvc/testing"
+)
+
+func TestMaxVersions(t *testing.T) {
+	cs := unittesting.NewClassService()
+	teacher, classID := uuid.New(), uuid.New()
+	cs.Enroll(teacher, classID, models.UserRoleTeacher)
+	ctx := unittesting.NewContext(teacher)
+	svc := unitsvc.New(unitsvc.NewMemoryRepository(), cs, unitsvc.MaxVersions(2))
+
+	if err := svc.CreateUnit(ctx, classID, "v1"); err != nil {
+		t.Fatalf("CreateUnit: %v", err)
+	}
+	units, err := svc.ListUnits(ctx, classID)
+	if err != nil {
+		t.Fatalf("ListUnits: %v", err)
+	}
+	for _, title := range []string{"v2", "v3", "v4"} {
+		if err := svc.RenameUnit(ctx, units[0], title); err != nil {
+			t.Fatalf("RenameUnit: %v", err)
+		}
+	}
+	versions, err := svc.ListUnitVersions(ctx, units[0])
+	if err != nil {
+		t.Fatalf("ListUnitVersions: %v", err)
+	}
+	if len(versions) != 2 || versions[0].Version != 3 || versions[1].Version != 4 {
+		t.Fatalf("got %d versions, want versions 3 and 4", len(versions))
+	}
+	if err := svc.RevertUnit(ctx, units[0], 1); err != unitsvc.ErrNotFound {
+		t.Fatalf("RevertUnit to pruned version: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+}
+
+func TestSearchUnits(t *testing.T) {
+	cs := unittesting.NewClassService()
+	student, biology, chemistry, other := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	cs.Enroll(student, biology, models.UserRoleStudent)
+	cs.Enroll(student, chemistry, models.UserRoleStudent)
+	repo := unitsvc.NewMemoryRepository()
+	admin := unitsvc.New(repo, nil, unitsvc.SkipAuthorization())
+	ctx := unittesting.NewContext(student)
+	for classID, title := range map[uuid.UUID]string{
+		biology:   "Photosynthesis",
+		chemistry: "Photochemistry and Photosynthesis",
+		other:     "Photosynthesis Lab",
+	} {
+		if err := admin.CreateUnit(ctx, classID, title); err != nil {
+			t.Fatalf("CreateUnit: %v", err)
+		}
+	}
+	svc := unitsvc.New(repo, cs)
+
+	// Only the user's classes are searched.
+	matches, err := svc.SearchUnits(ctx, "photosynthesis", unitsvc.SearchFilter{})
+	if err != nil {
+		t.Fatalf("SearchUnits: %v", err)
+	}
+	if len(matches) != 2 || matches[0].Unit.ClassID != biology || matches[1].Unit.ClassID != chemistry {
+		t.Fatalf("got %d matches, want the biology unit ranked above the chemistry unit", len(matches))
+	}
+	matches, err = svc.SearchUnits(ctx, "photosynthesis", unitsvc.SearchFilter{ClassIDs: []uuid.UUID{chemistry, other}})
+	if err != nil || len(matches) != 1 || matches[0].Unit.ClassID != chemistry {
+		t.Errorf("SearchUnits in chemistry and another class: got %v, %v; want the chemistry unit", matches, err)
+	}
+	if matches, err := svc.SearchUnits(ctx, "photosynthesis", unitsvc.SearchFilter{Limit: 1}); err != nil || len(matches) != 1 {
+		t.Errorf("SearchUnits with limit 1: got %v, %v", matches, err)
+	}
+
+	if _, err := svc.SearchUnits(ctx, "  ?! ", unitsvc.SearchFilter{}); err != unitsvc.ErrBadRequest {
+		t.Errorf("SearchUnits without words: got %v, want ErrBadRequest", err)
+	}
+	if _, err := svc.SearchUnits(ctx, "photosynthesis", unitsvc.SearchFilter{Limit: -1}); err != unitsvc.ErrBadRequest {
+		t.Errorf("SearchUnits with negative limit: got %v, want ErrBadRequest", err)
+	}
+}
+
+func TestListMyUnits(t *testing.T) {
+	cs := unittesting.NewClassService()
+	student, other := uuid.New(), uuid.New()
+	var classes []uuid.UUID
+	for i := 0; i < 3; i++ {
+		classID := uuid.New()
+		cs.Enroll(student, classID, models.UserRoleStudent)
+		classes = append(classes, classID)
+	}
+	repo := unitsvc.NewMemoryRepository()
+	admin := unitsvc.New(repo, nil, unitsvc.SkipAuthorization())
+	ctx := unittesting.NewContext(student)
+	// The last class has no units.
+	for _, classID := range []uuid.UUID{classes[0], classes[0], classes[1], other} {
+		if err := admin.CreateUnit(ctx, classID, "Unit"); err != nil {
+			t.Fatalf("CreateUnit: %v", err)
+		}
+	}
+	svc := unitsvc.New(repo, cs)
+
+	units := make(map[uuid.UUID]int)
+	after := uuid.Nil
+	for pages := 0; ; pages++ {
+		page, err := svc.ListMyUnits(ctx, after, 2, unitsvc.UnitFilter{})
+		if err != nil {
+			t.Fatalf("ListMyUnits: %v", err)
+		}
+		if len(page.Classes) > 2 || pages > 2 {
+			t.Fatalf("got %d classes on page %d, want at most 2 on each of 2 pages", len(page.Classes), pages+1)
+		}
+		for _, c := range page.Classes {
+			if _, ok := units[c.ClassID]; ok {
+				t.Fatalf("got class %v twice", c.ClassID)
+			}
+			units[c.ClassID] = len(c.Units)
+		}
+		if page.Next == nil {
+			break
+		}
+		after = *page.Next
+	}
+	if len(units) != 3 || units[classes[0]] != 2 || units[classes[1]] != 1 || units[classes[2]] != 0 {
+		t.Errorf("got units per class %v, want 2, 1 and 0 units in the student's classes", units)
+	}
+
+	if _, err := svc.ListMyUnits(ctx, uuid.Nil, -1, unitsvc.UnitFilter{}); err != unitsvc.ErrBadRequest {
+		t.Errorf("ListMyUnits with negative limit: got %v, want ErrBadRequest", err)
+	}
+	now := time.Now()
+	for name, filter := range map[string]unitsvc.UnitFilter{
+		"unknown status":       {Statuses: []unitsvcmodels.UnitStatus{"deleted"}},
+		"window ending before": {From: &now, To: &now},
+	} {
+		if _, err := svc.ListMyUnits(ctx, uuid.Nil, 0, filter); err != unitsvc.ErrBadRequest {
+			t.Errorf("ListMyUnits with %s: got %v, want ErrBadRequest", name, err)
+		}
+	}
+	// Filtered out units leave their classes on the page.
+	page, err := svc.ListMyUnits(ctx, uuid.Nil, 0, unitsvc.UnitFilter{Statuses: []unitsvcmodels.UnitStatus{unitsvcmodels.StatusDraft}})
+	if err != nil || len(page.Classes) != 3 || len(page.Classes[0].Units)+len(page.Classes[1].Units)+len(page.Classes[2].Units) != 0 {
+		t.Errorf("ListMyUnits of drafts: got %+v, %v; want the 3 classes without units", page, err)
+	}
+	// Without authorization, every class with units is listed.
+	page, err = admin.ListMyUnits(ctx, uuid.Nil, 10, unitsvc.UnitFilter{})
+	if err != nil || len(page.Classes) != 3 || page.Next != nil {
+		t.Errorf("ListMyUnits without authorization: got %+v, %v; want the 3 classes with units", page, err)
+	}
+}
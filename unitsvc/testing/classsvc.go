@@ -0,0 +1,154 @@
+package testing
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/studiously/classsvc/classsvc"
+	"github.com/studiously/classsvc/models"
+	"github.com/studiously/introspector"
+)
+
+// ErrNotImplemented is returned by ClassService methods that unitsvc never calls.
+var ErrNotImplemented = errors.New("not implemented by fake classsvc")
+
+// ClassService is a fake classsvc.Service backed by an in-memory enrollment table.
+// The current user is read from introspector.SubjectContextKey, as set by introspector.New.
+// It is safe for concurrent use.
+type ClassService struct {
+	mu      sync.RWMutex
+	members map[uuid.UUID]map[uuid.UUID]models.UserRole // class ID -> user ID -> role
+	err     error
+}
+
+var _ classsvc.Service = (*ClassService)(nil)
+
+// NewClassService returns a ClassService with no classes.
+func NewClassService() *ClassService {
+	return &ClassService{members: make(map[uuid.UUID]map[uuid.UUID]models.UserRole)}
+}
+
+// Enroll adds subject to classID with role, replacing any existing role.
+func (c *ClassService) Enroll(subject, classID uuid.UUID, role models.UserRole) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.members[classID] == nil {
+		c.members[classID] = make(map[uuid.UUID]models.UserRole)
+	}
+	c.members[classID][subject] = role
+}
+
+// Unenroll removes subject from classID.
+func (c *ClassService) Unenroll(subject, classID uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.members[classID], subject)
+}
+
+// Fail makes every subsequent call return err. Fail(nil) restores normal behaviour.
+func (c *ClassService) Fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = err
+}
+
+// current returns the subject of ctx, or an error if the call should fail.
+func (c *ClassService) current(ctx context.Context) (uuid.UUID, error) {
+	if c.err != nil {
+		return uuid.Nil, c.err
+	}
+	subject, ok := ctx.Value(introspector.SubjectContextKey).(uuid.UUID)
+	if !ok {
+		return uuid.Nil, classsvc.ErrUnauthorized
+	}
+	return subject, nil
+}
+
+func (c *ClassService) ListClasses(ctx context.Context) ([]uuid.UUID, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	subject, err := c.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	classes := []uuid.UUID{}
+	for classID, members := range c.members {
+		if _, ok := members[subject]; ok {
+			classes = append(classes, classID)
+		}
+	}
+	return classes, nil
+}
+
+func (c *ClassService) GetClass(ctx context.Context, classID uuid.UUID) (*models.Class, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	subject, err := c.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := c.members[classID][subject]; !ok {
+		return nil, classsvc.ErrNotFound
+	}
+	return &models.Class{ID: classID, Active: true}, nil
+}
+
+func (c *ClassService) ListMembers(ctx context.Context, classID uuid.UUID) ([]*models.Member, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	subject, err := c.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := c.members[classID][subject]; !ok {
+		return nil, classsvc.ErrNotFound
+	}
+	members := []*models.Member{}
+	for userID, role := range c.members[classID] {
+		members = append(members, &models.Member{UserID: userID, ClassID: classID, Role: role})
+	}
+	return members, nil
+}
+
+func (c *ClassService) GetMember(ctx context.Context, classID, userID uuid.UUID) (*models.Member, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	subject, err := c.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := c.members[classID][subject]; !ok {
+		return nil, classsvc.ErrNotFound
+	}
+	role, ok := c.members[classID][userID]
+	if !ok {
+		return nil, classsvc.ErrNotFound
+	}
+	return &models.Member{UserID: userID, ClassID: classID, Role: role}, nil
+}
+
+func (c *ClassService) CreateClass(ctx context.Context, name string) (*uuid.UUID, error) {
+	return nil, ErrNotImplemented
+}
+
+func (c *ClassService) UpdateClass(ctx context.Context, classID uuid.UUID, name *string, currentUnit *uuid.UUID) error {
+	return ErrNotImplemented
+}
+
+func (c *ClassService) DeleteClass(ctx context.Context, classID uuid.UUID) error {
+	return ErrNotImplemented
+}
+
+func (c *ClassService) JoinClass(ctx context.Context, classID uuid.UUID) error {
+	return ErrNotImplemented
+}
+
+func (c *ClassService) LeaveClass(ctx context.Context, userID *uuid.UUID, classID uuid.UUID) error {
+	return ErrNotImplemented
+}
+
+func (c *ClassService) SetRole(ctx context.Context, classID uuid.UUID, userID uuid.UUID, role models.UserRole) error {
+	return ErrNotImplemented
+}
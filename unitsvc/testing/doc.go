@@ -0,0 +1,7 @@
+// Package testing provides test doubles for the services unitsvc depends on, and a harness
+// that serves unitsvc's HTTP API backed by them.
+//
+// Import it under another name to avoid clashing with the standard library:
+//
+//	import unittesting "github.com/studiously/unitsvc/unitsvc/testing"
+package testing
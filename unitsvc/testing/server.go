@@ -0,0 +1,86 @@
+package testing
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/go-kit/kit/log"
+	"github.com/google/uuid"
+	"github.com/opentracing/opentracing-go"
+	"github.com/studiously/unitsvc/unitsvc"
+)
+
+// ClientID is the OAuth2 client of tokens issued by Server.Token.
+const ClientID = "unitsvc-test"
+
+// Server serves unitsvc.MakeHTTPHandler from an httptest.Server, with a fake classsvc
+// and a fake Hydra introspector in place of the real ones. Changes are streamed from Feed.
+// Webhook deliveries stay queued in Repository until they are dispatched.
+type Server struct {
+	*httptest.Server
+	Service      unitsvc.Service
+	Repository   unitsvc.Repository
+	Feed         *unitsvc.Feed
+	Classes      *ClassService
+	Introspector *Introspector
+}
+
+// NewServer starts a Server whose units are kept in memory. Callers must Close it.
+func NewServer() *Server {
+	return NewServerWithRepository(unitsvc.NewMemoryRepository())
+}
+
+// NewServerWithRepository starts a Server whose units are kept in repo. Callers must Close it.
+func NewServerWithRepository(repo unitsvc.Repository) *Server {
+	s := &Server{
+		Repository:   repo,
+		Classes:      NewClassService(),
+		Introspector: NewIntrospector(),
+	}
+	s.Feed = unitsvc.NewFeed(100)
+	s.Service = unitsvc.New(repo, s.Classes, unitsvc.OnChange(s.Feed.OnChange))
+	s.Server = httptest.NewServer(unitsvc.MakeHTTPHandler(s.Service, s.Feed, log.NewNopLogger(), s.Introspector, opentracing.NoopTracer{}))
+	return s
+}
+
+// Close ends open change streams, then shuts the server down.
+func (s *Server) Close() {
+	s.Feed.Close()
+	s.Server.Close()
+}
+
+// Token issues a non-expiring access token for subject with the given scopes.
+func (s *Server) Token(subject uuid.UUID, scopes ...string) string {
+	return s.Introspector.Issue(Token{
+		Subject:  subject.String(),
+		ClientID: ClientID,
+		Scopes:   scopes,
+	})
+}
+
+// Do sends a request to the server. If token is not empty it is sent as a bearer token,
+// and if body is not nil it is sent JSON-encoded.
+func (s *Server) Do(method, path, token string, body interface{}) (*http.Response, error) {
+	var r io.Reader
+	if body != nil {
+		var buf bytes.Buffer
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			return nil, err
+		}
+		r = &buf
+	}
+	req, err := http.NewRequest(method, s.URL+path, r)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return http.DefaultClient.Do(req)
+}
@@ -4,76 +4,265 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"mime"
 	"net/http"
 	"net/url"
+	"strconv"
+	"time"
 
+	"github.com/go-kit/kit/endpoint"
 	"github.com/go-kit/kit/log"
+	kitot "github.com/go-kit/kit/tracing/opentracing"
 	httptransport "github.com/go-kit/kit/transport/http"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"github.com/opentracing/opentracing-go"
+	"github.com/ory/fosite"
 	"github.com/ory/hydra/oauth2"
+	pkgerrors "github.com/pkg/errors"
 	"github.com/studiously/introspector"
-	"github.com/studiously/svcerror"
-	"github.com/studiously/unitsvc/codes"
-	"errors"
+	"github.com/studiously/unitsvc/models"
 )
 
 var (
-	ErrBadRequest = errors.New( "request is malformed or invalid")
+	ErrBadRequest   = errors.New("request is malformed or invalid")
+	ErrUnauthorized = errors.New("access token is missing, inactive or lacks the required scope")
 )
 
-func MakeHTTPHandler(s Service, logger log.Logger, ti oauth2.Introspector) http.Handler {
+// maxImportSize limits the size of import files, which are read whole before being applied.
+const maxImportSize = 1 << 20
+
+// MakeHTTPHandler mounts the service's endpoints on a router. Each request is traced with tracer,
+// continuing any trace propagated in its headers. If feed is not nil, changes to a class are
+// streamed from it as Server-Sent Events on /units/stream and over a WebSocket on /units/stream/ws.
+func MakeHTTPHandler(s Service, feed *Feed, logger log.Logger, ti oauth2.Introspector, tracer opentracing.Tracer) http.Handler {
 	r := mux.NewRouter()
+	if feed != nil {
+		// Mounted first so that "stream" is not taken for a unit ID.
+		r.Methods("GET").Path("/units/stream").Handler(makeStreamHandler(s, feed, ti, tracer, logger, "StreamUnits", serveSSE))
+		r.Methods("GET").Path("/units/stream/ws").Handler(makeStreamHandler(s, feed, ti, tracer, logger, "StreamUnitsWebSocket", serveWebSocket))
+	}
 	e := MakeServerEndpoints(s)
 	options := []httptransport.ServerOption{
 		httptransport.ServerErrorLogger(logger),
 		httptransport.ServerErrorEncoder(encodeError),
 		// All endpoints are secured
 		httptransport.ServerBefore(introspector.ToHTTPContext()),
+		httptransport.ServerBefore(RequestIDFromHTTPRequest()),
+		httptransport.ServerAfter(RequestIDToHTTPResponse()),
 	}
 
+	// Mounted before ListUnits so that requests with mine=true need no classID.
+	r.Methods("GET").Path("/units/").Queries("mine", "true").Handler(httptransport.NewServer(
+		kitot.TraceServer(tracer, "ListMyUnits")(authenticate(ti, "units.list")(e.ListMyUnitsEndpoint)),
+		DecodeListMyUnitsRequest,
+		encodeResponse,
+		append(options, httptransport.ServerBefore(kitot.FromHTTPRequest(tracer, "ListMyUnits", logger)))...
+	))
 	r.Methods("GET").Path("/units/").Handler(httptransport.NewServer(
-		introspector.New(ti, "units.list")(e.ListUnitsEndpoint),
+		kitot.TraceServer(tracer, "ListUnits")(authenticate(ti, "units.list")(e.ListUnitsEndpoint)),
 		DecodeListUnitsRequest,
 		encodeResponse,
-		options...
+		append(options, httptransport.ServerBefore(kitot.FromHTTPRequest(tracer, "ListUnits", logger)))...
+	))
+	// Mounted before /units/{unitID} so that "export", "search" and "current" are not taken for unit IDs.
+	r.Methods("GET").Path("/units/export").Handler(httptransport.NewServer(
+		kitot.TraceServer(tracer, "ExportUnits")(authenticate(ti, "units.export")(e.ExportUnitsEndpoint)),
+		DecodeExportUnitsRequest,
+		EncodeExportUnitsResponse,
+		append(options, httptransport.ServerBefore(kitot.FromHTTPRequest(tracer, "ExportUnits", logger)))...
+	))
+	r.Methods("POST").Path("/units/import").Handler(httptransport.NewServer(
+		kitot.TraceServer(tracer, "ImportUnits")(authenticate(ti, "units.import")(e.ImportUnitsEndpoint)),
+		DecodeImportUnitsRequest,
+		encodeResponse,
+		append(options, httptransport.ServerBefore(kitot.FromHTTPRequest(tracer, "ImportUnits", logger)))...
+	))
+	r.Methods("GET").Path("/units/search").Handler(httptransport.NewServer(
+		kitot.TraceServer(tracer, "SearchUnits")(authenticate(ti, "units.search")(e.SearchUnitsEndpoint)),
+		DecodeSearchUnitsRequest,
+		encodeResponse,
+		append(options, httptransport.ServerBefore(kitot.FromHTTPRequest(tracer, "SearchUnits", logger)))...
+	))
+	r.Methods("GET").Path("/units/current").Handler(httptransport.NewServer(
+		kitot.TraceServer(tracer, "GetCurrentUnit")(authenticate(ti, "units.get")(e.GetCurrentUnitEndpoint)),
+		DecodeGetCurrentUnitRequest,
+		encodeResponse,
+		append(options, httptransport.ServerBefore(kitot.FromHTTPRequest(tracer, "GetCurrentUnit", logger)))...
+	))
+	r.Methods("PUT").Path("/units/current").Handler(httptransport.NewServer(
+		kitot.TraceServer(tracer, "SetCurrentUnit")(authenticate(ti, "units.current")(e.SetCurrentUnitEndpoint)),
+		DecodeSetCurrentUnitRequest,
+		encodeResponse,
+		append(options, httptransport.ServerBefore(kitot.FromHTTPRequest(tracer, "SetCurrentUnit", logger)))...
+	))
+	r.Methods("POST").Path("/units/current/advance").Handler(httptransport.NewServer(
+		kitot.TraceServer(tracer, "AdvanceUnit")(authenticate(ti, "units.current")(e.AdvanceUnitEndpoint)),
+		DecodeAdvanceUnitRequest,
+		encodeResponse,
+		append(options, httptransport.ServerBefore(kitot.FromHTTPRequest(tracer, "AdvanceUnit", logger)))...
 	))
 	r.Methods("GET").Path("/units/{unitID}").Handler(httptransport.NewServer(
-		introspector.New(ti, "units.get")(e.GetUnitEndpoint),
+		kitot.TraceServer(tracer, "GetUnit")(authenticate(ti, "units.get")(e.GetUnitEndpoint)),
 		DecodeGetUnitRequest,
 		encodeResponse,
-		options...
+		append(options, httptransport.ServerBefore(kitot.FromHTTPRequest(tracer, "GetUnit", logger)))...
 	))
 	r.Methods("POST").Path("/units/").Handler(httptransport.NewServer(
-		introspector.New(ti, "units.create")(e.GetUnitEndpoint),
+		kitot.TraceServer(tracer, "CreateUnit")(authenticate(ti, "units.create")(e.CreateUnitEndpoint)),
 		DecodeCreateUnitRequest,
 		encodeResponse,
-		options...
+		append(options, httptransport.ServerBefore(kitot.FromHTTPRequest(tracer, "CreateUnit", logger)))...
 	))
-	r.Methods("PATCH").Path("/units/{id}").Handler(httptransport.NewServer(
-		introspector.New(ti, "units.rename")(e.RenameUnitEndpoint),
+	r.Methods("PATCH").Path("/units/{unitID}").Handler(httptransport.NewServer(
+		kitot.TraceServer(tracer, "RenameUnit")(authenticate(ti, "units.rename")(e.RenameUnitEndpoint)),
 		DecodeRenameUnitRequest,
 		encodeResponse,
-		options...
+		append(options, httptransport.ServerBefore(kitot.FromHTTPRequest(tracer, "RenameUnit", logger)))...
 	))
 	r.Methods("DELETE").Path("/units/{unitID}").Handler(httptransport.NewServer(
-		introspector.New(ti, "units.delete")(e.DeleteUnitEndpoint),
+		kitot.TraceServer(tracer, "DeleteUnit")(authenticate(ti, "units.delete")(e.DeleteUnitEndpoint)),
 		DecodeDeleteUnitRequest,
 		encodeResponse,
-		options...
+		append(options, httptransport.ServerBefore(kitot.FromHTTPRequest(tracer, "DeleteUnit", logger)))...
+	))
+
+	r.Methods("GET").Path("/units/{unitID}/history").Handler(httptransport.NewServer(
+		kitot.TraceServer(tracer, "UnitHistory")(authenticate(ti, "units.audit")(e.UnitHistoryEndpoint)),
+		DecodeUnitHistoryRequest,
+		encodeResponse,
+		append(options, httptransport.ServerBefore(kitot.FromHTTPRequest(tracer, "UnitHistory", logger)))...
+	))
+	r.Methods("PUT").Path("/units/{unitID}/progress").Handler(httptransport.NewServer(
+		kitot.TraceServer(tracer, "MarkUnitProgress")(authenticate(ti, "units.progress")(e.MarkProgressEndpoint)),
+		DecodeMarkProgressRequest,
+		encodeResponse,
+		append(options, httptransport.ServerBefore(kitot.FromHTTPRequest(tracer, "MarkUnitProgress", logger)))...
+	))
+	r.Methods("GET").Path("/progress/").Handler(httptransport.NewServer(
+		kitot.TraceServer(tracer, "GetClassProgress")(authenticate(ti, "units.progress")(e.ClassProgressEndpoint)),
+		DecodeClassProgressRequest,
+		encodeResponse,
+		append(options, httptransport.ServerBefore(kitot.FromHTTPRequest(tracer, "GetClassProgress", logger)))...
+	))
+	r.Methods("GET").Path("/audit/").Handler(httptransport.NewServer(
+		kitot.TraceServer(tracer, "ClassAudit")(authenticate(ti, "units.audit")(e.ClassAuditEndpoint)),
+		DecodeClassAuditRequest,
+		encodeResponse,
+		append(options, httptransport.ServerBefore(kitot.FromHTTPRequest(tracer, "ClassAudit", logger)))...
+	))
+	r.Methods("GET").Path("/units/{unitID}/versions").Handler(httptransport.NewServer(
+		kitot.TraceServer(tracer, "ListUnitVersions")(authenticate(ti, "units.versions")(e.ListVersionsEndpoint)),
+		DecodeListVersionsRequest,
+		encodeResponse,
+		append(options, httptransport.ServerBefore(kitot.FromHTTPRequest(tracer, "ListUnitVersions", logger)))...
+	))
+	r.Methods("GET").Path("/units/{unitID}/versions/{version}").Handler(httptransport.NewServer(
+		kitot.TraceServer(tracer, "GetUnitVersion")(authenticate(ti, "units.versions")(e.GetVersionEndpoint)),
+		DecodeGetVersionRequest,
+		encodeResponse,
+		append(options, httptransport.ServerBefore(kitot.FromHTTPRequest(tracer, "GetUnitVersion", logger)))...
+	))
+	r.Methods("POST").Path("/units/{unitID}/revert").Handler(httptransport.NewServer(
+		kitot.TraceServer(tracer, "RevertUnit")(authenticate(ti, "units.revert")(e.RevertUnitEndpoint)),
+		DecodeRevertUnitRequest,
+		encodeResponse,
+		append(options, httptransport.ServerBefore(kitot.FromHTTPRequest(tracer, "RevertUnit", logger)))...
+	))
+
+	r.Methods("POST").Path("/webhooks").Handler(httptransport.NewServer(
+		kitot.TraceServer(tracer, "CreateWebhook")(authenticate(ti, "units.webhooks")(e.CreateWebhookEndpoint)),
+		DecodeCreateWebhookRequest,
+		encodeResponse,
+		append(options, httptransport.ServerBefore(kitot.FromHTTPRequest(tracer, "CreateWebhook", logger)))...
+	))
+	r.Methods("GET").Path("/webhooks").Handler(httptransport.NewServer(
+		kitot.TraceServer(tracer, "ListWebhooks")(authenticate(ti, "units.webhooks")(e.ListWebhooksEndpoint)),
+		DecodeListWebhooksRequest,
+		encodeResponse,
+		append(options, httptransport.ServerBefore(kitot.FromHTTPRequest(tracer, "ListWebhooks", logger)))...
+	))
+	r.Methods("DELETE").Path("/webhooks/{webhookID}").Handler(httptransport.NewServer(
+		kitot.TraceServer(tracer, "DeleteWebhook")(authenticate(ti, "units.webhooks")(e.DeleteWebhookEndpoint)),
+		DecodeDeleteWebhookRequest,
+		encodeResponse,
+		append(options, httptransport.ServerBefore(kitot.FromHTTPRequest(tracer, "DeleteWebhook", logger)))...
+	))
+	r.Methods("GET").Path("/webhooks/{webhookID}/deliveries").Handler(httptransport.NewServer(
+		kitot.TraceServer(tracer, "ListDeliveries")(authenticate(ti, "units.webhooks")(e.ListDeliveriesEndpoint)),
+		DecodeListDeliveriesRequest,
+		encodeResponse,
+		append(options, httptransport.ServerBefore(kitot.FromHTTPRequest(tracer, "ListDeliveries", logger)))...
+	))
+
+	r.Methods("PUT").Path("/oneroster/classes/{sourcedID}").Handler(httptransport.NewServer(
+		kitot.TraceServer(tracer, "LinkOneRosterClass")(authenticate(ti, "units.oneroster")(e.LinkOneRosterEndpoint)),
+		DecodeLinkOneRosterRequest,
+		encodeResponse,
+		append(options, httptransport.ServerBefore(kitot.FromHTTPRequest(tracer, "LinkOneRosterClass", logger)))...
+	))
+	r.Methods("POST").Path("/oneroster/sync").Handler(httptransport.NewServer(
+		kitot.TraceServer(tracer, "SyncOneRoster")(authenticate(ti, "units.oneroster")(e.SyncOneRosterEndpoint)),
+		DecodeSyncOneRosterRequest,
+		encodeResponse,
+		append(options, httptransport.ServerBefore(kitot.FromHTTPRequest(tracer, "SyncOneRoster", logger)))...
 	))
 
 	return r
 }
 
+// authenticate wraps introspector.New, which panics when the request carries no token.
+// Inactive tokens, including those lacking a scope, are reported as ErrUnauthorized.
+func authenticate(ti oauth2.Introspector, scopes ...string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		introspected := introspector.New(ti, scopes...)(next)
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			if _, ok := ctx.Value(introspector.TokenContextKey).(string); !ok {
+				return nil, ErrUnauthorized
+			}
+			response, err := introspected(ctx, request)
+			if pkgerrors.Cause(err) == fosite.ErrInactiveToken {
+				return nil, ErrUnauthorized
+			}
+			return response, err
+		}
+	}
+}
+
 func EncodeListUnitsRequest(ctx context.Context, req *http.Request, request interface{}) error {
 	r := request.(listUnitsRequest)
-	classID := url.QueryEscape(r.ClassID.String())
-	req.Method, req.URL.Path = "GET", "/units?classID="+classID
+	req.Method, req.URL.Path = "GET", "/units/"
+	req.URL.RawQuery = url.Values{"classID": {r.ClassID.String()}}.Encode()
 	return encodeRequest(ctx, req, request)
 }
 
+func EncodeListMyUnitsRequest(_ context.Context, req *http.Request, request interface{}) error {
+	r := request.(listMyUnitsRequest)
+	req.Method, req.URL.Path = "GET", "/units/"
+	query := url.Values{"mine": {"true"}}
+	if r.After != uuid.Nil {
+		query.Set("after", r.After.String())
+	}
+	if r.Limit != 0 {
+		query.Set("limit", strconv.Itoa(r.Limit))
+	}
+	for _, status := range r.Filter.Statuses {
+		query.Add("status", string(status))
+	}
+	if r.Filter.From != nil {
+		query.Set("from", r.Filter.From.Format(time.RFC3339Nano))
+	}
+	if r.Filter.To != nil {
+		query.Set("to", r.Filter.To.Format(time.RFC3339Nano))
+	}
+	req.URL.RawQuery = query.Encode()
+	return nil
+}
+
 func EncodeGetUnitRequest(ctx context.Context, req *http.Request, request interface{}) error {
 	r := request.(getUnitRequest)
 	unitID := url.QueryEscape(r.UnitID.String())
@@ -100,6 +289,165 @@ func EncodeDeleteUnitRequest(ctx context.Context, req *http.Request, request int
 	return encodeRequest(ctx, req, request)
 }
 
+func EncodeSearchUnitsRequest(_ context.Context, req *http.Request, request interface{}) error {
+	r := request.(searchUnitsRequest)
+	req.Method, req.URL.Path = "GET", "/units/search"
+	query := url.Values{"q": {r.Query}}
+	for _, classID := range r.Filter.ClassIDs {
+		query.Add("classID", classID.String())
+	}
+	if r.Filter.Limit != 0 {
+		query.Set("limit", strconv.Itoa(r.Filter.Limit))
+	}
+	req.URL.RawQuery = query.Encode()
+	return nil
+}
+
+func EncodeGetCurrentUnitRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	r := request.(getCurrentUnitRequest)
+	req.Method, req.URL.Path = "GET", "/units/current"
+	req.URL.RawQuery = url.Values{"classID": {r.ClassID.String()}}.Encode()
+	return encodeRequest(ctx, req, request)
+}
+
+func EncodeSetCurrentUnitRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	r := request.(setCurrentUnitRequest)
+	req.Method, req.URL.Path = "PUT", "/units/current"
+	req.URL.RawQuery = url.Values{"classID": {r.ClassID.String()}}.Encode()
+	return encodeRequest(ctx, req, request)
+}
+
+func EncodeAdvanceUnitRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	r := request.(advanceUnitRequest)
+	req.Method, req.URL.Path = "POST", "/units/current/advance"
+	req.URL.RawQuery = url.Values{"classID": {r.ClassID.String()}}.Encode()
+	return encodeRequest(ctx, req, request)
+}
+
+func EncodeMarkProgressRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	r := request.(markProgressRequest)
+	unitID := url.QueryEscape(r.UnitID.String())
+	req.Method, req.URL.Path = "PUT", "/units/"+unitID+"/progress"
+	return encodeRequest(ctx, req, request)
+}
+
+func EncodeClassProgressRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	r := request.(classProgressRequest)
+	req.Method, req.URL.Path = "GET", "/progress/"
+	req.URL.RawQuery = url.Values{"classID": {r.ClassID.String()}}.Encode()
+	return encodeRequest(ctx, req, request)
+}
+
+func EncodeUnitHistoryRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	r := request.(unitHistoryRequest)
+	unitID := url.QueryEscape(r.UnitID.String())
+	req.Method, req.URL.Path = "GET", "/units/"+unitID+"/history"
+	return encodeRequest(ctx, req, request)
+}
+
+func EncodeClassAuditRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	r := request.(classAuditRequest)
+	req.Method, req.URL.Path = "GET", "/audit/"
+	req.URL.RawQuery = url.Values{"classID": {r.ClassID.String()}}.Encode()
+	return encodeRequest(ctx, req, request)
+}
+
+func EncodeListVersionsRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	r := request.(listVersionsRequest)
+	unitID := url.QueryEscape(r.UnitID.String())
+	req.Method, req.URL.Path = "GET", "/units/"+unitID+"/versions"
+	return encodeRequest(ctx, req, request)
+}
+
+func EncodeGetVersionRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	r := request.(getVersionRequest)
+	unitID := url.QueryEscape(r.UnitID.String())
+	req.Method, req.URL.Path = "GET", "/units/"+unitID+"/versions/"+strconv.Itoa(r.Version)
+	return encodeRequest(ctx, req, request)
+}
+
+func EncodeRevertUnitRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	r := request.(revertUnitRequest)
+	unitID := url.QueryEscape(r.UnitID.String())
+	req.Method, req.URL.Path = "POST", "/units/"+unitID+"/revert"
+	return encodeRequest(ctx, req, request)
+}
+
+func EncodeCreateWebhookRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	req.Method, req.URL.Path = "POST", "/webhooks"
+	return encodeRequest(ctx, req, request)
+}
+
+func EncodeListWebhooksRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	r := request.(listWebhooksRequest)
+	req.Method, req.URL.Path = "GET", "/webhooks"
+	req.URL.RawQuery = url.Values{"classID": {r.ClassID.String()}}.Encode()
+	return encodeRequest(ctx, req, request)
+}
+
+func EncodeDeleteWebhookRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	r := request.(deleteWebhookRequest)
+	webhookID := url.QueryEscape(r.WebhookID.String())
+	req.Method, req.URL.Path = "DELETE", "/webhooks/"+webhookID
+	return encodeRequest(ctx, req, request)
+}
+
+func EncodeListDeliveriesRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	r := request.(listDeliveriesRequest)
+	webhookID := url.QueryEscape(r.WebhookID.String())
+	req.Method, req.URL.Path = "GET", "/webhooks/"+webhookID+"/deliveries"
+	if r.Status != "" {
+		req.URL.RawQuery = url.Values{"status": {r.Status}}.Encode()
+	}
+	return encodeRequest(ctx, req, request)
+}
+
+func EncodeExportUnitsRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	r := request.(exportUnitsRequest)
+	req.Method, req.URL.Path = "GET", "/units/export"
+	req.URL.RawQuery = url.Values{"classID": {r.ClassID.String()}, "format": {r.Format}}.Encode()
+	return nil
+}
+
+func EncodeImportUnitsRequest(_ context.Context, req *http.Request, request interface{}) error {
+	r := request.(importUnitsRequest)
+	req.Method, req.URL.Path = "POST", "/units/import"
+	req.URL.RawQuery = url.Values{"classID": {r.ClassID.String()}, "dryRun": {strconv.FormatBool(r.DryRun)}}.Encode()
+	doc := exportDocument{ClassID: r.ClassID, Units: []unitRecord{}}
+	for _, row := range r.Rows {
+		rec := unitRecord{Title: row.Title, DisplayOrder: row.DisplayOrder, Description: row.Description, Metadata: row.Metadata,
+			ParentRow: row.Parent, Resources: row.Resources, Status: row.Status, Schedule: row.Schedule}
+		if row.ID != uuid.Nil {
+			rec.ID = row.ID.String()
+		}
+		doc.Units = append(doc.Units, rec)
+	}
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(doc); err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Body = ioutil.NopCloser(&buf)
+	return nil
+}
+
+func EncodeLinkOneRosterRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	r := request.(linkOneRosterRequest)
+	sourcedID := url.PathEscape(r.SourcedID)
+	req.Method, req.URL.Path, req.URL.RawPath = "PUT", "/oneroster/classes/"+r.SourcedID, "/oneroster/classes/"+sourcedID
+	return encodeRequest(ctx, req, request)
+}
+
+// EncodeSyncOneRosterRequest sends the bundle as JSON, which DecodeSyncOneRosterRequest accepts
+// as well as the zip file of a OneRoster CSV bundle.
+func EncodeSyncOneRosterRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	r := request.(syncOneRosterRequest)
+	req.Method, req.URL.Path = "POST", "/oneroster/sync"
+	req.URL.RawQuery = url.Values{"dryRun": {strconv.FormatBool(r.DryRun)}, "overwrite": {strconv.FormatBool(r.Overwrite)}}.Encode()
+	req.Header.Set("Content-Type", "application/json")
+	return encodeRequest(ctx, req, r.Bundle)
+}
+
 func DecodeListUnitsRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	classID, err := uuid.Parse(r.URL.Query().Get("classID"))
 	if err != nil {
@@ -108,6 +456,52 @@ func DecodeListUnitsRequest(_ context.Context, r *http.Request) (interface{}, er
 	return listUnitsRequest{ClassID: classID}, nil
 }
 
+// DecodeListMyUnitsRequest accepts optional after and limit query parameters to page through the
+// user's classes. Repeated status parameters list only units with those statuses, and from and
+// to, as RFC 3339 times, list only units scheduled in that window.
+func DecodeListMyUnitsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	query := r.URL.Query()
+	var req listMyUnitsRequest
+	if v := query.Get("after"); v != "" {
+		after, err := uuid.Parse(v)
+		if err != nil {
+			return nil, ErrBadRequest
+		}
+		req.After = after
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, ErrBadRequest
+		}
+		req.Limit = limit
+	}
+	for _, v := range query["status"] {
+		req.Filter.Statuses = append(req.Filter.Statuses, models.UnitStatus(v))
+	}
+	var err error
+	if req.Filter.From, err = queryTime(query, "from"); err != nil {
+		return nil, err
+	}
+	if req.Filter.To, err = queryTime(query, "to"); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// queryTime returns the RFC 3339 time in the query parameter name, or nil if it is missing.
+func queryTime(query url.Values, name string) (*time.Time, error) {
+	v := query.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, ErrBadRequest
+	}
+	return &t, nil
+}
+
 func DecodeGetUnitRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	vars := mux.Vars(r)
 	unitID, err := uuid.Parse(vars["unitID"])
@@ -117,10 +511,62 @@ func DecodeGetUnitRequest(_ context.Context, r *http.Request) (interface{}, erro
 	return getUnitRequest{UnitID: unitID}, nil
 }
 
+func DecodeGetCurrentUnitRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	classID, err := uuid.Parse(r.URL.Query().Get("classID"))
+	if err != nil {
+		return nil, ErrBadRequest
+	}
+	return getCurrentUnitRequest{ClassID: classID}, nil
+}
+
+// DecodeSetCurrentUnitRequest accepts a unit_id of the nil UUID to move the class to no unit.
+func DecodeSetCurrentUnitRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	classID, err := uuid.Parse(r.URL.Query().Get("classID"))
+	if err != nil {
+		return nil, ErrBadRequest
+	}
+	var req setCurrentUnitRequest
+	if e := json.NewDecoder(r.Body).Decode(&req); e != nil {
+		return nil, ErrBadRequest
+	}
+	req.ClassID = classID
+	return req, nil
+}
+
+func DecodeAdvanceUnitRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	classID, err := uuid.Parse(r.URL.Query().Get("classID"))
+	if err != nil {
+		return nil, ErrBadRequest
+	}
+	return advanceUnitRequest{ClassID: classID}, nil
+}
+
+func DecodeMarkProgressRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	unitID, err := uuid.Parse(vars["unitID"])
+	if err != nil {
+		return nil, ErrBadRequest
+	}
+	var req markProgressRequest
+	if e := json.NewDecoder(r.Body).Decode(&req); e != nil {
+		return nil, ErrBadRequest
+	}
+	req.UnitID = unitID
+	return req, nil
+}
+
+func DecodeClassProgressRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	classID, err := uuid.Parse(r.URL.Query().Get("classID"))
+	if err != nil {
+		return nil, ErrBadRequest
+	}
+	return classProgressRequest{ClassID: classID}, nil
+}
+
 func DecodeCreateUnitRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	var req createUnitRequest
 	if e := json.NewDecoder(r.Body).Decode(&req); e != nil {
-		return nil, e
+		return nil, ErrBadRequest
 	}
 	return req, nil
 }
@@ -132,10 +578,10 @@ func DecodeRenameUnitRequest(_ context.Context, r *http.Request) (interface{}, e
 		return nil, ErrBadRequest
 	}
 	var req renameUnitRequest
-	req.UnitID = unitID
 	if e := json.NewDecoder(r.Body).Decode(&req); e != nil {
-		return nil, e
+		return nil, ErrBadRequest
 	}
+	req.UnitID = unitID
 	return req, nil
 }
 
@@ -148,36 +594,486 @@ func DecodeDeleteUnitRequest(_ context.Context, r *http.Request) (interface{}, e
 	return deleteUnitRequest{unitID}, nil
 }
 
+// DecodeSearchUnitsRequest accepts a q query parameter, optional classID parameters to search only
+// some classes, and an optional limit.
+func DecodeSearchUnitsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	query := r.URL.Query()
+	req := searchUnitsRequest{Query: query.Get("q")}
+	for _, v := range query["classID"] {
+		classID, err := uuid.Parse(v)
+		if err != nil {
+			return nil, ErrBadRequest
+		}
+		req.Filter.ClassIDs = append(req.Filter.ClassIDs, classID)
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, ErrBadRequest
+		}
+		req.Filter.Limit = limit
+	}
+	return req, nil
+}
+
+func DecodeUnitHistoryRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	unitID, err := uuid.Parse(vars["unitID"])
+	if err != nil {
+		return nil, ErrBadRequest
+	}
+	return unitHistoryRequest{UnitID: unitID}, nil
+}
+
+func DecodeClassAuditRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	classID, err := uuid.Parse(r.URL.Query().Get("classID"))
+	if err != nil {
+		return nil, ErrBadRequest
+	}
+	return classAuditRequest{ClassID: classID}, nil
+}
+
+func DecodeListVersionsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	unitID, err := uuid.Parse(vars["unitID"])
+	if err != nil {
+		return nil, ErrBadRequest
+	}
+	return listVersionsRequest{UnitID: unitID}, nil
+}
+
+func DecodeGetVersionRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	unitID, err := uuid.Parse(vars["unitID"])
+	if err != nil {
+		return nil, ErrBadRequest
+	}
+	version, err := strconv.Atoi(vars["version"])
+	if err != nil {
+		return nil, ErrBadRequest
+	}
+	return getVersionRequest{UnitID: unitID, Version: version}, nil
+}
+
+func DecodeRevertUnitRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	unitID, err := uuid.Parse(vars["unitID"])
+	if err != nil {
+		return nil, ErrBadRequest
+	}
+	var req revertUnitRequest
+	if e := json.NewDecoder(r.Body).Decode(&req); e != nil {
+		return nil, ErrBadRequest
+	}
+	req.UnitID = unitID
+	return req, nil
+}
+
+func DecodeCreateWebhookRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var req createWebhookRequest
+	if e := json.NewDecoder(r.Body).Decode(&req); e != nil {
+		return nil, ErrBadRequest
+	}
+	return req, nil
+}
+
+func DecodeListWebhooksRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	classID, err := uuid.Parse(r.URL.Query().Get("classID"))
+	if err != nil {
+		return nil, ErrBadRequest
+	}
+	return listWebhooksRequest{ClassID: classID}, nil
+}
+
+func DecodeDeleteWebhookRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	webhookID, err := uuid.Parse(vars["webhookID"])
+	if err != nil {
+		return nil, ErrBadRequest
+	}
+	return deleteWebhookRequest{WebhookID: webhookID}, nil
+}
+
+// DecodeListDeliveriesRequest accepts an optional status query parameter: pending, delivered,
+// or dead for the dead-letter list.
+func DecodeListDeliveriesRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	webhookID, err := uuid.Parse(vars["webhookID"])
+	if err != nil {
+		return nil, ErrBadRequest
+	}
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", models.DeliveryPending, models.DeliveryDelivered, models.DeliveryDead:
+	default:
+		return nil, ErrBadRequest
+	}
+	return listDeliveriesRequest{WebhookID: webhookID, Status: status}, nil
+}
+
+// DecodeExportUnitsRequest accepts a format query parameter of json, the default, csv or imscc.
+func DecodeExportUnitsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	classID, err := uuid.Parse(r.URL.Query().Get("classID"))
+	if err != nil {
+		return nil, ErrBadRequest
+	}
+	format := r.URL.Query().Get("format")
+	switch format {
+	case "":
+		format = FormatJSON
+	case FormatJSON, FormatCSV, FormatCommonCartridge:
+	default:
+		return nil, ErrBadRequest
+	}
+	return exportUnitsRequest{ClassID: classID, Format: format}, nil
+}
+
+// DecodeImportUnitsRequest reads a file in the format of DecodeExportUnitsRequest, named by the
+// format query parameter or else the Content-Type. With dryRun=true, the import is only planned.
+func DecodeImportUnitsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	q := r.URL.Query()
+	classID, err := uuid.Parse(q.Get("classID"))
+	if err != nil {
+		return nil, ErrBadRequest
+	}
+	var dryRun bool
+	if s := q.Get("dryRun"); s != "" {
+		if dryRun, err = strconv.ParseBool(s); err != nil {
+			return nil, ErrBadRequest
+		}
+	}
+	format := q.Get("format")
+	if format == "" {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		switch mediaType {
+		case "text/csv":
+			format = FormatCSV
+		case "application/zip", "application/vnd.ims.imsccv1p1":
+			format = FormatCommonCartridge
+		default:
+			format = FormatJSON
+		}
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxImportSize+1))
+	if err != nil || len(body) > maxImportSize {
+		return nil, ErrBadRequest
+	}
+	rows, err := ParseImport(bytes.NewReader(body), format)
+	if err != nil {
+		return nil, err
+	}
+	return importUnitsRequest{ClassID: classID, Rows: rows, DryRun: dryRun}, nil
+}
+
+func DecodeLinkOneRosterRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var req linkOneRosterRequest
+	if e := json.NewDecoder(r.Body).Decode(&req); e != nil {
+		return nil, ErrBadRequest
+	}
+	req.SourcedID = mux.Vars(r)["sourcedID"]
+	return req, nil
+}
+
+// DecodeSyncOneRosterRequest reads a OneRoster CSV bundle, or the JSON of a RosterBundle if the
+// Content-Type is application/json. dryRun and overwrite are optional boolean query parameters.
+func DecodeSyncOneRosterRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	q := r.URL.Query()
+	var req syncOneRosterRequest
+	for name, v := range map[string]*bool{"dryRun": &req.DryRun, "overwrite": &req.Overwrite} {
+		if s := q.Get(name); s != "" {
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return nil, ErrBadRequest
+			}
+			*v = b
+		}
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxImportSize+1))
+	if err != nil || len(body) > maxImportSize {
+		return nil, ErrBadRequest
+	}
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/json" {
+		if err := json.Unmarshal(body, &req.Bundle); err != nil || req.Bundle == nil {
+			return nil, ErrBadRequest
+		}
+		return req, nil
+	}
+	if req.Bundle, err = ParseOneRoster(bytes.NewReader(body)); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
 func DecodeListUnitsResponse(_ context.Context, resp *http.Response) (interface{}, error) {
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp)
+	}
 	var response listUnitsResponse
 	err := json.NewDecoder(resp.Body).Decode(&response)
 	return response, err
 }
 
+func DecodeListMyUnitsResponse(_ context.Context, resp *http.Response) (interface{}, error) {
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp)
+	}
+	var response listMyUnitsResponse
+	err := json.NewDecoder(resp.Body).Decode(&response)
+	return response, err
+}
+
 func DecodeGetUnitResponse(_ context.Context, resp *http.Response) (interface{}, error) {
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp)
+	}
 	var response getUnitResponse
 	err := json.NewDecoder(resp.Body).Decode(&response )
 	return response, err
 }
 
 func DecodeCreateUnitResponse(_ context.Context, resp *http.Response) (interface{}, error) {
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp)
+	}
 	var response createUnitResponse
 	err := json.NewDecoder(resp.Body).Decode(&response)
 	return response, err
 }
 
 func DecodeRenameUnitResponse(_ context.Context, resp *http.Response) (interface{}, error) {
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp)
+	}
 	var response renameUnitResponse
 	err := json.NewDecoder(resp.Body).Decode(&response)
 	return response, err
 }
 
 func DecodeDeleteUnitResponse(_ context.Context, resp *http.Response) (interface{}, error) {
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp)
+	}
 	var response deleteUnitResponse
 	err := json.NewDecoder(resp.Body).Decode(&response)
 	return response, err
 }
 
+func DecodeSearchUnitsResponse(_ context.Context, resp *http.Response) (interface{}, error) {
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp)
+	}
+	var response searchUnitsResponse
+	err := json.NewDecoder(resp.Body).Decode(&response)
+	return response, err
+}
+
+// DecodeCurrentUnitResponse decodes the responses of both GetCurrentUnit and AdvanceUnit.
+func DecodeCurrentUnitResponse(_ context.Context, resp *http.Response) (interface{}, error) {
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp)
+	}
+	var response currentUnitResponse
+	err := json.NewDecoder(resp.Body).Decode(&response)
+	return response, err
+}
+
+func DecodeSetCurrentUnitResponse(_ context.Context, resp *http.Response) (interface{}, error) {
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp)
+	}
+	var response setCurrentUnitResponse
+	err := json.NewDecoder(resp.Body).Decode(&response)
+	return response, err
+}
+
+func DecodeMarkProgressResponse(_ context.Context, resp *http.Response) (interface{}, error) {
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp)
+	}
+	var response markProgressResponse
+	err := json.NewDecoder(resp.Body).Decode(&response)
+	return response, err
+}
+
+func DecodeClassProgressResponse(_ context.Context, resp *http.Response) (interface{}, error) {
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp)
+	}
+	var response classProgressResponse
+	err := json.NewDecoder(resp.Body).Decode(&response)
+	return response, err
+}
+
+func DecodeUnitHistoryResponse(_ context.Context, resp *http.Response) (interface{}, error) {
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp)
+	}
+	var response auditResponse
+	err := json.NewDecoder(resp.Body).Decode(&response)
+	return response, err
+}
+
+func DecodeClassAuditResponse(_ context.Context, resp *http.Response) (interface{}, error) {
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp)
+	}
+	var response auditResponse
+	err := json.NewDecoder(resp.Body).Decode(&response)
+	return response, err
+}
+
+func DecodeListVersionsResponse(_ context.Context, resp *http.Response) (interface{}, error) {
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp)
+	}
+	var response listVersionsResponse
+	err := json.NewDecoder(resp.Body).Decode(&response)
+	return response, err
+}
+
+func DecodeGetVersionResponse(_ context.Context, resp *http.Response) (interface{}, error) {
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp)
+	}
+	var response getVersionResponse
+	err := json.NewDecoder(resp.Body).Decode(&response)
+	return response, err
+}
+
+func DecodeRevertUnitResponse(_ context.Context, resp *http.Response) (interface{}, error) {
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp)
+	}
+	var response revertUnitResponse
+	err := json.NewDecoder(resp.Body).Decode(&response)
+	return response, err
+}
+
+func DecodeCreateWebhookResponse(_ context.Context, resp *http.Response) (interface{}, error) {
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp)
+	}
+	var response createWebhookResponse
+	err := json.NewDecoder(resp.Body).Decode(&response)
+	return response, err
+}
+
+func DecodeListWebhooksResponse(_ context.Context, resp *http.Response) (interface{}, error) {
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp)
+	}
+	var response listWebhooksResponse
+	err := json.NewDecoder(resp.Body).Decode(&response)
+	return response, err
+}
+
+func DecodeDeleteWebhookResponse(_ context.Context, resp *http.Response) (interface{}, error) {
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp)
+	}
+	var response deleteWebhookResponse
+	err := json.NewDecoder(resp.Body).Decode(&response)
+	return response, err
+}
+
+func DecodeListDeliveriesResponse(_ context.Context, resp *http.Response) (interface{}, error) {
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp)
+	}
+	var response listDeliveriesResponse
+	err := json.NewDecoder(resp.Body).Decode(&response)
+	return response, err
+}
+
+// DecodeExportUnitsResponse reads an export requested in FormatJSON.
+func DecodeExportUnitsResponse(_ context.Context, resp *http.Response) (interface{}, error) {
+	if resp.StatusCode != http.StatusOK {
+		return exportUnitsResponse{Error: decodeError(resp)}, nil
+	}
+	var doc exportDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, err
+	}
+	response := exportUnitsResponse{ClassID: doc.ClassID, Format: FormatJSON, Units: []*models.Unit{}}
+	for _, rec := range doc.Units {
+		id, err := uuid.Parse(rec.ID)
+		if err != nil || rec.DisplayOrder == nil || rec.CreatedAt == nil {
+			return nil, fmt.Errorf("malformed unit in export: %+v", rec)
+		}
+		unit := &models.Unit{
+			ID:           id,
+			ClassID:      doc.ClassID,
+			Title:        rec.Title,
+			DisplayOrder: *rec.DisplayOrder,
+			CreatedAt:    *rec.CreatedAt,
+			Metadata:     rec.Metadata,
+			Resources:    rec.Resources,
+			Status:       rec.Status.OrPublished(),
+		}
+		if rec.Schedule != nil {
+			unit.StartsAt, unit.EndsAt = rec.Schedule.StartsAt, rec.Schedule.EndsAt
+		}
+		if rec.Description != nil {
+			unit.Description = *rec.Description
+		}
+		if rec.ParentID != nil && *rec.ParentID != "" {
+			parentID, err := uuid.Parse(*rec.ParentID)
+			if err != nil {
+				return nil, fmt.Errorf("malformed unit in export: %+v", rec)
+			}
+			unit.ParentID = &parentID
+		}
+		response.Units = append(response.Units, unit)
+	}
+	return response, nil
+}
+
+func DecodeImportUnitsResponse(_ context.Context, resp *http.Response) (interface{}, error) {
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp)
+	}
+	var response importUnitsResponse
+	err := json.NewDecoder(resp.Body).Decode(&response)
+	return response, err
+}
+
+func DecodeLinkOneRosterResponse(_ context.Context, resp *http.Response) (interface{}, error) {
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp)
+	}
+	var response linkOneRosterResponse
+	err := json.NewDecoder(resp.Body).Decode(&response)
+	return response, err
+}
+
+func DecodeSyncOneRosterResponse(_ context.Context, resp *http.Response) (interface{}, error) {
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp)
+	}
+	var response syncOneRosterResponse
+	err := json.NewDecoder(resp.Body).Decode(&response)
+	return response, err
+}
+
+// EncodeExportUnitsResponse writes the export as a file to download, in the requested format.
+func EncodeExportUnitsResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	r := response.(exportUnitsResponse)
+	if r.Error != nil {
+		encodeError(ctx, r.Error, w)
+		return nil
+	}
+	contentType := "application/json; charset=utf-8"
+	switch r.Format {
+	case FormatCSV:
+		contentType = "text/csv; charset=utf-8"
+	case FormatCommonCartridge:
+		contentType = "application/vnd.ims.imsccv1p1"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="units-%s.%s"`, r.ClassID, r.Format))
+	return WriteExport(w, r.Format, r.ClassID, r.Units)
+}
+
 // errorer is implemented by all concrete response types that may contain
 // errors. It allows us to change the HTTP response code without needing to
 // trigger an endpoint (transport-level) error. For more information, read the
@@ -215,24 +1111,66 @@ func encodeRequest(_ context.Context, req *http.Request, request interface{}) er
 	return nil
 }
 
-func encodeError(_ context.Context, err error, w http.ResponseWriter) {
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
 	if err == nil {
 		panic("encodeError with nil error")
 	}
+	body := map[string]interface{}{
+		"error": err.Error(),
+	}
+	if e, ok := err.(*ImportError); ok {
+		body["rows"] = e.Rows
+	}
+	if id := RequestID(ctx); id != "" {
+		w.Header().Set(RequestIDHeader, id)
+		body["request_id"] = id
+	}
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(codeFrom(err))
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"error": err.Error(),
-	})
+	json.NewEncoder(w).Encode(body)
 }
 
 func codeFrom(err error) int {
+	if _, ok := err.(*ImportError); ok {
+		return http.StatusUnprocessableEntity
+	}
 	switch err {
 	case ErrNotFound:
 		return http.StatusNotFound
 	case ErrBadRequest:
-			return http.StatusBadRequest
+		return http.StatusBadRequest
+	case ErrUnauthorized:
+		return http.StatusUnauthorized
+	case ErrForbidden:
+		return http.StatusForbidden
 	default:
 		return http.StatusInternalServerError
 	}
 }
+
+// decodeError reads an error written by encodeError and returns the service error behind
+// its status code, so that clients can compare it with ErrNotFound and the like.
+func decodeError(resp *http.Response) error {
+	var body struct {
+		Error string     `json:"error"`
+		Rows  []RowError `json:"rows"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Error == "" {
+		return fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusBadRequest:
+		return ErrBadRequest
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	case http.StatusForbidden:
+		return ErrForbidden
+	case http.StatusUnprocessableEntity:
+		if len(body.Rows) > 0 {
+			return &ImportError{body.Rows}
+		}
+	}
+	return errors.New(body.Error)
+}
@@ -0,0 +1,465 @@
+package unitsvc_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/google/uuid"
+	"github.com/opentracing/opentracing-go"
+	"github.com/studiously/classsvc/models"
+	"github.com/studiously/introspector"
+	unitsvcmodels "github.com/studiously/unitsvc/models"
+	"github.com/studiously/unitsvc/tracing"
+	"github.com/studiously/unitsvc/unitsvc"
+	unittesting "github.com/studiously/unitsvc/unitsvc/testing"
+)
+
+// newClass starts a server with one class and a teacher enrolled in it.
+func newClass(t *testing.T) (s *unittesting.Server, teacher, classID uuid.UUID) {
+	s = unittesting.NewServer()
+	teacher, classID = uuid.New(), uuid.New()
+	s.Classes.Enroll(teacher, classID, models.UserRoleTeacher)
+	return s, teacher, classID
+}
+
+func do(t *testing.T, s *unittesting.Server, method, path, token string, body interface{}, wantStatus int) *http.Response {
+	resp, err := s.Do(method, path, token, body)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	if resp.StatusCode != wantStatus {
+		resp.Body.Close()
+		t.Fatalf("%s %s: got status %d, want %d", method, path, resp.StatusCode, wantStatus)
+	}
+	return resp
+}
+
+// listUnits lists the units in classID through the HTTP API.
+func listUnits(t *testing.T, s *unittesting.Server, token string, classID uuid.UUID) []uuid.UUID {
+	resp := do(t, s, "GET", "/units/?classID="+classID.String(), token, nil, http.StatusOK)
+	defer resp.Body.Close()
+	var body struct {
+		Units []uuid.UUID `json:"units"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode units: %v", err)
+	}
+	return body.Units
+}
+
+func TestHTTPScopeEnforcement(t *testing.T) {
+	s, teacher, classID := newClass(t)
+	defer s.Close()
+	unitID := func() uuid.UUID {
+		token := s.Token(teacher, "units.create", "units.list")
+		do(t, s, "POST", "/units/", token, map[string]interface{}{"class_id": classID, "title": "Scoped"}, http.StatusOK).Body.Close()
+		units := listUnits(t, s, token, classID)
+		return units[len(units)-1]
+	}
+	webhookID := func() string {
+		return createWebhook(t, s, s.Token(teacher, "units.webhooks"), classID, "https://lms.example.com/hooks").String()
+	}
+	current := "/units/current?classID=" + classID.String()
+	onUnit := func() string {
+		do(t, s, "PUT", current, s.Token(teacher, "units.current"), map[string]interface{}{"unit_id": unitID()}, http.StatusOK).Body.Close()
+		return current
+	}
+
+	tests := []struct {
+		scope  string
+		method string
+		path   func() string
+		body   interface{}
+	}{
+		{"units.list", "GET", func() string { return "/units/?classID=" + classID.String() }, nil},
+		{"units.list", "GET", func() string { return "/units/?mine=true" }, nil},
+		{"units.get", "GET", func() string { return "/units/" + unitID().String() }, nil},
+		{"units.create", "POST", func() string { return "/units/" }, map[string]interface{}{"class_id": classID, "title": "New"}},
+		{"units.rename", "PATCH", func() string { return "/units/" + unitID().String() }, map[string]string{"title": "Renamed"}},
+		{"units.delete", "DELETE", func() string { return "/units/" + unitID().String() }, nil},
+		{"units.audit", "GET", func() string { return "/units/" + unitID().String() + "/history" }, nil},
+		{"units.audit", "GET", func() string { return "/audit/?classID=" + classID.String() }, nil},
+		{"units.versions", "GET", func() string { return "/units/" + unitID().String() + "/versions" }, nil},
+		{"units.versions", "GET", func() string { return "/units/" + unitID().String() + "/versions/1" }, nil},
+		{"units.revert", "POST", func() string { return "/units/" + unitID().String() + "/revert" }, map[string]int{"version": 1}},
+		{"units.webhooks", "POST", func() string { return "/webhooks" }, map[string]interface{}{"class_id": classID, "url": "https://lms.example.com/", "secret": "s3cret"}},
+		{"units.webhooks", "GET", func() string { return "/webhooks?classID=" + classID.String() }, nil},
+		{"units.webhooks", "DELETE", func() string { return "/webhooks/" + webhookID() }, nil},
+		{"units.webhooks", "GET", func() string { return "/webhooks/" + webhookID() + "/deliveries" }, nil},
+		{"units.search", "GET", func() string { return "/units/search?q=cells" }, nil},
+		{"units.get", "GET", onUnit, nil},
+		{"units.current", "PUT", func() string { return current }, map[string]interface{}{"unit_id": uuid.Nil}},
+		// Each new unit comes after the current one, so the class can always advance to it.
+		{"units.progress", "GET", func() string { return "/progress/?classID=" + classID.String() }, nil},
+		{"units.current", "POST", func() string { unitID(); return "/units/current/advance?classID=" + classID.String() }, nil},
+		{"units.export", "GET", func() string { return "/units/export?classID=" + classID.String() }, nil},
+		{"units.import", "POST", func() string { return "/units/import?dryRun=true&classID=" + classID.String() }, map[string]interface{}{"units": []interface{}{}}},
+		{"units.oneroster", "PUT", func() string { return "/oneroster/classes/class-1" }, map[string]interface{}{"class_id": classID}},
+		{"units.oneroster", "POST", func() string { return "/oneroster/sync?dryRun=true" }, map[string]interface{}{"classes": []interface{}{}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scope, func(t *testing.T) {
+			do(t, s, tt.method, tt.path(), "", tt.body, http.StatusUnauthorized).Body.Close()
+			do(t, s, tt.method, tt.path(), "not-a-token", tt.body, http.StatusUnauthorized).Body.Close()
+			do(t, s, tt.method, tt.path(), s.Token(teacher, "classes"), tt.body, http.StatusUnauthorized).Body.Close()
+			do(t, s, tt.method, tt.path(), s.Token(teacher, tt.scope), tt.body, http.StatusOK).Body.Close()
+			// Hydra scopes are hierarchical.
+			do(t, s, tt.method, tt.path(), s.Token(teacher, "units"), tt.body, http.StatusOK).Body.Close()
+		})
+	}
+}
+
+func TestHTTPExpiredAndRevokedTokens(t *testing.T) {
+	s, teacher, classID := newClass(t)
+	defer s.Close()
+	path := "/units/?classID=" + classID.String()
+
+	expired := s.Introspector.Issue(unittesting.Token{
+		Subject:   teacher.String(),
+		ClientID:  unittesting.ClientID,
+		Scopes:    []string{"units.list"},
+		ExpiresAt: time.Now().Add(-time.Minute),
+	})
+	do(t, s, "GET", path, expired, nil, http.StatusUnauthorized).Body.Close()
+
+	revoked := s.Token(teacher, "units.list")
+	do(t, s, "GET", path, revoked, nil, http.StatusOK).Body.Close()
+	s.Introspector.Revoke(revoked)
+	do(t, s, "GET", path, revoked, nil, http.StatusUnauthorized).Body.Close()
+}
+
+func TestHTTPUnitLifecycle(t *testing.T) {
+	s, teacher, classID := newClass(t)
+	defer s.Close()
+	token := s.Token(teacher, "units")
+
+	do(t, s, "POST", "/units/", token, map[string]interface{}{"class_id": classID, "title": "Cells"}, http.StatusOK).Body.Close()
+	units := listUnits(t, s, token, classID)
+	if len(units) != 1 {
+		t.Fatalf("got %d units after create, want 1", len(units))
+	}
+	path := "/units/" + units[0].String()
+
+	do(t, s, "PATCH", path, token, map[string]string{"title": "Cell Biology"}, http.StatusOK).Body.Close()
+	resp := do(t, s, "GET", path, token, nil, http.StatusOK)
+	var body struct {
+		Unit struct {
+			ID      uuid.UUID `json:"id"`
+			ClassID uuid.UUID `json:"class_id"`
+			Title   string    `json:"title"`
+		} `json:"unit"`
+	}
+	err := json.NewDecoder(resp.Body).Decode(&body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("decode unit: %v", err)
+	}
+	if body.Unit.ID != units[0] || body.Unit.ClassID != classID || body.Unit.Title != "Cell Biology" {
+		t.Fatalf("got unit %+v after rename", body.Unit)
+	}
+
+	do(t, s, "DELETE", path, token, nil, http.StatusOK).Body.Close()
+	do(t, s, "GET", path, token, nil, http.StatusNotFound).Body.Close()
+}
+
+func TestHTTPAudit(t *testing.T) {
+	s, teacher, classID := newClass(t)
+	defer s.Close()
+	token := s.Token(teacher, "units")
+	do(t, s, "POST", "/units/", token, map[string]interface{}{"class_id": classID, "title": "Cells"}, http.StatusOK).Body.Close()
+	unitID := listUnits(t, s, token, classID)[0]
+	do(t, s, "PATCH", "/units/"+unitID.String(), token, map[string]string{"title": "Cell Biology"}, http.StatusOK).Body.Close()
+	do(t, s, "DELETE", "/units/"+unitID.String(), token, nil, http.StatusOK).Body.Close()
+
+	entries := func(path string) []string {
+		resp := do(t, s, "GET", path, token, nil, http.StatusOK)
+		defer resp.Body.Close()
+		var body struct {
+			Entries []struct {
+				UnitID uuid.UUID `json:"unit_id"`
+				Action string    `json:"action"`
+				Actor  uuid.UUID `json:"actor"`
+			} `json:"entries"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("decode entries: %v", err)
+		}
+		var actions []string
+		for _, e := range body.Entries {
+			if e.UnitID != unitID || e.Actor != teacher {
+				t.Errorf("GET %s: got entry for unit %v by %v, want unit %v by %v", path, e.UnitID, e.Actor, unitID, teacher)
+			}
+			actions = append(actions, e.Action)
+		}
+		return actions
+	}
+	want := "[create rename delete]"
+	if got := fmt.Sprint(entries("/units/" + unitID.String() + "/history")); got != want {
+		t.Errorf("got history %s, want %s", got, want)
+	}
+	if got := fmt.Sprint(entries("/audit/?classID=" + classID.String())); got != want {
+		t.Errorf("got class audit %s, want %s", got, want)
+	}
+
+	student := uuid.New()
+	s.Classes.Enroll(student, classID, models.UserRoleStudent)
+	do(t, s, "GET", "/units/"+unitID.String()+"/history", s.Token(student, "units"), nil, http.StatusForbidden).Body.Close()
+	do(t, s, "GET", "/audit/?classID="+classID.String(), s.Token(student, "units"), nil, http.StatusForbidden).Body.Close()
+	do(t, s, "GET", "/audit/?classID=nonsense", token, nil, http.StatusBadRequest).Body.Close()
+}
+
+func TestHTTPVersions(t *testing.T) {
+	s, teacher, classID := newClass(t)
+	defer s.Close()
+	token := s.Token(teacher, "units")
+	do(t, s, "POST", "/units/", token, map[string]interface{}{"class_id": classID, "title": "Cells"}, http.StatusOK).Body.Close()
+	path := "/units/" + listUnits(t, s, token, classID)[0].String()
+	do(t, s, "PATCH", path, token, map[string]string{"title": "Cell Biology"}, http.StatusOK).Body.Close()
+
+	resp := do(t, s, "GET", path+"/versions/2", token, nil, http.StatusOK)
+	var body struct {
+		Version struct {
+			Version int `json:"version"`
+			Unit    struct {
+				Title string `json:"title"`
+			} `json:"unit"`
+			Changes []struct {
+				Field  string `json:"field"`
+				Before string `json:"before"`
+				After  string `json:"after"`
+			} `json:"changes"`
+		} `json:"version"`
+	}
+	err := json.NewDecoder(resp.Body).Decode(&body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("decode version: %v", err)
+	}
+	v := body.Version
+	if v.Version != 2 || v.Unit.Title != "Cell Biology" || len(v.Changes) != 1 ||
+		v.Changes[0].Field != "title" || v.Changes[0].Before != "Cells" || v.Changes[0].After != "Cell Biology" {
+		t.Fatalf("got version %+v, want version 2 renamed from %q", v, "Cells")
+	}
+
+	do(t, s, "POST", path+"/revert", token, map[string]int{"version": 1}, http.StatusOK).Body.Close()
+	resp = do(t, s, "GET", path+"/versions", token, nil, http.StatusOK)
+	var list struct {
+		Versions []struct {
+			Version int `json:"version"`
+		} `json:"versions"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&list)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("decode versions: %v", err)
+	}
+	if len(list.Versions) != 3 {
+		t.Fatalf("got %d versions after revert, want 3", len(list.Versions))
+	}
+
+	do(t, s, "GET", path+"/versions/9", token, nil, http.StatusNotFound).Body.Close()
+	do(t, s, "GET", path+"/versions/first", token, nil, http.StatusBadRequest).Body.Close()
+	do(t, s, "POST", path+"/revert", token, "not an object", http.StatusBadRequest).Body.Close()
+}
+
+func TestHTTPNotMember(t *testing.T) {
+	s, teacher, classID := newClass(t)
+	defer s.Close()
+	do(t, s, "POST", "/units/", s.Token(teacher, "units"), map[string]interface{}{"class_id": classID, "title": "Private"}, http.StatusOK).Body.Close()
+	unitID := listUnits(t, s, s.Token(teacher, "units"), classID)[0]
+
+	outsider := s.Token(uuid.New(), "units")
+	do(t, s, "GET", "/units/?classID="+classID.String(), outsider, nil, http.StatusNotFound).Body.Close()
+	do(t, s, "GET", "/units/"+unitID.String(), outsider, nil, http.StatusNotFound).Body.Close()
+	do(t, s, "DELETE", "/units/"+unitID.String(), outsider, nil, http.StatusNotFound).Body.Close()
+}
+
+func TestHTTPBadRequest(t *testing.T) {
+	s, teacher, _ := newClass(t)
+	defer s.Close()
+	token := s.Token(teacher, "units")
+	do(t, s, "GET", "/units/?classID=nonsense", token, nil, http.StatusBadRequest).Body.Close()
+	do(t, s, "GET", "/units/nonsense", token, nil, http.StatusBadRequest).Body.Close()
+	do(t, s, "POST", "/units/", token, "not an object", http.StatusBadRequest).Body.Close()
+}
+
+func TestHTTPClient(t *testing.T) {
+	s, teacher, classID := newClass(t)
+	defer s.Close()
+	student := uuid.New()
+	s.Classes.Enroll(student, classID, models.UserRoleStudent)
+	client, err := unitsvc.MakeClientEndpoints(s.URL)
+	if err != nil {
+		t.Fatalf("MakeClientEndpoints: %v", err)
+	}
+	as := func(subject uuid.UUID, scopes ...string) context.Context {
+		return context.WithValue(context.Background(), introspector.TokenContextKey, s.Token(subject, scopes...))
+	}
+	ctx := as(teacher, "units")
+
+	if err := client.CreateUnit(ctx, classID, "Cells"); err != nil {
+		t.Fatalf("CreateUnit: %v", err)
+	}
+	units, err := client.ListUnits(ctx, classID)
+	if err != nil || len(units) != 1 {
+		t.Fatalf("ListUnits: got %v, %v; want one unit", units, err)
+	}
+	if err := client.RenameUnit(ctx, units[0], "Cell Biology"); err != nil {
+		t.Fatalf("RenameUnit: %v", err)
+	}
+	unit, err := client.GetUnit(ctx, units[0])
+	if err != nil || unit.ClassID != classID || unit.Title != "Cell Biology" {
+		t.Fatalf("GetUnit: got %+v, %v", unit, err)
+	}
+	page, err := client.ListMyUnits(ctx, uuid.Nil, 0, unitsvc.UnitFilter{})
+	if err != nil || len(page.Classes) != 1 || page.Classes[0].ClassID != classID || len(page.Classes[0].Units) != 1 || page.Next != nil {
+		t.Fatalf("ListMyUnits: got %+v, %v; want the class with its unit", page, err)
+	}
+	from := time.Now()
+	filter := unitsvc.UnitFilter{Statuses: []unitsvcmodels.UnitStatus{unitsvcmodels.StatusPublished}, From: &from}
+	if page, err := client.ListMyUnits(ctx, uuid.Nil, 0, filter); err != nil || len(page.Classes) != 1 || len(page.Classes[0].Units) != 0 {
+		t.Fatalf("ListMyUnits of scheduled units: got %+v, %v; want the class without its unscheduled unit", page, err)
+	}
+	filter.Statuses = []unitsvcmodels.UnitStatus{"deleted"}
+	if _, err := client.ListMyUnits(ctx, uuid.Nil, 0, filter); err != unitsvc.ErrBadRequest {
+		t.Fatalf("ListMyUnits with an unknown status: got %v, want ErrBadRequest", err)
+	}
+	matches, err := client.SearchUnits(ctx, "cell", unitsvc.SearchFilter{ClassIDs: []uuid.UUID{classID}})
+	if err != nil || len(matches) != 1 || matches[0].Unit.ID != units[0] || matches[0].Snippet != "<mark>Cell</mark> Biology" {
+		t.Fatalf("SearchUnits: got %v, %v", matches, err)
+	}
+	if unit, err := client.AdvanceUnit(ctx, classID); err != nil || unit.ID != units[0] {
+		t.Fatalf("AdvanceUnit: got %+v, %v; want %v", unit, err, units[0])
+	}
+	if err := client.SetCurrentUnit(ctx, classID, units[0]); err != nil {
+		t.Fatalf("SetCurrentUnit: %v", err)
+	}
+	if unit, err := client.GetCurrentUnit(as(student, "units"), classID); err != nil || unit.ID != units[0] {
+		t.Fatalf("GetCurrentUnit as student: got %+v, %v; want %v", unit, err, units[0])
+	}
+
+	// Errors come back as the service errors they were encoded from.
+	if _, err := client.GetUnit(as(uuid.New(), "units"), units[0]); err != unitsvc.ErrNotFound {
+		t.Errorf("GetUnit as outsider: got %v, want ErrNotFound", err)
+	}
+	if _, err := client.GetUnit(as(teacher, "classes"), units[0]); err != unitsvc.ErrUnauthorized {
+		t.Errorf("GetUnit without scope: got %v, want ErrUnauthorized", err)
+	}
+	// Only students mark progress, so the scope of MarkUnitProgress is checked here.
+	if _, err := client.MarkUnitProgress(as(student, "units.list"), units[0], unitsvcmodels.ProgressCompleted); err != unitsvc.ErrUnauthorized {
+		t.Errorf("MarkUnitProgress without scope: got %v, want ErrUnauthorized", err)
+	}
+	if p, err := client.MarkUnitProgress(as(student, "units.progress"), units[0], unitsvcmodels.ProgressCompleted); err != nil || p.Status != unitsvcmodels.ProgressCompleted || p.UserID != student {
+		t.Fatalf("MarkUnitProgress as student: got %+v, %v", p, err)
+	}
+	progress, err := client.GetClassProgress(ctx, classID)
+	if err != nil || len(progress.Students) != 1 || progress.Students[0].UserID != student || progress.Students[0].Completed != 1 {
+		t.Fatalf("GetClassProgress: got %+v, %v; want the student with one unit completed", progress, err)
+	}
+	if _, err := client.AdvanceUnit(ctx, classID); err != unitsvc.ErrBadRequest {
+		t.Errorf("AdvanceUnit past last unit: got %v, want ErrBadRequest", err)
+	}
+	if _, err := client.ImportUnits(as(student, "units"), classID, nil, true); err != unitsvc.ErrForbidden {
+		t.Errorf("ImportUnits as student: got %v, want ErrForbidden", err)
+	}
+	_, err = client.ImportUnits(ctx, classID, []unitsvc.ImportRow{{Row: 1, ID: uuid.New(), Title: "Genetics"}}, true)
+	if e, ok := err.(*unitsvc.ImportError); !ok || len(e.Rows) != 1 || e.Rows[0].Field != "id" {
+		t.Errorf("ImportUnits with unknown ID: got %v, want an *ImportError for the id", err)
+	}
+
+	if err := client.DeleteUnit(ctx, units[0]); err != nil {
+		t.Fatalf("DeleteUnit: %v", err)
+	}
+	if _, err := client.GetUnit(ctx, units[0]); err != unitsvc.ErrNotFound {
+		t.Errorf("GetUnit after delete: got %v, want ErrNotFound", err)
+	}
+	if _, err := client.GetCurrentUnit(ctx, classID); err != unitsvc.ErrNotFound {
+		t.Errorf("GetCurrentUnit after deleting it: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestHTTPRequestID(t *testing.T) {
+	s, teacher, classID := newClass(t)
+	defer s.Close()
+	token := s.Token(teacher, "units")
+
+	get := func(path, requestID string) *http.Response {
+		req, err := http.NewRequest("GET", s.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		if requestID != "" {
+			req.Header.Set(unitsvc.RequestIDHeader, requestID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := get("/units/?classID="+classID.String(), "abc-123")
+	resp.Body.Close()
+	if got := resp.Header.Get(unitsvc.RequestIDHeader); got != "abc-123" {
+		t.Errorf("got request ID %q on success, want the client's", got)
+	}
+
+	resp = get("/units/"+uuid.New().String(), "")
+	var body struct {
+		Error     string `json:"error"`
+		RequestID string `json:"request_id"`
+	}
+	err := json.NewDecoder(resp.Body).Decode(&body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if _, err := uuid.Parse(body.RequestID); err != nil || resp.Header.Get(unitsvc.RequestIDHeader) != body.RequestID {
+		t.Errorf("got generated request ID %q in body and %q in header", body.RequestID, resp.Header.Get(unitsvc.RequestIDHeader))
+	}
+
+	resp = get("/units/?classID="+classID.String(), "forged\tlevel=error")
+	resp.Body.Close()
+	if got := resp.Header.Get(unitsvc.RequestIDHeader); got == "forged\tlevel=error" || got == "" {
+		t.Errorf("got request ID %q for malformed header, want a generated one", got)
+	}
+}
+
+func TestRequestIDToHTTPRequest(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	unitsvc.RequestIDToHTTPRequest()(unitsvc.WithRequestID(context.Background(), "abc-123"), req)
+	if got := req.Header.Get(unitsvc.RequestIDHeader); got != "abc-123" {
+		t.Errorf("forwarded request ID %q, want abc-123", got)
+	}
+}
+
+func TestClientTracer(t *testing.T) {
+	var header http.Header
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		fmt.Fprint(w, `{"units":[]}`)
+	}))
+	defer s.Close()
+	tracer := tracing.NewLogTracer(log.NewNopLogger())
+	client, err := unitsvc.MakeClientEndpoints(s.URL, unitsvc.ClientTracer(tracer, log.NewNopLogger()))
+	if err != nil {
+		t.Fatalf("MakeClientEndpoints: %v", err)
+	}
+
+	span := tracer.StartSpan("caller")
+	ctx := opentracing.ContextWithSpan(context.Background(), span)
+	if _, err := client.ListUnits(ctx, uuid.New()); err != nil {
+		t.Fatalf("ListUnits: %v", err)
+	}
+	span.Finish()
+	if got, want := header.Get("X-B3-TraceId"), tracing.TraceID(ctx); got != want {
+		t.Errorf("propagated trace %q, want %q", got, want)
+	}
+}
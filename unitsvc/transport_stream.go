@@ -0,0 +1,182 @@
+package unitsvc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	kitot "github.com/go-kit/kit/tracing/opentracing"
+	"github.com/opentracing/opentracing-go"
+	"github.com/ory/hydra/oauth2"
+	"github.com/studiously/introspector"
+	"golang.org/x/net/websocket"
+)
+
+// streamHeartbeat is how often an idle event stream sends a comment, so that proxies do not
+// close it and disconnected clients are noticed.
+const streamHeartbeat = 15 * time.Second
+
+// StreamReauthorization is how often an open change stream checks again that its access token is
+// active and that its user can still list the class's units. The stream is closed when a check
+// fails, so that it does not outlive an expired or revoked token or the user's membership.
+var StreamReauthorization = time.Minute
+
+// makeStreamHandler authorizes a subscription to the changes of the class in the classID query
+// parameter, as ListUnits would, and then hands it to serve until the client goes away or the
+// subscription is no longer authorized. Clients resume after the event in the Last-Event-ID
+// header or lastEventID query parameter.
+func makeStreamHandler(
+	s Service,
+	feed *Feed,
+	ti oauth2.Introspector,
+	tracer opentracing.Tracer,
+	logger log.Logger,
+	operation string,
+	serve func(ctx context.Context, w http.ResponseWriter, r *http.Request, sub *FeedSubscription),
+) http.Handler {
+	check := authenticate(ti, "units.list")(
+		func(ctx context.Context, request interface{}) (interface{}, error) {
+			_, err := s.ListUnits(ctx, request.(listUnitsRequest).ClassID)
+			return nil, err
+		},
+	)
+	authorize := kitot.TraceServer(tracer, operation)(check)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		for _, before := range []func(context.Context, *http.Request) context.Context{
+			introspector.ToHTTPContext(),
+			accessTokenFromQuery,
+			RequestIDFromHTTPRequest(),
+			kitot.FromHTTPRequest(tracer, operation, logger),
+		} {
+			ctx = before(ctx, r)
+		}
+		request, err := DecodeListUnitsRequest(ctx, r)
+		if err == nil {
+			_, err = authorize(ctx, request)
+		}
+		if err != nil {
+			encodeError(ctx, err, w)
+			return
+		}
+		lastEventID := r.Header.Get("Last-Event-ID")
+		if lastEventID == "" {
+			lastEventID = r.URL.Query().Get("lastEventID")
+		}
+		sub := feed.Subscribe(request.(listUnitsRequest).ClassID, lastEventID)
+		defer sub.Close()
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go reauthorize(ctx, cancel, check, request, logger)
+		serve(ctx, w, r, sub)
+	})
+}
+
+// reauthorize runs check every StreamReauthorization until ctx is done, and cancels ctx when a
+// check fails. Checks are not traced as part of the request that opened the stream, which has
+// already been reported.
+func reauthorize(ctx context.Context, cancel context.CancelFunc, check endpoint.Endpoint, request interface{}, logger log.Logger) {
+	ticker := time.NewTicker(StreamReauthorization)
+	defer ticker.Stop()
+	checkCtx := opentracing.ContextWithSpan(ctx, nil)
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := check(checkCtx, request); err != nil {
+				logger.Log("msg", "closing change stream", "request_id", RequestID(ctx), "error", err)
+				cancel()
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// accessTokenFromQuery reads the access token from the access_token query parameter when the
+// Authorization header has none, since browsers cannot set headers on EventSource or WebSocket requests.
+func accessTokenFromQuery(ctx context.Context, r *http.Request) context.Context {
+	if _, ok := ctx.Value(introspector.TokenContextKey).(string); ok {
+		return ctx
+	}
+	if token := r.URL.Query().Get("access_token"); token != "" {
+		return context.WithValue(ctx, introspector.TokenContextKey, token)
+	}
+	return ctx
+}
+
+// serveSSE writes events as Server-Sent Events. Each event's SSE type is its FeedEvent type.
+func serveSSE(ctx context.Context, w http.ResponseWriter, r *http.Request, sub *FeedSubscription) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		encodeError(ctx, fmt.Errorf("streaming is not supported"), w)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	if id := RequestID(ctx); id != "" {
+		w.Header().Set(RequestIDHeader, id)
+	}
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case e, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// serveWebSocket sends each event as a JSON text message. Messages from the client are ignored.
+// The origin is not checked: the access token, not a cookie, authorizes the subscription.
+func serveWebSocket(ctx context.Context, w http.ResponseWriter, r *http.Request, sub *FeedSubscription) {
+	websocket.Server{Handler: func(ws *websocket.Conn) {
+		defer ws.Close()
+		gone := make(chan struct{})
+		go func() {
+			io.Copy(ioutil.Discard, ws)
+			close(gone)
+		}()
+		for {
+			select {
+			case e, ok := <-sub.C:
+				if !ok {
+					return
+				}
+				if err := websocket.JSON.Send(ws, e); err != nil {
+					return
+				}
+			case <-gone:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}}.ServeHTTP(w, r)
+}
@@ -0,0 +1,166 @@
+package unitsvc_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/studiously/unitsvc/unitsvc"
+	"golang.org/x/net/websocket"
+)
+
+// sseEvent is an event read from a Server-Sent Events stream.
+type sseEvent struct {
+	id, typ string
+	data    unitsvc.FeedEvent
+}
+
+// readSSE reads the next event from r, skipping comments.
+func readSSE(t *testing.T, r *bufio.Reader) sseEvent {
+	var e sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read event: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && e.typ != "":
+			return e
+		case strings.HasPrefix(line, "id: "):
+			e.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			e.typ = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &e.data); err != nil {
+				t.Fatalf("decode event data: %v", err)
+			}
+		}
+	}
+}
+
+func TestHTTPStreamSSE(t *testing.T) {
+	s, teacher, classID := newClass(t)
+	defer s.Close()
+	token := s.Token(teacher, "units")
+	stream := "/units/stream?classID=" + classID.String()
+
+	resp := do(t, s, "GET", stream, token, nil, http.StatusOK)
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("got Content-Type %q, want text/event-stream", ct)
+	}
+	events := bufio.NewReader(resp.Body)
+
+	do(t, s, "POST", "/units/", token, map[string]interface{}{"class_id": classID, "title": "Cells"}, http.StatusOK).Body.Close()
+	created := readSSE(t, events)
+	if created.typ != unitsvc.FeedCreated || created.data.Unit == nil || created.data.Unit.Title != "Cells" || created.id != created.data.ID {
+		t.Fatalf("got event %+v, want creation of %q", created, "Cells")
+	}
+	path := "/units/" + created.data.UnitID.String()
+	do(t, s, "PATCH", path, token, map[string]string{"title": "Cell Biology"}, http.StatusOK).Body.Close()
+	do(t, s, "DELETE", path, token, nil, http.StatusOK).Body.Close()
+	if e := readSSE(t, events); e.typ != unitsvc.FeedUpdated || e.data.Unit.Title != "Cell Biology" {
+		t.Fatalf("got event %+v, want rename to %q", e, "Cell Biology")
+	}
+	if e := readSSE(t, events); e.typ != unitsvc.FeedDeleted || e.data.UnitID != created.data.UnitID {
+		t.Fatalf("got event %+v, want deletion of %v", e, created.data.UnitID)
+	}
+
+	// Reconnecting with Last-Event-ID replays what came after it. Browsers pass the token in the query.
+	req, err := http.NewRequest("GET", s.URL+stream+"&access_token="+token, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Last-Event-ID", created.id)
+	resumed, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("resume stream: %v", err)
+	}
+	defer resumed.Body.Close()
+	replay := bufio.NewReader(resumed.Body)
+	if e := readSSE(t, replay); e.typ != unitsvc.FeedUpdated {
+		t.Fatalf("got %s event on resume, want %s", e.typ, unitsvc.FeedUpdated)
+	}
+	if e := readSSE(t, replay); e.typ != unitsvc.FeedDeleted {
+		t.Fatalf("got %s event on resume, want %s", e.typ, unitsvc.FeedDeleted)
+	}
+}
+
+func TestHTTPStreamAuthorization(t *testing.T) {
+	s, teacher, classID := newClass(t)
+	defer s.Close()
+	stream := "/units/stream?classID=" + classID.String()
+	do(t, s, "GET", stream, "", nil, http.StatusUnauthorized).Body.Close()
+	do(t, s, "GET", stream, s.Token(teacher, "units.get"), nil, http.StatusUnauthorized).Body.Close()
+	do(t, s, "GET", stream, s.Token(uuid.New(), "units.list"), nil, http.StatusNotFound).Body.Close()
+	do(t, s, "GET", "/units/stream?classID=nonsense", s.Token(teacher, "units.list"), nil, http.StatusBadRequest).Body.Close()
+	do(t, s, "GET", "/units/stream/ws?classID="+classID.String(), "", nil, http.StatusUnauthorized).Body.Close()
+}
+
+func TestHTTPStreamWebSocket(t *testing.T) {
+	s, teacher, classID := newClass(t)
+	defer s.Close()
+	token := s.Token(teacher, "units")
+
+	url := "ws" + strings.TrimPrefix(s.URL, "http") + "/units/stream/ws?classID=" + classID.String() + "&access_token=" + token
+	ws, err := websocket.Dial(url, "", s.URL)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer ws.Close()
+
+	do(t, s, "POST", "/units/", token, map[string]interface{}{"class_id": classID, "title": "Optics"}, http.StatusOK).Body.Close()
+	var e unitsvc.FeedEvent
+	if err := websocket.JSON.Receive(ws, &e); err != nil {
+		t.Fatalf("receive: %v", err)
+	}
+	if e.Type != unitsvc.FeedCreated || e.ClassID != classID || e.Unit == nil || e.Unit.Title != "Optics" || e.ID == "" {
+		t.Fatalf("got event %+v, want creation of %q", e, "Optics")
+	}
+}
+
+// waitClosed fails the test unless the server ends r within a few seconds.
+func waitClosed(t *testing.T, r io.Reader) {
+	closed := make(chan struct{})
+	go func() {
+		io.Copy(ioutil.Discard, r)
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream still open")
+	}
+}
+
+func TestHTTPStreamReauthorization(t *testing.T) {
+	defer func(d time.Duration) { unitsvc.StreamReauthorization = d }(unitsvc.StreamReauthorization)
+	unitsvc.StreamReauthorization = 10 * time.Millisecond
+	s, teacher, classID := newClass(t)
+	defer s.Close()
+	stream := "/units/stream?classID=" + classID.String()
+
+	// A revoked token ends the stream it opened.
+	token := s.Token(teacher, "units")
+	resp := do(t, s, "GET", stream, token, nil, http.StatusOK)
+	defer resp.Body.Close()
+	s.Introspector.Revoke(token)
+	waitClosed(t, resp.Body)
+
+	// So does leaving the class, over a WebSocket too.
+	url := "ws" + strings.TrimPrefix(s.URL, "http") + "/units/stream/ws?classID=" + classID.String() + "&access_token=" + s.Token(teacher, "units")
+	ws, err := websocket.Dial(url, "", s.URL)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer ws.Close()
+	s.Classes.Unenroll(teacher, classID)
+	waitClosed(t, ws)
+}
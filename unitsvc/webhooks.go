@@ -0,0 +1,308 @@
+package unitsvc
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/google/uuid"
+	"github.com/studiously/unitsvc/models"
+)
+
+// Headers sent with every webhook delivery. The body is the JSON Event that MessagingMiddleware
+// publishes to the subject in WebhookEventHeader.
+const (
+	WebhookEventHeader     = "X-Unitsvc-Event"
+	WebhookDeliveryHeader  = "X-Unitsvc-Delivery" // the same for every attempt, so receivers can ignore repeats
+	WebhookTimestampHeader = "X-Unitsvc-Timestamp"
+	WebhookSignatureHeader = "X-Unitsvc-Signature"
+)
+
+// webhookSubjects are the subjects a webhook can subscribe to.
+var webhookSubjects = []string{SubjCreateUnit, SubjRenameUnit, SubjDeleteUnit, SubjRevertUnit, SubjCurrentUnit, SubjUnitProgress}
+
+// SignWebhook returns the WebhookSignatureHeader value for a delivery: "sha256=" followed by the
+// hex HMAC-SHA256, keyed with the webhook's secret, of the WebhookTimestampHeader value, a period
+// and the body. Receivers should compare it with hmac.Equal and reject old timestamps.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// validateWebhook returns ErrBadRequest unless hook has an absolute http or https URL, a secret
+// and only known subjects in its event filter.
+func validateWebhook(hook *models.Webhook) error {
+	u, err := url.Parse(hook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || hook.Secret == "" {
+		return ErrBadRequest
+	}
+	for _, event := range hook.Events {
+		if !containsString(webhookSubjects, event) {
+			return ErrBadRequest
+		}
+	}
+	return nil
+}
+
+// wantsEvent reports whether hook subscribes to subj.
+func wantsEvent(hook *models.Webhook, subj string) bool {
+	return len(hook.Events) == 0 || containsString(hook.Events, subj)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// enqueueDeliveries queues a change for the webhooks of its class that subscribe to it, in the
+// transaction that makes the change, so that no committed change goes undelivered.
+func enqueueDeliveries(ctx context.Context, repo Repository, change *models.AuditEntry) error {
+	subj, event := EventFromChange(change)
+	return enqueueEvent(ctx, repo, change.ClassID, subj, event, change.CreatedAt)
+}
+
+// enqueueEvent queues an event of a class for its webhooks that subscribe to subj.
+func enqueueEvent(ctx context.Context, repo Repository, classID uuid.UUID, subj string, event Event, at time.Time) error {
+	hooks, err := repo.WebhooksByClassID(ctx, classID)
+	if err != nil || len(hooks) == 0 {
+		return err
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	for _, hook := range hooks {
+		if !wantsEvent(hook, subj) {
+			continue
+		}
+		err := repo.AppendDelivery(ctx, &models.WebhookDelivery{
+			ID:            uuid.New(),
+			WebhookID:     hook.ID,
+			Event:         subj,
+			Payload:       payload,
+			Status:        models.DeliveryPending,
+			NextAttemptAt: at,
+			CreatedAt:     at,
+			UpdatedAt:     at,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WebhookDispatcher sends queued webhook deliveries. A delivery succeeds when the receiver
+// responds with a 2xx status. Failed deliveries are retried with exponential backoff, and marked
+// dead after MaxAttempts attempts. Deliveries are sent at least once: an event may be sent again
+// if the process stops mid-delivery or several instances dispatch from the same database.
+type WebhookDispatcher struct {
+	// MaxAttempts is how many times a delivery is attempted before it is marked dead.
+	MaxAttempts int
+	// Backoff is the delay before the first retry. It doubles after each further failure, up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// BatchSize limits how many deliveries each call to Dispatch attempts.
+	BatchSize int
+
+	repo   Repository
+	client *http.Client
+	logger log.Logger
+}
+
+// NewWebhookDispatcher returns a WebhookDispatcher that sends the deliveries queued in repo with
+// client, which should come from NewWebhookClient unless every receiver is trusted. By default,
+// deliveries are attempted 10 times over about 3 hours.
+func NewWebhookDispatcher(repo Repository, client *http.Client, logger log.Logger) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		MaxAttempts: 10,
+		Backoff:     30 * time.Second,
+		MaxBackoff:  time.Hour,
+		BatchSize:   100,
+		repo:        repo,
+		client:      client,
+		logger:      logger,
+	}
+}
+
+// NewWebhookClient returns a client for NewWebhookDispatcher that gives up on a delivery after
+// timeout. It only connects to public addresses, checked after the receiver's host is resolved,
+// so that webhooks cannot be used to reach the service's own network, including through
+// redirects or DNS names that resolve to private addresses. Proxy environment variables are
+// ignored, since a proxy would make the connection on the dispatcher's behalf.
+func NewWebhookClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: 30 * time.Second,
+		Control:   dialPublic,
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+	}
+}
+
+// nonPublicNets are the reserved ranges that net.IP has no method for.
+var nonPublicNets = parseCIDRs(
+	"0.0.0.0/8",      // "this" network
+	"100.64.0.0/10",  // carrier-grade NAT
+	"192.0.0.0/24",   // IETF protocol assignments
+	"198.18.0.0/15",  // benchmarking
+	"240.0.0.0/4",    // reserved, including broadcast
+	"64:ff9b:1::/48", // local-use NAT64
+	"2001:db8::/32",  // documentation
+)
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets[i] = n
+	}
+	return nets
+}
+
+// isPublicIP reports whether ip is a globally routable unicast address.
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	for _, n := range nonPublicNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// dialPublic is a net.Dialer Control function that refuses connections to non-public addresses.
+func dialPublic(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+		return fmt.Errorf("webhook destination %s is not a public address", host)
+	}
+	return nil
+}
+
+// errNoResponse is recorded for attempts that got no response from the receiver. The cause is
+// only logged: ListDeliveries shows LastError to teachers, and dial errors would tell them which
+// addresses and ports the service can reach.
+var errNoResponse = errors.New("receiver did not respond")
+
+// Dispatch attempts the deliveries that are due, returning how many were attempted. It stops
+// early when ctx is done, but finishes the delivery in progress so that its outcome is recorded;
+// the client's timeout bounds how long that takes.
+func (d *WebhookDispatcher) Dispatch(ctx context.Context) (int, error) {
+	due, err := d.repo.DueDeliveries(ctx, time.Now().UTC(), d.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	for i, delivery := range due {
+		if ctx.Err() != nil {
+			return i, ctx.Err()
+		}
+		hook, err := d.repo.WebhookByID(ctx, delivery.WebhookID)
+		if err == ErrNotFound {
+			// Deleted since the delivery was read; its deliveries are gone with it.
+			continue
+		} else if err != nil {
+			return i, err
+		}
+		d.attempt(context.Background(), hook, delivery)
+		if err := d.repo.UpdateDelivery(context.Background(), delivery); err != nil {
+			return i + 1, err
+		}
+	}
+	return len(due), nil
+}
+
+// attempt sends delivery to hook and records the outcome in delivery.
+func (d *WebhookDispatcher) attempt(ctx context.Context, hook *models.Webhook, delivery *models.WebhookDelivery) {
+	code, err := d.send(ctx, hook, delivery)
+	if err != nil && code == 0 {
+		d.logger.Log("msg", "webhook delivery got no response", "error", err, "webhook", hook.ID, "delivery", delivery.ID)
+		err = errNoResponse
+	}
+	now := time.Now().UTC()
+	delivery.Attempts++
+	delivery.LastStatusCode = code
+	delivery.UpdatedAt = now
+	switch {
+	case err == nil:
+		delivery.Status, delivery.LastError = models.DeliveryDelivered, ""
+	case delivery.Attempts >= d.MaxAttempts:
+		delivery.Status, delivery.LastError = models.DeliveryDead, err.Error()
+		d.logger.Log("msg", "webhook delivery failed permanently", "error", err, "webhook", hook.ID, "delivery", delivery.ID, "attempts", delivery.Attempts)
+	default:
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = now.Add(d.backoff(delivery.Attempts))
+	}
+}
+
+// send posts a delivery, returning the receiver's status code, or 0 if it did not respond.
+func (d *WebhookDispatcher) send(ctx context.Context, hook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	req, err := http.NewRequest("POST", hook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, delivery.Event)
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID.String())
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(hook.Secret, timestamp, delivery.Payload))
+	resp, err := d.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver responded with %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns the delay before the next attempt of a delivery that has failed attempts times.
+func (d *WebhookDispatcher) backoff(attempts int) time.Duration {
+	delay := d.Backoff
+	for i := 1; i < attempts && delay < d.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.MaxBackoff {
+		delay = d.MaxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,307 @@
+package unitsvc_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/google/uuid"
+	"github.com/studiously/classsvc/models"
+	unitsvcmodels "github.com/studiously/unitsvc/models"
+	"github.com/studiously/unitsvc/unitsvc"
+	unittesting "github.com/studiously/unitsvc/unitsvc/testing"
+)
+
+// createWebhook subscribes url to the events of classID through the HTTP API.
+func createWebhook(t *testing.T, s *unittesting.Server, token string, classID uuid.UUID, url string) uuid.UUID {
+	body := map[string]interface{}{"class_id": classID, "url": url, "secret": "s3cret"}
+	resp := do(t, s, "POST", "/webhooks", token, body, http.StatusOK)
+	defer resp.Body.Close()
+	var created struct {
+		Webhook struct {
+			ID     uuid.UUID `json:"id"`
+			Secret string    `json:"secret"`
+		} `json:"webhook"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatalf("decode webhook: %v", err)
+	}
+	if created.Webhook.Secret != "" {
+		t.Errorf("webhook secret was returned")
+	}
+	return created.Webhook.ID
+}
+
+// listDeliveries lists the deliveries of a webhook through the HTTP API.
+func listDeliveries(t *testing.T, s *unittesting.Server, token string, webhookID uuid.UUID, status string) []*unitsvcmodels.WebhookDelivery {
+	resp := do(t, s, "GET", "/webhooks/"+webhookID.String()+"/deliveries?status="+status, token, nil, http.StatusOK)
+	defer resp.Body.Close()
+	var body struct {
+		Deliveries []*unitsvcmodels.WebhookDelivery `json:"deliveries"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode deliveries: %v", err)
+	}
+	return body.Deliveries
+}
+
+// receiver is a webhook endpoint that records the deliveries it accepts.
+type receiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	status   int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func newReceiver(status int) *receiver {
+	r := &receiver{status: status}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.requests = append(r.requests, req)
+		r.bodies = append(r.bodies, body)
+		w.WriteHeader(r.status)
+	}))
+	return r
+}
+
+// received returns the requests accepted so far and their bodies.
+func (r *receiver) received() ([]*http.Request, [][]byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.requests, r.bodies
+}
+
+func dispatch(t *testing.T, d *unitsvc.WebhookDispatcher, want int) {
+	n, err := d.Dispatch(context.Background())
+	if err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	if n != want {
+		t.Fatalf("Dispatch attempted %d deliveries, want %d", n, want)
+	}
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	s, teacher, classID := newClass(t)
+	defer s.Close()
+	rcv := newReceiver(http.StatusNoContent)
+	defer rcv.Close()
+	token := s.Token(teacher, "units")
+	webhookID := createWebhook(t, s, token, classID, rcv.URL+"/hooks")
+
+	do(t, s, "POST", "/units/", token, map[string]interface{}{"class_id": classID, "title": "Cells"}, http.StatusOK).Body.Close()
+	d := unitsvc.NewWebhookDispatcher(s.Repository, http.DefaultClient, log.NewNopLogger())
+	dispatch(t, d, 1)
+	dispatch(t, d, 0)
+
+	requests, bodies := rcv.received()
+	if len(requests) != 1 {
+		t.Fatalf("receiver got %d deliveries, want 1", len(requests))
+	}
+	req, body := requests[0], bodies[0]
+	var e unitsvc.Event
+	if err := json.Unmarshal(body, &e); err != nil {
+		t.Fatalf("decode delivery %s: %v", body, err)
+	}
+	if req.URL.Path != "/hooks" || req.Header.Get(unitsvc.WebhookEventHeader) != unitsvc.SubjCreateUnit || e.ID != classID {
+		t.Errorf("got %s event for %v at %s, want %s for %v at /hooks",
+			req.Header.Get(unitsvc.WebhookEventHeader), e.ID, req.URL.Path, unitsvc.SubjCreateUnit, classID)
+	}
+	timestamp, err := strconv.ParseInt(req.Header.Get(unitsvc.WebhookTimestampHeader), 10, 64)
+	if err != nil {
+		t.Fatalf("bad timestamp header: %v", err)
+	}
+	if got, want := req.Header.Get(unitsvc.WebhookSignatureHeader), unitsvc.SignWebhook("s3cret", timestamp, body); got != want {
+		t.Errorf("got signature %q, want %q", got, want)
+	}
+	if got, want := req.Header.Get(unitsvc.WebhookSignatureHeader), unitsvc.SignWebhook("wrong", timestamp, body); got == want {
+		t.Errorf("signature does not depend on the secret")
+	}
+
+	deliveries := listDeliveries(t, s, token, webhookID, "")
+	if len(deliveries) != 1 {
+		t.Fatalf("got %d deliveries, want 1", len(deliveries))
+	}
+	if got := deliveries[0]; got.Status != unitsvcmodels.DeliveryDelivered || got.Attempts != 1 || got.LastStatusCode != http.StatusNoContent ||
+		got.ID.String() != req.Header.Get(unitsvc.WebhookDeliveryHeader) {
+		t.Errorf("got delivery %+v, want delivered on the first attempt as %s", got, req.Header.Get(unitsvc.WebhookDeliveryHeader))
+	}
+
+	student := uuid.New()
+	s.Classes.Enroll(student, classID, models.UserRoleStudent)
+	do(t, s, "GET", "/webhooks/"+webhookID.String()+"/deliveries", s.Token(student, "units"), nil, http.StatusForbidden).Body.Close()
+	do(t, s, "GET", "/webhooks/"+webhookID.String()+"/deliveries?status=lost", token, nil, http.StatusBadRequest).Body.Close()
+	do(t, s, "POST", "/webhooks", token, map[string]interface{}{"class_id": classID, "url": "not a url", "secret": "s3cret"}, http.StatusBadRequest).Body.Close()
+}
+
+func TestWebhookRetryAndDeadLetter(t *testing.T) {
+	s, teacher, classID := newClass(t)
+	defer s.Close()
+	rcv := newReceiver(http.StatusServiceUnavailable)
+	defer rcv.Close()
+	token := s.Token(teacher, "units")
+	webhookID := createWebhook(t, s, token, classID, rcv.URL)
+	do(t, s, "POST", "/units/", token, map[string]interface{}{"class_id": classID, "title": "Cells"}, http.StatusOK).Body.Close()
+
+	d := unitsvc.NewWebhookDispatcher(s.Repository, http.DefaultClient, log.NewNopLogger())
+	d.MaxAttempts, d.Backoff = 3, 50*time.Millisecond
+	dispatch(t, d, 1)
+	// The retry is not due until the backoff has passed.
+	dispatch(t, d, 0)
+	pending := listDeliveries(t, s, token, webhookID, unitsvcmodels.DeliveryPending)
+	if len(pending) != 1 || pending[0].Attempts != 1 || pending[0].LastStatusCode != http.StatusServiceUnavailable || pending[0].LastError == "" {
+		t.Fatalf("got pending deliveries %+v, want one failed attempt", pending)
+	}
+	if wait := pending[0].NextAttemptAt.Sub(pending[0].UpdatedAt); wait != d.Backoff {
+		t.Errorf("got retry after %v, want %v", wait, d.Backoff)
+	}
+
+	time.Sleep(d.Backoff)
+	dispatch(t, d, 1)
+	time.Sleep(2 * d.Backoff)
+	dispatch(t, d, 1)
+	dispatch(t, d, 0)
+	requests, _ := rcv.received()
+	if len(requests) != 3 {
+		t.Fatalf("receiver got %d attempts, want 3", len(requests))
+	}
+	if ids := []string{requests[0].Header.Get(unitsvc.WebhookDeliveryHeader), requests[2].Header.Get(unitsvc.WebhookDeliveryHeader)}; ids[0] != ids[1] {
+		t.Errorf("retries have delivery IDs %v, want the same ID", ids)
+	}
+	dead := listDeliveries(t, s, token, webhookID, unitsvcmodels.DeliveryDead)
+	if len(dead) != 1 || dead[0].Attempts != 3 {
+		t.Fatalf("got dead letters %+v, want one after 3 attempts", dead)
+	}
+	if pending := listDeliveries(t, s, token, webhookID, unitsvcmodels.DeliveryPending); len(pending) != 0 {
+		t.Errorf("got %d pending deliveries after giving up, want 0", len(pending))
+	}
+}
+
+func TestWebhookCurrentUnit(t *testing.T) {
+	s, teacher, classID := newClass(t)
+	defer s.Close()
+	rcv := newReceiver(http.StatusNoContent)
+	defer rcv.Close()
+	token := s.Token(teacher, "units")
+	for _, title := range []string{"Cells", "Genetics"} {
+		do(t, s, "POST", "/units/", token, map[string]interface{}{"class_id": classID, "title": title}, http.StatusOK).Body.Close()
+	}
+	body := map[string]interface{}{"class_id": classID, "url": rcv.URL, "secret": "s3cret", "events": []string{unitsvc.SubjCurrentUnit}}
+	do(t, s, "POST", "/webhooks", token, body, http.StatusOK).Body.Close()
+
+	current := "/units/current?classID=" + classID.String()
+	do(t, s, "PUT", current, token, map[string]interface{}{"unit_id": listUnits(t, s, token, classID)[0]}, http.StatusOK).Body.Close()
+	do(t, s, "POST", "/units/current/advance?classID="+classID.String(), token, nil, http.StatusOK).Body.Close()
+	do(t, s, "PUT", current, token, map[string]interface{}{"unit_id": uuid.Nil}, http.StatusOK).Body.Close()
+	// Creates are not subscribed to.
+	do(t, s, "POST", "/units/", token, map[string]interface{}{"class_id": classID, "title": "Ecology"}, http.StatusOK).Body.Close()
+
+	d := unitsvc.NewWebhookDispatcher(s.Repository, http.DefaultClient, log.NewNopLogger())
+	dispatch(t, d, 3)
+	requests, bodies := rcv.received()
+	for i, req := range requests {
+		var e unitsvc.Event
+		if err := json.Unmarshal(bodies[i], &e); err != nil {
+			t.Fatalf("decode delivery %s: %v", bodies[i], err)
+		}
+		if got := req.Header.Get(unitsvc.WebhookEventHeader); got != unitsvc.SubjCurrentUnit || e.ID != classID {
+			t.Errorf("got %s event for %v, want %s for %v", got, e.ID, unitsvc.SubjCurrentUnit, classID)
+		}
+	}
+}
+
+func TestWebhookUnitProgress(t *testing.T) {
+	s, teacher, classID := newClass(t)
+	defer s.Close()
+	rcv := newReceiver(http.StatusNoContent)
+	defer rcv.Close()
+	token := s.Token(teacher, "units")
+	do(t, s, "POST", "/units/", token, map[string]interface{}{"class_id": classID, "title": "Cells"}, http.StatusOK).Body.Close()
+	unitID := listUnits(t, s, token, classID)[0]
+	body := map[string]interface{}{"class_id": classID, "url": rcv.URL, "secret": "s3cret", "events": []string{unitsvc.SubjUnitProgress}}
+	do(t, s, "POST", "/webhooks", token, body, http.StatusOK).Body.Close()
+
+	student := uuid.New()
+	s.Classes.Enroll(student, classID, models.UserRoleStudent)
+	path := "/units/" + unitID.String() + "/progress"
+	do(t, s, "PUT", path, s.Token(student, "units.progress"), map[string]interface{}{"status": unitsvcmodels.ProgressCompleted}, http.StatusOK).Body.Close()
+
+	d := unitsvc.NewWebhookDispatcher(s.Repository, http.DefaultClient, log.NewNopLogger())
+	dispatch(t, d, 1)
+	requests, bodies := rcv.received()
+	var e unitsvc.Event
+	if err := json.Unmarshal(bodies[0], &e); err != nil {
+		t.Fatalf("decode delivery %s: %v", bodies[0], err)
+	}
+	if got := requests[0].Header.Get(unitsvc.WebhookEventHeader); got != unitsvc.SubjUnitProgress || e.ID != unitID {
+		t.Errorf("got %s event for %v, want %s for %v", got, e.ID, unitsvc.SubjUnitProgress, unitID)
+	}
+}
+
+func TestWebhookClientRefusesNonPublicAddresses(t *testing.T) {
+	s, teacher, classID := newClass(t)
+	defer s.Close()
+	rcv := newReceiver(http.StatusNoContent)
+	defer rcv.Close()
+	token := s.Token(teacher, "units")
+	urls := []string{
+		rcv.URL,
+		strings.Replace(rcv.URL, "127.0.0.1", "localhost", 1),
+		"http://169.254.169.254/latest/meta-data/",
+		"http://10.0.0.1/",
+	}
+	var webhookIDs []uuid.UUID
+	for _, url := range urls {
+		webhookIDs = append(webhookIDs, createWebhook(t, s, token, classID, url))
+	}
+	do(t, s, "POST", "/units/", token, map[string]interface{}{"class_id": classID, "title": "Cells"}, http.StatusOK).Body.Close()
+
+	d := unitsvc.NewWebhookDispatcher(s.Repository, unitsvc.NewWebhookClient(time.Second), log.NewNopLogger())
+	dispatch(t, d, len(urls))
+	if requests, _ := rcv.received(); len(requests) != 0 {
+		t.Errorf("receiver on a loopback address got %d deliveries, want 0", len(requests))
+	}
+	for i, webhookID := range webhookIDs {
+		pending := listDeliveries(t, s, token, webhookID, unitsvcmodels.DeliveryPending)
+		if len(pending) != 1 || pending[0].LastStatusCode != 0 || pending[0].LastError != "receiver did not respond" {
+			t.Errorf("%s: got pending deliveries %+v, want one that got no response", urls[i], pending)
+		}
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	s, teacher, classID := newClass(t)
+	defer s.Close()
+	rcv := newReceiver(http.StatusInternalServerError)
+	defer rcv.Close()
+	token := s.Token(teacher, "units")
+	webhookID := createWebhook(t, s, token, classID, rcv.URL)
+	do(t, s, "POST", "/units/", token, map[string]interface{}{"class_id": classID, "title": "Cells"}, http.StatusOK).Body.Close()
+
+	d := unitsvc.NewWebhookDispatcher(s.Repository, http.DefaultClient, log.NewNopLogger())
+	d.Backoff, d.MaxBackoff = time.Millisecond, 4*time.Millisecond
+	var waits []time.Duration
+	for i := 0; i < 5; i++ {
+		time.Sleep(5 * time.Millisecond)
+		dispatch(t, d, 1)
+		pending := listDeliveries(t, s, token, webhookID, unitsvcmodels.DeliveryPending)
+		waits = append(waits, pending[0].NextAttemptAt.Sub(pending[0].UpdatedAt))
+	}
+	want := []time.Duration{1, 2, 4, 4, 4}
+	for i := range want {
+		if waits[i] != want[i]*time.Millisecond {
+			t.Fatalf("got backoffs %v, want %v milliseconds", waits, want)
+		}
+	}
+}
@@ -0,0 +1,1120 @@
+// Package unitsvctest provides conformance suites that every unitsvc backend must pass.
+package unitsvctest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/studiously/unitsvc/models"
+	"github.com/studiously/unitsvc/unitsvc"
+)
+
+// RepositoryFactory returns a ready-to-use Repository. Repositories may be shared between
+// calls; the suite only relies on units it created itself.
+type RepositoryFactory func(t *testing.T) unitsvc.Repository
+
+// RunRepositoryConformance runs the repository conformance suite against the Repository returned by newRepo.
+func RunRepositoryConformance(t *testing.T, newRepo RepositoryFactory) {
+	t.Run("SaveAndGet", func(t *testing.T) { testSaveAndGet(t, newRepo(t)) })
+	t.Run("GetMissing", func(t *testing.T) { testGetMissing(t, newRepo(t)) })
+	t.Run("SaveUpdatesExisting", func(t *testing.T) { testSaveUpdatesExisting(t, newRepo(t)) })
+	t.Run("UnitsByClassID", func(t *testing.T) { testUnitsByClassID(t, newRepo(t)) })
+	t.Run("UnitsByClassIDEmpty", func(t *testing.T) { testUnitsByClassIDEmpty(t, newRepo(t)) })
+	t.Run("UnitsByClassIDs", func(t *testing.T) { testUnitsByClassIDs(t, newRepo(t)) })
+	t.Run("UnitsByClassIDsFilter", func(t *testing.T) { testUnitsByClassIDsFilter(t, newRepo(t)) })
+	t.Run("Delete", func(t *testing.T) { testDelete(t, newRepo(t)) })
+	t.Run("DeleteMissing", func(t *testing.T) { testDeleteMissing(t, newRepo(t)) })
+	t.Run("ClassIDs", func(t *testing.T) { testClassIDs(t, newRepo(t)) })
+	t.Run("SearchUnits", func(t *testing.T) { testSearchUnits(t, newRepo(t)) })
+	t.Run("TransactCommit", func(t *testing.T) { testTransactCommit(t, newRepo(t)) })
+	t.Run("TransactRollback", func(t *testing.T) { testTransactRollback(t, newRepo(t)) })
+	t.Run("TransactRollbackRestores", func(t *testing.T) { testTransactRollbackRestores(t, newRepo(t)) })
+	t.Run("AuditAppendAndQuery", func(t *testing.T) { testAuditAppendAndQuery(t, newRepo(t)) })
+	t.Run("AuditPurge", func(t *testing.T) { testAuditPurge(t, newRepo(t)) })
+	t.Run("AuditTransactRollback", func(t *testing.T) { testAuditTransactRollback(t, newRepo(t)) })
+	t.Run("Versions", func(t *testing.T) { testVersions(t, newRepo(t)) })
+	t.Run("VersionsDuplicate", func(t *testing.T) { testVersionsDuplicate(t, newRepo(t)) })
+	t.Run("PruneVersions", func(t *testing.T) { testPruneVersions(t, newRepo(t)) })
+	t.Run("Webhooks", func(t *testing.T) { testWebhooks(t, newRepo(t)) })
+	t.Run("Deliveries", func(t *testing.T) { testDeliveries(t, newRepo(t)) })
+	t.Run("OneRoster", func(t *testing.T) { testOneRoster(t, newRepo(t)) })
+	t.Run("CurrentUnits", func(t *testing.T) { testCurrentUnits(t, newRepo(t)) })
+	t.Run("UnitProgress", func(t *testing.T) { testUnitProgress(t, newRepo(t)) })
+}
+
+func newUnit(classID uuid.UUID, title string, order int) *models.Unit {
+	return &models.Unit{
+		ID:           uuid.New(),
+		ClassID:      classID,
+		Title:        title,
+		DisplayOrder: order,
+		// Backends store at least microsecond precision.
+		CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+}
+
+func assertUnit(t *testing.T, got, want *models.Unit) {
+	if got.ID != want.ID || got.ClassID != want.ClassID || got.Title != want.Title || got.DisplayOrder != want.DisplayOrder ||
+		got.Description != want.Description || !got.Metadata.Equal(want.Metadata) ||
+		(got.ParentID == nil) != (want.ParentID == nil) || got.ParentID != nil && *got.ParentID != *want.ParentID ||
+		!got.Resources.Equal(want.Resources) || got.Status.OrPublished() != want.Status.OrPublished() {
+		t.Errorf("got unit %+v, want %+v", got, want)
+	}
+	if !got.CreatedAt.Equal(want.CreatedAt) {
+		t.Errorf("got created_at %v, want %v", got.CreatedAt, want.CreatedAt)
+	}
+	if !sameTime(got.StartsAt, want.StartsAt) || !sameTime(got.EndsAt, want.EndsAt) {
+		t.Errorf("got schedule %v to %v, want %v to %v", got.StartsAt, got.EndsAt, want.StartsAt, want.EndsAt)
+	}
+}
+
+// sameTime reports whether a and b are the same instant or are both nil.
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func newAuditEntry(action string, before, after *models.Unit, at time.Time) *models.AuditEntry {
+	unit := after
+	if unit == nil {
+		unit = before
+	}
+	return &models.AuditEntry{
+		ID:        uuid.New(),
+		UnitID:    unit.ID,
+		ClassID:   unit.ClassID,
+		Action:    action,
+		Actor:     uuid.New(),
+		ClientID:  "conformance-client",
+		RequestID: "conformance",
+		Before:    before,
+		After:     after,
+		CreatedAt: at,
+	}
+}
+
+func assertAuditEntry(t *testing.T, got, want *models.AuditEntry) {
+	if got.ID != want.ID || got.UnitID != want.UnitID || got.ClassID != want.ClassID || got.Action != want.Action ||
+		got.Actor != want.Actor || got.ClientID != want.ClientID || got.RequestID != want.RequestID {
+		t.Errorf("got audit entry %+v, want %+v", got, want)
+	}
+	if !got.CreatedAt.Equal(want.CreatedAt) {
+		t.Errorf("got audit created_at %v, want %v", got.CreatedAt, want.CreatedAt)
+	}
+	for _, state := range []struct {
+		name      string
+		got, want *models.Unit
+	}{{"before", got.Before, want.Before}, {"after", got.After, want.After}} {
+		switch {
+		case state.want == nil && state.got != nil:
+			t.Errorf("got %s state %+v, want none", state.name, state.got)
+		case state.want != nil && state.got == nil:
+			t.Errorf("got no %s state, want %+v", state.name, state.want)
+		case state.want != nil:
+			assertUnit(t, state.got, state.want)
+		}
+	}
+}
+
+func newVersion(unit *models.Unit, version int) *models.UnitVersion {
+	return &models.UnitVersion{
+		UnitID:    unit.ID,
+		Version:   version,
+		Unit:      unit,
+		Author:    uuid.New(),
+		CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+}
+
+func assertVersions(t *testing.T, got, want []*models.UnitVersion) {
+	if len(got) != len(want) {
+		t.Fatalf("got %d versions, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].UnitID != want[i].UnitID || got[i].Version != want[i].Version || got[i].Author != want[i].Author {
+			t.Errorf("got version %+v, want %+v", got[i], want[i])
+		}
+		if !got[i].CreatedAt.Equal(want[i].CreatedAt) {
+			t.Errorf("got version created_at %v, want %v", got[i].CreatedAt, want[i].CreatedAt)
+		}
+		assertUnit(t, got[i].Unit, want[i].Unit)
+	}
+}
+
+func testSaveAndGet(t *testing.T, repo unitsvc.Repository) {
+	ctx := context.Background()
+	want := newUnit(uuid.New(), "Photosynthesis", 0)
+	want.Description = "How plants turn light into sugar."
+	want.Metadata = models.Metadata{"standard": "HS-LS1-5", "weeks": "2"}
+	parentID := uuid.New()
+	want.ParentID = &parentID
+	want.Resources = models.Resources{{Title: "Leaf lab", URL: "https://example.com/leaf-lab"}}
+	want.Status = models.StatusDraft
+	startsAt := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	want.StartsAt = &startsAt
+	if err := repo.SaveUnit(ctx, want); err != nil {
+		t.Fatalf("SaveUnit: %v", err)
+	}
+	got, err := repo.UnitByID(ctx, want.ID)
+	if err != nil {
+		t.Fatalf("UnitByID: %v", err)
+	}
+	assertUnit(t, got, want)
+}
+
+func testGetMissing(t *testing.T, repo unitsvc.Repository) {
+	if _, err := repo.UnitByID(context.Background(), uuid.New()); err != unitsvc.ErrNotFound {
+		t.Fatalf("UnitByID of missing unit: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+}
+
+func testSaveUpdatesExisting(t *testing.T, repo unitsvc.Repository) {
+	ctx := context.Background()
+	unit := newUnit(uuid.New(), "Cells", 0)
+	unit.Metadata = models.Metadata{"weeks": "3"}
+	parentID := uuid.New()
+	unit.ParentID = &parentID
+	if err := repo.SaveUnit(ctx, unit); err != nil {
+		t.Fatalf("SaveUnit: %v", err)
+	}
+	// Update both a unit read back from the repository and a fresh copy with the same ID.
+	stored, err := repo.UnitByID(ctx, unit.ID)
+	if err != nil {
+		t.Fatalf("UnitByID: %v", err)
+	}
+	stored.Title = "Cell Biology"
+	if err := repo.SaveUnit(ctx, stored); err != nil {
+		t.Fatalf("SaveUnit of stored unit: %v", err)
+	}
+	fresh := *unit
+	fresh.DisplayOrder = 3
+	fresh.Title = "Cell Structure"
+	fresh.Description = "Organelles and membranes."
+	fresh.Metadata, fresh.ParentID = nil, nil
+	fresh.Resources = models.Resources{{Title: "Cell atlas", URL: "https://example.com/cells"}}
+	if err := repo.SaveUnit(ctx, &fresh); err != nil {
+		t.Fatalf("SaveUnit of existing ID: %v", err)
+	}
+	got, err := repo.UnitByID(ctx, unit.ID)
+	if err != nil {
+		t.Fatalf("UnitByID: %v", err)
+	}
+	assertUnit(t, got, &fresh)
+	units, err := repo.UnitsByClassID(ctx, unit.ClassID)
+	if err != nil {
+		t.Fatalf("UnitsByClassID: %v", err)
+	}
+	if len(units) != 1 {
+		t.Fatalf("got %d units after updates, want 1", len(units))
+	}
+}
+
+func testUnitsByClassID(t *testing.T, repo unitsvc.Repository) {
+	ctx := context.Background()
+	classID := uuid.New()
+	// Saved out of order; expected back sorted by display order.
+	want := []*models.Unit{
+		newUnit(classID, "First", 0),
+		newUnit(classID, "Second", 1),
+		newUnit(classID, "Third", 2),
+	}
+	for _, i := range []int{2, 0, 1} {
+		if err := repo.SaveUnit(ctx, want[i]); err != nil {
+			t.Fatalf("SaveUnit: %v", err)
+		}
+	}
+	if err := repo.SaveUnit(ctx, newUnit(uuid.New(), "Other class", 0)); err != nil {
+		t.Fatalf("SaveUnit: %v", err)
+	}
+	got, err := repo.UnitsByClassID(ctx, classID)
+	if err != nil {
+		t.Fatalf("UnitsByClassID: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d units, want %d", len(got), len(want))
+	}
+	for i := range want {
+		assertUnit(t, got[i], want[i])
+	}
+}
+
+func testUnitsByClassIDEmpty(t *testing.T, repo unitsvc.Repository) {
+	units, err := repo.UnitsByClassID(context.Background(), uuid.New())
+	if err != nil {
+		t.Fatalf("UnitsByClassID: %v", err)
+	}
+	if len(units) != 0 {
+		t.Fatalf("got %d units for empty class, want 0", len(units))
+	}
+}
+
+func testUnitsByClassIDs(t *testing.T, repo unitsvc.Repository) {
+	ctx := context.Background()
+	low, high, other := uuid.New(), uuid.New(), uuid.New()
+	if bytes.Compare(low[:], high[:]) > 0 {
+		low, high = high, low
+	}
+	for _, u := range []*models.Unit{
+		newUnit(high, "High 1", 1),
+		newUnit(low, "Low 0", 0),
+		newUnit(other, "Other", 0),
+		newUnit(high, "High 0", 0),
+	} {
+		if err := repo.SaveUnit(ctx, u); err != nil {
+			t.Fatalf("SaveUnit: %v", err)
+		}
+	}
+
+	units, err := repo.UnitsByClassIDs(ctx, []uuid.UUID{high, low}, unitsvc.UnitFilter{})
+	if err != nil {
+		t.Fatalf("UnitsByClassIDs: %v", err)
+	}
+	var titles []string
+	for _, u := range units {
+		titles = append(titles, u.Title)
+	}
+	if got, want := fmt.Sprint(titles), "[Low 0 High 0 High 1]"; got != want {
+		t.Errorf("got units %s, want %s", got, want)
+	}
+
+	if units, err := repo.UnitsByClassIDs(ctx, nil, unitsvc.UnitFilter{}); err != nil || len(units) != 0 {
+		t.Errorf("UnitsByClassIDs with no classes: got %d units, %v", len(units), err)
+	}
+}
+
+func testUnitsByClassIDsFilter(t *testing.T, repo unitsvc.Repository) {
+	ctx := context.Background()
+	classID := uuid.New()
+	day := func(month time.Month, day int) *time.Time {
+		t := time.Date(2026, month, day, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+	for i, u := range []struct {
+		title            string
+		status           models.UnitStatus
+		startsAt, endsAt *time.Time
+	}{
+		{"Outline", models.StatusDraft, nil, nil},
+		{"January", models.StatusPublished, day(1, 1), day(1, 31)},
+		{"February", "", day(2, 1), day(2, 28)},
+		{"Spring", models.StatusArchived, day(2, 15), nil},
+		{"Review", models.StatusDraft, nil, day(2, 10)},
+		{"Reference", "", nil, nil},
+	} {
+		unit := newUnit(classID, u.title, i)
+		unit.Status, unit.StartsAt, unit.EndsAt = u.status, u.startsAt, u.endsAt
+		if err := repo.SaveUnit(ctx, unit); err != nil {
+			t.Fatalf("SaveUnit: %v", err)
+		}
+	}
+
+	for _, tt := range []struct {
+		name   string
+		filter unitsvc.UnitFilter
+		want   string
+	}{
+		{"none", unitsvc.UnitFilter{}, "[Outline January February Spring Review Reference]"},
+		{"published", unitsvc.UnitFilter{Statuses: []models.UnitStatus{models.StatusPublished}}, "[January February Reference]"},
+		{"draft or archived", unitsvc.UnitFilter{Statuses: []models.UnitStatus{models.StatusDraft, models.StatusArchived}}, "[Outline Spring Review]"},
+		{"window", unitsvc.UnitFilter{From: day(2, 5), To: day(2, 20)}, "[February Spring Review]"},
+		{"window between units", unitsvc.UnitFilter{From: day(1, 31), To: day(2, 1)}, "[Review]"},
+		{"from", unitsvc.UnitFilter{From: day(3, 1)}, "[Spring]"},
+		{"to", unitsvc.UnitFilter{To: day(1, 15)}, "[January Review]"},
+		{"draft in window", unitsvc.UnitFilter{Statuses: []models.UnitStatus{models.StatusDraft}, From: day(2, 5), To: day(2, 20)}, "[Review]"},
+	} {
+		units, err := repo.UnitsByClassIDs(ctx, []uuid.UUID{classID}, tt.filter)
+		if err != nil {
+			t.Fatalf("UnitsByClassIDs %s: %v", tt.name, err)
+		}
+		var titles []string
+		for _, u := range units {
+			titles = append(titles, u.Title)
+		}
+		if got := fmt.Sprint(titles); got != tt.want {
+			t.Errorf("UnitsByClassIDs %s: got units %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func testDelete(t *testing.T, repo unitsvc.Repository) {
+	ctx := context.Background()
+	unit := newUnit(uuid.New(), "Genetics", 0)
+	if err := repo.SaveUnit(ctx, unit); err != nil {
+		t.Fatalf("SaveUnit: %v", err)
+	}
+	if err := repo.DeleteUnit(ctx, unit.ID); err != nil {
+		t.Fatalf("DeleteUnit: %v", err)
+	}
+	if _, err := repo.UnitByID(ctx, unit.ID); err != unitsvc.ErrNotFound {
+		t.Fatalf("UnitByID after delete: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+	units, err := repo.UnitsByClassID(ctx, unit.ClassID)
+	if err != nil {
+		t.Fatalf("UnitsByClassID: %v", err)
+	}
+	if len(units) != 0 {
+		t.Fatalf("got %d units after delete, want 0", len(units))
+	}
+}
+
+func testDeleteMissing(t *testing.T, repo unitsvc.Repository) {
+	if err := repo.DeleteUnit(context.Background(), uuid.New()); err != nil {
+		t.Fatalf("DeleteUnit of missing unit: %v", err)
+	}
+}
+
+func testClassIDs(t *testing.T, repo unitsvc.Repository) {
+	ctx := context.Background()
+	want := make(map[uuid.UUID]bool)
+	for i := 0; i < 5; i++ {
+		classID := uuid.New()
+		want[classID] = true
+		// Classes with several units are listed once.
+		for j := 0; j < 2; j++ {
+			if err := repo.SaveUnit(ctx, newUnit(classID, fmt.Sprintf("Unit %d", j), j)); err != nil {
+				t.Fatalf("SaveUnit: %v", err)
+			}
+		}
+	}
+
+	// Page through every class, in pages smaller than the number of classes.
+	seen := make(map[uuid.UUID]bool)
+	after := uuid.Nil
+	for {
+		page, err := repo.ClassIDs(ctx, after, 2)
+		if err != nil {
+			t.Fatalf("ClassIDs: %v", err)
+		}
+		if len(page) > 2 {
+			t.Fatalf("got %d class IDs, want at most 2", len(page))
+		}
+		if len(page) == 0 {
+			break
+		}
+		for _, id := range page {
+			if bytes.Compare(id[:], after[:]) <= 0 {
+				t.Fatalf("got class ID %v after %v, want ascending IDs", id, after)
+			}
+			if seen[id] {
+				t.Fatalf("got class ID %v twice", id)
+			}
+			seen[id] = true
+			after = id
+		}
+	}
+	for id := range want {
+		if !seen[id] {
+			t.Errorf("class %v was not listed", id)
+		}
+	}
+}
+
+func testSearchUnits(t *testing.T, repo unitsvc.Repository) {
+	ctx := context.Background()
+	biology, other := uuid.New(), uuid.New()
+	for i, title := range []string{"Cells", "Cell Division", "Photosynthesis", "The Cell Membrane and Cell Transport"} {
+		if err := repo.SaveUnit(ctx, newUnit(biology, title, i)); err != nil {
+			t.Fatalf("SaveUnit: %v", err)
+		}
+	}
+	if err := repo.SaveUnit(ctx, newUnit(other, "Cell Theory", 0)); err != nil {
+		t.Fatalf("SaveUnit: %v", err)
+	}
+	described := newUnit(biology, "Respiration", 5)
+	described.Description = "How mitochondria release energy across their inner membrane."
+	if err := repo.SaveUnit(ctx, described); err != nil {
+		t.Fatalf("SaveUnit: %v", err)
+	}
+	// Renamed units are found by their new title.
+	renamed := newUnit(biology, "Genetics", 4)
+	if err := repo.SaveUnit(ctx, renamed); err != nil {
+		t.Fatalf("SaveUnit: %v", err)
+	}
+	renamed.Title = "Evolution"
+	if err := repo.SaveUnit(ctx, renamed); err != nil {
+		t.Fatalf("SaveUnit: %v", err)
+	}
+
+	search := func(query string, classIDs []uuid.UUID, limit int) []*models.UnitMatch {
+		matches, err := repo.SearchUnits(ctx, query, classIDs, limit)
+		if err != nil {
+			t.Fatalf("SearchUnits(%q): %v", query, err)
+		}
+		return matches
+	}
+	matches := search("photosynthesis", []uuid.UUID{biology, other}, 10)
+	if len(matches) != 1 || matches[0].Unit.Title != "Photosynthesis" || matches[0].Unit.ClassID != biology {
+		t.Fatalf("got %d matches for photosynthesis, want the Photosynthesis unit", len(matches))
+	}
+	if matches[0].Snippet != "<mark>Photosynthesis</mark>" {
+		t.Errorf("got snippet %q, want the title highlighted", matches[0].Snippet)
+	}
+
+	// Only the given classes are searched, best match first.
+	matches = search("cell", []uuid.UUID{biology}, 10)
+	if len(matches) != 3 {
+		t.Fatalf("got %d matches for cell, want 3", len(matches))
+	}
+	for i, m := range matches {
+		if m.Unit.ClassID != biology {
+			t.Errorf("got a match in class %v, which was not searched", m.Unit.ClassID)
+		}
+		if i > 0 && m.Rank > matches[i-1].Rank {
+			t.Errorf("got match %q ranked above %q", matches[i-1].Unit.Title, m.Unit.Title)
+		}
+	}
+	if matches := search("cell division", []uuid.UUID{biology}, 10); len(matches) != 1 || matches[0].Unit.Title != "Cell Division" {
+		t.Errorf("got %d matches for cell division, want Cell Division", len(matches))
+	}
+	if matches := search("cell", []uuid.UUID{biology, other}, 2); len(matches) != 2 {
+		t.Errorf("got %d matches with limit 2", len(matches))
+	}
+	if matches := search("genetics", []uuid.UUID{biology}, 10); len(matches) != 0 {
+		t.Errorf("got %d matches for a unit's old title, want none", len(matches))
+	}
+	if matches := search("evolution", []uuid.UUID{biology}, 10); len(matches) != 1 {
+		t.Errorf("got %d matches for a unit's new title, want 1", len(matches))
+	}
+	// Descriptions are searched too, below titles.
+	if matches := search("mitochondria", []uuid.UUID{biology}, 10); len(matches) != 1 || matches[0].Unit.ID != described.ID || matches[0].Snippet != "Respiration" {
+		t.Errorf("got %d matches for a word of a description, want Respiration with its title as the snippet", len(matches))
+	}
+	if matches := search("membrane", []uuid.UUID{biology}, 10); len(matches) != 2 || matches[0].Unit.Title != "The Cell Membrane and Cell Transport" {
+		t.Errorf("got %d matches for membrane, want the title match before the description match", len(matches))
+	}
+	if matches := search("cell", nil, 10); len(matches) != 0 {
+		t.Errorf("got %d matches in no classes, want none", len(matches))
+	}
+}
+
+func testTransactCommit(t *testing.T, repo unitsvc.Repository) {
+	ctx := context.Background()
+	unit := newUnit(uuid.New(), "Ecology", 0)
+	err := repo.Transact(ctx, func(tx unitsvc.Repository) error {
+		if err := tx.SaveUnit(ctx, unit); err != nil {
+			return err
+		}
+		// Writes are visible inside the transaction.
+		_, err := tx.UnitByID(ctx, unit.ID)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Transact: %v", err)
+	}
+	got, err := repo.UnitByID(ctx, unit.ID)
+	if err != nil {
+		t.Fatalf("UnitByID after commit: %v", err)
+	}
+	assertUnit(t, got, unit)
+}
+
+func testTransactRollback(t *testing.T, repo unitsvc.Repository) {
+	ctx := context.Background()
+	kept := newUnit(uuid.New(), "Evolution", 0)
+	if err := repo.SaveUnit(ctx, kept); err != nil {
+		t.Fatalf("SaveUnit: %v", err)
+	}
+	discarded := newUnit(kept.ClassID, "Discarded", 1)
+	errAbort := errors.New("abort")
+	err := repo.Transact(ctx, func(tx unitsvc.Repository) error {
+		if err := tx.SaveUnit(ctx, discarded); err != nil {
+			return err
+		}
+		if err := tx.DeleteUnit(ctx, kept.ID); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if err != errAbort {
+		t.Fatalf("Transact: got error %v, want %v", err, errAbort)
+	}
+	if _, err := repo.UnitByID(ctx, discarded.ID); err != unitsvc.ErrNotFound {
+		t.Errorf("UnitByID of rolled back insert: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+	if _, err := repo.UnitByID(ctx, kept.ID); err != nil {
+		t.Errorf("UnitByID of rolled back delete: %v", err)
+	}
+}
+
+// testTransactRollbackRestores checks that rolling back restores rows that were updated,
+// pruned or deleted in the transaction, not just that inserts are discarded.
+func testTransactRollbackRestores(t *testing.T, repo unitsvc.Repository) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	unit := newUnit(uuid.New(), "Genetics", 0)
+	versions := []*models.UnitVersion{newVersion(unit, 1), newVersion(unit, 2)}
+	cu := &models.CurrentUnit{ClassID: unit.ClassID, UnitID: unit.ID, UpdatedBy: uuid.New(), UpdatedAt: now}
+	progress := &models.UnitProgress{UnitID: unit.ID, UserID: uuid.New(), ClassID: unit.ClassID, Status: models.ProgressInProgress, StartedAt: &now, UpdatedAt: now}
+	if err := repo.SaveUnit(ctx, unit); err != nil {
+		t.Fatalf("SaveUnit: %v", err)
+	}
+	for _, v := range versions {
+		if err := repo.AppendVersion(ctx, v); err != nil {
+			t.Fatalf("AppendVersion: %v", err)
+		}
+	}
+	if err := repo.SaveCurrentUnit(ctx, cu); err != nil {
+		t.Fatalf("SaveCurrentUnit: %v", err)
+	}
+	if err := repo.SaveUnitProgress(ctx, progress); err != nil {
+		t.Fatalf("SaveUnitProgress: %v", err)
+	}
+
+	errAbort := errors.New("abort")
+	err := repo.Transact(ctx, func(tx unitsvc.Repository) error {
+		renamed := *unit
+		renamed.Title = "Heredity"
+		if err := tx.SaveUnit(ctx, &renamed); err != nil {
+			return err
+		}
+		if err := tx.PruneVersions(ctx, unit.ID, 1); err != nil {
+			return err
+		}
+		if err := tx.AppendVersion(ctx, newVersion(&renamed, 3)); err != nil {
+			return err
+		}
+		if err := tx.SaveCurrentUnit(ctx, &models.CurrentUnit{ClassID: unit.ClassID, UnitID: uuid.New(), UpdatedAt: now}); err != nil {
+			return err
+		}
+		if err := tx.DeleteUnitProgress(ctx, unit.ID); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if err != errAbort {
+		t.Fatalf("Transact: got error %v, want %v", err, errAbort)
+	}
+
+	got, err := repo.UnitByID(ctx, unit.ID)
+	if err != nil {
+		t.Fatalf("UnitByID: %v", err)
+	}
+	assertUnit(t, got, unit)
+	gotVersions, err := repo.VersionsByUnitID(ctx, unit.ID)
+	if err != nil {
+		t.Fatalf("VersionsByUnitID: %v", err)
+	}
+	assertVersions(t, gotVersions, versions)
+	if got, err := repo.CurrentUnitByClassID(ctx, unit.ClassID); err != nil || got.UnitID != unit.ID {
+		t.Errorf("CurrentUnitByClassID: got %+v, %v; want unit %v", got, err, unit.ID)
+	}
+	if _, err := repo.UnitProgressByUser(ctx, unit.ID, progress.UserID); err != nil {
+		t.Errorf("UnitProgressByUser of rolled back delete: %v", err)
+	}
+}
+
+func testAuditAppendAndQuery(t *testing.T, repo unitsvc.Repository) {
+	ctx := context.Background()
+	created := newUnit(uuid.New(), "Optics", 0)
+	renamed := *created
+	renamed.Title = "Light"
+	other := newUnit(created.ClassID, "Waves", 1)
+	at := time.Now().UTC().Truncate(time.Millisecond)
+	want := []*models.AuditEntry{
+		newAuditEntry(models.AuditCreate, nil, created, at),
+		newAuditEntry(models.AuditCreate, nil, other, at.Add(time.Millisecond)),
+		newAuditEntry(models.AuditRename, created, &renamed, at.Add(2*time.Millisecond)),
+		newAuditEntry(models.AuditDelete, &renamed, nil, at.Add(3*time.Millisecond)),
+	}
+	want[0].Actor, want[0].ClientID, want[0].RequestID = uuid.Nil, "", ""
+	for _, e := range want {
+		if err := repo.AppendAudit(ctx, e); err != nil {
+			t.Fatalf("AppendAudit: %v", err)
+		}
+	}
+	if err := repo.AppendAudit(ctx, newAuditEntry(models.AuditCreate, nil, newUnit(uuid.New(), "Other class", 0), at)); err != nil {
+		t.Fatalf("AppendAudit: %v", err)
+	}
+
+	history, err := repo.AuditByUnitID(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("AuditByUnitID: %v", err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("got %d history entries, want 3", len(history))
+	}
+	for i, j := range []int{0, 2, 3} {
+		assertAuditEntry(t, history[i], want[j])
+	}
+
+	audit, err := repo.AuditByClassID(ctx, created.ClassID)
+	if err != nil {
+		t.Fatalf("AuditByClassID: %v", err)
+	}
+	if len(audit) != len(want) {
+		t.Fatalf("got %d class audit entries, want %d", len(audit), len(want))
+	}
+	for i := range want {
+		assertAuditEntry(t, audit[i], want[i])
+	}
+
+	empty, err := repo.AuditByUnitID(ctx, uuid.New())
+	if err != nil {
+		t.Fatalf("AuditByUnitID of unknown unit: %v", err)
+	}
+	if len(empty) != 0 {
+		t.Fatalf("got %d history entries for unknown unit, want 0", len(empty))
+	}
+}
+
+func testAuditPurge(t *testing.T, repo unitsvc.Repository) {
+	ctx := context.Background()
+	unit := newUnit(uuid.New(), "Magnetism", 0)
+	// Far enough in the past that no other test's entries are purged.
+	old := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+	expired := newAuditEntry(models.AuditCreate, nil, unit, old)
+	kept := newAuditEntry(models.AuditRename, unit, unit, time.Now().UTC().Truncate(time.Millisecond))
+	for _, e := range []*models.AuditEntry{expired, kept} {
+		if err := repo.AppendAudit(ctx, e); err != nil {
+			t.Fatalf("AppendAudit: %v", err)
+		}
+	}
+	n, err := repo.PurgeAudit(ctx, old.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("PurgeAudit: %v", err)
+	}
+	if n < 1 {
+		t.Errorf("PurgeAudit: purged %d entries, want at least 1", n)
+	}
+	history, err := repo.AuditByUnitID(ctx, unit.ID)
+	if err != nil {
+		t.Fatalf("AuditByUnitID: %v", err)
+	}
+	if len(history) != 1 {
+		t.Fatalf("got %d history entries after purge, want 1", len(history))
+	}
+	assertAuditEntry(t, history[0], kept)
+}
+
+func testAuditTransactRollback(t *testing.T, repo unitsvc.Repository) {
+	ctx := context.Background()
+	unit := newUnit(uuid.New(), "Thermodynamics", 0)
+	errAbort := errors.New("abort")
+	err := repo.Transact(ctx, func(tx unitsvc.Repository) error {
+		if err := tx.SaveUnit(ctx, unit); err != nil {
+			return err
+		}
+		if err := tx.AppendAudit(ctx, newAuditEntry(models.AuditCreate, nil, unit, time.Now().UTC())); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if err != errAbort {
+		t.Fatalf("Transact: got error %v, want %v", err, errAbort)
+	}
+	history, err := repo.AuditByUnitID(ctx, unit.ID)
+	if err != nil {
+		t.Fatalf("AuditByUnitID: %v", err)
+	}
+	if len(history) != 0 {
+		t.Fatalf("got %d history entries after rollback, want 0", len(history))
+	}
+}
+
+func testVersions(t *testing.T, repo unitsvc.Repository) {
+	ctx := context.Background()
+	unit := newUnit(uuid.New(), "Acids", 0)
+	renamed := *unit
+	renamed.Title = "Acids and Bases"
+	want := []*models.UnitVersion{newVersion(unit, 1), newVersion(&renamed, 2)}
+	for _, v := range want {
+		if err := repo.AppendVersion(ctx, v); err != nil {
+			t.Fatalf("AppendVersion: %v", err)
+		}
+	}
+	if err := repo.AppendVersion(ctx, newVersion(newUnit(unit.ClassID, "Other unit", 1), 1)); err != nil {
+		t.Fatalf("AppendVersion: %v", err)
+	}
+	got, err := repo.VersionsByUnitID(ctx, unit.ID)
+	if err != nil {
+		t.Fatalf("VersionsByUnitID: %v", err)
+	}
+	assertVersions(t, got, want)
+
+	none, err := repo.VersionsByUnitID(ctx, uuid.New())
+	if err != nil {
+		t.Fatalf("VersionsByUnitID of unknown unit: %v", err)
+	}
+	if len(none) != 0 {
+		t.Fatalf("got %d versions of unknown unit, want 0", len(none))
+	}
+}
+
+func testVersionsDuplicate(t *testing.T, repo unitsvc.Repository) {
+	ctx := context.Background()
+	unit := newUnit(uuid.New(), "Bonds", 0)
+	if err := repo.AppendVersion(ctx, newVersion(unit, 1)); err != nil {
+		t.Fatalf("AppendVersion: %v", err)
+	}
+	if err := repo.AppendVersion(ctx, newVersion(unit, 1)); err == nil {
+		t.Fatalf("AppendVersion of existing version: got no error")
+	}
+}
+
+func testPruneVersions(t *testing.T, repo unitsvc.Repository) {
+	ctx := context.Background()
+	unit := newUnit(uuid.New(), "Reactions", 0)
+	var all []*models.UnitVersion
+	for i := 1; i <= 5; i++ {
+		v := newVersion(unit, i)
+		all = append(all, v)
+		if err := repo.AppendVersion(ctx, v); err != nil {
+			t.Fatalf("AppendVersion: %v", err)
+		}
+	}
+	versions := func() []*models.UnitVersion {
+		got, err := repo.VersionsByUnitID(ctx, unit.ID)
+		if err != nil {
+			t.Fatalf("VersionsByUnitID: %v", err)
+		}
+		return got
+	}
+
+	if err := repo.PruneVersions(ctx, unit.ID, 10); err != nil {
+		t.Fatalf("PruneVersions: %v", err)
+	}
+	assertVersions(t, versions(), all)
+	if err := repo.PruneVersions(ctx, unit.ID, 2); err != nil {
+		t.Fatalf("PruneVersions: %v", err)
+	}
+	assertVersions(t, versions(), all[3:])
+	if err := repo.PruneVersions(ctx, unit.ID, 0); err != nil {
+		t.Fatalf("PruneVersions: %v", err)
+	}
+	assertVersions(t, versions(), nil)
+}
+
+func newWebhook(classID uuid.UUID, events ...string) *models.Webhook {
+	if events == nil {
+		events = []string{}
+	}
+	return &models.Webhook{
+		ID:        uuid.New(),
+		ClassID:   classID,
+		URL:       "https://lms.example.com/hooks/" + uuid.New().String(),
+		Secret:    "s3cret",
+		Events:    events,
+		CreatedBy: uuid.New(),
+		CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+}
+
+func assertWebhook(t *testing.T, got, want *models.Webhook) {
+	if got.ID != want.ID || got.ClassID != want.ClassID || got.URL != want.URL || got.Secret != want.Secret ||
+		fmt.Sprint(got.Events) != fmt.Sprint(want.Events) || got.CreatedBy != want.CreatedBy || !got.CreatedAt.Equal(want.CreatedAt) {
+		t.Errorf("got webhook %+v, want %+v", got, want)
+	}
+}
+
+func newDelivery(webhookID uuid.UUID, event string, at time.Time) *models.WebhookDelivery {
+	return &models.WebhookDelivery{
+		ID:            uuid.New(),
+		WebhookID:     webhookID,
+		Event:         event,
+		Payload:       []byte(`{"id":"` + uuid.New().String() + `"}`),
+		Status:        models.DeliveryPending,
+		NextAttemptAt: at,
+		CreatedAt:     at,
+		UpdatedAt:     at,
+	}
+}
+
+func assertDeliveries(t *testing.T, got, want []*models.WebhookDelivery) {
+	if len(got) != len(want) {
+		t.Fatalf("got %d deliveries, want %d", len(got), len(want))
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.ID != w.ID || g.WebhookID != w.WebhookID || g.Event != w.Event || string(g.Payload) != string(w.Payload) ||
+			g.Status != w.Status || g.Attempts != w.Attempts || g.LastError != w.LastError || g.LastStatusCode != w.LastStatusCode ||
+			!g.NextAttemptAt.Equal(w.NextAttemptAt) || !g.CreatedAt.Equal(w.CreatedAt) || !g.UpdatedAt.Equal(w.UpdatedAt) {
+			t.Errorf("got delivery %d %+v, want %+v", i, g, w)
+		}
+	}
+}
+
+func testWebhooks(t *testing.T, repo unitsvc.Repository) {
+	ctx := context.Background()
+	classID := uuid.New()
+	first := newWebhook(classID)
+	second := newWebhook(classID, unitsvc.SubjCreateUnit, unitsvc.SubjDeleteUnit)
+	second.CreatedAt = first.CreatedAt.Add(time.Second)
+	for _, h := range []*models.Webhook{first, second, newWebhook(uuid.New())} {
+		if err := repo.SaveWebhook(ctx, h); err != nil {
+			t.Fatalf("SaveWebhook: %v", err)
+		}
+	}
+
+	got, err := repo.WebhookByID(ctx, second.ID)
+	if err != nil {
+		t.Fatalf("WebhookByID: %v", err)
+	}
+	assertWebhook(t, got, second)
+	if _, err := repo.WebhookByID(ctx, uuid.New()); err != unitsvc.ErrNotFound {
+		t.Errorf("WebhookByID of missing webhook: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+
+	hooks, err := repo.WebhooksByClassID(ctx, classID)
+	if err != nil {
+		t.Fatalf("WebhooksByClassID: %v", err)
+	}
+	if len(hooks) != 2 {
+		t.Fatalf("got %d webhooks, want 2", len(hooks))
+	}
+	assertWebhook(t, hooks[0], first)
+	assertWebhook(t, hooks[1], second)
+
+	// Deleting a webhook deletes its deliveries too.
+	if err := repo.AppendDelivery(ctx, newDelivery(first.ID, unitsvc.SubjCreateUnit, first.CreatedAt)); err != nil {
+		t.Fatalf("AppendDelivery: %v", err)
+	}
+	if err := repo.DeleteWebhook(ctx, first.ID); err != nil {
+		t.Fatalf("DeleteWebhook: %v", err)
+	}
+	if _, err := repo.WebhookByID(ctx, first.ID); err != unitsvc.ErrNotFound {
+		t.Errorf("WebhookByID after delete: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+	deliveries, err := repo.DeliveriesByWebhookID(ctx, first.ID, "")
+	if err != nil {
+		t.Fatalf("DeliveriesByWebhookID: %v", err)
+	}
+	assertDeliveries(t, deliveries, nil)
+	if err := repo.DeleteWebhook(ctx, first.ID); err != nil {
+		t.Errorf("DeleteWebhook of missing webhook: %v", err)
+	}
+}
+
+func testDeliveries(t *testing.T, repo unitsvc.Repository) {
+	ctx := context.Background()
+	hook := newWebhook(uuid.New())
+	if err := repo.SaveWebhook(ctx, hook); err != nil {
+		t.Fatalf("SaveWebhook: %v", err)
+	}
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	later := newDelivery(hook.ID, unitsvc.SubjRenameUnit, now.Add(-time.Minute))
+	sooner := newDelivery(hook.ID, unitsvc.SubjCreateUnit, now.Add(-time.Hour))
+	future := newDelivery(hook.ID, unitsvc.SubjDeleteUnit, now.Add(-time.Second))
+	future.NextAttemptAt = now.Add(time.Hour)
+	for _, d := range []*models.WebhookDelivery{sooner, later, future} {
+		if err := repo.AppendDelivery(ctx, d); err != nil {
+			t.Fatalf("AppendDelivery: %v", err)
+		}
+	}
+
+	due, err := repo.DueDeliveries(ctx, now, 10)
+	if err != nil {
+		t.Fatalf("DueDeliveries: %v", err)
+	}
+	// Other tests may share the repository, so only this webhook's deliveries are checked.
+	var mine []*models.WebhookDelivery
+	for _, d := range due {
+		if d.WebhookID == hook.ID {
+			mine = append(mine, d)
+		}
+	}
+	assertDeliveries(t, mine, []*models.WebhookDelivery{sooner, later})
+
+	sooner.Status, sooner.Attempts, sooner.LastStatusCode, sooner.UpdatedAt = models.DeliveryDelivered, 1, 204, now
+	later.Status, later.Attempts, later.LastError, later.LastStatusCode, later.UpdatedAt = models.DeliveryDead, 3, "receiver responded with 500", 500, now
+	for _, d := range []*models.WebhookDelivery{sooner, later} {
+		if err := repo.UpdateDelivery(ctx, d); err != nil {
+			t.Fatalf("UpdateDelivery: %v", err)
+		}
+	}
+
+	all, err := repo.DeliveriesByWebhookID(ctx, hook.ID, "")
+	if err != nil {
+		t.Fatalf("DeliveriesByWebhookID: %v", err)
+	}
+	assertDeliveries(t, all, []*models.WebhookDelivery{sooner, later, future})
+	dead, err := repo.DeliveriesByWebhookID(ctx, hook.ID, models.DeliveryDead)
+	if err != nil {
+		t.Fatalf("DeliveriesByWebhookID: %v", err)
+	}
+	assertDeliveries(t, dead, []*models.WebhookDelivery{later})
+	due, err = repo.DueDeliveries(ctx, now.Add(2*time.Hour), 1)
+	if err != nil {
+		t.Fatalf("DueDeliveries: %v", err)
+	}
+	if len(due) != 1 {
+		t.Fatalf("got %d due deliveries with a limit of 1", len(due))
+	}
+}
+
+func testOneRoster(t *testing.T, repo unitsvc.Repository) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	if _, err := repo.OneRosterClassBySourcedID(ctx, "cls-1"); err != unitsvc.ErrNotFound {
+		t.Errorf("OneRosterClassBySourcedID of unlinked class: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+
+	// Linking a class again moves it to another class but keeps when it was first linked.
+	link := &models.OneRosterClass{SourcedID: "cls-1", ClassID: uuid.New(), CreatedAt: now}
+	if err := repo.SaveOneRosterClass(ctx, link); err != nil {
+		t.Fatalf("SaveOneRosterClass: %v", err)
+	}
+	classID := uuid.New()
+	if err := repo.SaveOneRosterClass(ctx, &models.OneRosterClass{SourcedID: "cls-1", ClassID: classID, CreatedAt: now.Add(time.Hour)}); err != nil {
+		t.Fatalf("SaveOneRosterClass: %v", err)
+	}
+	got, err := repo.OneRosterClassBySourcedID(ctx, "cls-1")
+	if err != nil {
+		t.Fatalf("OneRosterClassBySourcedID: %v", err)
+	}
+	if got.SourcedID != "cls-1" || got.ClassID != classID || !got.CreatedAt.Equal(now) {
+		t.Errorf("got link %+v, want cls-1 linked to %v at %v", got, classID, now)
+	}
+
+	b := &models.OneRosterUnit{ClassID: classID, SourcedID: "cat-b", UnitID: uuid.New(), Title: "Labs", SyncedAt: now}
+	a := &models.OneRosterUnit{ClassID: classID, SourcedID: "cat-a", UnitID: uuid.New(), Title: "Homework", SyncedAt: now}
+	other := &models.OneRosterUnit{ClassID: uuid.New(), SourcedID: "cat-a", UnitID: uuid.New(), Title: "Homework", SyncedAt: now}
+	for _, u := range []*models.OneRosterUnit{b, a, other} {
+		if err := repo.SaveOneRosterUnit(ctx, u); err != nil {
+			t.Fatalf("SaveOneRosterUnit: %v", err)
+		}
+	}
+	b.Title, b.SyncedAt = "Lab Reports", now.Add(time.Hour)
+	if err := repo.SaveOneRosterUnit(ctx, b); err != nil {
+		t.Fatalf("SaveOneRosterUnit: %v", err)
+	}
+	units, err := repo.OneRosterUnitsByClassID(ctx, classID)
+	if err != nil {
+		t.Fatalf("OneRosterUnitsByClassID: %v", err)
+	}
+	if len(units) != 2 {
+		t.Fatalf("got %d synced categories, want 2", len(units))
+	}
+	for i, want := range []*models.OneRosterUnit{a, b} {
+		if got := units[i]; got.ClassID != want.ClassID || got.SourcedID != want.SourcedID || got.UnitID != want.UnitID ||
+			got.Title != want.Title || !got.SyncedAt.Equal(want.SyncedAt) {
+			t.Errorf("got synced category %+v, want %+v", got, want)
+		}
+	}
+
+	if err := repo.DeleteOneRosterUnit(ctx, classID, "cat-a"); err != nil {
+		t.Fatalf("DeleteOneRosterUnit: %v", err)
+	}
+	if err := repo.DeleteOneRosterUnit(ctx, classID, "cat-a"); err != nil {
+		t.Errorf("DeleteOneRosterUnit of missing category: %v", err)
+	}
+	if units, err := repo.OneRosterUnitsByClassID(ctx, classID); err != nil || len(units) != 1 || units[0].SourcedID != "cat-b" {
+		t.Errorf("got synced categories %v (error %v) after delete, want cat-b", units, err)
+	}
+	if units, err := repo.OneRosterUnitsByClassID(ctx, other.ClassID); err != nil || len(units) != 1 {
+		t.Errorf("got %d synced categories (error %v) in other class, want 1", len(units), err)
+	}
+}
+
+func testCurrentUnits(t *testing.T, repo unitsvc.Repository) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	classID, otherID := uuid.New(), uuid.New()
+	if _, err := repo.CurrentUnitByClassID(ctx, classID); err != unitsvc.ErrNotFound {
+		t.Errorf("CurrentUnitByClassID of class on no unit: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+
+	other := &models.CurrentUnit{ClassID: otherID, UnitID: uuid.New(), UpdatedBy: uuid.New(), UpdatedAt: now}
+	cu := &models.CurrentUnit{ClassID: classID, UnitID: uuid.New(), UpdatedBy: uuid.New(), UpdatedAt: now}
+	for _, c := range []*models.CurrentUnit{other, cu} {
+		if err := repo.SaveCurrentUnit(ctx, c); err != nil {
+			t.Fatalf("SaveCurrentUnit: %v", err)
+		}
+	}
+	cu.UnitID, cu.UpdatedBy, cu.UpdatedAt = uuid.New(), uuid.Nil, now.Add(time.Hour)
+	if err := repo.SaveCurrentUnit(ctx, cu); err != nil {
+		t.Fatalf("SaveCurrentUnit: %v", err)
+	}
+	got, err := repo.CurrentUnitByClassID(ctx, classID)
+	if err != nil {
+		t.Fatalf("CurrentUnitByClassID: %v", err)
+	}
+	if got.ClassID != cu.ClassID || got.UnitID != cu.UnitID || got.UpdatedBy != cu.UpdatedBy || !got.UpdatedAt.Equal(cu.UpdatedAt) {
+		t.Errorf("got current unit %+v, want %+v", got, cu)
+	}
+
+	if err := repo.DeleteCurrentUnit(ctx, classID); err != nil {
+		t.Fatalf("DeleteCurrentUnit: %v", err)
+	}
+	if err := repo.DeleteCurrentUnit(ctx, classID); err != nil {
+		t.Errorf("DeleteCurrentUnit of class on no unit: %v", err)
+	}
+	if _, err := repo.CurrentUnitByClassID(ctx, classID); err != unitsvc.ErrNotFound {
+		t.Errorf("CurrentUnitByClassID after delete: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+	if got, err := repo.CurrentUnitByClassID(ctx, otherID); err != nil || got.UnitID != other.UnitID {
+		t.Errorf("got current unit %+v (error %v) of other class, want %v", got, err, other.UnitID)
+	}
+}
+
+func testUnitProgress(t *testing.T, repo unitsvc.Repository) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	classID, unitID, otherUnit := uuid.New(), uuid.New(), uuid.New()
+	student, other := uuid.New(), uuid.New()
+	if _, err := repo.UnitProgressByUser(ctx, unitID, student); err != unitsvc.ErrNotFound {
+		t.Errorf("UnitProgressByUser of unstarted unit: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+
+	started := now.Add(-time.Hour)
+	progress := []*models.UnitProgress{
+		{UnitID: unitID, UserID: student, ClassID: classID, Status: models.ProgressInProgress, StartedAt: &started, UpdatedAt: started},
+		{UnitID: otherUnit, UserID: student, ClassID: classID, Status: models.ProgressNotStarted, UpdatedAt: now},
+		{UnitID: unitID, UserID: other, ClassID: classID, Status: models.ProgressInProgress, StartedAt: &started, UpdatedAt: now},
+		{UnitID: uuid.New(), UserID: student, ClassID: uuid.New(), Status: models.ProgressInProgress, StartedAt: &now, UpdatedAt: now},
+	}
+	for _, p := range progress {
+		if err := repo.SaveUnitProgress(ctx, p); err != nil {
+			t.Fatalf("SaveUnitProgress: %v", err)
+		}
+	}
+	completed := &models.UnitProgress{UnitID: unitID, UserID: student, ClassID: classID, Status: models.ProgressCompleted, StartedAt: &started, CompletedAt: &now, UpdatedAt: now}
+	if err := repo.SaveUnitProgress(ctx, completed); err != nil {
+		t.Fatalf("SaveUnitProgress: %v", err)
+	}
+	got, err := repo.UnitProgressByUser(ctx, unitID, student)
+	if err != nil {
+		t.Fatalf("UnitProgressByUser: %v", err)
+	}
+	if got.UnitID != unitID || got.UserID != student || got.ClassID != classID || got.Status != models.ProgressCompleted ||
+		got.StartedAt == nil || !got.StartedAt.Equal(started) || got.CompletedAt == nil || !got.CompletedAt.Equal(now) || !got.UpdatedAt.Equal(now) {
+		t.Errorf("got progress %+v, want %+v", got, completed)
+	}
+	got, err = repo.UnitProgressByUser(ctx, otherUnit, student)
+	if err != nil {
+		t.Fatalf("UnitProgressByUser: %v", err)
+	}
+	if got.Status != models.ProgressNotStarted || got.StartedAt != nil || got.CompletedAt != nil {
+		t.Errorf("got progress %+v, want not started without timestamps", got)
+	}
+
+	all, err := repo.UnitProgressByClassID(ctx, classID)
+	if err != nil {
+		t.Fatalf("UnitProgressByClassID: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("got %d progress in class, want 3", len(all))
+	}
+	for i := 1; i < len(all); i++ {
+		a, b := all[i-1], all[i]
+		if c := bytes.Compare(a.UserID[:], b.UserID[:]); c > 0 || c == 0 && bytes.Compare(a.UnitID[:], b.UnitID[:]) > 0 {
+			t.Errorf("progress not sorted by student and unit: %v/%v before %v/%v", a.UserID, a.UnitID, b.UserID, b.UnitID)
+		}
+	}
+
+	if err := repo.DeleteUnitProgress(ctx, unitID); err != nil {
+		t.Fatalf("DeleteUnitProgress: %v", err)
+	}
+	if err := repo.DeleteUnitProgress(ctx, unitID); err != nil {
+		t.Errorf("DeleteUnitProgress of unit without progress: %v", err)
+	}
+	if all, err := repo.UnitProgressByClassID(ctx, classID); err != nil || len(all) != 1 || all[0].UnitID != otherUnit {
+		t.Errorf("got progress %v (error %v) after delete, want only %v", all, err, otherUnit)
+	}
+}
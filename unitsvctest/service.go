@@ -0,0 +1,1534 @@
+package unitsvctest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/studiously/classsvc/classsvc"
+	"github.com/studiously/classsvc/models"
+	unitsvcmodels "github.com/studiously/unitsvc/models"
+	"github.com/studiously/unitsvc/unitsvc"
+	unittesting "github.com/studiously/unitsvc/unitsvc/testing"
+)
+
+// ServiceFactory returns the Service under test, authorizing requests against cs.
+// Like repositories, services may share storage between calls.
+type ServiceFactory func(t *testing.T, cs classsvc.Service) unitsvc.Service
+
+// RunConformance runs the service conformance suite against the Service returned by newService.
+// The service is wrapped in unitsvc.MessagingMiddleware so that event emission is checked too.
+func RunConformance(t *testing.T, newService ServiceFactory) {
+	tests := []struct {
+		name string
+		fn   func(*testing.T, *fixture)
+	}{
+		{"ListUnitsOrdered", testListUnitsOrdered},
+		{"ListUnitsEmpty", testListUnitsEmpty},
+		{"ListUnitsNotMember", testListUnitsNotMember},
+		{"GetUnit", testGetUnit},
+		{"GetUnitNotFound", testGetUnitNotFound},
+		{"GetUnitNotMember", testGetUnitNotMember},
+		{"CreateUnitNotMember", testCreateUnitNotMember},
+		{"RenameUnit", testRenameUnit},
+		{"RenameUnitNotFound", testRenameUnitNotFound},
+		{"RenameUnitNotMember", testRenameUnitNotMember},
+		{"DeleteUnit", testDeleteUnit},
+		{"DeleteUnitNotFound", testDeleteUnitNotFound},
+		{"DeleteUnitNotMember", testDeleteUnitNotMember},
+		{"ClassServiceError", testClassServiceError},
+		{"ConcurrentCreates", testConcurrentCreates},
+		{"ConcurrentRenames", testConcurrentRenames},
+		{"AuditTrail", testAuditTrail},
+		{"AuditTeachersOnly", testAuditTeachersOnly},
+		{"UnitVersions", testUnitVersions},
+		{"RevertUnit", testRevertUnit},
+		{"VersionsTeachersOnly", testVersionsTeachersOnly},
+		{"WebhookQueue", testWebhookQueue},
+		{"WebhooksInvalid", testWebhooksInvalid},
+		{"WebhooksTeachersOnly", testWebhooksTeachersOnly},
+		{"ImportUnits", testImportUnits},
+		{"ImportUnitsDryRun", testImportUnitsDryRun},
+		{"ImportUnitDetails", testImportUnitDetails},
+		{"ImportUnitHierarchy", testImportUnitHierarchy},
+		{"ImportUnitsInvalid", testImportUnitsInvalid},
+		{"ImportTeachersOnly", testImportTeachersOnly},
+		{"SyncOneRoster", testSyncOneRoster},
+		{"SyncOneRosterConflicts", testSyncOneRosterConflicts},
+		{"SyncOneRosterDelta", testSyncOneRosterDelta},
+		{"SyncOneRosterTeachersOnly", testSyncOneRosterTeachersOnly},
+		{"CurrentUnit", testCurrentUnit},
+		{"CurrentUnitInvalid", testCurrentUnitInvalid},
+		{"CurrentUnitDeleted", testCurrentUnitDeleted},
+		{"CurrentUnitTeachersOnly", testCurrentUnitTeachersOnly},
+		{"MarkUnitProgress", testMarkUnitProgress},
+		{"MarkUnitProgressInvalid", testMarkUnitProgressInvalid},
+		{"UnitProgressRoles", testUnitProgressRoles},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newFixture(t, newService))
+		})
+	}
+}
+
+// fixture is a class with one enrolled member, who teaches it, and one outsider.
+type fixture struct {
+	svc      unitsvc.Service
+	classes  *unittesting.ClassService
+	events   *events
+	classID  uuid.UUID
+	memberID uuid.UUID
+	member   context.Context
+	outside  context.Context
+}
+
+func newFixture(t *testing.T, newService ServiceFactory) *fixture {
+	f := &fixture{
+		classes: unittesting.NewClassService(),
+		events:  &events{},
+		classID: uuid.New(),
+	}
+	member, outsider := uuid.New(), uuid.New()
+	f.memberID = member
+	f.classes.Enroll(member, f.classID, models.UserRoleTeacher)
+	// The outsider belongs to some other class, so it is not simply unenrolled.
+	f.classes.Enroll(outsider, uuid.New(), models.UserRoleTeacher)
+	f.member = unitsvc.WithRequestID(unittesting.NewContext(member), requestID)
+	f.outside = unittesting.NewContext(outsider)
+	f.svc = unitsvc.MessagingMiddleware(f.events)(newService(t, f.classes))
+	return f
+}
+
+// createUnit creates a unit as the member and returns its ID, which is the last one listed.
+func (f *fixture) createUnit(t *testing.T, title string) uuid.UUID {
+	if err := f.svc.CreateUnit(f.member, f.classID, title); err != nil {
+		t.Fatalf("CreateUnit: %v", err)
+	}
+	units, err := f.svc.ListUnits(f.member, f.classID)
+	if err != nil {
+		t.Fatalf("ListUnits: %v", err)
+	}
+	if len(units) == 0 {
+		t.Fatalf("ListUnits after CreateUnit returned no units")
+	}
+	return units[len(units)-1]
+}
+
+// requestID is the request ID of every call made as the fixture's member.
+const requestID = "conformance"
+
+// event is a message recorded by events.
+type event struct {
+	subject string
+	id      string
+}
+
+// events is a unitsvc.Publisher that records every message. Events are recorded under their
+// unversioned subject; the text IDs published there are recorded separately as legacy.
+type events struct {
+	mu         sync.Mutex
+	sent       []event
+	legacy     []event
+	requestIDs []string
+}
+
+func (e *events) Publish(subj string, data []byte) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !strings.HasPrefix(subj, unitsvc.EventSubject("")) {
+		e.legacy = append(e.legacy, event{subj, string(data)})
+		return nil
+	}
+	var ev unitsvc.Event
+	if err := json.Unmarshal(data, &ev); err != nil {
+		return err
+	}
+	e.sent = append(e.sent, event{strings.TrimPrefix(subj, unitsvc.EventSubject("")), ev.ID.String()})
+	e.requestIDs = append(e.requestIDs, ev.RequestID)
+	return nil
+}
+
+// expect fails t unless exactly want has been published since the last call,
+// each carrying the member's request ID.
+func (e *events) expect(t *testing.T, want ...event) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if fmt.Sprint(e.sent) != fmt.Sprint(want) {
+		t.Errorf("got events %v, want %v", e.sent, want)
+	}
+	if fmt.Sprint(e.legacy) != fmt.Sprint(want) {
+		t.Errorf("got legacy events %v, want %v", e.legacy, want)
+	}
+	for _, id := range e.requestIDs {
+		if id != requestID {
+			t.Errorf("got event with request ID %q, want %q", id, requestID)
+		}
+	}
+	e.sent, e.legacy, e.requestIDs = nil, nil, nil
+}
+
+func testListUnitsOrdered(t *testing.T, f *fixture) {
+	want := []uuid.UUID{
+		f.createUnit(t, "First"),
+		f.createUnit(t, "Second"),
+		f.createUnit(t, "Third"),
+	}
+	got, err := f.svc.ListUnits(f.member, f.classID)
+	if err != nil {
+		t.Fatalf("ListUnits: %v", err)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("got units %v, want %v", got, want)
+	}
+	prev := -1
+	for _, id := range want {
+		unit, err := f.svc.GetUnit(f.member, id)
+		if err != nil {
+			t.Fatalf("GetUnit: %v", err)
+		}
+		if unit.DisplayOrder <= prev {
+			t.Errorf("unit %q has display order %d, want more than %d", unit.Title, unit.DisplayOrder, prev)
+		}
+		prev = unit.DisplayOrder
+	}
+	classID := f.classID.String()
+	f.events.expect(t,
+		event{unitsvc.SubjCreateUnit, classID},
+		event{unitsvc.SubjCreateUnit, classID},
+		event{unitsvc.SubjCreateUnit, classID},
+	)
+}
+
+func testListUnitsEmpty(t *testing.T, f *fixture) {
+	units, err := f.svc.ListUnits(f.member, f.classID)
+	if err != nil {
+		t.Fatalf("ListUnits: %v", err)
+	}
+	if len(units) != 0 {
+		t.Fatalf("got %d units for new class, want 0", len(units))
+	}
+}
+
+func testListUnitsNotMember(t *testing.T, f *fixture) {
+	f.createUnit(t, "Hidden")
+	if _, err := f.svc.ListUnits(f.outside, f.classID); err != unitsvc.ErrNotFound {
+		t.Fatalf("ListUnits as outsider: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+}
+
+func testGetUnit(t *testing.T, f *fixture) {
+	id := f.createUnit(t, "Photosynthesis")
+	unit, err := f.svc.GetUnit(f.member, id)
+	if err != nil {
+		t.Fatalf("GetUnit: %v", err)
+	}
+	if unit.ID != id || unit.ClassID != f.classID || unit.Title != "Photosynthesis" {
+		t.Fatalf("got unit %+v, want ID %v, class %v and title %q", unit, id, f.classID, "Photosynthesis")
+	}
+	if unit.CreatedAt.IsZero() {
+		t.Errorf("unit has no creation time")
+	}
+}
+
+func testGetUnitNotFound(t *testing.T, f *fixture) {
+	if _, err := f.svc.GetUnit(f.member, uuid.New()); err != unitsvc.ErrNotFound {
+		t.Fatalf("GetUnit of missing unit: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+}
+
+// Outsiders must not be able to tell an existing unit from a missing one.
+func testGetUnitNotMember(t *testing.T, f *fixture) {
+	id := f.createUnit(t, "Hidden")
+	if _, err := f.svc.GetUnit(f.outside, id); err != unitsvc.ErrNotFound {
+		t.Fatalf("GetUnit as outsider: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+}
+
+func testCreateUnitNotMember(t *testing.T, f *fixture) {
+	if err := f.svc.CreateUnit(f.outside, f.classID, "Intruder"); err != unitsvc.ErrNotFound {
+		t.Fatalf("CreateUnit as outsider: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+	units, err := f.svc.ListUnits(f.member, f.classID)
+	if err != nil {
+		t.Fatalf("ListUnits: %v", err)
+	}
+	if len(units) != 0 {
+		t.Fatalf("outsider created %d units", len(units))
+	}
+	f.events.expect(t)
+}
+
+func testRenameUnit(t *testing.T, f *fixture) {
+	id := f.createUnit(t, "Cells")
+	f.events.expect(t, event{unitsvc.SubjCreateUnit, f.classID.String()})
+	if err := f.svc.RenameUnit(f.member, id, "Cell Biology"); err != nil {
+		t.Fatalf("RenameUnit: %v", err)
+	}
+	unit, err := f.svc.GetUnit(f.member, id)
+	if err != nil {
+		t.Fatalf("GetUnit: %v", err)
+	}
+	if unit.Title != "Cell Biology" {
+		t.Fatalf("got title %q after rename, want %q", unit.Title, "Cell Biology")
+	}
+	f.events.expect(t, event{unitsvc.SubjRenameUnit, id.String()})
+}
+
+func testRenameUnitNotFound(t *testing.T, f *fixture) {
+	if err := f.svc.RenameUnit(f.member, uuid.New(), "Nothing"); err != unitsvc.ErrNotFound {
+		t.Fatalf("RenameUnit of missing unit: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+	f.events.expect(t)
+}
+
+func testRenameUnitNotMember(t *testing.T, f *fixture) {
+	id := f.createUnit(t, "Original")
+	f.events.expect(t, event{unitsvc.SubjCreateUnit, f.classID.String()})
+	if err := f.svc.RenameUnit(f.outside, id, "Defaced"); err != unitsvc.ErrNotFound {
+		t.Fatalf("RenameUnit as outsider: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+	unit, err := f.svc.GetUnit(f.member, id)
+	if err != nil {
+		t.Fatalf("GetUnit: %v", err)
+	}
+	if unit.Title != "Original" {
+		t.Fatalf("outsider renamed unit to %q", unit.Title)
+	}
+	f.events.expect(t)
+}
+
+func testDeleteUnit(t *testing.T, f *fixture) {
+	id := f.createUnit(t, "Genetics")
+	f.events.expect(t, event{unitsvc.SubjCreateUnit, f.classID.String()})
+	if err := f.svc.DeleteUnit(f.member, id); err != nil {
+		t.Fatalf("DeleteUnit: %v", err)
+	}
+	if _, err := f.svc.GetUnit(f.member, id); err != unitsvc.ErrNotFound {
+		t.Fatalf("GetUnit after delete: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+	f.events.expect(t, event{unitsvc.SubjDeleteUnit, id.String()})
+}
+
+func testDeleteUnitNotFound(t *testing.T, f *fixture) {
+	if err := f.svc.DeleteUnit(f.member, uuid.New()); err != unitsvc.ErrNotFound {
+		t.Fatalf("DeleteUnit of missing unit: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+	f.events.expect(t)
+}
+
+func testDeleteUnitNotMember(t *testing.T, f *fixture) {
+	id := f.createUnit(t, "Protected")
+	f.events.expect(t, event{unitsvc.SubjCreateUnit, f.classID.String()})
+	if err := f.svc.DeleteUnit(f.outside, id); err != unitsvc.ErrNotFound {
+		t.Fatalf("DeleteUnit as outsider: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+	if _, err := f.svc.GetUnit(f.member, id); err != nil {
+		t.Fatalf("GetUnit after outsider delete: %v", err)
+	}
+	f.events.expect(t)
+}
+
+// Errors from classsvc are passed through rather than reported as missing units.
+func testClassServiceError(t *testing.T, f *fixture) {
+	id := f.createUnit(t, "Unreachable")
+	f.events.expect(t, event{unitsvc.SubjCreateUnit, f.classID.String()})
+	errDown := errors.New("classsvc unavailable")
+	f.classes.Fail(errDown)
+	if _, err := f.svc.ListUnits(f.member, f.classID); err != errDown {
+		t.Errorf("ListUnits: got error %v, want %v", err, errDown)
+	}
+	if _, err := f.svc.GetUnit(f.member, id); err != errDown {
+		t.Errorf("GetUnit: got error %v, want %v", err, errDown)
+	}
+	if err := f.svc.CreateUnit(f.member, f.classID, "Lost"); err != errDown {
+		t.Errorf("CreateUnit: got error %v, want %v", err, errDown)
+	}
+	if err := f.svc.RenameUnit(f.member, id, "Lost"); err != errDown {
+		t.Errorf("RenameUnit: got error %v, want %v", err, errDown)
+	}
+	if err := f.svc.DeleteUnit(f.member, id); err != errDown {
+		t.Errorf("DeleteUnit: got error %v, want %v", err, errDown)
+	}
+	f.events.expect(t)
+}
+
+func testConcurrentCreates(t *testing.T, f *fixture) {
+	const n = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- f.svc.CreateUnit(f.member, f.classID, fmt.Sprintf("Unit %d", i))
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("CreateUnit: %v", err)
+		}
+	}
+	units, err := f.svc.ListUnits(f.member, f.classID)
+	if err != nil {
+		t.Fatalf("ListUnits: %v", err)
+	}
+	seen := make(map[uuid.UUID]bool)
+	for _, id := range units {
+		seen[id] = true
+	}
+	if len(units) != n || len(seen) != n {
+		t.Fatalf("got %d units (%d distinct) after %d concurrent creates", len(units), len(seen), n)
+	}
+}
+
+func testConcurrentRenames(t *testing.T, f *fixture) {
+	id := f.createUnit(t, "Contended")
+	const n = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*n)
+	for i := 0; i < n; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			errs <- f.svc.RenameUnit(f.member, id, fmt.Sprintf("Title %d", i))
+		}(i)
+		go func() {
+			defer wg.Done()
+			_, err := f.svc.GetUnit(f.member, id)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent RenameUnit/GetUnit: %v", err)
+		}
+	}
+	units, err := f.svc.ListUnits(f.member, f.classID)
+	if err != nil {
+		t.Fatalf("ListUnits: %v", err)
+	}
+	if len(units) != 1 {
+		t.Fatalf("got %d units after concurrent renames, want 1", len(units))
+	}
+}
+
+func testAuditTrail(t *testing.T, f *fixture) {
+	id := f.createUnit(t, "Cells")
+	if err := f.svc.RenameUnit(f.member, id, "Cell Biology"); err != nil {
+		t.Fatalf("RenameUnit: %v", err)
+	}
+	if err := f.svc.DeleteUnit(f.member, id); err != nil {
+		t.Fatalf("DeleteUnit: %v", err)
+	}
+	other := f.createUnit(t, "Genetics")
+
+	// History outlives the unit.
+	history, err := f.svc.UnitHistory(f.member, id)
+	if err != nil {
+		t.Fatalf("UnitHistory: %v", err)
+	}
+	want := []struct {
+		action, before, after string
+	}{
+		{unitsvcmodels.AuditCreate, "", "Cells"},
+		{unitsvcmodels.AuditRename, "Cells", "Cell Biology"},
+		{unitsvcmodels.AuditDelete, "Cell Biology", ""},
+	}
+	if len(history) != len(want) {
+		t.Fatalf("got %d history entries, want %d", len(history), len(want))
+	}
+	title := func(u *unitsvcmodels.Unit) string {
+		if u == nil {
+			return ""
+		}
+		return u.Title
+	}
+	for i, e := range history {
+		if e.Action != want[i].action || title(e.Before) != want[i].before || title(e.After) != want[i].after {
+			t.Errorf("history entry %d: got %s %q -> %q, want %s %q -> %q", i,
+				e.Action, title(e.Before), title(e.After), want[i].action, want[i].before, want[i].after)
+		}
+		if e.UnitID != id || e.ClassID != f.classID {
+			t.Errorf("history entry %d: got unit %v in class %v, want unit %v in class %v", i, e.UnitID, e.ClassID, id, f.classID)
+		}
+		if e.Actor != f.memberID || e.ClientID != unittesting.ClientID || e.RequestID != requestID {
+			t.Errorf("history entry %d: got actor %v, client %q and request %q, want %v, %q and %q", i,
+				e.Actor, e.ClientID, e.RequestID, f.memberID, unittesting.ClientID, requestID)
+		}
+		if e.CreatedAt.IsZero() {
+			t.Errorf("history entry %d has no creation time", i)
+		}
+	}
+
+	audit, err := f.svc.ClassAudit(f.member, f.classID)
+	if err != nil {
+		t.Fatalf("ClassAudit: %v", err)
+	}
+	if len(audit) != len(want)+1 {
+		t.Fatalf("got %d class audit entries, want %d", len(audit), len(want)+1)
+	}
+	if last := audit[len(audit)-1]; last.UnitID != other || last.Action != unitsvcmodels.AuditCreate {
+		t.Errorf("got last class audit entry %s of %v, want %s of %v", last.Action, last.UnitID, unitsvcmodels.AuditCreate, other)
+	}
+}
+
+// Students may change units but only teachers may see who changed them.
+func testAuditTeachersOnly(t *testing.T, f *fixture) {
+	id := f.createUnit(t, "Optics")
+	student := uuid.New()
+	f.classes.Enroll(student, f.classID, models.UserRoleStudent)
+	ctx := unittesting.NewContext(student)
+	if _, err := f.svc.UnitHistory(ctx, id); err != unitsvc.ErrForbidden {
+		t.Errorf("UnitHistory as student: got error %v, want %v", err, unitsvc.ErrForbidden)
+	}
+	if _, err := f.svc.ClassAudit(ctx, f.classID); err != unitsvc.ErrForbidden {
+		t.Errorf("ClassAudit as student: got error %v, want %v", err, unitsvc.ErrForbidden)
+	}
+	if _, err := f.svc.UnitHistory(f.outside, id); err != unitsvc.ErrNotFound {
+		t.Errorf("UnitHistory as outsider: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+	if _, err := f.svc.ClassAudit(f.outside, f.classID); err != unitsvc.ErrNotFound {
+		t.Errorf("ClassAudit as outsider: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+	if _, err := f.svc.UnitHistory(f.member, uuid.New()); err != unitsvc.ErrNotFound {
+		t.Errorf("UnitHistory of missing unit: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+}
+
+func testUnitVersions(t *testing.T, f *fixture) {
+	id := f.createUnit(t, "Cells")
+	for _, title := range []string{"Cell Biology", "Cell Structure"} {
+		if err := f.svc.RenameUnit(f.member, id, title); err != nil {
+			t.Fatalf("RenameUnit: %v", err)
+		}
+	}
+	versions, err := f.svc.ListUnitVersions(f.member, id)
+	if err != nil {
+		t.Fatalf("ListUnitVersions: %v", err)
+	}
+	titles := []string{"Cells", "Cell Biology", "Cell Structure"}
+	if len(versions) != len(titles) {
+		t.Fatalf("got %d versions, want %d", len(versions), len(titles))
+	}
+	for i, v := range versions {
+		if v.Version != i+1 || v.Unit.Title != titles[i] || v.Author != f.memberID {
+			t.Errorf("got version %d titled %q by %v, want version %d titled %q by %v",
+				v.Version, v.Unit.Title, v.Author, i+1, titles[i], f.memberID)
+		}
+	}
+	if len(versions[0].Changes) != 0 {
+		t.Errorf("got changes %v in first version, want none", versions[0].Changes)
+	}
+	want := fmt.Sprint([]unitsvcmodels.FieldChange{{Field: "title", Before: "Cell Biology", After: "Cell Structure"}})
+	if got := fmt.Sprint(versions[2].Changes); got != want {
+		t.Errorf("got changes %s in version 3, want %s", got, want)
+	}
+
+	v, err := f.svc.GetUnitVersion(f.member, id, 2)
+	if err != nil {
+		t.Fatalf("GetUnitVersion: %v", err)
+	}
+	if v.Version != 2 || v.Unit.Title != "Cell Biology" || len(v.Changes) != 1 {
+		t.Errorf("got version %d titled %q with changes %v, want version 2 titled %q with one change",
+			v.Version, v.Unit.Title, v.Changes, "Cell Biology")
+	}
+	if _, err := f.svc.GetUnitVersion(f.member, id, 4); err != unitsvc.ErrNotFound {
+		t.Errorf("GetUnitVersion of missing version: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+
+	// Versions go with the unit.
+	if err := f.svc.DeleteUnit(f.member, id); err != nil {
+		t.Fatalf("DeleteUnit: %v", err)
+	}
+	if _, err := f.svc.ListUnitVersions(f.member, id); err != unitsvc.ErrNotFound {
+		t.Errorf("ListUnitVersions after delete: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+}
+
+func testRevertUnit(t *testing.T, f *fixture) {
+	id := f.createUnit(t, "Cells")
+	f.createUnit(t, "Genetics")
+	if err := f.svc.RenameUnit(f.member, id, "Cell Biology"); err != nil {
+		t.Fatalf("RenameUnit: %v", err)
+	}
+	f.events.expect(t,
+		event{unitsvc.SubjCreateUnit, f.classID.String()},
+		event{unitsvc.SubjCreateUnit, f.classID.String()},
+		event{unitsvc.SubjRenameUnit, id.String()},
+	)
+	before, err := f.svc.GetUnit(f.member, id)
+	if err != nil {
+		t.Fatalf("GetUnit: %v", err)
+	}
+
+	if err := f.svc.RevertUnit(f.member, id, 1); err != nil {
+		t.Fatalf("RevertUnit: %v", err)
+	}
+	unit, err := f.svc.GetUnit(f.member, id)
+	if err != nil {
+		t.Fatalf("GetUnit: %v", err)
+	}
+	if unit.Title != "Cells" || unit.DisplayOrder != before.DisplayOrder || !unit.CreatedAt.Equal(before.CreatedAt) {
+		t.Errorf("got unit %+v after revert, want title %q and the rest of %+v", unit, "Cells", before)
+	}
+	f.events.expect(t, event{unitsvc.SubjRevertUnit, id.String()})
+
+	versions, err := f.svc.ListUnitVersions(f.member, id)
+	if err != nil {
+		t.Fatalf("ListUnitVersions: %v", err)
+	}
+	if n := len(versions); n != 3 || versions[n-1].Unit.Title != "Cells" {
+		t.Fatalf("got %d versions after revert, want 3 with the newest titled %q", n, "Cells")
+	}
+	history, err := f.svc.UnitHistory(f.member, id)
+	if err != nil {
+		t.Fatalf("UnitHistory: %v", err)
+	}
+	if last := history[len(history)-1]; last.Action != unitsvcmodels.AuditRevert || last.Before.Title != "Cell Biology" || last.After.Title != "Cells" {
+		t.Errorf("got last history entry %+v, want a revert from %q to %q", last, "Cell Biology", "Cells")
+	}
+
+	if err := f.svc.RevertUnit(f.member, id, 7); err != unitsvc.ErrNotFound {
+		t.Errorf("RevertUnit to missing version: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+	if err := f.svc.RevertUnit(f.member, uuid.New(), 1); err != unitsvc.ErrNotFound {
+		t.Errorf("RevertUnit of missing unit: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+	f.events.expect(t)
+}
+
+func testVersionsTeachersOnly(t *testing.T, f *fixture) {
+	id := f.createUnit(t, "Optics")
+	if err := f.svc.RenameUnit(f.member, id, "Light"); err != nil {
+		t.Fatalf("RenameUnit: %v", err)
+	}
+	student := uuid.New()
+	f.classes.Enroll(student, f.classID, models.UserRoleStudent)
+	ctx := unittesting.NewContext(student)
+	if _, err := f.svc.ListUnitVersions(ctx, id); err != unitsvc.ErrForbidden {
+		t.Errorf("ListUnitVersions as student: got error %v, want %v", err, unitsvc.ErrForbidden)
+	}
+	if _, err := f.svc.GetUnitVersion(ctx, id, 1); err != unitsvc.ErrForbidden {
+		t.Errorf("GetUnitVersion as student: got error %v, want %v", err, unitsvc.ErrForbidden)
+	}
+	if err := f.svc.RevertUnit(ctx, id, 1); err != unitsvc.ErrForbidden {
+		t.Errorf("RevertUnit as student: got error %v, want %v", err, unitsvc.ErrForbidden)
+	}
+	if _, err := f.svc.ListUnitVersions(f.outside, id); err != unitsvc.ErrNotFound {
+		t.Errorf("ListUnitVersions as outsider: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+	if err := f.svc.RevertUnit(f.outside, id, 1); err != unitsvc.ErrNotFound {
+		t.Errorf("RevertUnit as outsider: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+	unit, err := f.svc.GetUnit(f.member, id)
+	if err != nil {
+		t.Fatalf("GetUnit: %v", err)
+	}
+	if unit.Title != "Light" {
+		t.Errorf("unit was reverted to %q without permission", unit.Title)
+	}
+}
+
+// Changes are queued for the class's webhooks as the events MessagingMiddleware publishes.
+func testWebhookQueue(t *testing.T, f *fixture) {
+	all, err := f.svc.CreateWebhook(f.member, f.classID, "https://lms.example.com/all", "s3cret", nil)
+	if err != nil {
+		t.Fatalf("CreateWebhook: %v", err)
+	}
+	deletes, err := f.svc.CreateWebhook(f.member, f.classID, "https://lms.example.com/deletes", "s3cret", []string{unitsvc.SubjDeleteUnit})
+	if err != nil {
+		t.Fatalf("CreateWebhook: %v", err)
+	}
+	if all.ClassID != f.classID || all.CreatedBy != f.memberID || len(all.Events) != 0 {
+		t.Errorf("got webhook %+v, want one for class %v by %v with no event filter", all, f.classID, f.memberID)
+	}
+	hooks, err := f.svc.ListWebhooks(f.member, f.classID)
+	if err != nil {
+		t.Fatalf("ListWebhooks: %v", err)
+	}
+	if len(hooks) != 2 || hooks[0].ID != all.ID || hooks[1].ID != deletes.ID {
+		t.Fatalf("got webhooks %+v, want %v then %v", hooks, all.ID, deletes.ID)
+	}
+
+	id := f.createUnit(t, "Waves")
+	if err := f.svc.RenameUnit(f.member, id, "Sound"); err != nil {
+		t.Fatalf("RenameUnit: %v", err)
+	}
+	if err := f.svc.DeleteUnit(f.member, id); err != nil {
+		t.Fatalf("DeleteUnit: %v", err)
+	}
+	want := []event{
+		{unitsvc.SubjCreateUnit, f.classID.String()},
+		{unitsvc.SubjRenameUnit, id.String()},
+		{unitsvc.SubjDeleteUnit, id.String()},
+	}
+	f.events.expect(t, want...)
+	for _, tt := range []struct {
+		hook *unitsvcmodels.Webhook
+		want []event
+	}{{all, want}, {deletes, want[2:]}} {
+		deliveries, err := f.svc.ListDeliveries(f.member, tt.hook.ID, unitsvcmodels.DeliveryPending)
+		if err != nil {
+			t.Fatalf("ListDeliveries: %v", err)
+		}
+		var got []event
+		for _, d := range deliveries {
+			var e unitsvc.Event
+			if err := json.Unmarshal(d.Payload, &e); err != nil {
+				t.Fatalf("delivery payload %s: %v", d.Payload, err)
+			}
+			if e.RequestID != requestID {
+				t.Errorf("got delivery with request ID %q, want %q", e.RequestID, requestID)
+			}
+			got = append(got, event{d.Event, e.ID.String()})
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("got deliveries %v to %s, want %v", got, tt.hook.URL, tt.want)
+		}
+	}
+
+	if err := f.svc.DeleteWebhook(f.member, all.ID); err != nil {
+		t.Fatalf("DeleteWebhook: %v", err)
+	}
+	if _, err := f.svc.ListDeliveries(f.member, all.ID, ""); err != unitsvc.ErrNotFound {
+		t.Errorf("ListDeliveries after delete: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+	if err := f.svc.DeleteWebhook(f.member, all.ID); err != unitsvc.ErrNotFound {
+		t.Errorf("DeleteWebhook of missing webhook: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+}
+
+func testWebhooksInvalid(t *testing.T, f *fixture) {
+	for _, tt := range []struct {
+		name, url, secret string
+		events            []string
+	}{
+		{"relative URL", "/hooks", "s3cret", nil},
+		{"unsupported scheme", "ftp://lms.example.com/hooks", "s3cret", nil},
+		{"no secret", "https://lms.example.com/hooks", "", nil},
+		{"unknown event", "https://lms.example.com/hooks", "s3cret", []string{"units.explode"}},
+	} {
+		if _, err := f.svc.CreateWebhook(f.member, f.classID, tt.url, tt.secret, tt.events); err != unitsvc.ErrBadRequest {
+			t.Errorf("CreateWebhook with %s: got error %v, want %v", tt.name, err, unitsvc.ErrBadRequest)
+		}
+	}
+	hooks, err := f.svc.ListWebhooks(f.member, f.classID)
+	if err != nil {
+		t.Fatalf("ListWebhooks: %v", err)
+	}
+	if len(hooks) != 0 {
+		t.Errorf("got %d webhooks after invalid creates, want 0", len(hooks))
+	}
+}
+
+func testWebhooksTeachersOnly(t *testing.T, f *fixture) {
+	hook, err := f.svc.CreateWebhook(f.member, f.classID, "https://lms.example.com/hooks", "s3cret", nil)
+	if err != nil {
+		t.Fatalf("CreateWebhook: %v", err)
+	}
+	student := uuid.New()
+	f.classes.Enroll(student, f.classID, models.UserRoleStudent)
+	ctx := unittesting.NewContext(student)
+	if _, err := f.svc.CreateWebhook(ctx, f.classID, "https://evil.example.com/", "s3cret", nil); err != unitsvc.ErrForbidden {
+		t.Errorf("CreateWebhook as student: got error %v, want %v", err, unitsvc.ErrForbidden)
+	}
+	if _, err := f.svc.ListWebhooks(ctx, f.classID); err != unitsvc.ErrForbidden {
+		t.Errorf("ListWebhooks as student: got error %v, want %v", err, unitsvc.ErrForbidden)
+	}
+	if err := f.svc.DeleteWebhook(ctx, hook.ID); err != unitsvc.ErrForbidden {
+		t.Errorf("DeleteWebhook as student: got error %v, want %v", err, unitsvc.ErrForbidden)
+	}
+	if _, err := f.svc.ListDeliveries(ctx, hook.ID, ""); err != unitsvc.ErrForbidden {
+		t.Errorf("ListDeliveries as student: got error %v, want %v", err, unitsvc.ErrForbidden)
+	}
+	if _, err := f.svc.ListWebhooks(f.outside, f.classID); err != unitsvc.ErrNotFound {
+		t.Errorf("ListWebhooks as outsider: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+	if err := f.svc.DeleteWebhook(f.outside, hook.ID); err != unitsvc.ErrNotFound {
+		t.Errorf("DeleteWebhook as outsider: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+	if _, err := f.svc.ListDeliveries(f.member, hook.ID, ""); err != nil {
+		t.Errorf("webhook was deleted without permission: %v", err)
+	}
+}
+
+// exportTitles returns the titles of the class's units as exported.
+func (f *fixture) exportTitles(t *testing.T) []string {
+	units, err := f.svc.ExportUnits(f.member, f.classID)
+	if err != nil {
+		t.Fatalf("ExportUnits: %v", err)
+	}
+	titles := make([]string, 0, len(units))
+	for _, u := range units {
+		titles = append(titles, u.Title)
+	}
+	return titles
+}
+
+// importRows moves the second of three units to the front, adds a unit after it, renames the
+// first and leaves out the third.
+func importRows(ids []uuid.UUID) []unitsvc.ImportRow {
+	return []unitsvc.ImportRow{
+		{Row: 1, ID: ids[1], Title: "Genetics"},
+		{Row: 2, Title: "Evolution"},
+		{Row: 3, ID: ids[0], Title: "Cell Biology"},
+	}
+}
+
+// createUnits creates units as the member, consuming their events, and returns their IDs.
+func (f *fixture) createUnits(t *testing.T, titles ...string) []uuid.UUID {
+	var ids []uuid.UUID
+	var created []event
+	for _, title := range titles {
+		ids = append(ids, f.createUnit(t, title))
+		created = append(created, event{unitsvc.SubjCreateUnit, f.classID.String()})
+	}
+	f.events.expect(t, created...)
+	return ids
+}
+
+func testImportUnits(t *testing.T, f *fixture) {
+	ids := f.createUnits(t, "Cells", "Genetics", "Ecology")
+	result, err := f.svc.ImportUnits(f.member, f.classID, importRows(ids), false)
+	if err != nil {
+		t.Fatalf("ImportUnits: %v", err)
+	}
+	if result.DryRun || len(result.Created) != 1 || len(result.Updated) != 2 || len(result.Deleted) != 1 {
+		t.Fatalf("got %d created, %d updated and %d deleted (dry run %v), want 1, 2 and 1",
+			len(result.Created), len(result.Updated), len(result.Deleted), result.DryRun)
+	}
+	if got, want := fmt.Sprint(f.exportTitles(t)), "[Genetics Evolution Cell Biology]"; got != want {
+		t.Errorf("got units %s after import, want %s", got, want)
+	}
+	f.events.expect(t,
+		event{unitsvc.SubjCreateUnit, f.classID.String()},
+		event{unitsvc.SubjRenameUnit, ids[1].String()},
+		event{unitsvc.SubjRenameUnit, ids[0].String()},
+		event{unitsvc.SubjDeleteUnit, ids[2].String()},
+	)
+
+	// Each unit's change is audited on its own.
+	history, err := f.svc.UnitHistory(f.member, ids[0])
+	if err != nil {
+		t.Fatalf("UnitHistory: %v", err)
+	}
+	if last := history[len(history)-1]; last.Action != unitsvcmodels.AuditUpdate || last.Before.Title != "Cells" || last.After.DisplayOrder != 2 {
+		t.Errorf("got last history entry %s %+v -> %+v, want update of Cells to display order 2", last.Action, last.Before, last.After)
+	}
+
+	// Importing an export changes nothing.
+	units, err := f.svc.ExportUnits(f.member, f.classID)
+	if err != nil {
+		t.Fatalf("ExportUnits: %v", err)
+	}
+	var rows []unitsvc.ImportRow
+	for i, u := range units {
+		order := u.DisplayOrder
+		rows = append(rows, unitsvc.ImportRow{Row: i + 1, ID: u.ID, Title: u.Title, DisplayOrder: &order})
+	}
+	result, err = f.svc.ImportUnits(f.member, f.classID, rows, false)
+	if err != nil {
+		t.Fatalf("ImportUnits of export: %v", err)
+	}
+	if len(result.Created)+len(result.Updated)+len(result.Deleted) != 0 {
+		t.Errorf("import of export made changes: %+v", result)
+	}
+	f.events.expect(t)
+}
+
+// testImportUnitDetails checks that imports set descriptions and metadata, and keep them when a
+// row leaves them out.
+func testImportUnitDetails(t *testing.T, f *fixture) {
+	ids := f.createUnits(t, "Cells")
+	description := "Organelles and membranes."
+	rows := []unitsvc.ImportRow{
+		{Row: 1, ID: ids[0], Title: "Cells", Description: &description, Metadata: unitsvcmodels.Metadata{"weeks": "3"}},
+		{Row: 2, Title: "Genetics", Description: &description},
+	}
+	result, err := f.svc.ImportUnits(f.member, f.classID, rows, false)
+	if err != nil {
+		t.Fatalf("ImportUnits: %v", err)
+	}
+	if len(result.Created) != 1 || len(result.Updated) != 1 {
+		t.Fatalf("got %d created and %d updated, want 1 and 1", len(result.Created), len(result.Updated))
+	}
+	f.events.expect(t, event{unitsvc.SubjCreateUnit, f.classID.String()}, event{unitsvc.SubjRenameUnit, ids[0].String()})
+	unit, err := f.svc.GetUnit(f.member, ids[0])
+	if err != nil {
+		t.Fatalf("GetUnit: %v", err)
+	}
+	if unit.Description != description || unit.Metadata["weeks"] != "3" {
+		t.Errorf("got description %q and metadata %v, want %q and weeks 3", unit.Description, unit.Metadata, description)
+	}
+	versions, err := f.svc.ListUnitVersions(f.member, ids[0])
+	if err != nil {
+		t.Fatalf("ListUnitVersions: %v", err)
+	}
+	var changed []string
+	for _, c := range versions[len(versions)-1].Changes {
+		changed = append(changed, c.Field)
+	}
+	if got, want := fmt.Sprint(changed), "[description metadata]"; got != want {
+		t.Errorf("got changes to %s, want %s", got, want)
+	}
+
+	// Rows without details keep them; empty metadata clears them.
+	rows = []unitsvc.ImportRow{
+		{Row: 1, ID: ids[0], Title: "Cells"},
+		{Row: 2, ID: result.Created[0].ID, Title: "Genetics", Metadata: unitsvcmodels.Metadata{}},
+	}
+	if result, err = f.svc.ImportUnits(f.member, f.classID, rows, false); err != nil {
+		t.Fatalf("ImportUnits without details: %v", err)
+	}
+	if len(result.Updated) != 0 {
+		t.Errorf("import without details updated %d units, want 0", len(result.Updated))
+	}
+	rows[0].Metadata = unitsvcmodels.Metadata{}
+	if result, err = f.svc.ImportUnits(f.member, f.classID, rows, false); err != nil {
+		t.Fatalf("ImportUnits clearing metadata: %v", err)
+	}
+	if unit, err = f.svc.GetUnit(f.member, ids[0]); err != nil || len(unit.Metadata) != 0 || unit.Description != description {
+		t.Errorf("got unit %+v and error %v after clearing metadata, want only the metadata cleared", unit, err)
+	}
+	f.events.expect(t, event{unitsvc.SubjRenameUnit, ids[0].String()})
+
+	rows[0].Metadata = unitsvcmodels.Metadata{"": "3"}
+	if _, err := f.svc.ImportUnits(f.member, f.classID, rows, false); err == nil {
+		t.Errorf("ImportUnits with an empty metadata key succeeded, want an *ImportError")
+	}
+	f.events.expect(t)
+}
+
+func testImportUnitHierarchy(t *testing.T, f *fixture) {
+	ids := f.createUnits(t, "Cells", "Genetics", "Ecology")
+	cells, genetics, ecology := ids[0], ids[1], ids[2]
+	top, inCells, inGenetics := 0, 1, 2
+	reading := unitsvcmodels.Resources{{Title: "Mendel", URL: "https://example.com/mendel"}}
+	rows := []unitsvc.ImportRow{
+		{Row: 1, ID: cells, Title: "Cells", Parent: &top},
+		{Row: 2, ID: genetics, Title: "Genetics", Parent: &inCells, Resources: reading},
+		{Row: 3, Title: "Heredity", Parent: &inGenetics},
+		{Row: 4, ID: ecology, Title: "Ecology"},
+	}
+	result, err := f.svc.ImportUnits(f.member, f.classID, rows, false)
+	if err != nil {
+		t.Fatalf("ImportUnits: %v", err)
+	}
+	if len(result.Created) != 1 || len(result.Updated) != 2 {
+		t.Fatalf("got %d created and %d updated, want 1 and 2", len(result.Created), len(result.Updated))
+	}
+	f.events.expect(t, event{unitsvc.SubjCreateUnit, f.classID.String()},
+		event{unitsvc.SubjRenameUnit, genetics.String()}, event{unitsvc.SubjRenameUnit, ecology.String()})
+	heredity := result.Created[0].ID
+	parents := func() map[uuid.UUID]*uuid.UUID {
+		ids, err := f.svc.ListUnits(f.member, f.classID)
+		if err != nil {
+			t.Fatalf("ListUnits: %v", err)
+		}
+		parents := make(map[uuid.UUID]*uuid.UUID)
+		for _, id := range ids {
+			unit, err := f.svc.GetUnit(f.member, id)
+			if err != nil {
+				t.Fatalf("GetUnit: %v", err)
+			}
+			parents[id] = unit.ParentID
+		}
+		return parents
+	}
+	got := parents()
+	if got[cells] != nil || got[genetics] == nil || *got[genetics] != cells || got[heredity] == nil || *got[heredity] != genetics {
+		t.Errorf("got parents %v, want Heredity in Genetics in Cells", got)
+	}
+	if unit, err := f.svc.GetUnit(f.member, genetics); err != nil || !unit.Resources.Equal(reading) {
+		t.Errorf("got Genetics %+v and error %v, want the Mendel reading", unit, err)
+	}
+
+	// Nesting a unit in itself, directly or through its descendants, or linking to anything but
+	// web pages is rejected.
+	for name, change := range map[string]func(rows []unitsvc.ImportRow){
+		"self":  func(rows []unitsvc.ImportRow) { rows[0].Parent = &inCells },
+		"cycle": func(rows []unitsvc.ImportRow) { rows[0].Parent = &inGenetics },
+		"resource": func(rows []unitsvc.ImportRow) {
+			rows[1].Resources = unitsvcmodels.Resources{{URL: "javascript:alert(1)"}}
+		},
+	} {
+		invalid := append([]unitsvc.ImportRow(nil), rows...)
+		invalid[2].ID = heredity
+		change(invalid)
+		if _, err := f.svc.ImportUnits(f.member, f.classID, invalid, false); err == nil {
+			t.Errorf("ImportUnits with an invalid %s succeeded, want an *ImportError", name)
+		}
+	}
+	f.events.expect(t)
+
+	// Units nested in a deleted unit move up to its parent.
+	if err := f.svc.DeleteUnit(f.member, genetics); err != nil {
+		t.Fatalf("DeleteUnit: %v", err)
+	}
+	f.events.expect(t, event{unitsvc.SubjDeleteUnit, genetics.String()})
+	if got := parents()[heredity]; got == nil || *got != cells {
+		t.Errorf("got Heredity in %v after deleting Genetics, want it in Cells", got)
+	}
+
+	// Rows that keep their parent move up to the nearest unit the import keeps.
+	rows = []unitsvc.ImportRow{{Row: 1, ID: heredity, Title: "Heredity"}, {Row: 2, ID: ecology, Title: "Ecology"}}
+	if result, err = f.svc.ImportUnits(f.member, f.classID, rows, false); err != nil {
+		t.Fatalf("ImportUnits deleting Cells: %v", err)
+	}
+	if len(result.Updated) != 2 || len(result.Deleted) != 1 {
+		t.Fatalf("got %d updated and %d deleted, want 2 and 1", len(result.Updated), len(result.Deleted))
+	}
+	f.events.expect(t, event{unitsvc.SubjRenameUnit, heredity.String()}, event{unitsvc.SubjRenameUnit, ecology.String()},
+		event{unitsvc.SubjDeleteUnit, cells.String()})
+	if got := parents()[heredity]; got != nil {
+		t.Errorf("got Heredity in %v after deleting Cells, want a top-level unit", got)
+	}
+}
+
+func testImportUnitsDryRun(t *testing.T, f *fixture) {
+	ids := f.createUnits(t, "Cells", "Genetics", "Ecology")
+	result, err := f.svc.ImportUnits(f.member, f.classID, importRows(ids), true)
+	if err != nil {
+		t.Fatalf("ImportUnits: %v", err)
+	}
+	if !result.DryRun || len(result.Created) != 1 || len(result.Updated) != 2 || len(result.Deleted) != 1 {
+		t.Fatalf("got %d created, %d updated and %d deleted (dry run %v), want 1, 2 and 1 in a dry run",
+			len(result.Created), len(result.Updated), len(result.Deleted), result.DryRun)
+	}
+	if got, want := fmt.Sprint(f.exportTitles(t)), "[Cells Genetics Ecology]"; got != want {
+		t.Errorf("got units %s after dry run, want %s", got, want)
+	}
+	f.events.expect(t)
+}
+
+func testImportUnitsInvalid(t *testing.T, f *fixture) {
+	ids := f.createUnits(t, "Cells")
+	rows := []unitsvc.ImportRow{
+		{Row: 1, Title: " "},
+		{Row: 2, ID: uuid.New(), Title: "Genetics"},
+		{Row: 3, ID: ids[0], Title: "Cells"},
+		{Row: 4, ID: ids[0], Title: "Cell Biology"},
+		{Row: 5, Title: "Ecology"},
+	}
+	_, err := f.svc.ImportUnits(f.member, f.classID, rows, false)
+	ierr, ok := err.(*unitsvc.ImportError)
+	if !ok {
+		t.Fatalf("got error %v, want an *ImportError", err)
+	}
+	var got []string
+	for _, row := range ierr.Rows {
+		got = append(got, fmt.Sprintf("%d %s", row.Row, row.Field))
+	}
+	if want := "[1 title 2 id 4 id]"; fmt.Sprint(got) != want {
+		t.Errorf("got row errors %v, want %s", got, want)
+	}
+	if got, want := fmt.Sprint(f.exportTitles(t)), "[Cells]"; got != want {
+		t.Errorf("got units %s after invalid import, want %s", got, want)
+	}
+	f.events.expect(t)
+}
+
+// Students may export units but only teachers may import them.
+func testImportTeachersOnly(t *testing.T, f *fixture) {
+	f.createUnits(t, "Cells")
+	student := uuid.New()
+	f.classes.Enroll(student, f.classID, models.UserRoleStudent)
+	ctx := unittesting.NewContext(student)
+	if units, err := f.svc.ExportUnits(ctx, f.classID); err != nil || len(units) != 1 {
+		t.Errorf("ExportUnits as student: got %d units and error %v, want 1 and no error", len(units), err)
+	}
+	if _, err := f.svc.ImportUnits(ctx, f.classID, nil, false); err != unitsvc.ErrForbidden {
+		t.Errorf("ImportUnits as student: got error %v, want %v", err, unitsvc.ErrForbidden)
+	}
+	if _, err := f.svc.ExportUnits(f.outside, f.classID); err != unitsvc.ErrNotFound {
+		t.Errorf("ExportUnits as outsider: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+	if _, err := f.svc.ImportUnits(f.outside, f.classID, nil, false); err != unitsvc.ErrNotFound {
+		t.Errorf("ImportUnits as outsider: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+	if got, want := fmt.Sprint(f.exportTitles(t)), "[Cells]"; got != want {
+		t.Errorf("got units %s, want %s", got, want)
+	}
+}
+
+// linkRoster links a new OneRoster class to the fixture's class as the member and returns its
+// sourcedId, which is unique so that services sharing storage do not see each other's links.
+func (f *fixture) linkRoster(t *testing.T) string {
+	sourcedID := "class-" + uuid.New().String()
+	if err := f.svc.LinkOneRosterClass(f.member, sourcedID, f.classID); err != nil {
+		t.Fatalf("LinkOneRosterClass: %v", err)
+	}
+	return sourcedID
+}
+
+// rosterBundle returns a bulk bundle of one class with categories given as sourcedId, title pairs.
+func rosterBundle(sourcedID string, categories ...string) *unitsvc.RosterBundle {
+	class := unitsvc.RosterClass{SourcedID: sourcedID, Categories: []unitsvc.RosterCategory{}}
+	for i := 0; i+1 < len(categories); i += 2 {
+		class.Categories = append(class.Categories, unitsvc.RosterCategory{SourcedID: categories[i], Title: categories[i+1]})
+	}
+	return &unitsvc.RosterBundle{Classes: []unitsvc.RosterClass{class}}
+}
+
+// syncRoster syncs bundle as the member and returns the report of its only class.
+func (f *fixture) syncRoster(t *testing.T, bundle *unitsvc.RosterBundle, dryRun, overwrite bool) *unitsvc.ClassSync {
+	report, err := f.svc.SyncOneRoster(f.member, bundle, dryRun, overwrite)
+	if err != nil {
+		t.Fatalf("SyncOneRoster: %v", err)
+	}
+	if report.DryRun != dryRun || len(report.Classes) != 1 {
+		t.Fatalf("got report of %d classes (dry run %v), want 1 (dry run %v)", len(report.Classes), report.DryRun, dryRun)
+	}
+	return report.Classes[0]
+}
+
+func testSyncOneRoster(t *testing.T, f *fixture) {
+	f.createUnits(t, "Welcome")
+	sourcedID := f.linkRoster(t)
+	bundle := rosterBundle(sourcedID, "cat-hw", "Homework", "cat-labs", "Labs")
+
+	class := f.syncRoster(t, bundle, true, false)
+	if class.ClassID != f.classID || class.Skipped != "" || len(class.Created) != 2 {
+		t.Fatalf("got dry run %+v, want 2 units created in %v", class, f.classID)
+	}
+	if got, want := fmt.Sprint(f.exportTitles(t)), "[Welcome]"; got != want {
+		t.Errorf("got units %s after dry run, want %s", got, want)
+	}
+	f.events.expect(t)
+
+	// Categories become units after the class's own units.
+	class = f.syncRoster(t, bundle, false, false)
+	if len(class.Created) != 2 || len(class.Updated)+len(class.Deleted)+len(class.Conflicts) != 0 {
+		t.Fatalf("got sync %+v, want 2 units created", class)
+	}
+	if got, want := fmt.Sprint(f.exportTitles(t)), "[Welcome Homework Labs]"; got != want {
+		t.Errorf("got units %s after sync, want %s", got, want)
+	}
+	f.events.expect(t,
+		event{unitsvc.SubjCreateUnit, f.classID.String()},
+		event{unitsvc.SubjCreateUnit, f.classID.String()},
+	)
+	homework, labs := class.Created[0].ID, class.Created[1].ID
+
+	// Syncing the same bundle again changes nothing.
+	class = f.syncRoster(t, bundle, false, false)
+	if len(class.Created)+len(class.Updated)+len(class.Deleted)+len(class.Conflicts) != 0 {
+		t.Errorf("second sync of the same bundle made changes: %+v", class)
+	}
+	f.events.expect(t)
+
+	// A renamed category renames its unit, and a category missing from a bulk bundle deletes it.
+	class = f.syncRoster(t, rosterBundle(sourcedID, "cat-hw", "Practice"), false, false)
+	if len(class.Updated) != 1 || class.Updated[0].ID != homework || len(class.Deleted) != 1 || class.Deleted[0].ID != labs {
+		t.Fatalf("got sync %+v, want Homework renamed and Labs deleted", class)
+	}
+	if got, want := fmt.Sprint(f.exportTitles(t)), "[Welcome Practice]"; got != want {
+		t.Errorf("got units %s after sync, want %s", got, want)
+	}
+	f.events.expect(t,
+		event{unitsvc.SubjRenameUnit, homework.String()},
+		event{unitsvc.SubjDeleteUnit, labs.String()},
+	)
+	history, err := f.svc.UnitHistory(f.member, homework)
+	if err != nil {
+		t.Fatalf("UnitHistory: %v", err)
+	}
+	if last := history[len(history)-1]; last.Action != unitsvcmodels.AuditUpdate || last.After.Title != "Practice" {
+		t.Errorf("got last history entry %s %+v, want update to Practice", last.Action, last.After)
+	}
+}
+
+// Units that a teacher renamed or deleted are left alone unless the sync overwrites them.
+func testSyncOneRosterConflicts(t *testing.T, f *fixture) {
+	sourcedID := f.linkRoster(t)
+	class := f.syncRoster(t, rosterBundle(sourcedID, "cat-hw", "Homework", "cat-labs", "Labs", "cat-quiz", "Quizzes"), false, false)
+	homework, labs, quizzes := class.Created[0].ID, class.Created[1].ID, class.Created[2].ID
+	if err := f.svc.RenameUnit(f.member, homework, "Practice Problems"); err != nil {
+		t.Fatalf("RenameUnit: %v", err)
+	}
+	if err := f.svc.RenameUnit(f.member, quizzes, "Weekly Quizzes"); err != nil {
+		t.Fatalf("RenameUnit: %v", err)
+	}
+	if err := f.svc.DeleteUnit(f.member, labs); err != nil {
+		t.Fatalf("DeleteUnit: %v", err)
+	}
+	f.events.expect(t,
+		event{unitsvc.SubjCreateUnit, f.classID.String()},
+		event{unitsvc.SubjCreateUnit, f.classID.String()},
+		event{unitsvc.SubjCreateUnit, f.classID.String()},
+		event{unitsvc.SubjRenameUnit, homework.String()},
+		event{unitsvc.SubjRenameUnit, quizzes.String()},
+		event{unitsvc.SubjDeleteUnit, labs.String()},
+	)
+
+	bundle := rosterBundle(sourcedID, "cat-hw", "Homework Sets", "cat-labs", "Labs")
+	class = f.syncRoster(t, bundle, false, false)
+	if len(class.Created)+len(class.Updated)+len(class.Deleted) != 0 || len(class.Conflicts) != 3 {
+		t.Fatalf("got sync %+v, want 3 conflicts and no changes", class)
+	}
+	var got []string
+	for _, c := range class.Conflicts {
+		got = append(got, c.SourcedID)
+	}
+	if want := "[cat-hw cat-labs cat-quiz]"; fmt.Sprint(got) != want {
+		t.Errorf("got conflicts for %v, want %s", got, want)
+	}
+	// The removed category's unit is kept, but no longer synced.
+	if got, want := fmt.Sprint(f.exportTitles(t)), "[Practice Problems Weekly Quizzes]"; got != want {
+		t.Errorf("got units %s after sync, want %s", got, want)
+	}
+	f.events.expect(t)
+
+	class = f.syncRoster(t, bundle, false, true)
+	if len(class.Created) != 1 || len(class.Updated) != 1 || len(class.Deleted)+len(class.Conflicts) != 0 {
+		t.Fatalf("got overwriting sync %+v, want Labs created again and Homework Sets renamed", class)
+	}
+	if got, want := fmt.Sprint(f.exportTitles(t)), "[Homework Sets Weekly Quizzes Labs]"; got != want {
+		t.Errorf("got units %s after overwriting sync, want %s", got, want)
+	}
+	f.events.expect(t,
+		event{unitsvc.SubjCreateUnit, f.classID.String()},
+		event{unitsvc.SubjRenameUnit, homework.String()},
+	)
+}
+
+// Delta bundles only remove the categories they mark as deleted, and leave categories without a title alone.
+func testSyncOneRosterDelta(t *testing.T, f *fixture) {
+	sourcedID := f.linkRoster(t)
+	class := f.syncRoster(t, rosterBundle(sourcedID, "cat-hw", "Homework", "cat-labs", "Labs", "cat-quiz", "Quizzes"), false, false)
+	labs := class.Created[1].ID
+	f.events.expect(t,
+		event{unitsvc.SubjCreateUnit, f.classID.String()},
+		event{unitsvc.SubjCreateUnit, f.classID.String()},
+		event{unitsvc.SubjCreateUnit, f.classID.String()},
+	)
+
+	delta := rosterBundle(sourcedID, "cat-hw", "", "cat-exam", "Exams", "cat-new", "")
+	delta.Delta, delta.DeletedCategories = true, []string{"cat-labs"}
+	class = f.syncRoster(t, delta, false, false)
+	if len(class.Created) != 1 || len(class.Deleted) != 1 || class.Deleted[0].ID != labs || len(class.Updated) != 0 {
+		t.Fatalf("got delta sync %+v, want Exams created and Labs deleted", class)
+	}
+	if len(class.Conflicts) != 1 || class.Conflicts[0].SourcedID != "cat-new" {
+		t.Errorf("got conflicts %+v, want cat-new, which has no title", class.Conflicts)
+	}
+	if got, want := fmt.Sprint(f.exportTitles(t)), "[Homework Quizzes Exams]"; got != want {
+		t.Errorf("got units %s after delta sync, want %s", got, want)
+	}
+	f.events.expect(t,
+		event{unitsvc.SubjCreateUnit, f.classID.String()},
+		event{unitsvc.SubjDeleteUnit, labs.String()},
+	)
+}
+
+// Only teachers may link classes, and classes the user does not teach are skipped.
+func testSyncOneRosterTeachersOnly(t *testing.T, f *fixture) {
+	student := uuid.New()
+	f.classes.Enroll(student, f.classID, models.UserRoleStudent)
+	ctx := unittesting.NewContext(student)
+	if err := f.svc.LinkOneRosterClass(ctx, "class-"+uuid.New().String(), f.classID); err != unitsvc.ErrForbidden {
+		t.Errorf("LinkOneRosterClass as student: got error %v, want %v", err, unitsvc.ErrForbidden)
+	}
+	if err := f.svc.LinkOneRosterClass(f.outside, "class-"+uuid.New().String(), f.classID); err != unitsvc.ErrNotFound {
+		t.Errorf("LinkOneRosterClass as outsider: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+
+	// Teachers of other classes may not take over this class's link.
+	sourcedID := f.linkRoster(t)
+	teacher, other := uuid.New(), uuid.New()
+	f.classes.Enroll(teacher, other, models.UserRoleTeacher)
+	if err := f.svc.LinkOneRosterClass(unittesting.NewContext(teacher), sourcedID, other); err != unitsvc.ErrForbidden {
+		t.Errorf("LinkOneRosterClass of another teacher's class: got error %v, want %v", err, unitsvc.ErrForbidden)
+	}
+
+	bundle := rosterBundle(sourcedID, "cat-hw", "Homework")
+	bundle.Classes = append(bundle.Classes, rosterBundle("class-"+uuid.New().String()).Classes...)
+	for _, ctx := range []context.Context{ctx, f.outside} {
+		report, err := f.svc.SyncOneRoster(ctx, bundle, false, false)
+		if err != nil {
+			t.Fatalf("SyncOneRoster: %v", err)
+		}
+		for _, class := range report.Classes {
+			if class.Skipped == "" || len(class.Created) != 0 {
+				t.Errorf("got sync %+v, want the class skipped", class)
+			}
+		}
+	}
+	if got := f.exportTitles(t); len(got) != 0 {
+		t.Errorf("got units %v after syncs by non-teachers, want none", got)
+	}
+}
+
+// expectCurrentUnit fails t unless the class is on the unit with the given ID.
+func (f *fixture) expectCurrentUnit(t *testing.T, want uuid.UUID) {
+	unit, err := f.svc.GetCurrentUnit(f.member, f.classID)
+	if err != nil {
+		t.Fatalf("GetCurrentUnit: %v", err)
+	}
+	if unit.ID != want {
+		t.Errorf("got current unit %v (%s), want %v", unit.ID, unit.Title, want)
+	}
+}
+
+func testCurrentUnit(t *testing.T, f *fixture) {
+	if _, err := f.svc.GetCurrentUnit(f.member, f.classID); err != unitsvc.ErrNotFound {
+		t.Errorf("GetCurrentUnit of class on no unit: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+	if _, err := f.svc.AdvanceUnit(f.member, f.classID); err != unitsvc.ErrNotFound {
+		t.Errorf("AdvanceUnit of class without units: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+	ids := f.createUnits(t, "Cells", "Genetics", "Ecology")
+
+	// A class on no unit advances to its first one.
+	for _, want := range ids[:2] {
+		unit, err := f.svc.AdvanceUnit(f.member, f.classID)
+		if err != nil {
+			t.Fatalf("AdvanceUnit: %v", err)
+		}
+		if unit.ID != want {
+			t.Errorf("AdvanceUnit moved to %v (%s), want %v", unit.ID, unit.Title, want)
+		}
+		f.expectCurrentUnit(t, want)
+	}
+	if err := f.svc.SetCurrentUnit(f.member, f.classID, ids[2]); err != nil {
+		t.Fatalf("SetCurrentUnit: %v", err)
+	}
+	f.expectCurrentUnit(t, ids[2])
+	if _, err := f.svc.AdvanceUnit(f.member, f.classID); err != unitsvc.ErrBadRequest {
+		t.Errorf("AdvanceUnit past last unit: got error %v, want %v", err, unitsvc.ErrBadRequest)
+	}
+	f.expectCurrentUnit(t, ids[2])
+	if err := f.svc.SetCurrentUnit(f.member, f.classID, uuid.Nil); err != nil {
+		t.Fatalf("SetCurrentUnit to no unit: %v", err)
+	}
+	if _, err := f.svc.GetCurrentUnit(f.member, f.classID); err != unitsvc.ErrNotFound {
+		t.Errorf("GetCurrentUnit after clearing: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+	moved := event{unitsvc.SubjCurrentUnit, f.classID.String()}
+	f.events.expect(t, moved, moved, moved, moved)
+}
+
+func testCurrentUnitInvalid(t *testing.T, f *fixture) {
+	ids := f.createUnits(t, "Cells")
+	otherClass := uuid.New()
+	f.classes.Enroll(f.memberID, otherClass, models.UserRoleTeacher)
+	if err := f.svc.CreateUnit(f.member, otherClass, "Elsewhere"); err != nil {
+		t.Fatalf("CreateUnit: %v", err)
+	}
+	other, err := f.svc.ListUnits(f.member, otherClass)
+	if err != nil || len(other) != 1 {
+		t.Fatalf("ListUnits of other class: got %d units and error %v, want 1 and no error", len(other), err)
+	}
+	f.events.expect(t, event{unitsvc.SubjCreateUnit, otherClass.String()})
+
+	if err := f.svc.SetCurrentUnit(f.member, f.classID, ids[0]); err != nil {
+		t.Fatalf("SetCurrentUnit: %v", err)
+	}
+	f.events.expect(t, event{unitsvc.SubjCurrentUnit, f.classID.String()})
+	for _, unitID := range []uuid.UUID{uuid.New(), other[0]} {
+		if err := f.svc.SetCurrentUnit(f.member, f.classID, unitID); err != unitsvc.ErrBadRequest {
+			t.Errorf("SetCurrentUnit to unit %v outside the class: got error %v, want %v", unitID, err, unitsvc.ErrBadRequest)
+		}
+	}
+	f.expectCurrentUnit(t, ids[0])
+	f.events.expect(t)
+}
+
+// A class on a unit that is deleted moves to the unit that took its place.
+func testCurrentUnitDeleted(t *testing.T, f *fixture) {
+	ids := f.createUnits(t, "Cells", "Genetics", "Ecology", "Evolution")
+	if err := f.svc.SetCurrentUnit(f.member, f.classID, ids[1]); err != nil {
+		t.Fatalf("SetCurrentUnit: %v", err)
+	}
+	f.events.expect(t, event{unitsvc.SubjCurrentUnit, f.classID.String()})
+
+	// Deleting another unit leaves the class where it is.
+	if err := f.svc.DeleteUnit(f.member, ids[0]); err != nil {
+		t.Fatalf("DeleteUnit: %v", err)
+	}
+	f.expectCurrentUnit(t, ids[1])
+	if err := f.svc.DeleteUnit(f.member, ids[1]); err != nil {
+		t.Fatalf("DeleteUnit: %v", err)
+	}
+	f.expectCurrentUnit(t, ids[2])
+
+	// Importing rows without the current unit deletes it too. The unit left is moved to the front.
+	if _, err := f.svc.ImportUnits(f.member, f.classID, []unitsvc.ImportRow{{Row: 1, ID: ids[3], Title: "Evolution"}}, false); err != nil {
+		t.Fatalf("ImportUnits: %v", err)
+	}
+	f.expectCurrentUnit(t, ids[3])
+	f.events.expect(t,
+		event{unitsvc.SubjDeleteUnit, ids[0].String()},
+		event{unitsvc.SubjDeleteUnit, ids[1].String()},
+		event{unitsvc.SubjRenameUnit, ids[3].String()},
+		event{unitsvc.SubjDeleteUnit, ids[2].String()},
+	)
+
+	// The last unit moves to no unit.
+	if err := f.svc.DeleteUnit(f.member, ids[3]); err != nil {
+		t.Fatalf("DeleteUnit: %v", err)
+	}
+	if _, err := f.svc.GetCurrentUnit(f.member, f.classID); err != unitsvc.ErrNotFound {
+		t.Errorf("GetCurrentUnit after deleting every unit: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+	f.events.expect(t, event{unitsvc.SubjDeleteUnit, ids[3].String()})
+}
+
+func testCurrentUnitTeachersOnly(t *testing.T, f *fixture) {
+	ids := f.createUnits(t, "Cells", "Genetics")
+	if err := f.svc.SetCurrentUnit(f.member, f.classID, ids[0]); err != nil {
+		t.Fatalf("SetCurrentUnit: %v", err)
+	}
+	f.events.expect(t, event{unitsvc.SubjCurrentUnit, f.classID.String()})
+	student := uuid.New()
+	f.classes.Enroll(student, f.classID, models.UserRoleStudent)
+	ctx := unittesting.NewContext(student)
+	if unit, err := f.svc.GetCurrentUnit(ctx, f.classID); err != nil || unit.ID != ids[0] {
+		t.Errorf("GetCurrentUnit as student: got %v and error %v, want %v and no error", unit, err, ids[0])
+	}
+	if err := f.svc.SetCurrentUnit(ctx, f.classID, ids[1]); err != unitsvc.ErrForbidden {
+		t.Errorf("SetCurrentUnit as student: got error %v, want %v", err, unitsvc.ErrForbidden)
+	}
+	if _, err := f.svc.AdvanceUnit(ctx, f.classID); err != unitsvc.ErrForbidden {
+		t.Errorf("AdvanceUnit as student: got error %v, want %v", err, unitsvc.ErrForbidden)
+	}
+	if _, err := f.svc.GetCurrentUnit(f.outside, f.classID); err != unitsvc.ErrNotFound {
+		t.Errorf("GetCurrentUnit as outsider: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+	if err := f.svc.SetCurrentUnit(f.outside, f.classID, ids[1]); err != unitsvc.ErrNotFound {
+		t.Errorf("SetCurrentUnit as outsider: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+	if _, err := f.svc.AdvanceUnit(f.outside, f.classID); err != unitsvc.ErrNotFound {
+		t.Errorf("AdvanceUnit as outsider: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+	f.expectCurrentUnit(t, ids[0])
+	f.events.expect(t)
+}
+
+// enrollStudent enrolls a new student in the fixture's class and returns their ID and a context
+// that acts as them.
+func (f *fixture) enrollStudent() (uuid.UUID, context.Context) {
+	student := uuid.New()
+	f.classes.Enroll(student, f.classID, models.UserRoleStudent)
+	return student, unitsvc.WithRequestID(unittesting.NewContext(student), requestID)
+}
+
+func testMarkUnitProgress(t *testing.T, f *fixture) {
+	ids := f.createUnits(t, "Cells", "Genetics")
+	student, ctx := f.enrollStudent()
+	idle, _ := f.enrollStudent()
+
+	started, err := f.svc.MarkUnitProgress(ctx, ids[0], unitsvcmodels.ProgressInProgress)
+	if err != nil {
+		t.Fatalf("MarkUnitProgress: %v", err)
+	}
+	if started.UnitID != ids[0] || started.UserID != student || started.ClassID != f.classID || started.StartedAt == nil || started.CompletedAt != nil {
+		t.Errorf("got progress %+v after starting, want %v started by %v", started, ids[0], student)
+	}
+	done, err := f.svc.MarkUnitProgress(ctx, ids[0], unitsvcmodels.ProgressCompleted)
+	if err != nil {
+		t.Fatalf("MarkUnitProgress: %v", err)
+	}
+	if done.Status != unitsvcmodels.ProgressCompleted || done.StartedAt == nil || !done.StartedAt.Equal(*started.StartedAt) || done.CompletedAt == nil {
+		t.Errorf("got progress %+v after completing, want completed and started at %v", done, started.StartedAt)
+	}
+	if _, err := f.svc.MarkUnitProgress(ctx, ids[1], unitsvcmodels.ProgressInProgress); err != nil {
+		t.Fatalf("MarkUnitProgress: %v", err)
+	}
+	f.events.expect(t,
+		event{unitsvc.SubjUnitProgress, ids[0].String()},
+		event{unitsvc.SubjUnitProgress, ids[0].String()},
+		event{unitsvc.SubjUnitProgress, ids[1].String()},
+	)
+
+	progress, err := f.svc.GetClassProgress(f.member, f.classID)
+	if err != nil {
+		t.Fatalf("GetClassProgress: %v", err)
+	}
+	if len(progress.Units) != 2 || progress.Units[0].ID != ids[0] || progress.Units[1].ID != ids[1] {
+		t.Fatalf("got units %v, want %v in display order", progress.Units, ids)
+	}
+	// The teacher is not a student, so only the two students are listed.
+	want := map[uuid.UUID]string{
+		student: fmt.Sprint([]string{unitsvcmodels.ProgressCompleted, unitsvcmodels.ProgressInProgress}),
+		idle:    fmt.Sprint([]string{unitsvcmodels.ProgressNotStarted, unitsvcmodels.ProgressNotStarted}),
+	}
+	if len(progress.Students) != len(want) {
+		t.Fatalf("got %d students, want %d", len(progress.Students), len(want))
+	}
+	for _, sp := range progress.Students {
+		var statuses []string
+		for _, p := range sp.Units {
+			statuses = append(statuses, p.Status)
+		}
+		if got := fmt.Sprint(statuses); got != want[sp.UserID] {
+			t.Errorf("got progress %s for %v, want %s", got, sp.UserID, want[sp.UserID])
+		}
+		if sp.UserID == student && sp.Completed != 1 {
+			t.Errorf("got %d units completed by %v, want 1", sp.Completed, sp.UserID)
+		}
+	}
+
+	// Starting a completed unit again reopens it, and marking it not started forgets it was started.
+	reopened, err := f.svc.MarkUnitProgress(ctx, ids[0], unitsvcmodels.ProgressInProgress)
+	if err != nil {
+		t.Fatalf("MarkUnitProgress: %v", err)
+	}
+	if reopened.CompletedAt != nil || reopened.StartedAt == nil || !reopened.StartedAt.Equal(*started.StartedAt) {
+		t.Errorf("got progress %+v after reopening, want started at %v and not completed", reopened, started.StartedAt)
+	}
+	reset, err := f.svc.MarkUnitProgress(ctx, ids[0], unitsvcmodels.ProgressNotStarted)
+	if err != nil {
+		t.Fatalf("MarkUnitProgress: %v", err)
+	}
+	if reset.StartedAt != nil || reset.CompletedAt != nil {
+		t.Errorf("got progress %+v after resetting, want no timestamps", reset)
+	}
+	f.events.expect(t,
+		event{unitsvc.SubjUnitProgress, ids[0].String()},
+		event{unitsvc.SubjUnitProgress, ids[0].String()},
+	)
+}
+
+func testMarkUnitProgressInvalid(t *testing.T, f *fixture) {
+	ids := f.createUnits(t, "Cells")
+	_, ctx := f.enrollStudent()
+	for _, status := range []string{"", "done", "COMPLETED"} {
+		if _, err := f.svc.MarkUnitProgress(ctx, ids[0], status); err != unitsvc.ErrBadRequest {
+			t.Errorf("MarkUnitProgress with status %q: got error %v, want %v", status, err, unitsvc.ErrBadRequest)
+		}
+	}
+	if _, err := f.svc.MarkUnitProgress(ctx, uuid.New(), unitsvcmodels.ProgressCompleted); err != unitsvc.ErrNotFound {
+		t.Errorf("MarkUnitProgress of missing unit: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+	f.events.expect(t)
+}
+
+func testUnitProgressRoles(t *testing.T, f *fixture) {
+	ids := f.createUnits(t, "Cells")
+	_, ctx := f.enrollStudent()
+	if _, err := f.svc.MarkUnitProgress(f.member, ids[0], unitsvcmodels.ProgressCompleted); err != unitsvc.ErrForbidden {
+		t.Errorf("MarkUnitProgress as teacher: got error %v, want %v", err, unitsvc.ErrForbidden)
+	}
+	if _, err := f.svc.MarkUnitProgress(f.outside, ids[0], unitsvcmodels.ProgressCompleted); err != unitsvc.ErrNotFound {
+		t.Errorf("MarkUnitProgress as outsider: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+	if _, err := f.svc.GetClassProgress(ctx, f.classID); err != unitsvc.ErrForbidden {
+		t.Errorf("GetClassProgress as student: got error %v, want %v", err, unitsvc.ErrForbidden)
+	}
+	if _, err := f.svc.GetClassProgress(f.outside, f.classID); err != unitsvc.ErrNotFound {
+		t.Errorf("GetClassProgress as outsider: got error %v, want %v", err, unitsvc.ErrNotFound)
+	}
+	f.events.expect(t)
+}